@@ -74,6 +74,19 @@ type Input interface {
 	Preimage() fn.Option[lntypes.Preimage]
 }
 
+// WitnessSizeEstimator is an optional interface that can be implemented by an
+// Input to report the estimated size of its witness. This is useful for inputs
+// whose witness size cannot be derived from their witness type alone, such as
+// taproot script-path spends, where the witness size depends on the revealed
+// script, its control block and the stack items.
+type WitnessSizeEstimator interface {
+	// EstimatedWitnessSize returns the estimated size of the witness used
+	// to spend the input, including the byte for the number of witness
+	// elements. It returns false if no estimate is available, in which
+	// case the witness type should be used instead.
+	EstimatedWitnessSize() (lntypes.WeightUnit, bool)
+}
+
 // TxInfo describes properties of a parent tx that are relevant for CPFP.
 type TxInfo struct {
 	// Fee is the fee of the tx.
@@ -119,6 +132,10 @@ type inputKit struct {
 	// resolutionBlob is an optional blob that can be used to resolve an
 	// input.
 	resolutionBlob fn.Option[tlv.Blob]
+
+	// witnessSize is an optional estimate of the witness size, which
+	// overrides the size derived from the witness type.
+	witnessSize fn.Option[lntypes.WeightUnit]
 }
 
 // OutPoint returns the breached output's identifier that is to be included as
@@ -175,11 +192,22 @@ func (i *inputKit) ResolutionBlob() fn.Option[tlv.Blob] {
 	return i.resolutionBlob
 }
 
+// EstimatedWitnessSize returns the estimated witness size of the input if
+// it's specified.
+//
+// NOTE: part of the WitnessSizeEstimator interface.
+func (i *inputKit) EstimatedWitnessSize() (lntypes.WeightUnit, bool) {
+	return i.witnessSize.UnwrapOr(0), i.witnessSize.IsSome()
+}
+
 // inputOpts contains options for constructing a new input.
 type inputOpts struct {
 	// resolutionBlob is an optional blob that can be used to resolve an
 	// input.
 	resolutionBlob fn.Option[tlv.Blob]
+
+	// witnessSize is an optional estimate of the witness size.
+	witnessSize fn.Option[lntypes.WeightUnit]
 }
 
 // defaultInputOpts returns a new inputOpts with default values.
@@ -199,6 +227,16 @@ func WithResolutionBlob(b fn.Option[tlv.Blob]) InputOpt {
 	}
 }
 
+// WithWitnessSize is an option that can be used to set the estimated witness
+// size of an input, which is used instead of the size derived from its witness
+// type when estimating the weight of the sweeping tx. See
+// TapscriptWitnessSize for calculating the size of a script-path spend.
+func WithWitnessSize(size lntypes.WeightUnit) InputOpt {
+	return func(o *inputOpts) {
+		o.witnessSize = fn.Some(size)
+	}
+}
+
 // BaseInput contains all the information needed to sweep a basic
 // output (CSV/CLTV/no time lock).
 type BaseInput struct {
@@ -224,6 +262,7 @@ func MakeBaseInput(outpoint *wire.OutPoint, witnessType WitnessType,
 			heightHint:     heightHint,
 			unconfParent:   unconfParent,
 			resolutionBlob: opt.resolutionBlob,
+			witnessSize:    opt.witnessSize,
 		},
 	}
 }
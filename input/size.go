@@ -912,20 +912,32 @@ func (twe *TxWeightEstimator) AddTapscriptInput(
 	leafWitnessSize lntypes.WeightUnit,
 	tapscript *waddrmgr.Tapscript) *TxWeightEstimator {
 
+	twe.inputSize += InputSize
+	twe.inputWitnessSize += TapscriptWitnessSize(leafWitnessSize, tapscript)
+	twe.inputCount++
+	twe.hasWitness = true
+
+	return twe
+}
+
+// TapscriptWitnessSize returns the total size of the witness used to spend a
+// segwit v1 pay-to-taproot output using the script path. This accepts the
+// total size of the witness for the script leaf that is executed and adds the
+// size of the control block and the revealed script to it.
+//
+// NOTE: The leaf witness size must be calculated without the byte that accounts
+// for the number of witness elements, only the total size of all elements on
+// the stack that are consumed by the revealed script should be counted.
+func TapscriptWitnessSize(leafWitnessSize lntypes.WeightUnit,
+	tapscript *waddrmgr.Tapscript) lntypes.WeightUnit {
+
 	// We add 1 byte for the total number of witness elements.
 	controlBlockWitnessSize := 1 + TaprootBaseControlBlockWitnessSize +
 		// 1 byte for the length of the element plus the element itself.
 		1 + len(tapscript.RevealedScript) +
 		1 + len(tapscript.ControlBlock.InclusionProof)
 
-	twe.inputSize += InputSize
-	twe.inputWitnessSize += leafWitnessSize + lntypes.WeightUnit(
-		controlBlockWitnessSize,
-	)
-	twe.inputCount++
-	twe.hasWitness = true
-
-	return twe
+	return leafWitnessSize + lntypes.WeightUnit(controlBlockWitnessSize)
 }
 
 // AddTaprootKeySpendInput updates the weight estimate to account for an
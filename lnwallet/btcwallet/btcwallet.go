@@ -1946,11 +1946,17 @@ func (b *BtcWallet) CheckMempoolAcceptance(tx *wire.MsgTx) error {
 	log.Debugf("TestMempoolAccept result: %s", spew.Sdump(result))
 
 	// Mempool check failed, we now map the reject reason to a proper RPC
-	// error and return it.
+	// error and return it. The reject reason is kept in the error as it
+	// may carry details such as the fee required by the mempool, unless
+	// the mapped error already reads the same.
 	if !result.Allowed {
 		err := b.chain.MapRPCErr(errors.New(result.RejectReason))
+		if err.Error() == result.RejectReason {
+			return fmt.Errorf("mempool rejection: %w", err)
+		}
 
-		return fmt.Errorf("mempool rejection: %w", err)
+		return fmt.Errorf("mempool rejection: %w: %v", err,
+			result.RejectReason)
 	}
 
 	return nil
@@ -1,6 +1,7 @@
 package btcwallet
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcjson"
@@ -211,6 +212,25 @@ func TestCheckMempoolAcceptance(t *testing.T) {
 	// Now call the method under test.
 	err = wallet.CheckMempoolAcceptance(tx)
 	rt.ErrorIs(err, chain.ErrInsufficientFee)
+	rt.ErrorContains(err, "insufficient fee")
+
+	// Assert that when the reject reason cannot be mapped, it's only
+	// included in the error once.
+	//
+	// Mock the chain backend to return the reject reason as is.
+	results = []*btcjson.TestMempoolAcceptResult{{
+		Txid:         tx.TxHash().String(),
+		Allowed:      false,
+		RejectReason: "unknown reason",
+	}}
+	mockChain.On("TestMempoolAccept", []*wire.MsgTx{tx}, maxFeeRate).Return(
+		results, nil).Once()
+	mockChain.On("MapRPCErr", mock.Anything).Return(
+		errors.New("unknown reason")).Once()
+
+	// Now call the method under test.
+	err = wallet.CheckMempoolAcceptance(tx)
+	rt.EqualError(err, "mempool rejection: unknown reason")
 
 	// Assert that when the tx is accepted, no error is returned.
 	//
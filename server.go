@@ -109,6 +109,10 @@ const (
 	// multiAddrConnectionStagger is the number of seconds to wait between
 	// attempting to a peer with each of its advertised addresses.
 	multiAddrConnectionStagger = 10 * time.Second
+
+	// txPublisherStopTimeout is the max duration we will wait for the tx
+	// publisher to deliver its pending results when shutting down.
+	txPublisherStopTimeout = 5 * time.Second
 )
 
 var (
@@ -1194,7 +1198,7 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 
 	s.txPublisher = sweep.NewTxPublisher(sweep.TxPublisherConfig{
 		Signer:     cc.Wallet.Cfg.Signer,
-		Wallet:     cc.Wallet,
+		Wallet:     newSweeperWallet(cc.Wallet),
 		Estimator:  cc.FeeEstimator,
 		Notifier:   cc.ChainNotifier,
 		AuxSweeper: s.implCfg.AuxSweeper,
@@ -2089,6 +2093,17 @@ func (s *server) Started() bool {
 	return atomic.LoadInt32(&s.active) != 0
 }
 
+// stopTxPublisher gracefully stops the tx publisher, giving it a limited time
+// to deliver its pending results.
+func (s *server) stopTxPublisher() error {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), txPublisherStopTimeout,
+	)
+	defer cancel()
+
+	return s.txPublisher.Stop(ctx)
+}
+
 // cleaner is used to aggregate "cleanup" functions during an operation that
 // starts several subsystems. In case one of the subsystem fails to start
 // and a proper resource cleanup is required, the "run" method achieves this
@@ -2232,7 +2247,7 @@ func (s *server) Start() error {
 			}
 		}
 
-		cleanup = cleanup.add(s.txPublisher.Stop)
+		cleanup = cleanup.add(s.stopTxPublisher)
 		if err := s.txPublisher.Start(beat); err != nil {
 			startErr = err
 			return
@@ -2606,12 +2621,14 @@ func (s *server) Stop() error {
 		if err := s.authGossiper.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop authGossiper: %v", err)
 		}
+		// Stop the tx publisher before the sweeper, so its pending
+		// results can still be delivered to the sweeper.
+		if err := s.stopTxPublisher(); err != nil {
+			srvrLog.Warnf("failed to stop txPublisher: %v", err)
+		}
 		if err := s.sweeper.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop sweeper: %v", err)
 		}
-		if err := s.txPublisher.Stop(); err != nil {
-			srvrLog.Warnf("failed to stop txPublisher: %v", err)
-		}
 		if err := s.channelNotifier.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop channelNotifier: %v", err)
 		}
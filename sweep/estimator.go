@@ -0,0 +1,375 @@
+package sweep
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+)
+
+// fallbackEstimator is a chainfee.Estimator that tries a list of estimators in
+// order when estimating the fee rate, so a brief outage of the primary
+// estimator doesn't strand the sweeps. If all of them fail and a relay fee
+// margin is specified, the relay fee rate plus the margin is used instead.
+type fallbackEstimator struct {
+	// estimators is the list of estimators to try, starting with the
+	// primary one.
+	estimators []chainfee.Estimator
+
+	// relayFeeMargin is the optional margin added to the relay fee rate
+	// when all the estimators fail.
+	relayFeeMargin fn.Option[chainfee.SatPerKWeight]
+}
+
+// Compile-time check to ensure fallbackEstimator satisfies the
+// chainfee.Estimator interface.
+var _ chainfee.Estimator = (*fallbackEstimator)(nil)
+
+// newFallbackEstimator creates a new fallbackEstimator using the given primary
+// and fallback estimators.
+func newFallbackEstimator(primary chainfee.Estimator,
+	fallbacks []chainfee.Estimator,
+	relayFeeMargin fn.Option[chainfee.SatPerKWeight]) *fallbackEstimator {
+
+	estimators := make([]chainfee.Estimator, 0, len(fallbacks)+1)
+	estimators = append(estimators, primary)
+	estimators = append(estimators, fallbacks...)
+
+	return &fallbackEstimator{
+		estimators:     estimators,
+		relayFeeMargin: relayFeeMargin,
+	}
+}
+
+// EstimateFeePerKW returns the fee rate estimated by the first estimator that
+// succeeds. If all of them fail, the relay fee rate plus the margin is
+// returned if the margin is specified, otherwise the errors are returned.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (f *fallbackEstimator) EstimateFeePerKW(
+	numBlocks uint32) (chainfee.SatPerKWeight, error) {
+
+	var errs []error
+	for i, estimator := range f.estimators {
+		feeRate, err := estimator.EstimateFeePerKW(numBlocks)
+		if err == nil {
+			return feeRate, nil
+		}
+
+		log.Warnf("Fee estimator %d failed to estimate fee rate for "+
+			"conf target %v: %v", i, numBlocks, err)
+
+		errs = append(errs, err)
+	}
+
+	err := fmt.Errorf("all fee estimators failed: %w",
+		errors.Join(errs...))
+
+	if f.relayFeeMargin.IsNone() {
+		return 0, err
+	}
+
+	margin := f.relayFeeMargin.UnwrapOr(0)
+	feeRate := f.RelayFeePerKW() + margin
+
+	log.Warnf("Using relay fee rate plus margin %v as the estimated fee "+
+		"rate %v: %v", margin, feeRate, err)
+
+	return feeRate, nil
+}
+
+// RelayFeePerKW returns the relay fee rate of the primary estimator.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (f *fallbackEstimator) RelayFeePerKW() chainfee.SatPerKWeight {
+	return f.estimators[0].RelayFeePerKW()
+}
+
+// Start is a no-op as the estimators are managed by their owners.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (f *fallbackEstimator) Start() error {
+	return nil
+}
+
+// Stop is a no-op as the estimators are managed by their owners.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (f *fallbackEstimator) Stop() error {
+	return nil
+}
+
+// cachedFeeRate is a fee rate cached by the cachedEstimator.
+type cachedFeeRate struct {
+	// feeRate is the estimated fee rate.
+	feeRate chainfee.SatPerKWeight
+
+	// expiry is the time after which the fee rate is stale.
+	expiry time.Time
+}
+
+// cachedEstimator is a chainfee.Estimator that caches the estimated fee rates
+// by conf target for a short period, so the sweeps initialized around the
+// same time don't each make an expensive estimation call.
+type cachedEstimator struct {
+	// estimator is the underlying estimator.
+	estimator chainfee.Estimator
+
+	// ttl is how long an estimated fee rate is cached for.
+	ttl time.Duration
+
+	// maxSize is the max number of conf targets cached.
+	maxSize int
+
+	// now returns the current time, which is mocked in the tests.
+	now func() time.Time
+
+	// mu guards the entries.
+	mu sync.Mutex
+
+	// entries is the cached fee rates keyed by their conf targets.
+	entries map[uint32]cachedFeeRate
+}
+
+// Compile-time check to ensure cachedEstimator satisfies the
+// chainfee.Estimator interface.
+var _ chainfee.Estimator = (*cachedEstimator)(nil)
+
+// newCachedEstimator creates a new cachedEstimator wrapping the given
+// estimator.
+func newCachedEstimator(estimator chainfee.Estimator, ttl time.Duration,
+	maxSize int) *cachedEstimator {
+
+	return &cachedEstimator{
+		estimator: estimator,
+		ttl:       ttl,
+		maxSize:   maxSize,
+		now:       time.Now,
+		entries:   make(map[uint32]cachedFeeRate),
+	}
+}
+
+// EstimateFeePerKW returns the cached fee rate for the given conf target if
+// it's not stale, otherwise the fee rate is estimated and cached.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (c *cachedEstimator) EstimateFeePerKW(
+	numBlocks uint32) (chainfee.SatPerKWeight, error) {
+
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[numBlocks]
+	c.mu.Unlock()
+
+	if ok && now.Before(entry.expiry) {
+		return entry.feeRate, nil
+	}
+
+	feeRate, err := c.estimator.EstimateFeePerKW(numBlocks)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[numBlocks]; !ok && len(c.entries) >= c.maxSize {
+		c.evict(now)
+	}
+
+	c.entries[numBlocks] = cachedFeeRate{
+		feeRate: feeRate,
+		expiry:  now.Add(c.ttl),
+	}
+
+	return feeRate, nil
+}
+
+// evict removes the stale entries from the cache. If the cache is still full,
+// the entry that expires the soonest is removed.
+//
+// NOTE: must be called with the mutex held.
+func (c *cachedEstimator) evict(now time.Time) {
+	var (
+		oldest    uint32
+		oldestExp time.Time
+	)
+	for target, entry := range c.entries {
+		if !now.Before(entry.expiry) {
+			delete(c.entries, target)
+			continue
+		}
+
+		if oldestExp.IsZero() || entry.expiry.Before(oldestExp) {
+			oldest = target
+			oldestExp = entry.expiry
+		}
+	}
+
+	if len(c.entries) >= c.maxSize {
+		delete(c.entries, oldest)
+	}
+}
+
+// RelayFeePerKW returns the relay fee rate of the underlying estimator, which
+// is not cached.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (c *cachedEstimator) RelayFeePerKW() chainfee.SatPerKWeight {
+	return c.estimator.RelayFeePerKW()
+}
+
+// Start is a no-op as the estimator is managed by its owner.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (c *cachedEstimator) Start() error {
+	return nil
+}
+
+// Stop is a no-op as the estimator is managed by its owner.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (c *cachedEstimator) Stop() error {
+	return nil
+}
+
+// WeightedEstimator is a fee estimator paired with the weight of its votes
+// used by the WeightedMedianEstimator.
+type WeightedEstimator struct {
+	// Estimator is the fee estimator.
+	Estimator chainfee.Estimator
+
+	// Weight is the weight of the estimator's fee rate, which must be
+	// positive.
+	Weight uint32
+}
+
+// WeightedMedianEstimator is a chainfee.Estimator that wraps several
+// estimators, and returns the weighted median of their estimated fee rates so
+// a single misbehaving fee source cannot skew the estimate.
+type WeightedMedianEstimator struct {
+	// estimators is the list of weighted estimators.
+	estimators []WeightedEstimator
+}
+
+// Compile-time check to ensure WeightedMedianEstimator satisfies the
+// chainfee.Estimator interface.
+var _ chainfee.Estimator = (*WeightedMedianEstimator)(nil)
+
+// NewWeightedMedianEstimator creates a new WeightedMedianEstimator using the
+// given weighted estimators.
+func NewWeightedMedianEstimator(
+	estimators []WeightedEstimator) (*WeightedMedianEstimator, error) {
+
+	if len(estimators) == 0 {
+		return nil, errors.New("no estimators provided")
+	}
+
+	for i, e := range estimators {
+		if e.Estimator == nil {
+			return nil, fmt.Errorf("estimator %d is nil", i)
+		}
+
+		if e.Weight == 0 {
+			return nil, fmt.Errorf("estimator %d has zero "+
+				"weight", i)
+		}
+	}
+
+	return &WeightedMedianEstimator{
+		estimators: estimators,
+	}, nil
+}
+
+// EstimateFeePerKW returns the weighted median of the fee rates estimated by
+// the estimators, skipping the ones that fail. When the total weight is
+// evenly split, the lower of the two middle fee rates is used. An error is
+// returned if all the estimators fail.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (w *WeightedMedianEstimator) EstimateFeePerKW(
+	numBlocks uint32) (chainfee.SatPerKWeight, error) {
+
+	type vote struct {
+		feeRate chainfee.SatPerKWeight
+		weight  uint64
+	}
+
+	var (
+		votes       []vote
+		totalWeight uint64
+		errs        []error
+	)
+	for i, e := range w.estimators {
+		feeRate, err := e.Estimator.EstimateFeePerKW(numBlocks)
+		if err != nil {
+			log.Warnf("Fee estimator %d failed to estimate fee "+
+				"rate for conf target %v: %v", i, numBlocks,
+				err)
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		votes = append(votes, vote{
+			feeRate: feeRate,
+			weight:  uint64(e.Weight),
+		})
+		totalWeight += uint64(e.Weight)
+	}
+
+	if len(votes) == 0 {
+		return 0, fmt.Errorf("all fee estimators failed: %w",
+			errors.Join(errs...))
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].feeRate < votes[j].feeRate
+	})
+
+	// Find the first fee rate at which the accumulated weight reaches
+	// half of the total weight.
+	var accumulated uint64
+	for _, v := range votes {
+		accumulated += v.weight
+		if accumulated*2 >= totalWeight {
+			return v.feeRate, nil
+		}
+	}
+
+	// Unreachable as the accumulated weight of all votes is the total.
+	return votes[len(votes)-1].feeRate, nil
+}
+
+// RelayFeePerKW returns the highest relay fee rate of the estimators, so the
+// txns are relayed by all the fee sources' backends.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (w *WeightedMedianEstimator) RelayFeePerKW() chainfee.SatPerKWeight {
+	var relayFee chainfee.SatPerKWeight
+	for _, e := range w.estimators {
+		relayFee = max(relayFee, e.Estimator.RelayFeePerKW())
+	}
+
+	return relayFee
+}
+
+// Start is a no-op as the estimators are managed by their owners.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (w *WeightedMedianEstimator) Start() error {
+	return nil
+}
+
+// Stop is a no-op as the estimators are managed by their owners.
+//
+// NOTE: part of the chainfee.Estimator interface.
+func (w *WeightedMedianEstimator) Stop() error {
+	return nil
+}
@@ -0,0 +1,219 @@
+package sweep
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFallbackEstimator checks the fallback estimators are tried in order, and
+// the relay fee rate plus the margin is used when all of them fail.
+func TestFallbackEstimator(t *testing.T) {
+	t.Parallel()
+
+	primary := &chainfee.MockEstimator{}
+	fallback1 := &chainfee.MockEstimator{}
+	fallback2 := &chainfee.MockEstimator{}
+	t.Cleanup(func() {
+		primary.AssertExpectations(t)
+		fallback1.AssertExpectations(t)
+		fallback2.AssertExpectations(t)
+	})
+
+	confTarget := uint32(6)
+	feeRate := chainfee.SatPerKWeight(1000)
+	relayFeeRate := chainfee.FeePerKwFloor
+	margin := chainfee.SatPerKWeight(100)
+
+	// When the primary estimator fails, the second fallback is used as
+	// the first fallback fails too.
+	primary.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(0), errDummy).Twice()
+	fallback1.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(0), errDummy).Twice()
+	fallback2.On("EstimateFeePerKW", confTarget).Return(
+		feeRate, nil).Once()
+
+	e := newFallbackEstimator(
+		primary, []chainfee.Estimator{fallback1, fallback2},
+		fn.Some(margin),
+	)
+	estimated, err := e.EstimateFeePerKW(confTarget)
+	require.NoError(t, err)
+	require.Equal(t, feeRate, estimated)
+
+	// When all of them fail, the relay fee rate of the primary estimator
+	// plus the margin is used.
+	fallback2.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+	primary.On("RelayFeePerKW").Return(relayFeeRate).Once()
+
+	estimated, err = e.EstimateFeePerKW(confTarget)
+	require.NoError(t, err)
+	require.Equal(t, relayFeeRate+margin, estimated)
+
+	// Without a margin, the error is returned.
+	primary.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+
+	e = newFallbackEstimator(
+		primary, nil, fn.None[chainfee.SatPerKWeight](),
+	)
+	_, err = e.EstimateFeePerKW(confTarget)
+	require.ErrorIs(t, err, errDummy)
+}
+
+// TestCachedEstimator checks the estimated fee rates are cached per conf
+// target until they expire, and the cache size is bounded.
+func TestCachedEstimator(t *testing.T) {
+	t.Parallel()
+
+	estimator := &chainfee.MockEstimator{}
+	t.Cleanup(func() {
+		estimator.AssertExpectations(t)
+	})
+
+	// Create a cache holding two conf targets using a mocked clock.
+	ttl := time.Minute
+	now := time.Unix(1000, 0)
+	c := newCachedEstimator(estimator, ttl, 2)
+	c.now = func() time.Time {
+		return now
+	}
+
+	// A failed estimation should not be cached.
+	estimator.On("EstimateFeePerKW", uint32(1)).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+	_, err := c.EstimateFeePerKW(1)
+	require.ErrorIs(t, err, errDummy)
+
+	// The first estimation for conf target 1 is cached, so the second one
+	// doesn't hit the estimator.
+	feeRate := chainfee.SatPerKWeight(1000)
+	estimator.On("EstimateFeePerKW", uint32(1)).Return(
+		feeRate, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		estimated, err := c.EstimateFeePerKW(1)
+		require.NoError(t, err)
+		require.Equal(t, feeRate, estimated)
+	}
+
+	// Once expired, the fee rate is estimated again.
+	now = now.Add(ttl)
+	newFeeRate := chainfee.SatPerKWeight(2000)
+	estimator.On("EstimateFeePerKW", uint32(1)).Return(
+		newFeeRate, nil).Once()
+
+	estimated, err := c.EstimateFeePerKW(1)
+	require.NoError(t, err)
+	require.Equal(t, newFeeRate, estimated)
+
+	// Fill the cache with two more conf targets, which evicts conf target
+	// 1 as it expires the soonest.
+	now = now.Add(time.Second)
+	estimator.On("EstimateFeePerKW", uint32(2)).Return(
+		feeRate, nil).Once()
+	estimator.On("EstimateFeePerKW", uint32(3)).Return(
+		feeRate, nil).Once()
+
+	_, err = c.EstimateFeePerKW(2)
+	require.NoError(t, err)
+	_, err = c.EstimateFeePerKW(3)
+	require.NoError(t, err)
+
+	require.Len(t, c.entries, 2)
+	require.NotContains(t, c.entries, uint32(1))
+}
+
+// TestWeightedMedianEstimator checks the weighted median of the estimated fee
+// rates is returned, and the failed estimators are skipped.
+func TestWeightedMedianEstimator(t *testing.T) {
+	t.Parallel()
+
+	confTarget := uint32(6)
+
+	// Create three estimators returning different fee rates.
+	newEstimators := func(weights ...uint32) ([]WeightedEstimator,
+		[]*chainfee.MockEstimator) {
+
+		feeRates := []chainfee.SatPerKWeight{2000, 1000, 5000}
+		mocks := make([]*chainfee.MockEstimator, 0, len(weights))
+		estimators := make([]WeightedEstimator, 0, len(weights))
+		for i, weight := range weights {
+			m := &chainfee.MockEstimator{}
+			m.On("EstimateFeePerKW", confTarget).Return(
+				feeRates[i], nil).Maybe()
+			m.On("RelayFeePerKW").Return(
+				chainfee.SatPerKWeight(250 * (i + 1))).Maybe()
+
+			mocks = append(mocks, m)
+			estimators = append(estimators, WeightedEstimator{
+				Estimator: m,
+				Weight:    weight,
+			})
+		}
+
+		return estimators, mocks
+	}
+
+	// With equal weights, the median is used.
+	estimators, _ := newEstimators(1, 1, 1)
+	e, err := NewWeightedMedianEstimator(estimators)
+	require.NoError(t, err)
+
+	feeRate, err := e.EstimateFeePerKW(confTarget)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.SatPerKWeight(2000), feeRate)
+
+	// The highest relay fee rate is used.
+	require.Equal(t, chainfee.SatPerKWeight(750), e.RelayFeePerKW())
+
+	// A heavy estimator moves the median towards its fee rate.
+	estimators, _ = newEstimators(1, 1, 3)
+	e, err = NewWeightedMedianEstimator(estimators)
+	require.NoError(t, err)
+
+	feeRate, err = e.EstimateFeePerKW(confTarget)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.SatPerKWeight(5000), feeRate)
+
+	// When the heavy estimator fails, it's skipped and the lower of the
+	// two remaining fee rates is used as the weight is evenly split.
+	estimators, mocks := newEstimators(1, 1, 3)
+	mocks[2].ExpectedCalls = nil
+	mocks[2].On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+
+	e, err = NewWeightedMedianEstimator(estimators)
+	require.NoError(t, err)
+
+	feeRate, err = e.EstimateFeePerKW(confTarget)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.SatPerKWeight(1000), feeRate)
+
+	// When all the estimators fail, an error is returned.
+	failing := &chainfee.MockEstimator{}
+	failing.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+
+	e, err = NewWeightedMedianEstimator([]WeightedEstimator{
+		{Estimator: failing, Weight: 1},
+	})
+	require.NoError(t, err)
+
+	_, err = e.EstimateFeePerKW(confTarget)
+	require.ErrorIs(t, err, errDummy)
+
+	// A zero weight or no estimators are rejected.
+	_, err = NewWeightedMedianEstimator(nil)
+	require.Error(t, err)
+
+	_, err = NewWeightedMedianEstimator([]WeightedEstimator{
+		{Estimator: failing, Weight: 0},
+	})
+	require.Error(t, err)
+}
@@ -1,19 +1,33 @@
 package sweep
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btclog/v2"
 	"github.com/btcsuite/btcwallet/chain"
 	"github.com/lightningnetwork/lnd/chainio"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/fn/v2"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/labels"
@@ -22,6 +36,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/tlv"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -43,8 +58,175 @@ var (
 	// ErrThirdPartySpent is returned when a third party has spent the
 	// input in the sweeping tx.
 	ErrThirdPartySpent = errors.New("third party spent the output")
+
+	// ErrLocktimeInFuture is returned when the locktime specified in the
+	// bump request has not been reached, which makes the tx non-final.
+	ErrLocktimeInFuture = errors.New("locktime in the future")
+
+	// ErrPublisherHalted is returned when the publisher has been halted
+	// and no longer accepts or publishes any sweeps.
+	ErrPublisherHalted = errors.New("publisher halted")
+
+	// ErrConflictingDeadline is returned when a bump request specifies
+	// both a ConfTarget and a DeadlineHeight that don't agree.
+	ErrConflictingDeadline = errors.New("conflicting conf target and " +
+		"deadline height")
+
+	// ErrInvalidExtraOutput is returned when an extra output specified in
+	// a bump request is not valid.
+	ErrInvalidExtraOutput = errors.New("invalid extra output")
+
+	// ErrFixedFeeRateTooHigh is returned when the fixed fee rate specified
+	// in a bump request exceeds the max fee rate allowed by its budget.
+	ErrFixedFeeRateTooHigh = errors.New("fixed fee rate too high")
+
+	// ErrInputAlreadyTracked is returned when a request includes an input
+	// that's already being swept by another tracked request.
+	ErrInputAlreadyTracked = errors.New("input already tracked")
+
+	// ErrInputSpent is returned when an input of the sweeping tx has
+	// already been spent by another tx.
+	ErrInputSpent = errors.New("input already spent")
+
+	// ErrLostRBFRace is returned when an input of the sweeping tx is spent
+	// by a conflicting mempool tx paying a fee rate above the max fee rate
+	// allowed by the request, which cannot be outbid.
+	ErrLostRBFRace = errors.New("lost RBF race to conflicting tx")
+
+	// ErrTooManyReplacements is returned when the sweeping tx is rejected
+	// by the mempool as replacing the conflicting txns would evict more
+	// txns than allowed by BIP125 rule 5.
+	ErrTooManyReplacements = errors.New("too many replacements")
+
+	// ErrPackageRelayUnsupported is returned by the wallet when the
+	// backend doesn't support submitting txns as a package.
+	ErrPackageRelayUnsupported = errors.New("package relay unsupported")
+
+	// ErrLabelTooLong is returned when the label of a bump request
+	// exceeds MaxLabelLength.
+	ErrLabelTooLong = errors.New("label too long")
+
+	// ErrConflictingMaxFeeRate is returned when both MaxFeeRate and
+	// MaxFeeRateVByte are set on a bump request but don't agree.
+	ErrConflictingMaxFeeRate = errors.New("conflicting max fee rate")
+
+	// ErrInvalidBudgetFraction is returned when the BudgetFraction of a
+	// bump request is not in range [0, 1).
+	ErrInvalidBudgetFraction = errors.New("invalid budget fraction")
+
+	// ErrConflictingBudget is returned when both Budget and BudgetFraction
+	// are set on a bump request but don't agree.
+	ErrConflictingBudget = errors.New("conflicting budget")
+
+	// ErrInvalidMaxFeeFraction is returned when the MaxFeeFractionOfValue
+	// of a bump request is not in range [0, 1].
+	ErrInvalidMaxFeeFraction = errors.New("invalid max fee fraction")
+
+	// ErrInvalidNoBump is returned when a bump request specifying NoBump
+	// doesn't set a FixedFeeRate, or has an input whose sequence would
+	// signal RBF.
+	ErrInvalidNoBump = errors.New("invalid no-bump request")
+
+	// ErrBumpDisabled is returned when a fee bump is requested for a
+	// request specifying NoBump.
+	ErrBumpDisabled = errors.New("fee bumping disabled for request")
+
+	// ErrTimedOut is returned when the tx of a bump request is not
+	// confirmed within the Timeout of the request.
+	ErrTimedOut = errors.New("sweep timed out")
+
+	// ErrRequestNotFound is returned when the given request ID is not
+	// tracked by the publisher.
+	ErrRequestNotFound = errors.New("request not found")
+
+	// ErrPublisherStopped is returned when a broadcast request is received
+	// after the publisher has started shutting down.
+	ErrPublisherStopped = errors.New("publisher stopped")
+
+	// ErrTooManyInFlight is returned when a request is made while the
+	// number of monitored requests has reached the configured
+	// MaxInFlight.
+	ErrTooManyInFlight = errors.New("too many requests in flight")
+
+	// ErrEstimatorUnhealthy is returned by HealthCheck when the fee
+	// estimator fails to respond.
+	ErrEstimatorUnhealthy = errors.New("fee estimator unhealthy")
+
+	// ErrWalletUnhealthy is returned by HealthCheck when the wallet fails
+	// to respond.
+	ErrWalletUnhealthy = errors.New("wallet unhealthy")
+
+	// ErrFeeRateAtMax is returned by BumpNow when the fee function of the
+	// request cannot increase its fee rate any further.
+	ErrFeeRateAtMax = errors.New("fee rate cannot be increased further")
+
+	// ErrBumpInProgress is returned by BumpNow when the tx of the request
+	// is already being bumped.
+	ErrBumpInProgress = errors.New("fee bump in progress")
+
+	// ErrFeeRateAboveConfirmed is returned when the fee rate of a tx is
+	// far above the fee rates required by the recently confirmed blocks.
+	ErrFeeRateAboveConfirmed = errors.New("fee rate far above recently " +
+		"confirmed fee rates")
+
+	// ErrChangeAddressReuse is returned when a request rejecting change
+	// reuse sends its change output to the script of one of its inputs.
+	ErrChangeAddressReuse = errors.New("change output reuses input script")
+
+	// ErrNoMempoolFeeSource is returned when a request specifies a
+	// MempoolFeePercentile, but the publisher is not configured with a
+	// MempoolFeeSource.
+	ErrNoMempoolFeeSource = errors.New("no mempool fee source configured")
 )
 
+// healthCheckConfTarget is the conf target used to query the fee estimator
+// when performing a health check.
+const healthCheckConfTarget = 6
+
+// defaultConfirmedFeeRateMultiplier is the default multiple of the recently
+// confirmed fee rate above which a fee rate is considered too high.
+const defaultConfirmedFeeRateMultiplier = 10
+
+// defaultPublishRetryBackoff is the default delay used before the first retry
+// of a transiently failed publish attempt. The delay is doubled for each
+// subsequent retry, up to the max publish retry backoff.
+const defaultPublishRetryBackoff = 500 * time.Millisecond
+
+// defaultMaxPublishRetryBackoff is the default cap on the delay between two
+// retries of a transiently failed publish attempt.
+const defaultMaxPublishRetryBackoff = 30 * time.Second
+
+// defaultEstimatorCacheSize is the default max number of conf targets whose
+// estimated fee rates are cached by the publisher.
+const defaultEstimatorCacheSize = 16
+
+// defaultFailureHistorySize is the default max number of failed requests
+// retained by the publisher.
+const defaultFailureHistorySize = 32
+
+// maxBumpHistorySize is the max number of broadcasts kept in the history of a
+// request. The oldest entries are dropped once it's reached.
+const maxBumpHistorySize = 64
+
+// globalSubscriberBufferSize is the size of the buffered chan returned by
+// SubscribeAll. Results are dropped once the buffer is full.
+const globalSubscriberBufferSize = 100
+
+// defaultSubscriberBufferSize is the default size of the buffered chan returned
+// to the subscriber of a request.
+const defaultSubscriberBufferSize = 1
+
+// defaultMaxConfReregistrations is the default max number of times the conf
+// ntfn of a confirmed tx is re-registered after being closed by the notifier.
+const defaultMaxConfReregistrations = 3
+
+// DefaultFeeRateSanityCap is the default absolute cap on the max fee rate
+// derived from the budget of a request, which is 10,000 sat/vb.
+const DefaultFeeRateSanityCap = chainfee.SatPerKWeight(2_500_000)
+
+// MaxLabelLength is the max length in bytes of the label of a bump request.
+const MaxLabelLength = 500
+
 var (
 	// dummyChangePkScript is a dummy tapscript change script that's used
 	// when we don't need a real address, just something that can be used
@@ -100,6 +282,71 @@ const (
 	// error, which means they cannot be retried with increased budget.
 	TxFatal
 
+	// TxReorged is sent when a previously confirmed tx has been reorged
+	// out of the chain. The publisher will resume monitoring the tx and
+	// perform fee bumps on it if needed.
+	TxReorged
+
+	// TxFeeUndershoot is sent as a diagnostic when the fee rate achieved
+	// by a published tx is below the intended fee rate by more than the
+	// configured tolerance, which may happen when the weight of the tx is
+	// under-estimated. The tx is still being monitored.
+	TxFeeUndershoot
+
+	// TxFeeBumped is sent each time a replacement tx with a higher fee
+	// rate is successfully broadcast, which gives the subscriber a live
+	// view of the escalating fee. It's sent on a best-effort basis after
+	// the TxReplaced event, and may be dropped if the subscriber is not
+	// ready to receive it.
+	TxFeeBumped
+
+	// TxBudgetExhausted is sent when the deadline has passed and the fee
+	// function can no longer increase the fee rate as the budget has been
+	// used up, while the tx is still unconfirmed. It's sent at most once
+	// per request so the caller can decide whether to give up or retry
+	// with more budget. The tx is still being monitored.
+	TxBudgetExhausted
+
+	// TxCancelled is sent when the context used to broadcast the request
+	// is cancelled. The request is no longer monitored, and the error in
+	// the result is the context's error.
+	TxCancelled
+
+	// TxInputSpent is sent when an input of the tx is found to be spent by
+	// another tx before a replacement is broadcast, in which case the tx
+	// can no longer be confirmed. The spent input is attached to the
+	// result, and the request is no longer monitored.
+	TxInputSpent
+
+	// TxDeadlineUnreachable is sent as a warning after the initial
+	// broadcast when the fee rate required to confirm the tx by its
+	// deadline is above the max fee rate allowed. The tx is published at
+	// the max fee rate allowed and is still being monitored.
+	TxDeadlineUnreachable
+
+	// TxLostRBFRace is sent when an input of the tx is spent by a
+	// conflicting mempool tx whose fee rate is above the max fee rate
+	// allowed by the request, so it cannot be outbid. The spent input is
+	// attached to the result, and the request is no longer monitored.
+	TxLostRBFRace
+
+	// TxTimedOut is sent when the tx is not confirmed within the Timeout
+	// of the request. The request is no longer monitored, and the latest
+	// tx, if any, is attached to the result.
+	TxTimedOut
+
+	// TxUneconomical is sent as a warning after the initial broadcast when
+	// the deadline cannot be reached because the fee is capped by the
+	// MaxFeeFractionOfValue of the request. The tx is published at the max
+	// fee rate allowed and is still being monitored.
+	TxUneconomical
+
+	// TxMempoolAccepted is sent when the tx passes the mempool acceptance
+	// check, right before it's published, if the EmitMempoolAccept of the
+	// request is set. It's followed by a TxPublished or TxFailed event
+	// once the tx is published.
+	TxMempoolAccepted
+
 	// sentinalEvent is used to check if an event is unknown.
 	sentinalEvent
 )
@@ -117,6 +364,28 @@ func (e BumpEvent) String() string {
 		return "Confirmed"
 	case TxFatal:
 		return "Fatal"
+	case TxReorged:
+		return "Reorged"
+	case TxFeeUndershoot:
+		return "FeeUndershoot"
+	case TxFeeBumped:
+		return "FeeBumped"
+	case TxBudgetExhausted:
+		return "BudgetExhausted"
+	case TxCancelled:
+		return "Cancelled"
+	case TxInputSpent:
+		return "InputSpent"
+	case TxDeadlineUnreachable:
+		return "DeadlineUnreachable"
+	case TxLostRBFRace:
+		return "LostRBFRace"
+	case TxTimedOut:
+		return "TimedOut"
+	case TxUneconomical:
+		return "Uneconomical"
+	case TxMempoolAccepted:
+		return "MempoolAccepted"
 	default:
 		return "Unknown"
 	}
@@ -127,6 +396,96 @@ func (e BumpEvent) Unknown() bool {
 	return e >= sentinalEvent
 }
 
+// informational returns true if the event is a diagnostic or warning that
+// doesn't change the state of the request, which can be dropped when the
+// subscriber is not keeping up.
+func (e BumpEvent) informational() bool {
+	switch e {
+	case TxFeeUndershoot, TxFeeBumped, TxBudgetExhausted,
+		TxDeadlineUnreachable, TxUneconomical, TxMempoolAccepted:
+
+		return true
+
+	default:
+		return false
+	}
+}
+
+// ReplaceReason describes why a sweeping tx was replaced.
+type ReplaceReason uint8
+
+const (
+	// ReplaceReasonNone is used when the tx has not been replaced.
+	ReplaceReasonNone ReplaceReason = iota
+
+	// ReplaceReasonDeadline is used when the fee function increased the
+	// fee rate as the deadline approaches.
+	ReplaceReasonDeadline
+
+	// ReplaceReasonMempoolFee is used when the tx was rejected by the
+	// mempool for paying too little fee, such as not meeting the mempool
+	// min fee, and was rebuilt with a higher fee rate.
+	ReplaceReasonMempoolFee
+
+	// ReplaceReasonRelayFloor is used when the relay fee floor has risen
+	// above the fee rate paid by the tx.
+	ReplaceReasonRelayFloor
+
+	// ReplaceReasonBudgetAdded is used when the budget of the request was
+	// increased via AddBudget.
+	ReplaceReasonBudgetAdded
+
+	// ReplaceReasonForced is used when the fee bump was forced via
+	// BumpNow.
+	ReplaceReasonForced
+)
+
+// String returns a human-readable string for the replace reason.
+func (r ReplaceReason) String() string {
+	switch r {
+	case ReplaceReasonNone:
+		return "None"
+	case ReplaceReasonDeadline:
+		return "Deadline"
+	case ReplaceReasonMempoolFee:
+		return "MempoolFee"
+	case ReplaceReasonRelayFloor:
+		return "RelayFloor"
+	case ReplaceReasonBudgetAdded:
+		return "BudgetAdded"
+	case ReplaceReasonForced:
+		return "Forced"
+	default:
+		return "Unknown"
+	}
+}
+
+// FeeProjection specifies the point of the fee schedule of a request used to
+// project the total fee it will pay.
+type FeeProjection uint8
+
+const (
+	// FeeProjectionDeadline projects the fee using the fee rate reached
+	// at the deadline.
+	FeeProjectionDeadline FeeProjection = iota
+
+	// FeeProjectionMidpoint projects the fee using the fee rate reached
+	// halfway to the deadline.
+	FeeProjectionMidpoint
+)
+
+// String returns a human-readable string for the fee projection.
+func (p FeeProjection) String() string {
+	switch p {
+	case FeeProjectionDeadline:
+		return "Deadline"
+	case FeeProjectionMidpoint:
+		return "Midpoint"
+	default:
+		return "Unknown"
+	}
+}
+
 // BumpRequest is used by the caller to give the Bumper the necessary info to
 // create and manage potential fee bumps for a set of inputs.
 type BumpRequest struct {
@@ -134,6 +493,12 @@ type BumpRequest struct {
 	// inputs.
 	Budget btcutil.Amount
 
+	// BudgetFraction optionally specifies the budget as a fraction, in
+	// range [0, 1), of the total value of the inputs. When set and Budget
+	// is not, the Budget is derived from it when the request is
+	// broadcast. If both are set, they must agree.
+	BudgetFraction float64
+
 	// Inputs is the set of inputs to sweep.
 	Inputs []input.Input
 
@@ -141,16 +506,175 @@ type BumpRequest struct {
 	// confirmed.
 	DeadlineHeight int32
 
+	// InputDeadlines optionally specifies the deadline heights of
+	// individual inputs, which allows inputs with different deadlines to
+	// be swept in the same tx. Inputs not found in this map use the
+	// DeadlineHeight.
+	InputDeadlines map[wire.OutPoint]int32
+
+	// NumConfs is the number of confirmations the tx must reach before
+	// it's reported as confirmed. The tx is not fee bumped once it's
+	// confirmed, and the record is kept to watch for reorgs till the tx is
+	// deep enough. Defaults to 1 if not set.
+	NumConfs uint32
+
+	// ConfTarget optionally specifies the number of blocks within which
+	// the tx should be confirmed. When set and DeadlineHeight is not, it's
+	// translated into a DeadlineHeight relative to the block height at
+	// which the fee function is initialized. If both are set, they must
+	// agree.
+	ConfTarget uint32
+
+	// AltConfTarget optionally specifies a second conf target used to
+	// derive an alternative fee schedule, which allows comparing how
+	// different conf targets perform. When set, the tx tracks both the
+	// schedule derived from its deadline and the alternative one, using
+	// the higher fee rate of the two each round, and the results report
+	// which schedule bound the fee rate. It's ignored when the request
+	// uses a FixedFeeRate or its deadline is unreachable.
+	AltConfTarget uint32
+
+	// CLTVExpiry optionally specifies the CLTV expiry height of a
+	// time-sensitive input, such as an HTLC, which the tx must confirm
+	// before. When set, the deadline height is capped at the expiry minus
+	// the CLTVBuffer using DeadlineFromCLTV, so the fee function reaches
+	// the max fee rate before the buffer is entered.
+	CLTVExpiry int32
+
+	// CLTVBuffer is the number of blocks kept as a safety margin before
+	// the CLTVExpiry. It's only used when CLTVExpiry is set.
+	CLTVBuffer int32
+
 	// DeliveryAddress is the script to send the change output to.
 	DeliveryAddress lnwallet.AddrWithKey
 
+	// DeliveryAddrFn is an optional function that's called each time a
+	// new tx is built to generate a fresh script to send the change output
+	// to, so replacements don't reuse the same address. When not set, the
+	// static DeliveryAddress is used.
+	DeliveryAddrFn func() ([]byte, error)
+
 	// MaxFeeRate is the maximum fee rate that can be used for fee bumping.
 	MaxFeeRate chainfee.SatPerKWeight
 
+	// MaxFeeRateVByte is an optional max fee rate expressed in sat/vbyte.
+	// When set, it's converted to sat/kw and used in place of MaxFeeRate.
+	// As one vbyte is exactly four weight units, the conversion is exact,
+	// while converting a sat/kw rate back to sat/vbyte rounds down.
+	// Setting both to conflicting values results in
+	// ErrConflictingMaxFeeRate.
+	MaxFeeRateVByte chainfee.SatPerVByte
+
+	// ExtraOutputs is an optional set of outputs to be added to the sweep
+	// tx, such as an OP_RETURN output carrying a small data push. Their
+	// values are paid from the swept inputs, and the remaining value goes
+	// to the change output. An OP_RETURN output must carry zero value and
+	// at most 80 bytes of data, while other outputs must be above dust.
+	ExtraOutputs []*wire.TxOut
+
+	// InputOutputs optionally maps an input to an output paying a
+	// specific script and amount, allowing the value of each input to be
+	// delivered to its own address. The outputs are added after the
+	// ExtraOutputs in the order of the inputs, and are subject to the same
+	// checks. The value left after paying them and the fee goes to the
+	// change output.
+	InputOutputs map[wire.OutPoint]*wire.TxOut
+
+	// MaxFeeAbsolute is an optional cap on the total fee paid by the tx,
+	// regardless of its fee rate. When set, the max fee that can be used
+	// is the minimum of it and the Budget.
+	MaxFeeAbsolute btcutil.Amount
+
+	// ShrinkChangeForFee allows the tx to pay a fee above the Budget when
+	// it's funded by shrinking the change output, as long as the change
+	// stays above the dust limit. The MaxFeeAbsolute, if set, is still
+	// enforced.
+	ShrinkChangeForFee bool
+
 	// StartingFeeRate is an optional parameter that can be used to specify
 	// the initial fee rate to use for the fee function.
 	StartingFeeRate fn.Option[chainfee.SatPerKWeight]
 
+	// Parents are the unconfirmed parent txns of the sweeping tx, which
+	// will be broadcast together with it as a package. This allows the
+	// sweeping tx to pay for parents that are below the min relay fee.
+	Parents []*wire.MsgTx
+
+	// BypassFeeCache specifies whether the initial fee rate should always
+	// be freshly estimated, skipping the publisher's estimator cache.
+	BypassFeeCache bool
+
+	// ConfPkScript is an optional script of one of the outputs of the
+	// sweeping tx, which is used when registering for its confirmation.
+	// When not set, the script of the first output is used.
+	ConfPkScript []byte
+
+	// Label is an optional tag, such as the channel point or the purpose
+	// of the sweep, which is attached to every result of the request and
+	// its logs. It must be no longer than MaxLabelLength bytes.
+	Label string
+
+	// IdempotencyKey is an optional key identifying the logical sweep of
+	// this request. When a request with the same key is still being
+	// monitored, Broadcast returns the existing subscription instead of
+	// creating a duplicate record.
+	IdempotencyKey string
+
+	// FixedFeeRate is an optional fee rate to be used for the tx without
+	// any fee bumping. When set, the tx is created using exactly this fee
+	// rate, which must not exceed the max fee rate allowed, and is never
+	// replaced.
+	FixedFeeRate chainfee.SatPerKWeight
+
+	// MempoolCheckOverride is an optional function used in place of the
+	// wallet's CheckMempoolAcceptance to validate the txns created for
+	// this request. When set, it's used even if the publisher is
+	// configured to skip the mempool check, and its error is handled the
+	// same way as the wallet's.
+	MempoolCheckOverride func(*wire.MsgTx) error
+
+	// RejectChangeReuse specifies whether the tx should be rejected with
+	// ErrChangeAddressReuse when its change output pays to the pkScript of
+	// one of its inputs, which catches accidental address reuse.
+	RejectChangeReuse bool
+
+	// OnFirstBroadcast is an optional callback fired once, in its own
+	// goroutine, with the txid of the first tx of the request that's
+	// successfully published. It's never fired for replacements, nor for
+	// a tx adopted via BumpExisting.
+	OnFirstBroadcast func(txid chainhash.Hash)
+
+	// NoBump specifies whether the tx should be broadcast once at the
+	// FixedFeeRate, which must be set, and only be monitored for its
+	// confirmation. Unlike a plain FixedFeeRate, the inputs of the tx use
+	// non-signaling sequences so it opts out of RBF, and it's never
+	// replaced. The inputs must not require a relative locktime or a
+	// sequence that signals RBF.
+	NoBump bool
+
+	// Timeout is an optional wall-clock duration, measured from when the
+	// request is broadcast, within which the tx must be confirmed. Once
+	// elapsed without a confirmation, a TxTimedOut event is sent and the
+	// request is no longer monitored. For an imported request, it's
+	// measured from when the request is imported.
+	Timeout time.Duration
+
+	// MaxFeeFractionOfValue optionally caps the fee, in range (0, 1], at
+	// this fraction of the total value of the inputs, so the sweep never
+	// pays more than the fraction in fees, regardless of the Budget.
+	MaxFeeFractionOfValue float64
+
+	// EmitMempoolAccept specifies whether a TxMempoolAccepted event is
+	// sent when the tx passes the mempool acceptance check, before the
+	// TxPublished event. No such event is sent if the check is skipped.
+	EmitMempoolAccept bool
+
+	// CPFPFallback specifies whether the request may fall back to bumping
+	// the conflicting mempool tx via a child, when replacing it is
+	// rejected for evicting too many txns as limited by BIP125 rule 5.
+	// See the CPFPInputSource of the publisher.
+	CPFPFallback bool
+
 	// ExtraTxOut tracks if this bump request has an optional set of extra
 	// outputs to add to the transaction.
 	ExtraTxOut fn.Option[SweepOutput]
@@ -158,13 +682,392 @@ type BumpRequest struct {
 	// Immediate is used to specify that the tx should be broadcast
 	// immediately.
 	Immediate bool
+
+	// LockTime is an optional block height to be used as the locktime of
+	// the sweeping tx. When not set, the locktime required by the inputs,
+	// or the current block height is used. It must not be greater than
+	// the current block height, otherwise the tx would be non-final.
+	LockTime uint32
+
+	// MempoolFeePercentile is an optional mempool fee rate percentile, in
+	// range (0, 1], to be tracked when bumping the fee. When set, the fee
+	// rate follows the live mempool percentile instead of a linear ramp.
+	// It requires the publisher to be configured with a MempoolFeeSource,
+	// otherwise the request is rejected.
+	MempoolFeePercentile fn.Option[float64]
+
+	// AnchorParent is an optional unconfirmed parent tx, usually the
+	// commitment tx of an anchor channel, which is CPFPed by sweeping its
+	// anchor output in this request. The fee rates used for this request
+	// are package fee rates, such that the parents paying less than the
+	// target fee rate and the sweeping tx combined pay the target fee
+	// rate. When set, it's used instead of the unconfirmed parents of the
+	// inputs.
+	AnchorParent fn.Option[input.TxInfo]
+
+	// deadlineUnreachable is set when the fee function is initialized if
+	// the fee rate required to confirm by the deadline is above the max
+	// fee rate allowed.
+	deadlineUnreachable bool
+
+	// uneconomical is set along with deadlineUnreachable if the max fee
+	// rate allowed is limited by the MaxFeeFractionOfValue.
+	uneconomical bool
+}
+
+// EarliestDeadline returns the most urgent deadline height among the inputs
+// of this request. Since it's calculated using the current set of inputs, the
+// next-earliest deadline is returned once the most urgent input is removed
+// from the request. If there are no inputs, the DeadlineHeight is returned.
+func (r *BumpRequest) EarliestDeadline() int32 {
+	if len(r.Inputs) == 0 {
+		return r.DeadlineHeight
+	}
+
+	earliest := int32(math.MaxInt32)
+	for _, inp := range r.Inputs {
+		deadline, ok := r.InputDeadlines[inp.OutPoint()]
+		if !ok {
+			deadline = r.DeadlineHeight
+		}
+
+		earliest = min(earliest, deadline)
+	}
+
+	return earliest
+}
+
+// numConfs returns the number of confirmations required for the tx to be
+// considered confirmed.
+func (r *BumpRequest) numConfs() uint32 {
+	if r.NumConfs == 0 {
+		return 1
+	}
+
+	return r.NumConfs
+}
+
+// maxFee returns the max fee that can be paid by the tx, which is the Budget,
+// capped by the MaxFeeAbsolute if set.
+func (r *BumpRequest) maxFee() btcutil.Amount {
+	maxFee := r.Budget
+	if r.MaxFeeAbsolute > 0 && r.MaxFeeAbsolute < maxFee {
+		maxFee = r.MaxFeeAbsolute
+	}
+
+	if r.valueFeeCapBinds() {
+		maxFee = r.valueFeeCap()
+	}
+
+	return maxFee
+}
+
+// valueFeeCap returns the max fee allowed by the MaxFeeFractionOfValue, which
+// is the fraction of the total value of the inputs.
+func (r *BumpRequest) valueFeeCap() btcutil.Amount {
+	return r.inputValue().MulF64(r.MaxFeeFractionOfValue)
+}
+
+// valueFeeCapBinds returns true if the MaxFeeFractionOfValue is set and caps
+// the fee below both the Budget and the MaxFeeAbsolute.
+func (r *BumpRequest) valueFeeCapBinds() bool {
+	if r.MaxFeeFractionOfValue == 0 {
+		return false
+	}
+
+	valueCap := r.valueFeeCap()
+	if r.MaxFeeAbsolute > 0 && r.MaxFeeAbsolute <= valueCap {
+		return false
+	}
+
+	return valueCap < r.Budget
+}
+
+// inputValue returns the total value of the inputs of the request.
+func (r *BumpRequest) inputValue() btcutil.Amount {
+	var total btcutil.Amount
+	for _, inp := range r.Inputs {
+		total += btcutil.Amount(inp.SignDesc().Output.Value)
+	}
+
+	return total
+}
+
+// validateMaxFeeFraction checks the MaxFeeFractionOfValue is in range [0, 1].
+func (r *BumpRequest) validateMaxFeeFraction() error {
+	if r.MaxFeeFractionOfValue < 0 || r.MaxFeeFractionOfValue > 1 {
+		return fmt.Errorf("%w: %v must be in range [0, 1]",
+			ErrInvalidMaxFeeFraction, r.MaxFeeFractionOfValue)
+	}
+
+	return nil
+}
+
+// validateLabel checks the label of the request is not too long.
+func (r *BumpRequest) validateLabel() error {
+	if len(r.Label) > MaxLabelLength {
+		return fmt.Errorf("%w: %d bytes exceeds max of %d",
+			ErrLabelTooLong, len(r.Label), MaxLabelLength)
+	}
+
+	return nil
+}
+
+// maxFeeRate returns the max fee rate specified by the request, taking
+// MaxFeeRateVByte into account. An error is returned if it conflicts with
+// MaxFeeRate.
+func (r *BumpRequest) maxFeeRate() (chainfee.SatPerKWeight, error) {
+	if r.MaxFeeRateVByte == 0 {
+		return r.MaxFeeRate, nil
+	}
+
+	rate := r.MaxFeeRateVByte.FeePerKWeight()
+	if r.MaxFeeRate != 0 && r.MaxFeeRate != rate {
+		return 0, fmt.Errorf("%w: MaxFeeRate=%v, MaxFeeRateVByte=%v "+
+			"(%v)", ErrConflictingMaxFeeRate, r.MaxFeeRate,
+			r.MaxFeeRateVByte, rate)
+	}
+
+	return rate, nil
+}
+
+// validateNoBump checks a request specifying NoBump has a fixed fee rate and
+// that none of its inputs requires a sequence signaling RBF.
+func (r *BumpRequest) validateNoBump() error {
+	if !r.NoBump {
+		return nil
+	}
+
+	if r.FixedFeeRate == 0 {
+		return fmt.Errorf("%w: fixed fee rate not set",
+			ErrInvalidNoBump)
+	}
+
+	for _, inp := range r.Inputs {
+		sequence := inputSequence(inp)
+		if sequence != 0 && sequence < wire.MaxTxInSequenceNum-1 {
+			return fmt.Errorf("%w: input %v requires sequence %v",
+				ErrInvalidNoBump, inp.OutPoint(), sequence)
+		}
+	}
+
+	return nil
+}
+
+// validateRequest checks the given request is valid, and derives its Budget
+// from the BudgetFraction if set, so the request is treated the same way
+// whether it's broadcast or previewed.
+func validateRequest(req *BumpRequest) error {
+	if err := req.validateLabel(); err != nil {
+		return err
+	}
+	if _, err := req.maxFeeRate(); err != nil {
+		return err
+	}
+	if err := req.resolveBudget(); err != nil {
+		return err
+	}
+	if err := req.validateMaxFeeFraction(); err != nil {
+		return err
+	}
+
+	return req.validateNoBump()
+}
+
+// resolveBudget derives the Budget from the BudgetFraction and the total value
+// of the inputs, if the fraction is set. An error is returned if the fraction
+// is not in range [0, 1), or the request also specifies a Budget that doesn't
+// match the derived one.
+func (r *BumpRequest) resolveBudget() error {
+	if r.BudgetFraction == 0 {
+		return nil
+	}
+
+	if r.BudgetFraction < 0 || r.BudgetFraction >= 1 {
+		return fmt.Errorf("%w: %v must be in range [0, 1)",
+			ErrInvalidBudgetFraction, r.BudgetFraction)
+	}
+
+	total := r.inputValue()
+	budget := total.MulF64(r.BudgetFraction)
+
+	switch {
+	// Derive the budget if it's not specified.
+	case r.Budget == 0:
+		r.Budget = budget
+
+	case r.Budget != budget:
+		return fmt.Errorf("%w: budget fraction %v of input value %v "+
+			"gives budget %v, but budget is %v",
+			ErrConflictingBudget, r.BudgetFraction, total, budget,
+			r.Budget)
+	}
+
+	return nil
+}
+
+// resolveDeadline translates the ConfTarget, if set, into the DeadlineHeight
+// using the given current height. An error is returned if the request also
+// specifies a DeadlineHeight that doesn't match the translated one. If a
+// CLTVExpiry is set, the DeadlineHeight is then capped at the deadline derived
+// from it.
+func (r *BumpRequest) resolveDeadline(currentHeight int32) error {
+	if r.ConfTarget != 0 {
+		deadline := currentHeight + int32(r.ConfTarget)
+
+		switch {
+		// Translate the conf target if no deadline is specified.
+		case r.DeadlineHeight == 0:
+			r.DeadlineHeight = deadline
+
+		case r.DeadlineHeight != deadline:
+			return fmt.Errorf("%w: conf target %v gives deadline "+
+				"%v at height %v, but deadline height is %v",
+				ErrConflictingDeadline, r.ConfTarget, deadline,
+				currentHeight, r.DeadlineHeight)
+		}
+	}
+
+	if r.CLTVExpiry == 0 {
+		return nil
+	}
+
+	deadline := DeadlineFromCLTV(r.CLTVExpiry, currentHeight, r.CLTVBuffer)
+	if r.DeadlineHeight == 0 || r.DeadlineHeight > deadline {
+		r.DeadlineHeight = deadline
+	}
+
+	return nil
+}
+
+// DeadlineFromCLTV returns the deadline height for a tx that must confirm
+// before the given CLTV expiry, leaving a safety buffer of the given number of
+// blocks. The deadline is never below the current height, in which case the
+// fee function uses its max fee rate right away.
+func DeadlineFromCLTV(cltvExpiry, currentHeight, buffer int32) int32 {
+	return max(cltvExpiry-max(buffer, 0), currentHeight)
+}
+
+// deliveryAddress returns the address to send the change output to. If a
+// DeliveryAddrFn is specified, a fresh script is generated and validated,
+// otherwise the static DeliveryAddress is returned.
+func (r *BumpRequest) deliveryAddress() (lnwallet.AddrWithKey, error) {
+	if r.DeliveryAddrFn == nil {
+		return r.DeliveryAddress, nil
+	}
+
+	pkScript, err := r.DeliveryAddrFn()
+	if err != nil {
+		return lnwallet.AddrWithKey{}, fmt.Errorf("generate delivery "+
+			"address: %w", err)
+	}
+
+	// Make sure the generated script is a type we can estimate its weight.
+	_, err = calcSweepTxWeight(r.Inputs, [][]byte{pkScript}, nil)
+	if err != nil {
+		return lnwallet.AddrWithKey{}, fmt.Errorf("invalid delivery "+
+			"address %x: %w", pkScript, err)
+	}
+
+	return lnwallet.AddrWithKey{DeliveryAddress: pkScript}, nil
+}
+
+// checkChangeReuse returns ErrChangeAddressReuse if the given change script
+// matches the pkScript of any of the inputs.
+func checkChangeReuse(inputs []input.Input, changeScript []byte) error {
+	for _, inp := range inputs {
+		signDesc := inp.SignDesc()
+		if signDesc == nil || signDesc.Output == nil {
+			continue
+		}
+
+		if bytes.Equal(signDesc.Output.PkScript, changeScript) {
+			return fmt.Errorf("%w: input=%v, script=%x",
+				ErrChangeAddressReuse, inp.OutPoint(),
+				changeScript)
+		}
+	}
+
+	return nil
+}
+
+// extraOutputs returns the outputs to be added to the sweep tx besides the
+// required and change outputs, which are the ExtraOutputs followed by the
+// InputOutputs in the order of the inputs.
+func (r *BumpRequest) extraOutputs() []*wire.TxOut {
+	if len(r.InputOutputs) == 0 {
+		return r.ExtraOutputs
+	}
+
+	outputs := make(
+		[]*wire.TxOut, 0, len(r.ExtraOutputs)+len(r.InputOutputs),
+	)
+	outputs = append(outputs, r.ExtraOutputs...)
+	for _, inp := range r.Inputs {
+		txOut, ok := r.InputOutputs[inp.OutPoint()]
+		if ok {
+			outputs = append(outputs, txOut)
+		}
+	}
+
+	return outputs
+}
+
+// validateInputOutputs checks every input in the InputOutputs belongs to the
+// request, and that the extra outputs don't spend more than the total value
+// of the inputs. Whether the remaining value also covers the fee is checked
+// when the tx is created.
+func (r *BumpRequest) validateInputOutputs() error {
+	if len(r.InputOutputs) == 0 {
+		return nil
+	}
+
+	var inputTotal btcutil.Amount
+	inputs := make(map[wire.OutPoint]struct{}, len(r.Inputs))
+	for _, inp := range r.Inputs {
+		inputs[inp.OutPoint()] = struct{}{}
+		inputTotal += btcutil.Amount(inp.SignDesc().Output.Value)
+	}
+
+	for op := range r.InputOutputs {
+		if _, ok := inputs[op]; !ok {
+			return fmt.Errorf("%w: input %v not found in request",
+				ErrInvalidExtraOutput, op)
+		}
+	}
+
+	var outputTotal btcutil.Amount
+	for _, txOut := range r.extraOutputs() {
+		outputTotal += btcutil.Amount(txOut.Value)
+	}
+
+	if outputTotal > inputTotal {
+		return fmt.Errorf("%w: outputs total %v exceeds inputs total "+
+			"%v", ErrInvalidExtraOutput, outputTotal, inputTotal)
+	}
+
+	return nil
 }
 
 // MaxFeeRateAllowed returns the maximum fee rate allowed for the given
 // request. It calculates the feerate using the supplied budget and the weight,
 // compares it with the specified MaxFeeRate, and returns the smaller of the
-// two.
+// two. The budget feerate is capped by the DefaultFeeRateSanityCap.
 func (r *BumpRequest) MaxFeeRateAllowed() (chainfee.SatPerKWeight, error) {
+	return r.maxFeeRateAllowed(DefaultFeeRateSanityCap)
+}
+
+// maxFeeRateAllowed returns the maximum fee rate allowed for the given
+// request as described in MaxFeeRateAllowed, using the given sanity cap on
+// the budget feerate.
+func (r *BumpRequest) maxFeeRateAllowed(
+	sanityCap chainfee.SatPerKWeight) (chainfee.SatPerKWeight, error) {
+
+	maxFeeRate, err := r.maxFeeRate()
+	if err != nil {
+		return 0, err
+	}
+
 	// We'll want to know if we have any blobs, as we need to factor this
 	// into the max fee rate for this bump request.
 	hasBlobs := fn.Any(r.Inputs, func(i input.Input) bool {
@@ -189,52 +1092,82 @@ func (r *BumpRequest) MaxFeeRateAllowed() (chainfee.SatPerKWeight, error) {
 	}
 
 	// Get the size of the sweep tx, which will be used to calculate the
-	// budget fee rate.
-	size, err := calcSweepTxWeight(
-		r.Inputs, sweepAddrs,
+	// budget fee rate. If we are CPFPing unconfirmed parents, this is the
+	// size of the package.
+	estimator, err := estimateSweepPackage(
+		r.Inputs, sweepAddrs, maxFeeRate, r.AnchorParent,
+		r.extraOutputs(),
 	)
 	if err != nil {
 		return 0, err
 	}
+	size := estimator.packageWeight()
+
+	// The fee already paid by the parents also counts towards the package
+	// fee rate.
+	budget := r.maxFee() + estimator.parentsFee
+
+	if size <= 0 {
+		return 0, fmt.Errorf("invalid sweep tx weight %v", size)
+	}
 
 	// Use the budget and MaxFeeRate to decide the max allowed fee rate.
 	// This is needed as, when the input has a large value and the user
 	// sets the budget to be proportional to the input value, the fee rate
 	// can be very high and we need to make sure it doesn't exceed the max
 	// fee rate.
-	maxFeeRateAllowed := chainfee.NewSatPerKWeight(r.Budget, size)
-	if maxFeeRateAllowed > r.MaxFeeRate {
+	maxFeeRateAllowed := chainfee.NewSatPerKWeight(budget, size)
+
+	// When the absolute fee cap binds, we round the fee rate down instead
+	// so the fee paid never exceeds the cap.
+	if r.maxFee() < r.Budget {
+		maxFeeRateAllowed = chainfee.SatPerKWeight(
+			budget * 1000 / btcutil.Amount(size),
+		)
+	}
+
+	// A tiny weight can give an implausibly high fee rate, or even
+	// overflow, so we clamp it to the sanity cap.
+	if maxFeeRateAllowed > sanityCap || maxFeeRateAllowed < 0 {
+		log.Warnf("Budget feerate %v exceeds sanity cap %v, clamping "+
+			"it: budget=%v, txWeight=%v", maxFeeRateAllowed,
+			sanityCap, budget, size)
+
+		maxFeeRateAllowed = sanityCap
+	}
+
+	if maxFeeRateAllowed > maxFeeRate {
 		log.Debugf("Budget feerate %v exceeds MaxFeeRate %v, use "+
 			"MaxFeeRate instead, txWeight=%v", maxFeeRateAllowed,
-			r.MaxFeeRate, size)
+			maxFeeRate, size)
 
-		return r.MaxFeeRate, nil
+		return maxFeeRate, nil
 	}
 
 	log.Debugf("Budget feerate %v below MaxFeeRate %v, use budget feerate "+
-		"instead, txWeight=%v", maxFeeRateAllowed, r.MaxFeeRate, size)
+		"instead, txWeight=%v", maxFeeRateAllowed, maxFeeRate, size)
 
 	return maxFeeRateAllowed, nil
 }
 
 // calcSweepTxWeight calculates the weight of the sweep tx. It assumes a
 // sweeping tx always has a single output(change).
-func calcSweepTxWeight(inputs []input.Input,
-	outputPkScript [][]byte) (lntypes.WeightUnit, error) {
+func calcSweepTxWeight(inputs []input.Input, outputPkScript [][]byte,
+	extraOutputs []*wire.TxOut) (lntypes.WeightUnit, error) {
 
 	// Use a const fee rate as we only use the weight estimator to
 	// calculate the size.
 	const feeRate = 1
 
 	// Initialize the tx weight estimator with,
-	// - nil outputs as we only have one single change output.
+	// - the extra outputs, if any, besides the change output.
 	// - const fee rate as we don't care about the fees here.
 	// - 0 maxfeerate as we don't care about fees here.
 	//
 	// TODO(yy): we should refactor the weight estimator to not require a
 	// fee rate and max fee rate and make it a pure tx weight calculator.
 	_, estimator, err := getWeightEstimate(
-		inputs, nil, feeRate, 0, outputPkScript,
+		inputs, extraOutputs, feeRate, 0, outputPkScript,
 	)
 	if err != nil {
 		return 0, err
@@ -243,6 +1176,26 @@ func calcSweepTxWeight(inputs []input.Input,
 	return estimator.weight(), nil
 }
 
+// estimateSweepPackage returns the weight estimator of the sweep tx paying the
+// given fee rate, which also tracks the unconfirmed parents CPFPed by the tx.
+// These are the parents of the inputs paying less than the fee rate, or the
+// given anchor parent if specified.
+func estimateSweepPackage(inputs []input.Input, outputPkScript [][]byte,
+	feeRate chainfee.SatPerKWeight, anchorParent fn.Option[input.TxInfo],
+	extraOutputs []*wire.TxOut) (*weightEstimator, error) {
+
+	_, estimator, err := getWeightEstimate(
+		inputs, extraOutputs, feeRate, 0, outputPkScript,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	anchorParent.WhenSome(estimator.setParent)
+
+	return estimator, nil
+}
+
 // BumpResult is used by the Bumper to send updates about the tx being
 // broadcast.
 type BumpResult struct {
@@ -264,8 +1217,57 @@ type BumpResult struct {
 	// Err is the error that occurred during the broadcast.
 	Err error
 
+	// RawTx is the serialized tx, which is only set for a TxFailed event
+	// when `LogFailedTx` is enabled in the publisher's config.
+	RawTx []byte
+
+	// SpentInput is the input found to be spent by another tx, which is
+	// only set for a TxInputSpent or TxLostRBFRace event.
+	SpentInput fn.Option[wire.OutPoint]
+
+	// Label is the label of the request that created this result.
+	Label string
+
+	// ConfHeight is the height of the block that confirmed the tx, which
+	// is only set for a TxConfirmed event.
+	ConfHeight uint32
+
+	// ConfBlockHash is the hash of the block that confirmed the tx, which
+	// is only set for a TxConfirmed event.
+	ConfBlockHash chainhash.Hash
+
+	// ReplaceReason is the reason the tx was replaced, which is only set
+	// for a TxFeeBumped event.
+	ReplaceReason ReplaceReason
+
+	// FeeSchedule is the fee schedule whose fee rate was used by the tx,
+	// which is only different from FeeSchedulePrimary when the request
+	// specifies an AltConfTarget.
+	FeeSchedule FeeSchedule
+
+	// Weight is the estimated weight of the tx, calculated in the same
+	// way as the weight used to derive the max fee rate of the request.
+	// It's only set for the TxPublished, TxReplaced and TxConfirmed
+	// events.
+	Weight int64
+
+	// VSize is the virtual size of the tx derived from its Weight.
+	VSize int64
+
 	// requestID is the ID of the request that created this record.
 	requestID uint64
+
+	// reorgSafe is closed once the confirmed tx is no longer watched for
+	// reorgs, either because it's buried deep enough or the watch has
+	// ended. It's left open when the tx is reorged out of the chain, in
+	// which case a TxReorged event is sent instead. It's only set for the
+	// TxConfirmed event.
+	reorgSafe <-chan struct{}
+}
+
+// RequestID returns the ID of the request that created this result.
+func (b *BumpResult) RequestID() uint64 {
+	return b.requestID
 }
 
 // String returns a human-readable string for the result.
@@ -278,11 +1280,19 @@ func (b *BumpResult) String() string {
 	return fmt.Sprintf("[%s]", desc)
 }
 
+// setWeight sets the Weight and VSize of the result using the given weight.
+func (b *BumpResult) setWeight(weight lntypes.WeightUnit) {
+	b.Weight = int64(weight)
+	b.VSize = int64(weight.ToVB())
+}
+
 // Validate validates the BumpResult so it's safe to use.
 func (b *BumpResult) Validate() error {
-	isFailureEvent := b.Event == TxFailed || b.Event == TxFatal
+	isFailureEvent := b.Event == TxFailed || b.Event == TxFatal ||
+		b.Event == TxCancelled || b.Event == TxTimedOut
 
-	// Every result must have a tx except the fatal or failed case.
+	// Every result must have a tx except the fatal, failed, cancelled or
+	// timed out case.
 	if b.Tx == nil && !isFailureEvent {
 		return fmt.Errorf("%w: nil tx", ErrInvalidBumpResult)
 	}
@@ -297,17 +1307,49 @@ func (b *BumpResult) Validate() error {
 		return fmt.Errorf("%w: nil replacing tx", ErrInvalidBumpResult)
 	}
 
-	// If it's a failed or fatal event, it must have an error.
+	// If it's a failed, fatal, cancelled or timed out event, it must have
+	// an error.
 	if isFailureEvent && b.Err == nil {
 		return fmt.Errorf("%w: nil error", ErrInvalidBumpResult)
 	}
 
+	// If it's an input spent event, it must have the spent input.
+	if b.Event == TxInputSpent && b.SpentInput.IsNone() {
+		return fmt.Errorf("%w: missing spent input",
+			ErrInvalidBumpResult)
+	}
+
+	// If it's a lost RBF race event, it must have the spent input and an
+	// error.
+	if b.Event == TxLostRBFRace &&
+		(b.SpentInput.IsNone() || b.Err == nil) {
+
+		return fmt.Errorf("%w: missing spent input or error",
+			ErrInvalidBumpResult)
+	}
+
+	// If it's a fee bumped event, it must have a fee rate.
+	if b.Event == TxFeeBumped && b.FeeRate == 0 {
+		return fmt.Errorf("%w: missing fee rate", ErrInvalidBumpResult)
+	}
+
+	// If it's a mempool accepted event, it must have a fee rate.
+	if b.Event == TxMempoolAccepted && b.FeeRate == 0 {
+		return fmt.Errorf("%w: missing fee rate", ErrInvalidBumpResult)
+	}
+
 	// If it's a confirmed event, it must have a fee rate and fee.
 	if b.Event == TxConfirmed && (b.FeeRate == 0 || b.Fee == 0) {
 		return fmt.Errorf("%w: missing fee rate or fee",
 			ErrInvalidBumpResult)
 	}
 
+	// If it's a confirmed event, it must have the conf height.
+	if b.Event == TxConfirmed && b.ConfHeight == 0 {
+		return fmt.Errorf("%w: missing conf height",
+			ErrInvalidBumpResult)
+	}
+
 	return nil
 }
 
@@ -316,6 +1358,12 @@ type TxPublisherConfig struct {
 	// Signer is used to create the tx signature.
 	Signer input.Signer
 
+	// SignerResolver is an optional function that returns the signer used
+	// to sign the given input, which allows the inputs of a tx to be
+	// signed by different backends. When not set, or a nil signer is
+	// returned, the Signer is used.
+	SignerResolver func(input.Input) input.Signer
+
 	// Wallet is used primarily to publish the tx.
 	Wallet Wallet
 
@@ -323,12 +1371,358 @@ type TxPublisherConfig struct {
 	// its deadline conf target.
 	Estimator chainfee.Estimator
 
-	// Notifier is used to monitor the confirmation status of the tx.
-	Notifier chainntnfs.ChainNotifier
+	// FallbackEstimators is an optional list of estimators that are tried
+	// in order when the Estimator fails to estimate the initial fee rate.
+	FallbackEstimators []chainfee.Estimator
+
+	// UtxoChecker is an optional checker used to verify the inputs of a
+	// tx are still unspent before broadcasting its replacement. When an
+	// input is found spent, a TxInputSpent event is sent instead.
+	UtxoChecker fn.Option[UtxoChecker]
+
+	// Mempool is an optional mempool watcher used to look for conflicting
+	// txns spending the inputs of a tx in each block. When a conflicting
+	// tx pays a fee rate above the max fee rate allowed by the request, a
+	// TxLostRBFRace event is sent and the tx is no longer bumped.
+	Mempool fn.Option[chainntnfs.MempoolWatcher]
+
+	// RelayFeeFallbackMargin is an optional margin added to the relay fee
+	// rate, which is used as the initial fee rate when the Estimator and
+	// all the FallbackEstimators fail. If not set, the initial fee rate
+	// cannot be estimated in that case and the request fails.
+	RelayFeeFallbackMargin fn.Option[chainfee.SatPerKWeight]
+
+	// Notifier is used to monitor the confirmation status of the tx.
+	Notifier chainntnfs.ChainNotifier
+
+	// MaxConfReregistrations is the max number of times the conf ntfn of
+	// a confirmed tx is re-registered when the notifier closes it, such
+	// as when the backend reconnects, before the tx is no longer watched
+	// for reorgs. If not set, defaultMaxConfReregistrations is used.
+	MaxConfReregistrations uint32
+
+	// TotalFeeProjection specifies the point of the fee schedule used by
+	// EstimateTotalFee. If not set, the fee rate at the deadline is used.
+	TotalFeeProjection FeeProjection
 
 	// AuxSweeper is an optional interface that can be used to modify the
 	// way sweep transaction are generated.
 	AuxSweeper fn.Option[AuxSweeper]
+
+	// LogFailedTx specifies whether the full serialized tx should be
+	// logged and attached to the result when a sweep fails, which can be
+	// used for manual inspection or rebroadcast.
+	LogFailedTx bool
+
+	// GroupSigningByWitnessType specifies whether the inputs should be
+	// signed in an order grouped by their witness types, which can speed
+	// up signers that cache by script. This only changes the order in
+	// which the signer is called, the input order in the final tx is
+	// preserved.
+	GroupSigningByWitnessType bool
+
+	// PublishRetryErrors is the list of errors that are considered
+	// transient when returned from publishing a tx. A publish attempt that
+	// fails with one of these errors is retried up to MaxPublishRetries
+	// times before the failure is reported.
+	PublishRetryErrors []error
+
+	// MaxPublishRetries is the max number of times a publish attempt is
+	// retried when it fails with a transient error. Zero disables retry.
+	MaxPublishRetries int
+
+	// PublishRetryBackoff is the delay used before the first retry of a
+	// publish attempt, which is doubled for each subsequent retry until
+	// MaxPublishRetryBackoff is reached. If not set,
+	// defaultPublishRetryBackoff is used.
+	PublishRetryBackoff time.Duration
+
+	// MaxPublishRetryBackoff caps the delay between two retries of a
+	// publish attempt, so a large MaxPublishRetries doesn't stall the
+	// broadcast. If not set, defaultMaxPublishRetryBackoff is used.
+	MaxPublishRetryBackoff time.Duration
+
+	// Broadcasters is an optional list of extra backends the txns are
+	// published to along with the Wallet, so a single backend cannot
+	// censor or drop them. A publish succeeds if any of them accepts the
+	// tx or already knows it.
+	Broadcasters []Broadcaster
+
+	// CPFPInputSource is an optional source of the inputs used by the
+	// requests that permit the CPFP fallback. When the initial tx of such
+	// a request is rejected for evicting too many txns, it's called with
+	// the conflicting mempool tx, found using the Mempool, and returns an
+	// input spending one of its outputs. The input is then swept by a
+	// child which pays for the conflicting tx instead.
+	CPFPInputSource func(parent *wire.MsgTx) (input.Input, error)
+
+	// MempoolFeeSource is an optional source of live mempool fee rates,
+	// which is used by requests that specify a MempoolFeePercentile.
+	MempoolFeeSource fn.Option[MempoolFeeSource]
+
+	// ConfirmedFeeRateSource is an optional source of the fee rates paid
+	// in the recently confirmed blocks, which is used to sanity check the
+	// fee rate of each tx before it's broadcast. A warning is logged when
+	// the fee rate exceeds the recent one by the
+	// ConfirmedFeeRateMultiplier.
+	ConfirmedFeeRateSource fn.Option[ConfirmedFeeRateSource]
+
+	// ConfirmedFeeRateMultiplier is the multiple of the recently confirmed
+	// fee rate above which a fee rate is considered too high. If not set,
+	// defaultConfirmedFeeRateMultiplier is used.
+	ConfirmedFeeRateMultiplier float64
+
+	// RefuseAboveConfirmedFeeRate specifies whether a tx whose fee rate is
+	// considered too high by the ConfirmedFeeRateSource is refused with
+	// ErrFeeRateAboveConfirmed, instead of only logging a warning.
+	RefuseAboveConfirmedFeeRate bool
+
+	// PreBroadcastHook is an optional hook that's invoked with each tx,
+	// including replacements, right before it's published. A non-nil
+	// error vetoes the broadcast, and a TxFailed event carrying the error
+	// is sent instead.
+	PreBroadcastHook func(*wire.MsgTx) error
+
+	// FeeUndershootTolerance is the max fraction, in range [0, 1), by
+	// which the achieved fee rate of a published tx can fall below the
+	// intended fee rate before a TxFeeUndershoot event is sent. Zero
+	// disables the check.
+	FeeUndershootTolerance float64
+
+	// PadFeeOnUndershoot specifies whether the fee function should be
+	// incremented by an extra step in the next fee bump when a fee
+	// undershoot is detected.
+	PadFeeOnUndershoot bool
+
+	// StartFeeRateMultiplier is applied to the estimated fee rate when
+	// seeding the fee function, which allows the initial tx to start above
+	// the estimate to reduce the number of replacements on a congested
+	// network. The result is capped by the request's max fee rate allowed.
+	// It must be no less than 1.0, and defaults to 1.0 when not set. It's
+	// not applied to requests that specify a StartingFeeRate.
+	StartFeeRateMultiplier float64
+
+	// BroadcastRateLimit is the max number of broadcasts allowed per
+	// second, including replacements, which is enforced using a token
+	// bucket so a burst of requests doesn't flood the backend. Zero
+	// disables the limit.
+	BroadcastRateLimit rate.Limit
+
+	// MinBumpIncrementPercent is the min percentage by which the fee rate
+	// of a replacement must exceed the fee rate paid by the current tx.
+	// Fee bumps below this threshold are skipped until the fee function
+	// climbs high enough, unless the fee function has reached its max.
+	// Zero disables the check.
+	MinBumpIncrementPercent float64
+
+	// FeeJitterPercent is the max percentage by which each fee rate used
+	// by the fee function is randomly perturbed, which makes the fee rates
+	// of the sweeping txns less predictable. The randomness is seeded per
+	// request. Zero disables jitter. It's not applied to requests that
+	// specify a FixedFeeRate.
+	FeeJitterPercent float64
+
+	// EstimatorCacheTTL is how long the fee rate estimated for a conf
+	// target is cached, so the requests initialized within this window
+	// reuse the same estimate. Zero disables the cache. Requests that set
+	// BypassFeeCache always use a fresh estimate.
+	EstimatorCacheTTL time.Duration
+
+	// EstimatorCacheSize is the max number of conf targets cached. If not
+	// set, defaultEstimatorCacheSize is used.
+	EstimatorCacheSize int
+
+	// FeeFunctionFactory is an optional factory used to create the fee
+	// functions of the requests, which allows custom fee rate curves to be
+	// used. If not set, NewLinearFeeFunctionFromParams is used. It's not
+	// used by requests that specify a FixedFeeRate, or a
+	// MempoolFeePercentile.
+	FeeFunctionFactory FeeFunctionFactory
+
+	// AllocationCurve is an optional curve passed to the fee function
+	// factory, which maps the progress towards the deadline to the
+	// fraction of the fee rate range to use. If not set, the budget is
+	// allocated evenly across the deadline.
+	AllocationCurve AllocationCurve
+
+	// MaxFeeRateDeltaPerBlock is an optional cap on how much the fee rate
+	// can be increased per block, which is passed to the fee function
+	// factory to smooth the fee bumps close to the deadline. When set, the
+	// fee rate may not reach the max fee rate allowed by the deadline.
+	MaxFeeRateDeltaPerBlock chainfee.SatPerKWeight
+
+	// FailureHistorySize is the max number of failed requests retained by
+	// the publisher, which can be listed using RecentFailures. The oldest
+	// failure is dropped once full. If not set,
+	// defaultFailureHistorySize is used.
+	FailureHistorySize int
+
+	// SkipMempoolCheck specifies whether the mempool acceptance check is
+	// skipped before broadcasting, which is needed for backends that don't
+	// support testmempoolaccept. When skipped, a tx rejected at publish
+	// time for paying too little fee is rebuilt with an increased fee
+	// rate and published again.
+	SkipMempoolCheck bool
+
+	// JumpToRequiredFeeRate specifies whether the fee function skips
+	// straight to the fee rate required by the mempool when a tx is
+	// rejected for paying too little fee and the required fee can be
+	// parsed from the rejection, instead of increasing by a single step.
+	JumpToRequiredFeeRate bool
+
+	// TrajectoryResetThreshold is the fraction, in range [0, 1), by which
+	// the estimated fee rate for a request's conf target must drop between
+	// two blocks to reset the trajectory of its fee function, such that
+	// future replacements ramp from the new estimate instead of
+	// overpaying once a congestion spike subsides. Zero disables it.
+	TrajectoryResetThreshold float64
+
+	// FirstBumpGracePeriod is the number of blocks after the initial
+	// broadcast during which the tx is not replaced, giving it a fair
+	// chance to confirm at the estimated fee rate. Once elapsed, the tx
+	// is bumped in every block as usual. Zero disables it.
+	FirstBumpGracePeriod uint32
+
+	// MinBudgetUtilizationByDeadline is the min fraction, in range [0, 1],
+	// of the max fee rate allowed by the budget that the fee rate must
+	// reach by the deadline. A fee function whose schedule ends below it
+	// is shaped to ramp towards it, and it's never raised above the max
+	// fee rate allowed. Zero disables it. It's not applied to requests
+	// that specify a FixedFeeRate.
+	MinBudgetUtilizationByDeadline float64
+
+	// FeeRateSanityCap is the absolute cap on the max fee rate derived
+	// from the budget of a request, which guards against an absurd fee
+	// rate that would drain the whole budget in one tx when the tx weight
+	// is tiny. If not set, DefaultFeeRateSanityCap is used.
+	FeeRateSanityCap chainfee.SatPerKWeight
+
+	// MaxInFlight is the max number of requests monitored at the same
+	// time. Once reached, new requests are rejected with
+	// ErrTooManyInFlight, or wait for a slot to be freed if
+	// BlockWhenFull is set. Zero means no limit.
+	MaxInFlight int
+
+	// BlockWhenFull specifies whether a new request waits for a slot to
+	// be freed when MaxInFlight is reached, instead of being rejected.
+	BlockWhenFull bool
+
+	// SubscriberBufferSize is the size of the buffered chan returned to
+	// the subscriber of each request. If not set,
+	// defaultSubscriberBufferSize is used.
+	SubscriberBufferSize int
+
+	// DropOldestResult specifies whether the oldest buffered result is
+	// dropped when a subscriber's chan is full, instead of blocking until
+	// the subscriber receives it. This prevents a slow subscriber from
+	// stalling the fee bumping, at the cost of missing results. Only
+	// informational results, such as TxFeeBumped, are dropped, while the
+	// results that change the state of the request are always delivered.
+	DropOldestResult bool
+
+	// Clock is used for all the timers and timestamps of the publisher,
+	// which can be mocked in tests. If not set, the real clock is used.
+	Clock clock.Clock
+
+	// BlockDebounce is an optional window used to coalesce blocks that
+	// arrive in quick succession, such as during a reorg. When set, the
+	// records are only processed once no new block has arrived for this
+	// duration, measured using the Clock, so the txns are evaluated for
+	// fee bumping once per settled tip. The blocks are still marked as
+	// processed right away, so the blockbeat dispatcher is not held up.
+	BlockDebounce time.Duration
+}
+
+// Validate checks the config is sane.
+func (c *TxPublisherConfig) Validate() error {
+	if c.StartFeeRateMultiplier != 0 && c.StartFeeRateMultiplier < 1 {
+		return fmt.Errorf("start fee rate multiplier %v must be no "+
+			"less than 1.0", c.StartFeeRateMultiplier)
+	}
+
+	if c.ConfirmedFeeRateMultiplier != 0 &&
+		c.ConfirmedFeeRateMultiplier < 1 {
+
+		return fmt.Errorf("confirmed fee rate multiplier %v must be "+
+			"no less than 1.0", c.ConfirmedFeeRateMultiplier)
+	}
+
+	if c.MinBumpIncrementPercent < 0 {
+		return fmt.Errorf("min bump increment percent %v must not be "+
+			"negative", c.MinBumpIncrementPercent)
+	}
+
+	if c.FeeJitterPercent < 0 || c.FeeJitterPercent >= 100 {
+		return fmt.Errorf("fee jitter percent %v must be in range "+
+			"[0, 100)", c.FeeJitterPercent)
+	}
+
+	if c.BroadcastRateLimit < 0 {
+		return fmt.Errorf("broadcast rate limit %v must not be "+
+			"negative", c.BroadcastRateLimit)
+	}
+
+	if c.EstimatorCacheTTL < 0 {
+		return fmt.Errorf("estimator cache ttl %v must not be "+
+			"negative", c.EstimatorCacheTTL)
+	}
+
+	if c.EstimatorCacheSize < 0 {
+		return fmt.Errorf("estimator cache size %v must not be "+
+			"negative", c.EstimatorCacheSize)
+	}
+
+	if c.FailureHistorySize < 0 {
+		return fmt.Errorf("failure history size %v must not be "+
+			"negative", c.FailureHistorySize)
+	}
+
+	if c.TrajectoryResetThreshold < 0 || c.TrajectoryResetThreshold >= 1 {
+		return fmt.Errorf("trajectory reset threshold %v must be in "+
+			"range [0, 1)", c.TrajectoryResetThreshold)
+	}
+
+	if c.MinBudgetUtilizationByDeadline < 0 ||
+		c.MinBudgetUtilizationByDeadline > 1 {
+
+		return fmt.Errorf("min budget utilization %v must be in "+
+			"range [0, 1]", c.MinBudgetUtilizationByDeadline)
+	}
+
+	if c.MaxInFlight < 0 {
+		return fmt.Errorf("max in flight %v must not be negative",
+			c.MaxInFlight)
+	}
+
+	if c.SubscriberBufferSize < 0 {
+		return fmt.Errorf("subscriber buffer size %v must not be "+
+			"negative", c.SubscriberBufferSize)
+	}
+
+	if c.FeeRateSanityCap < 0 {
+		return fmt.Errorf("fee rate sanity cap %v must not be "+
+			"negative", c.FeeRateSanityCap)
+	}
+
+	if c.PublishRetryBackoff < 0 {
+		return fmt.Errorf("publish retry backoff %v must not be "+
+			"negative", c.PublishRetryBackoff)
+	}
+
+	if c.MaxPublishRetryBackoff < 0 {
+		return fmt.Errorf("max publish retry backoff %v must not be "+
+			"negative", c.MaxPublishRetryBackoff)
+	}
+
+	if c.MaxPublishRetryBackoff != 0 &&
+		c.MaxPublishRetryBackoff < c.PublishRetryBackoff {
+
+		return fmt.Errorf("max publish retry backoff %v must be no "+
+			"less than publish retry backoff %v",
+			c.MaxPublishRetryBackoff, c.PublishRetryBackoff)
+	}
+
+	return nil
 }
 
 // TxPublisher is an implementation of the Bumper interface. It utilizes the
@@ -358,8 +1752,8 @@ type TxPublisher struct {
 	currentHeight atomic.Int32
 
 	// records is a map keyed by the requestCounter and the value is the tx
-	// being monitored.
-	records lnutils.SyncMap[uint64, *monitorRecord]
+	// being monitored. It also indexes the outpoints of their inputs.
+	records recordMap
 
 	// requestCounter is a monotonically increasing counter used to keep
 	// track of how many requests have been made.
@@ -369,6 +1763,81 @@ type TxPublisher struct {
 	// the chan that the publisher sends the fee bump result to.
 	subscriberChans lnutils.SyncMap[uint64, chan *BumpResult]
 
+	// haltErr is set once the publisher is halted, and is used as the
+	// error for all the failed sweeps.
+	haltErr atomic.Pointer[error]
+
+	// paused is set while the publisher is paused, during which no tx is
+	// broadcast by the monitor loop.
+	paused atomic.Bool
+
+	// pendingResults is the number of results that are being sent to
+	// their subscribers.
+	pendingResults atomic.Int64
+
+	// resultDelivered is signaled whenever a pending result is no longer
+	// pending, which wakes up a graceful stop waiting for the pending
+	// results to be delivered.
+	resultDelivered chan struct{}
+
+	// broadcastLimiter limits the rate of broadcasts. It's nil if no
+	// limit is configured.
+	broadcastLimiter *rate.Limiter
+
+	// feeCache caches the estimated fee rates. It's nil if the cache is
+	// disabled.
+	feeCache *cachedEstimator
+
+	// failures is the list of recently failed requests, ordered from the
+	// oldest to the newest, which is bounded by the FailureHistorySize.
+	failures []FailedBump
+
+	// failuresMtx guards the failures.
+	failuresMtx sync.Mutex
+
+	// globalSubscribers is a map keyed by the subscription ID, each item
+	// is a chan that receives the results of all requests.
+	globalSubscribers map[uint64]chan *BumpResult
+
+	// globalSubCounter is used to assign IDs to global subscriptions.
+	globalSubCounter uint64
+
+	// globalSubsMtx guards the globalSubscribers and globalSubCounter.
+	globalSubsMtx sync.RWMutex
+
+	// dropOldestMtx serializes making room in the subscribers' chans when
+	// DropOldestResult is set, so the order of the results is kept.
+	dropOldestMtx sync.Mutex
+
+	// droppedGlobalResults is the number of results dropped as a global
+	// subscriber wasn't keeping up.
+	droppedGlobalResults atomic.Uint64
+
+	// inFlight holds a slot for each monitored request, which is used to
+	// limit the number of requests to MaxInFlight. It's nil if no limit is
+	// configured.
+	inFlight chan struct{}
+
+	// bumping is a set of the requests whose txns are being fee bumped,
+	// which makes sure the monitor loop and BumpNow never bump the same
+	// tx concurrently.
+	bumping lnutils.SyncMap[uint64, struct{}]
+
+	// idempotencyKeys maps the idempotency keys of the monitored requests
+	// to their requestIDs.
+	idempotencyKeys lnutils.SyncMap[string, uint64]
+
+	// lastEstimates maps the requestIDs to the fee rates estimated for
+	// their conf targets in the previous block, which are used to detect
+	// a drop in the estimated fee rate.
+	lastEstimates lnutils.SyncMap[uint64, chainfee.SatPerKWeight]
+
+	// doneChans is a map keyed by the requestCounter, each item is a chan
+	// that's closed once the request is no longer monitored. It's only
+	// created for requests broadcast using a cancellable context or a
+	// timeout.
+	doneChans lnutils.SyncMap[uint64, chan struct{}]
+
 	// quit is used to signal the publisher to stop.
 	quit chan struct{}
 }
@@ -381,13 +1850,38 @@ var _ chainio.Consumer = (*TxPublisher)(nil)
 
 // NewTxPublisher creates a new TxPublisher.
 func NewTxPublisher(cfg TxPublisherConfig) *TxPublisher {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.NewDefaultClock()
+	}
+
 	tp := &TxPublisher{
 		cfg:             &cfg,
-		records:         lnutils.SyncMap[uint64, *monitorRecord]{},
+		records:         recordMap{},
 		subscriberChans: lnutils.SyncMap[uint64, chan *BumpResult]{},
+		resultDelivered: make(chan struct{}, 1),
 		quit:            make(chan struct{}),
 	}
 
+	if cfg.BroadcastRateLimit > 0 {
+		tp.broadcastLimiter = rate.NewLimiter(cfg.BroadcastRateLimit, 1)
+	}
+
+	if cfg.MaxInFlight > 0 {
+		tp.inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+
+	if cfg.EstimatorCacheTTL > 0 {
+		size := cfg.EstimatorCacheSize
+		if size == 0 {
+			size = defaultEstimatorCacheSize
+		}
+
+		tp.feeCache = newCachedEstimator(
+			tp.uncachedEstimator(), cfg.EstimatorCacheTTL, size,
+		)
+		tp.feeCache.now = cfg.Clock.Now
+	}
+
 	// Mount the block consumer.
 	tp.BeatConsumer = chainio.NewBeatConsumer(tp.quit, tp.Name())
 
@@ -406,708 +1900,4385 @@ func (t *TxPublisher) isNeutrinoBackend() bool {
 //
 // NOTE: part of the Bumper interface.
 func (t *TxPublisher) Broadcast(req *BumpRequest) <-chan *BumpResult {
+	// NOTE: the background context is never cancelled, so no error is
+	// returned here.
+	subscriber, _ := t.BroadcastWithContext(context.Background(), req)
+
+	return subscriber
+}
+
+// BroadcastWithContext works the same as Broadcast, except that the request
+// is cancelled once the given context is done. When cancelled, the request is
+// no longer monitored, and a TxCancelled event is sent to the subscriber. An
+// error is returned if the context is already done.
+func (t *TxPublisher) BroadcastWithContext(ctx context.Context,
+	req *BumpRequest) (<-chan *BumpResult, error) {
+
 	log.Tracef("Received broadcast request: %s",
 		lnutils.SpewLogClosure(req))
 
-	// Store the request.
-	requestID, record := t.storeInitialRecord(req)
-
-	// Create a chan to send the result to the caller.
-	subscriber := make(chan *BumpResult, 1)
-	t.subscriberChans.Store(requestID, subscriber)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	// Publish the tx immediately if specified.
-	if req.Immediate {
-		t.handleInitialBroadcast(record, requestID)
+	// Reject the request if the publisher has been halted or is shutting
+	// down.
+	if errPtr := t.haltErr.Load(); errPtr != nil {
+		return rejectBroadcast(req, *errPtr), nil
+	}
+	if t.stopped.Load() {
+		return rejectBroadcast(req, ErrPublisherStopped), nil
 	}
 
-	return subscriber
-}
+	// Return the existing subscription if the same logical sweep is
+	// already being monitored.
+	if subscriber, ok := t.subscriberByKey(req.IdempotencyKey); ok {
+		log.Debugf("Found existing request for idempotency key %q",
+			req.IdempotencyKey)
 
-// storeInitialRecord initializes a monitor record and saves it in the map.
-func (t *TxPublisher) storeInitialRecord(req *BumpRequest) (
-	uint64, *monitorRecord) {
+		return subscriber, nil
+	}
 
-	// Increase the request counter.
-	//
-	// NOTE: this is the only place where we increase the counter.
-	requestID := t.requestCounter.Add(1)
+	if err := validateRequest(req); err != nil {
+		return rejectBroadcast(req, err), nil
+	}
 
-	// Register the record.
-	record := &monitorRecord{req: req}
-	t.records.Store(requestID, record)
+	// Reject the request if any of its inputs is already being swept, as
+	// the txns would otherwise conflict with each other.
+	if err := t.checkTrackedInputs(req.Inputs); err != nil {
+		return rejectBroadcast(req, err), nil
+	}
 
-	return requestID, record
-}
+	// Take a slot for the request, which may wait for one to be freed.
+	if err := t.acquireInFlight(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 
-// NOTE: part of the `chainio.Consumer` interface.
-func (t *TxPublisher) Name() string {
-	return "TxPublisher"
-}
+		return rejectBroadcast(req, err), nil
+	}
 
-// initializeTx initializes a fee function and creates an RBF-compliant tx. If
-// succeeded, the initial tx is stored in the records map.
-func (t *TxPublisher) initializeTx(requestID uint64, req *BumpRequest) error {
-	// Create a fee bumping algorithm to be used for future RBF.
-	feeAlgo, err := t.initializeFeeFunction(req)
+	// Store the request. The inputs are checked again as another request
+	// may have taken them while we were waiting for a slot.
+	requestID, record, err := t.storeInitialRecord(req)
 	if err != nil {
-		return fmt.Errorf("init fee function: %w", err)
+		t.releaseInFlight()
+
+		return rejectBroadcast(req, err), nil
 	}
 
-	// Create the initial tx to be broadcasted. This tx is guaranteed to
-	// comply with the RBF restrictions.
-	err = t.createRBFCompliantTx(requestID, req, feeAlgo)
-	if err != nil {
-		return fmt.Errorf("create RBF-compliant tx: %w", err)
+	// Create a chan to send the result to the caller.
+	subscriber := t.newSubscriber()
+	t.subscriberChans.Store(requestID, subscriber)
+
+	if req.IdempotencyKey != "" {
+		t.idempotencyKeys.Store(req.IdempotencyKey, requestID)
 	}
 
-	return nil
-}
+	// Watch the context if it can be cancelled, and the timeout if
+	// specified.
+	if ctx.Done() != nil || req.Timeout > 0 {
+		done := make(chan struct{})
+		t.doneChans.Store(requestID, done)
 
-// initializeFeeFunction initializes a fee function to be used for this request
-// for future fee bumping.
-func (t *TxPublisher) initializeFeeFunction(
-	req *BumpRequest) (FeeFunction, error) {
+		if ctx.Done() != nil {
+			t.wg.Add(1)
+			go t.watchCancel(ctx, requestID, done)
+		}
 
-	// Get the max allowed feerate.
-	maxFeeRateAllowed, err := req.MaxFeeRateAllowed()
+		if req.Timeout > 0 {
+			t.wg.Add(1)
+			go t.watchTimeout(requestID, req.Timeout, done)
+		}
+	}
+
+	// Publish the tx immediately if specified, unless paused, in which
+	// case it's published once resumed.
+	if req.Immediate && !t.paused.Load() {
+		t.handleInitialBroadcast(record, requestID)
+	}
+
+	return subscriber, nil
+}
+
+// BumpExisting adopts the given tx, which has been created and broadcast
+// elsewhere, and monitors it for fee bumping. The inputs must be the full set
+// of inputs spent by the tx, which are used to calculate the fee it currently
+// pays. Unless the request specifies a StartingFeeRate, the fee rate of the tx
+// is used as the starting point of the fee function. Replacements will spend
+// the inputs to the request's DeliveryAddress. It returns a chan that the
+// caller can use to receive updates about the tx.
+func (t *TxPublisher) BumpExisting(tx *wire.MsgTx, inputs []input.Input,
+	req *BumpRequest) (<-chan *BumpResult, error) {
+
+	requestID, record, feeRate, err := t.adoptTx(tx, inputs, req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the initial conf target.
-	confTarget := calcCurrentConfTarget(
-		t.currentHeight.Load(), req.DeadlineHeight,
-	)
+	log.Infof("Adopting existing tx=%v with fee=%v, feerate=%v",
+		tx.TxHash(), record.fee, feeRate)
 
-	log.Debugf("Initializing fee function with conf target=%v, budget=%v, "+
-		"maxFeeRateAllowed=%v", confTarget, req.Budget,
-		maxFeeRateAllowed)
+	// Seed the history with the adopted tx, so its replacements are not
+	// mistaken for the first broadcast of the request.
+	seeded := *record
+	seeded.history = []BumpHistoryEntry{{
+		Height:  t.currentHeight.Load(),
+		Txid:    tx.TxHash(),
+		FeeRate: feeRate,
+		Fee:     record.fee,
+	}}
+	t.records.Store(requestID, &seeded)
 
-	// Initialize the fee function and return it.
-	//
-	// TODO(yy): return based on differet req.Strategy?
-	return NewLinearFeeFunction(
-		maxFeeRateAllowed, confTarget, t.cfg.Estimator,
-		req.StartingFeeRate,
-	)
+	// Create a chan to send the result to the caller.
+	subscriber := t.newSubscriber()
+	t.subscriberChans.Store(requestID, subscriber)
+
+	return subscriber, nil
 }
 
-// createRBFCompliantTx creates a tx that is compliant with RBF rules. It does
-// so by creating a tx, validate it using `TestMempoolAccept`, and bump its fee
-// and redo the process until the tx is valid, or return an error when non-RBF
-// related errors occur or the budget has been used up.
-func (t *TxPublisher) createRBFCompliantTx(requestID uint64, req *BumpRequest,
-	f FeeFunction) error {
+// BroadcastPSBT extracts the tx from the given finalized PSBT, which is
+// usually signed by an external signer, broadcasts it and monitors it for fee
+// bumping in the same way as BumpExisting. The tx must spend exactly the given
+// inputs, whose values must match the witness UTXOs of the PSBT if specified.
+// Replacements are signed using the Signer of the publisher. It returns a chan
+// that the caller can use to receive updates about the tx, which also
+// receives the result of the initial broadcast.
+func (t *TxPublisher) BroadcastPSBT(packet *psbt.Packet,
+	inputs []input.Input, req *BumpRequest) (<-chan *BumpResult, error) {
+
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, fmt.Errorf("extract tx from psbt: %w", err)
+	}
 
-	for {
-		// Create a new tx with the given fee rate and check its
-		// mempool acceptance.
-		sweepCtx, err := t.createAndCheckTx(req, f)
+	if err := checkPSBTInputs(packet, inputs); err != nil {
+		return nil, err
+	}
 
-		switch {
-		case err == nil:
-			// The tx is valid, store it.
-			t.storeRecord(
-				requestID, sweepCtx.tx, req, f, sweepCtx.fee,
-				sweepCtx.outpointToTxIndex,
-			)
+	requestID, record, feeRate, err := t.adoptTx(tx, inputs, req)
+	if err != nil {
+		return nil, err
+	}
 
-			log.Infof("Created initial sweep tx=%v for %v inputs: "+
-				"feerate=%v, fee=%v, inputs:\n%v",
-				sweepCtx.tx.TxHash(), len(req.Inputs),
-				f.FeeRate(), sweepCtx.fee,
-				inputTypeSummary(req.Inputs))
+	record.log().Infof("Broadcasting tx=%v from psbt with fee=%v, "+
+		"feerate=%v", tx.TxHash(), record.fee, feeRate)
 
-			return nil
+	// Create a chan to send the result to the caller.
+	subscriber := t.newSubscriber()
+	t.subscriberChans.Store(requestID, subscriber)
 
-		// If the error indicates the fees paid is not enough, we will
-		// ask the fee function to increase the fee rate and retry.
-		case errors.Is(err, lnwallet.ErrMempoolFee):
-			// We should at least start with a feerate above the
-			// mempool min feerate, so if we get this error, it
-			// means something is wrong earlier in the pipeline.
-			log.Errorf("Current fee=%v, feerate=%v, %v",
-				sweepCtx.fee, f.FeeRate(), err)
+	result, err := t.broadcast(requestID)
+	if err != nil {
+		result = &BumpResult{
+			Event:     TxFailed,
+			Err:       err,
+			requestID: requestID,
+		}
+	}
 
-			fallthrough
+	t.handleResult(result)
 
-		// We are not paying enough fees so we increase it.
-		case errors.Is(err, chain.ErrInsufficientFee):
-			increased := false
+	return subscriber, nil
+}
 
-			// Keep calling the fee function until the fee rate is
-			// increased or maxed out.
-			for !increased {
-				log.Debugf("Increasing fee for next round, "+
-					"current fee=%v, feerate=%v",
-					sweepCtx.fee, f.FeeRate())
+// checkPSBTInputs checks the values of the given inputs match the witness
+// UTXOs specified in the PSBT for the outpoints they spend.
+func checkPSBTInputs(packet *psbt.Packet, inputs []input.Input) error {
+	values := make(map[wire.OutPoint]int64, len(inputs))
+	for _, inp := range inputs {
+		values[inp.OutPoint()] = inp.SignDesc().Output.Value
+	}
 
-				// If the fee function tells us that we have
-				// used up the budget, we will return an error
-				// indicating this tx cannot be made. The
-				// sweeper should handle this error and try to
-				// cluster these inputs differetly.
-				increased, err = f.Increment()
-				if err != nil {
-					return err
-				}
-			}
+	for i, pInput := range packet.Inputs {
+		if pInput.WitnessUtxo == nil {
+			continue
+		}
 
-		// TODO(yy): suppose there's only one bad input, we can do a
-		// binary search to find out which input is causing this error
-		// by recreating a tx using half of the inputs and check its
-		// mempool acceptance.
-		default:
-			log.Debugf("Failed to create RBF-compliant tx: %v", err)
-			return err
+		op := packet.UnsignedTx.TxIn[i].PreviousOutPoint
+		value, ok := values[op]
+		if ok && value != pInput.WitnessUtxo.Value {
+			return fmt.Errorf("input %v has value %v, but psbt "+
+				"specifies %v", op, value,
+				pInput.WitnessUtxo.Value)
 		}
 	}
+
+	return nil
 }
 
-// storeRecord stores the given record in the records map.
-func (t *TxPublisher) storeRecord(requestID uint64, tx *wire.MsgTx,
-	req *BumpRequest, f FeeFunction, fee btcutil.Amount,
-	outpointToTxIndex map[wire.OutPoint]int) {
+// adoptTx validates the given tx spends exactly the given inputs, and stores a
+// record for it so it's monitored for confirmation and fee bumping. It returns
+// the requestID and the record, along with the fee rate paid by the tx.
+func (t *TxPublisher) adoptTx(tx *wire.MsgTx, inputs []input.Input,
+	req *BumpRequest) (uint64, *monitorRecord, chainfee.SatPerKWeight,
+	error) {
+
+	// Reject the request if the publisher has been halted or is shutting
+	// down.
+	if errPtr := t.haltErr.Load(); errPtr != nil {
+		return 0, nil, 0, *errPtr
+	}
+	if t.stopped.Load() {
+		return 0, nil, 0, ErrPublisherStopped
+	}
 
-	// Register the record.
-	t.records.Store(requestID, &monitorRecord{
-		tx:                tx,
-		req:               req,
-		feeFunction:       f,
-		fee:               fee,
-		outpointToTxIndex: outpointToTxIndex,
-	})
-}
+	if err := req.validateLabel(); err != nil {
+		return 0, nil, 0, err
+	}
+	if _, err := req.maxFeeRate(); err != nil {
+		return 0, nil, 0, err
+	}
 
-// createAndCheckTx creates a tx based on the given inputs, change output
-// script, and the fee rate. In addition, it validates the tx's mempool
-// acceptance before returning a tx that can be published directly, along with
-// its fee.
-func (t *TxPublisher) createAndCheckTx(req *BumpRequest,
-	f FeeFunction) (*sweepTxCtx, error) {
+	if err := t.checkTrackedInputs(inputs); err != nil {
+		return 0, nil, 0, err
+	}
 
-	// Create the sweep tx with max fee rate of 0 as the fee function
-	// guarantees the fee rate used here won't exceed the max fee rate.
-	sweepCtx, err := t.createSweepTx(
-		req.Inputs, req.DeliveryAddress, f.FeeRate(),
-	)
-	if err != nil {
-		return sweepCtx, fmt.Errorf("create sweep tx: %w", err)
+	if len(inputs) != len(tx.TxIn) {
+		return 0, nil, 0, fmt.Errorf("tx %v spends %d inputs, but %d "+
+			"are provided", tx.TxHash(), len(tx.TxIn), len(inputs))
 	}
 
-	// Sanity check the budget still covers the fee.
-	if sweepCtx.fee > req.Budget {
-		return sweepCtx, fmt.Errorf("%w: budget=%v, fee=%v",
-			ErrNotEnoughBudget, req.Budget, sweepCtx.fee)
+	// Map the inputs to their indexes in the tx, and sum up their values.
+	outpointToTxIndex := make(map[wire.OutPoint]int, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		outpointToTxIndex[txIn.PreviousOutPoint] = i
 	}
 
-	// If we had an extra txOut, then we'll update the result to include
-	// it.
-	req.ExtraTxOut = sweepCtx.extraTxOut
+	var inputTotal btcutil.Amount
+	for _, inp := range inputs {
+		op := inp.OutPoint()
+		if _, ok := outpointToTxIndex[op]; !ok {
+			return 0, nil, 0, fmt.Errorf("input %v not spent by "+
+				"tx %v", op, tx.TxHash())
+		}
 
-	// Validate the tx's mempool acceptance.
-	err = t.cfg.Wallet.CheckMempoolAcceptance(sweepCtx.tx)
+		inputTotal += btcutil.Amount(inp.SignDesc().Output.Value)
+	}
 
-	// Exit early if the tx is valid.
-	if err == nil {
-		return sweepCtx, nil
+	var outputTotal btcutil.Amount
+	for _, txOut := range tx.TxOut {
+		outputTotal += btcutil.Amount(txOut.Value)
 	}
 
-	// Print an error log if the chain backend doesn't support the mempool
-	// acceptance test RPC.
-	if errors.Is(err, rpcclient.ErrBackendVersion) {
-		log.Errorf("TestMempoolAccept not supported by backend, " +
-			"consider upgrading it to a newer version")
-		return sweepCtx, nil
+	fee := inputTotal - outputTotal
+	if fee <= 0 {
+		return 0, nil, 0, fmt.Errorf("tx %v has invalid fee %v",
+			tx.TxHash(), fee)
+	}
+
+	// Calculate the fee rate paid by the tx.
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+	feeRate := chainfee.NewSatPerKWeight(fee, lntypes.WeightUnit(weight))
+
+	// Make a copy of the request so the caller's request is not mutated.
+	adopted := *req
+	adopted.Inputs = inputs
+	if adopted.StartingFeeRate.IsNone() {
+		adopted.StartingFeeRate = fn.Some(feeRate)
+	}
+	if err := adopted.resolveBudget(); err != nil {
+		return 0, nil, 0, err
+	}
+	if err := adopted.validateMaxFeeFraction(); err != nil {
+		return 0, nil, 0, err
+	}
+
+	// Make sure the budget leaves room to bump the tx.
+	maxFeeRate, err := adopted.maxFeeRateAllowed(t.feeRateSanityCap())
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if feeRate >= maxFeeRate {
+		return 0, nil, 0, fmt.Errorf("%w: tx %v already pays fee "+
+			"rate %v, max allowed is %v", ErrNotEnoughBudget,
+			tx.TxHash(), feeRate, maxFeeRate)
+	}
+
+	// Initialize the fee function using the adopted fee rate.
+	f, err := t.initializeFeeFunction(&adopted)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("init fee function: %w", err)
+	}
+
+	// Take a slot for the request, which may wait for one to be freed.
+	if err := t.acquireInFlight(context.Background()); err != nil {
+		return 0, nil, 0, err
+	}
+
+	// Register the record so the tx will be monitored for confirmation
+	// and fee bumping.
+	//
+	// NOTE: we don't use storeInitialRecord here, as an initial record
+	// without a tx would be picked up for its initial broadcast.
+	requestID := t.requestCounter.Add(1)
+	record := t.newRecord(
+		requestID, tx, &adopted, f, fee, outpointToTxIndex,
+	)
+	if err := t.records.StoreUntracked(requestID, record); err != nil {
+		t.releaseInFlight()
+
+		return 0, nil, 0, err
+	}
+
+	return requestID, record, feeRate, nil
+}
+
+// RequestIDByKey returns the requestID of the monitored request that was
+// broadcast using the given idempotency key.
+func (t *TxPublisher) RequestIDByKey(key string) (uint64, bool) {
+	if key == "" {
+		return 0, false
+	}
+
+	return t.idempotencyKeys.Load(key)
+}
+
+// subscriberByKey returns the result chan of the monitored request that was
+// broadcast using the given idempotency key.
+func (t *TxPublisher) subscriberByKey(
+	key string) (<-chan *BumpResult, bool) {
+
+	requestID, ok := t.RequestIDByKey(key)
+	if !ok {
+		return nil, false
+	}
+
+	return t.Subscriber(requestID)
+}
+
+// Subscriber returns the chan used to send the results of the given request.
+func (t *TxPublisher) Subscriber(
+	requestID uint64) (<-chan *BumpResult, bool) {
+
+	subscriber, ok := t.subscriberChans.Load(requestID)
+	if !ok {
+		return nil, false
+	}
+
+	return subscriber, true
+}
+
+// TrackedOutpoints returns the outpoints of the inputs being swept by the
+// tracked requests, mapped to their requestIDs. Callers can use it to check
+// whether an input is already being swept before making a new request.
+func (t *TxPublisher) TrackedOutpoints() map[wire.OutPoint]uint64 {
+	return t.records.outpoints()
+}
+
+// IsTracking returns the requestID of the tracked request sweeping the given
+// outpoint, if any. Unlike TrackedOutpoints, it doesn't copy the index, so
+// it's cheap enough to be called frequently.
+func (t *TxPublisher) IsTracking(op wire.OutPoint) (uint64, bool) {
+	return t.records.lookup(op)
+}
+
+// checkTrackedInputs returns an ErrInputAlreadyTracked if any of the given
+// inputs is already being swept by a tracked request.
+func (t *TxPublisher) checkTrackedInputs(inputs []input.Input) error {
+	for _, inp := range inputs {
+		op := inp.OutPoint()
+		if requestID, ok := t.IsTracking(op); ok {
+			return fmt.Errorf("%w: %v in requestID=%v",
+				ErrInputAlreadyTracked, op, requestID)
+		}
+	}
+
+	return nil
+}
+
+// newSubscriber creates a chan used to send the results of a request to its
+// subscriber, whose size is specified by the SubscriberBufferSize.
+func (t *TxPublisher) newSubscriber() chan *BumpResult {
+	size := t.cfg.SubscriberBufferSize
+	if size == 0 {
+		size = defaultSubscriberBufferSize
+	}
+
+	return make(chan *BumpResult, size)
+}
+
+// rejectBroadcast returns a chan that holds a single TxFailed result with the
+// given error, which is used to reject a broadcast request.
+func rejectBroadcast(req *BumpRequest, err error) <-chan *BumpResult {
+	log.Warnf("Rejecting broadcast request: %v", err)
+
+	subscriber := make(chan *BumpResult, 1)
+	subscriber <- &BumpResult{
+		Event: TxFailed,
+		Err:   err,
+		Label: req.Label,
+	}
+
+	return subscriber
+}
+
+// Pause temporarily stops the publisher from broadcasting any txns. The
+// records are retained and their confirmations are still processed, while
+// the initial broadcasts of the new requests and the fee bumps are held back
+// until the publisher is resumed.
+func (t *TxPublisher) Pause() {
+	if t.paused.Swap(true) {
+		return
+	}
+
+	log.Info("TxPublisher paused")
+}
+
+// Resume resumes a paused publisher. The held back broadcasts and fee bumps
+// are carried out when the next block is processed.
+func (t *TxPublisher) Resume() {
+	if !t.paused.Swap(false) {
+		return
+	}
+
+	log.Info("TxPublisher resumed")
+}
+
+// Halt is an emergency kill-switch that stops the publisher from publishing
+// any further txns. All new broadcast requests are rejected with
+// ErrPublisherHalted, the rebroadcasting of the monitored txns is cancelled,
+// and a TxFailed event with the given reason is sent for all in-flight sweeps.
+func (t *TxPublisher) Halt(reason string) {
+	haltErr := fmt.Errorf("%w: %s", ErrPublisherHalted, reason)
+
+	// Exit early if the publisher has already been halted.
+	if !t.haltErr.CompareAndSwap(nil, &haltErr) {
+		log.Warnf("TxPublisher already halted, ignored reason: %v",
+			reason)
+
+		return
+	}
+
+	log.Criticalf("Halting TxPublisher: %v", reason)
+
+	t.records.ForEach(func(requestID uint64, r *monitorRecord) error {
+		// Skip confirmed records as their sweeps are finished.
+		if r.confirmed {
+			return nil
+		}
+
+		if r.tx != nil {
+			t.cfg.Wallet.CancelRebroadcast(r.tx.TxHash())
+		}
+
+		t.wg.Add(1)
+		go t.handleHalted(r, requestID, haltErr)
+
+		return nil
+	})
+}
+
+// handleHalted sends a TxFailed event with the halt error for the given record
+// and removes it from the maps.
+//
+// NOTE: Must be run as a goroutine to avoid blocking on sending the result.
+func (t *TxPublisher) handleHalted(r *monitorRecord, requestID uint64,
+	haltErr error) {
+
+	defer t.wg.Done()
+
+	t.handleResult(&BumpResult{
+		Event:     TxFailed,
+		Tx:        r.tx,
+		Err:       haltErr,
+		requestID: requestID,
+	})
+}
+
+// storeInitialRecord initializes a monitor record and saves it in the map. An
+// ErrInputAlreadyTracked is returned if any of its inputs is already tracked
+// by another record.
+func (t *TxPublisher) storeInitialRecord(req *BumpRequest) (
+	uint64, *monitorRecord, error) {
+
+	// Increase the request counter.
+	//
+	// NOTE: this is the only place where we increase the counter, except
+	// for BumpExisting, which adopts an already broadcast tx.
+	requestID := t.requestCounter.Add(1)
+
+	// Register the record.
+	record := &monitorRecord{
+		req:    req,
+		logger: newRequestLogger(requestID, req.Label, nil),
+	}
+	if err := t.records.StoreUntracked(requestID, record); err != nil {
+		return 0, nil, err
+	}
+
+	return requestID, record, nil
+}
+
+// NOTE: part of the `chainio.Consumer` interface.
+func (t *TxPublisher) Name() string {
+	return "TxPublisher"
+}
+
+// initializeTx initializes a fee function and creates an RBF-compliant tx. If
+// succeeded, the initial tx is stored in the records map.
+func (t *TxPublisher) initializeTx(requestID uint64, req *BumpRequest) error {
+	// Create a fee bumping algorithm to be used for future RBF.
+	feeAlgo, err := t.initializeFeeFunction(req)
+	if err != nil {
+		return fmt.Errorf("init fee function: %w", err)
+	}
+
+	// Create the initial tx to be broadcasted. This tx is guaranteed to
+	// comply with the RBF restrictions.
+	err = t.createRBFCompliantTx(requestID, req, feeAlgo)
+	if err != nil {
+		return fmt.Errorf("create RBF-compliant tx: %w", err)
+	}
+
+	return nil
+}
+
+// initializeFeeFunction initializes a fee function to be used for this request
+// for future fee bumping.
+func (t *TxPublisher) initializeFeeFunction(
+	req *BumpRequest) (FeeFunction, error) {
+
+	// Translate the conf target into a deadline height if specified, which
+	// is then used for the following fee bumps.
+	err := req.resolveDeadline(t.currentHeight.Load())
+	if err != nil {
+		return nil, err
+	}
+
+	// A mempool fee percentile cannot be tracked without a source of
+	// mempool fee rates, so we reject the request instead of silently
+	// using the default fee function.
+	if req.MempoolFeePercentile.IsSome() &&
+		t.cfg.MempoolFeeSource.IsNone() {
+
+		return nil, fmt.Errorf("%w: percentile=%v",
+			ErrNoMempoolFeeSource,
+			req.MempoolFeePercentile.UnwrapOr(0))
+	}
+
+	// Get the max allowed feerate.
+	maxFeeRateAllowed, err := req.maxFeeRateAllowed(
+		t.feeRateSanityCap(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the initial conf target.
+	confTarget := calcCurrentConfTarget(
+		t.currentHeight.Load(), req.EarliestDeadline(),
+	)
+
+	log.Debugf("Initializing fee function with conf target=%v, budget=%v, "+
+		"maxFeeRateAllowed=%v", confTarget, req.Budget,
+		maxFeeRateAllowed)
+
+	// Use a constant fee function if the request asks for a fixed fee
+	// rate.
+	if req.FixedFeeRate > 0 {
+		if req.FixedFeeRate > maxFeeRateAllowed {
+			return nil, fmt.Errorf("%w: fixed fee rate %v exceeds "+
+				"max fee rate allowed %v",
+				ErrFixedFeeRateTooHigh, req.FixedFeeRate,
+				maxFeeRateAllowed)
+		}
+
+		return NewConstantFeeFunction(req.FixedFeeRate, confTarget)
+	}
+
+	estimator := t.estimator(req)
+
+	// Apply the start fee rate multiplier if the request doesn't specify
+	// its starting fee rate.
+	startingFeeRate := req.StartingFeeRate
+	if startingFeeRate.IsNone() && t.cfg.StartFeeRateMultiplier > 1 &&
+		confTarget < chainfee.MaxBlockTarget {
+
+		start, err := t.scaledStartFeeRate(
+			estimator, confTarget, maxFeeRateAllowed,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		startingFeeRate = fn.Some(start)
+	}
+
+	// If the default fee function would estimate its starting fee rate
+	// using the conf target, we estimate it here to check whether the
+	// deadline can be reached using the max fee rate allowed.
+	source := t.cfg.MempoolFeeSource.UnwrapOr(nil)
+	usesMempool := source != nil && req.MempoolFeePercentile.IsSome()
+	if startingFeeRate.IsNone() && !usesMempool &&
+		t.cfg.FeeFunctionFactory == nil && confTarget > 1 &&
+		confTarget < chainfee.MaxBlockTarget {
+
+		info := FeeEstimateInfo{ConfTarget: confTarget}
+		required, err := info.Estimate(estimator, 0)
+		if err != nil {
+			return nil, fmt.Errorf("estimate initial fee rate: %w",
+				err)
+		}
+
+		// If the fee rate required by the deadline is above the max
+		// fee rate allowed, the tx cannot confirm in time. Instead of
+		// under-bidding, we go straight to the max fee rate allowed
+		// and let the caller know.
+		if required > maxFeeRateAllowed {
+			log.Warnf("Deadline unreachable: conf target=%v "+
+				"requires fee rate %v, above max fee rate "+
+				"allowed %v", confTarget, required,
+				maxFeeRateAllowed)
+
+			req.deadlineUnreachable = true
+			req.uneconomical = req.valueFeeCapBinds()
+
+			return NewConstantFeeFunction(
+				maxFeeRateAllowed, confTarget,
+			)
+		}
+
+		startingFeeRate = fn.Some(required)
+	}
+
+	var f FeeFunction
+
+	// If the request specifies a mempool fee percentile and we have a
+	// mempool fee source, use it to track the live mempool fee rates.
+	if usesMempool {
+		f, err = NewMempoolPercentileFeeFunction(
+			source, req.MempoolFeePercentile.UnwrapOr(0),
+			maxFeeRateAllowed,
+			estimator.RelayFeePerKW(), confTarget,
+			startingFeeRate,
+		)
+	} else {
+		// Initialize the fee function using the configured factory.
+		//
+		// TODO(yy): return based on differet req.Strategy?
+		factory := t.cfg.FeeFunctionFactory
+		if factory == nil {
+			factory = NewLinearFeeFunctionFromParams
+		}
+
+		f, err = factory(FeeFunctionParams{
+			StartingFeeRate: startingFeeRate,
+			MaxFeeRate:      maxFeeRateAllowed,
+			ConfTarget:      confTarget,
+			CurrentHeight:   t.currentHeight.Load(),
+			Estimator:       estimator,
+			AllocationCurve: t.cfg.AllocationCurve,
+			MaxFeeRateDelta: t.cfg.MaxFeeRateDeltaPerBlock,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the fee rate reaches the min budget utilization by the
+	// deadline if configured.
+	if t.cfg.MinBudgetUtilizationByDeadline > 0 {
+		f = withMinUtilization(
+			f, t.cfg.MinBudgetUtilizationByDeadline,
+			maxFeeRateAllowed, confTarget,
+		)
+	}
+
+	// Jitter the fee rates if configured.
+	if t.cfg.FeeJitterPercent > 0 {
+		f = newJitterFeeFunction(
+			f, t.cfg.FeeJitterPercent, jitterSeed(req),
+			estimator.RelayFeePerKW(),
+		)
+	}
+
+	// Track an alternative fee schedule if the request asks for one.
+	if req.AltConfTarget > 0 {
+		alt, err := NewLinearFeeFunctionFromParams(FeeFunctionParams{
+			MaxFeeRate:      maxFeeRateAllowed,
+			ConfTarget:      req.AltConfTarget,
+			CurrentHeight:   t.currentHeight.Load(),
+			Estimator:       estimator,
+			AllocationCurve: t.cfg.AllocationCurve,
+			MaxFeeRateDelta: t.cfg.MaxFeeRateDeltaPerBlock,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("alt fee schedule: %w", err)
+		}
+
+		f = newDualFeeFunction(f, alt, confTarget, req.AltConfTarget)
+	}
+
+	return f, nil
+}
+
+// jitterSeed derives the seed used to jitter the fee rates of the request from
+// its first input, so the jitter is deterministic per request.
+func jitterSeed(req *BumpRequest) int64 {
+	if len(req.Inputs) == 0 {
+		return 0
+	}
+
+	op := req.Inputs[0].OutPoint()
+	seed := binary.LittleEndian.Uint64(op.Hash[:8]) ^ uint64(op.Index)
+
+	return int64(seed)
+}
+
+// EstimateStartFeeRate returns the fee rate the given request would start at
+// if it were broadcast now, which is capped by its max fee rate allowed. The
+// request is not mutated, and no tx is created or tracked.
+func (t *TxPublisher) EstimateStartFeeRate(
+	req *BumpRequest) (chainfee.SatPerKWeight, error) {
+
+	// Make a copy of the request as validating it may resolve its budget,
+	// and initializing the fee function may resolve its deadline.
+	preview := *req
+	if err := validateRequest(&preview); err != nil {
+		return 0, err
+	}
+
+	f, err := t.initializeFeeFunction(&preview)
+	if err != nil {
+		return 0, fmt.Errorf("init fee function: %w", err)
+	}
+
+	return f.FeeRate(), nil
+}
+
+// EstimateTotalFee projects the total fee the given request will pay if its tx
+// confirms around the point of its fee schedule specified by the configured
+// TotalFeeProjection. The fee is the projected fee rate times the estimated
+// weight of the tx. When CPFPing an anchor parent, the projected rate is a
+// package fee rate, and the fee already paid by the parent is deducted. The
+// request is not mutated, and no tx is created or tracked.
+func (t *TxPublisher) EstimateTotalFee(req *BumpRequest) (btcutil.Amount,
+	error) {
+
+	// Make a copy of the request as validating it may resolve its budget,
+	// and initializing the fee function may resolve its deadline.
+	preview := *req
+	if err := validateRequest(&preview); err != nil {
+		return 0, err
+	}
+
+	f, err := t.initializeFeeFunction(&preview)
+	if err != nil {
+		return 0, fmt.Errorf("init fee function: %w", err)
+	}
+
+	feeRate := projectFeeRate(f.Schedule(), t.cfg.TotalFeeProjection)
+	if feeRate == 0 {
+		feeRate = f.FeeRate()
+	}
+
+	// The fee already paid by the unconfirmed parents counts towards the
+	// package fee rate.
+	estimator, err := estimateSweepPackage(
+		preview.Inputs,
+		[][]byte{preview.DeliveryAddress.DeliveryAddress},
+		feeRate, preview.AnchorParent, preview.extraOutputs(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("estimate tx weight: %w", err)
+	}
+
+	fee := estimator.feeWithParent()
+
+	log.Debugf("Estimated total fee=%v using feerate=%v at %v, "+
+		"weight=%v", fee, feeRate, t.cfg.TotalFeeProjection,
+		estimator.packageWeight())
+
+	return fee, nil
+}
+
+// projectFeeRate returns the fee rate of the given schedule at the point
+// specified by the projection. Zero is returned for an empty schedule.
+func projectFeeRate(schedule []chainfee.SatPerKWeight,
+	projection FeeProjection) chainfee.SatPerKWeight {
+
+	if len(schedule) == 0 {
+		return 0
+	}
+
+	if projection == FeeProjectionMidpoint {
+		return schedule[(len(schedule)-1)/2]
+	}
+
+	return schedule[len(schedule)-1]
+}
+
+// feeRateSanityCap returns the configured absolute cap on the budget fee rate,
+// or DefaultFeeRateSanityCap if not set.
+func (t *TxPublisher) feeRateSanityCap() chainfee.SatPerKWeight {
+	if t.cfg.FeeRateSanityCap == 0 {
+		return DefaultFeeRateSanityCap
+	}
+
+	return t.cfg.FeeRateSanityCap
+}
+
+// estimator returns the fee estimator used to estimate the initial fee rate
+// of the given request, which uses the cached fee rates unless the request
+// bypasses the cache.
+func (t *TxPublisher) estimator(req *BumpRequest) chainfee.Estimator {
+	if t.feeCache != nil && !req.BypassFeeCache {
+		return t.feeCache
+	}
+
+	return t.uncachedEstimator()
+}
+
+// uncachedEstimator returns the fee estimator which falls back to the
+// configured fallback estimators and the relay fee rate when the primary
+// estimator fails.
+func (t *TxPublisher) uncachedEstimator() chainfee.Estimator {
+	if len(t.cfg.FallbackEstimators) == 0 &&
+		t.cfg.RelayFeeFallbackMargin.IsNone() {
+
+		return t.cfg.Estimator
+	}
+
+	return newFallbackEstimator(
+		t.cfg.Estimator, t.cfg.FallbackEstimators,
+		t.cfg.RelayFeeFallbackMargin,
+	)
+}
+
+// scaledStartFeeRate estimates the fee rate for the given conf target using
+// the given estimator and scales it by the configured StartFeeRateMultiplier,
+// capped by the max fee rate allowed.
+func (t *TxPublisher) scaledStartFeeRate(estimator chainfee.Estimator,
+	confTarget uint32,
+	maxFeeRate chainfee.SatPerKWeight) (chainfee.SatPerKWeight, error) {
+
+	fee := FeeEstimateInfo{ConfTarget: confTarget}
+	estimated, err := fee.Estimate(estimator, maxFeeRate)
+	if err != nil {
+		return 0, fmt.Errorf("estimate initial fee rate: %w", err)
+	}
+
+	feeRate := chainfee.SatPerKWeight(
+		float64(estimated) * t.cfg.StartFeeRateMultiplier,
+	)
+	if feeRate > maxFeeRate {
+		feeRate = maxFeeRate
+	}
+
+	log.Debugf("Scaled start fee rate from %v to %v using multiplier=%v",
+		estimated, feeRate, t.cfg.StartFeeRateMultiplier)
+
+	return feeRate, nil
+}
+
+// createRBFCompliantTx creates a tx that is compliant with RBF rules. It does
+// so by creating a tx, validate it using `TestMempoolAccept`, and bump its fee
+// and redo the process until the tx is valid, or return an error when non-RBF
+// related errors occur or the budget has been used up. Once created, the tx is
+// stored in the records map.
+func (t *TxPublisher) createRBFCompliantTx(requestID uint64, req *BumpRequest,
+	f FeeFunction) error {
+
+	sweepCtx, err := t.buildRBFCompliantTx(
+		req, f, newRequestLogger(requestID, req.Label, nil),
+	)
+
+	// Further RBF attempts are futile if the replacement evicts too many
+	// txns, so we try to bump the conflicting tx via a child instead.
+	if errors.Is(err, ErrTooManyReplacements) {
+		return t.createCPFPTx(requestID, req, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The tx is valid, store it.
+	r := t.storeInitialTx(requestID, req, f, sweepCtx)
+
+	r.log().Infof("Created initial sweep tx=%v for %v inputs: feerate=%v, "+
+		"fee=%v, inputs:\n%v", sweepCtx.tx.TxHash(), len(req.Inputs),
+		f.FeeRate(), sweepCtx.fee, inputTypeSummary(req.Inputs))
+
+	return nil
+}
+
+// storeInitialTx stores the record of the given initial tx, which is created
+// using the given request and fee function, and returns it.
+func (t *TxPublisher) storeInitialTx(requestID uint64, req *BumpRequest,
+	f FeeFunction, sweepCtx *sweepTxCtx) *monitorRecord {
+
+	r := t.storeRecord(
+		requestID, sweepCtx.tx, req, f, sweepCtx.fee,
+		sweepCtx.outpointToTxIndex,
+	)
+	if !sweepCtx.mempoolAccepted {
+		return r
+	}
+
+	accepted := *r
+	accepted.mempoolAccepted = true
+	t.records.Store(requestID, &accepted)
+
+	return &accepted
+}
+
+// createCPFPTx falls back to bumping the conflicting mempool tx, which spends
+// the inputs of the request, via a child when replacing it evicts too many
+// txns. The child sweeps the input given by the CPFPInputSource, and pays for
+// the conflicting tx as its unconfirmed parent. Once created, the child is
+// stored in the records map in place of the replacement. The given rbfErr is
+// returned, wrapped with the reason, if the fallback cannot be used.
+func (t *TxPublisher) createCPFPTx(requestID uint64, req *BumpRequest,
+	rbfErr error) error {
+
+	if !req.CPFPFallback {
+		return fmt.Errorf("%w: CPFP fallback not permitted by request",
+			rbfErr)
+	}
+
+	source := t.cfg.CPFPInputSource
+	if source == nil {
+		return fmt.Errorf("%w: no CPFP input source", rbfErr)
+	}
+
+	conflict := t.findConflictingTx(req.Inputs)
+	if conflict.IsNone() {
+		return fmt.Errorf("%w: conflicting tx not found", rbfErr)
+	}
+	parent := conflict.UnwrapOr(wire.MsgTx{})
+
+	childInput, err := source(&parent)
+	if err != nil {
+		return fmt.Errorf("get CPFP input: %w", err)
+	}
+
+	// Sweep the child input instead, paying for the conflicting tx. Its
+	// fee is only known if it spends no inputs other than ours, otherwise
+	// the child pays for the whole parent.
+	//
+	// NOTE: the deadline has already been resolved, so the conf target is
+	// removed to avoid it being resolved again using the current height.
+	child := *req
+	child.ConfTarget = 0
+	child.Inputs = []input.Input{childInput}
+	child.AnchorParent = fn.Some(input.TxInfo{
+		Fee:    max(spentInputsFee(req.Inputs, &parent), 0),
+		Weight: weightOf(&parent),
+	})
+
+	f, err := t.initializeFeeFunction(&child)
+	if err != nil {
+		return fmt.Errorf("init CPFP fee function: %w", err)
+	}
+
+	sweepCtx, err := t.buildRBFCompliantTx(
+		&child, f, newRequestLogger(requestID, req.Label, nil),
+	)
+	if err != nil {
+		return fmt.Errorf("create CPFP tx: %w", err)
+	}
+
+	r := t.storeInitialTx(requestID, &child, f, sweepCtx)
+
+	r.log().Infof("Created CPFP child tx=%v of conflicting tx=%v: "+
+		"feerate=%v, fee=%v, input=%v", sweepCtx.tx.TxHash(),
+		parent.TxHash(), f.FeeRate(), sweepCtx.fee,
+		childInput.OutPoint())
+
+	return nil
+}
+
+// findConflictingTx looks up the mempool for a tx spending any of the given
+// inputs.
+func (t *TxPublisher) findConflictingTx(
+	inputs []input.Input) fn.Option[wire.MsgTx] {
+
+	mempool := t.cfg.Mempool.UnwrapOr(nil)
+	if mempool == nil {
+		return fn.None[wire.MsgTx]()
+	}
+
+	for _, inp := range inputs {
+		spend := mempool.LookupInputMempoolSpend(inp.OutPoint())
+		if spend.IsSome() {
+			return spend
+		}
+	}
+
+	return fn.None[wire.MsgTx]()
+}
+
+// buildRBFCompliantTx creates a tx that is compliant with RBF rules without
+// storing it. It keeps asking the fee function to increase the fee rate until
+// the tx passes the mempool acceptance check, or returns an error when non-RBF
+// related errors occur or the budget has been used up. The given logger is
+// used to log the attempts.
+func (t *TxPublisher) buildRBFCompliantTx(req *BumpRequest, f FeeFunction,
+	logger btclog.Logger) (*sweepTxCtx, error) {
+
+	for {
+		// Create a new tx with the given fee rate and check its
+		// mempool acceptance.
+		sweepCtx, err := t.createAndCheckTx(req, f, logger)
+
+		switch {
+		case err == nil:
+			return sweepCtx, nil
+
+		// If the error indicates the fees paid is not enough, we will
+		// ask the fee function to increase the fee rate and retry.
+		case errors.Is(err, lnwallet.ErrMempoolFee):
+			// We should at least start with a feerate above the
+			// mempool min feerate, so if we get this error, it
+			// means something is wrong earlier in the pipeline.
+			logger.Errorf("Current fee=%v, feerate=%v, %v",
+				sweepCtx.fee, f.FeeRate(), err)
+
+			fallthrough
+
+		// We are not paying enough fees so we increase it.
+		case errors.Is(err, chain.ErrInsufficientFee):
+			// A fixed fee rate can never be increased, so we fail
+			// here instead.
+			if _, ok := f.(*ConstantFeeFunction); ok {
+				return nil, fmt.Errorf("%w: fixed fee rate %v "+
+					"cannot be increased: %v",
+					ErrMaxPosition, f.FeeRate(), err)
+			}
+
+			shortfall := mempoolShortfall(logger, sweepCtx.tx, err)
+			increased := false
+
+			// Keep calling the fee function until the fee rate is
+			// increased or maxed out.
+			for !increased {
+				logger.Debugf("Increasing fee for next round, "+
+					"current fee=%v, feerate=%v",
+					sweepCtx.fee, f.FeeRate())
+
+				// If the fee function tells us that we have
+				// used up the budget, we will return an error
+				// indicating this tx cannot be made. The
+				// sweeper should handle this error and try to
+				// cluster these inputs differetly.
+				increased, err = f.Increment(
+					weightOf(sweepCtx.tx),
+				)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			t.jumpToRequiredFeeRate(
+				f, weightOf(sweepCtx.tx), shortfall, logger,
+			)
+
+		// Replacing the conflicting txns would evict more txns than
+		// allowed by BIP125 rule 5, which more fees cannot fix.
+		case isTooManyReplacementsErr(err):
+			logger.Warnf("Tx rejected for evicting too many txns: "+
+				"%v", err)
+
+			return nil, fmt.Errorf("%w: %w", ErrTooManyReplacements,
+				err)
+
+		// TODO(yy): suppose there's only one bad input, we can do a
+		// binary search to find out which input is causing this error
+		// by recreating a tx using half of the inputs and check its
+		// mempool acceptance.
+		default:
+			logger.Debugf("Failed to create RBF-compliant tx: %v",
+				err)
+			return nil, err
+		}
+	}
+}
+
+// DryRun builds and validates the tx that would be broadcast for the given
+// request without publishing it. It initializes a fee function and creates an
+// RBF-compliant tx, which is checked against the mempool, then returns the tx
+// along with its fee rate and fee. No record is created and the request
+// counter is left untouched.
+func (t *TxPublisher) DryRun(req *BumpRequest) (*wire.MsgTx,
+	chainfee.SatPerKWeight, btcutil.Amount, error) {
+
+	// Make a copy of the request so the caller's request is not modified
+	// when validating it and creating the tx.
+	reqCopy := *req
+	if err := validateRequest(&reqCopy); err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Create a fee bumping algorithm as if the request is broadcast.
+	f, err := t.initializeFeeFunction(&reqCopy)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("init fee function: %w", err)
+	}
+
+	sweepCtx, err := t.buildRBFCompliantTx(&reqCopy, f, log)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("create RBF-compliant tx: %w", err)
+	}
+
+	log.Debugf("Dry run created sweep tx=%v for %v inputs: feerate=%v, "+
+		"fee=%v", sweepCtx.tx.TxHash(), len(req.Inputs), f.FeeRate(),
+		sweepCtx.fee)
+
+	return sweepCtx.tx, f.FeeRate(), sweepCtx.fee, nil
+}
+
+// storeRecord stores the given record in the records map and returns it.
+func (t *TxPublisher) storeRecord(requestID uint64, tx *wire.MsgTx,
+	req *BumpRequest, f FeeFunction, fee btcutil.Amount,
+	outpointToTxIndex map[wire.OutPoint]int) *monitorRecord {
+
+	// Register the record.
+	record := t.newRecord(requestID, tx, req, f, fee, outpointToTxIndex)
+	t.records.Store(requestID, record)
+
+	return record
+}
+
+// newRecord creates a monitor record for the given tx without storing it.
+func (t *TxPublisher) newRecord(requestID uint64, tx *wire.MsgTx,
+	req *BumpRequest, f FeeFunction, fee btcutil.Amount,
+	outpointToTxIndex map[wire.OutPoint]int) *monitorRecord {
+
+	return &monitorRecord{
+		tx:                tx,
+		req:               req,
+		feeFunction:       f,
+		fee:               fee,
+		outpointToTxIndex: outpointToTxIndex,
+		heightHint:        uint32(t.currentHeight.Load()),
+		logger:            newRequestLogger(requestID, req.Label, tx),
+	}
+}
+
+// createAndCheckTx creates a tx based on the given inputs, change output
+// script, and the fee rate. In addition, it validates the tx's mempool
+// acceptance before returning a tx that can be published directly, along with
+// its fee. The given logger is used to log the check results.
+func (t *TxPublisher) createAndCheckTx(req *BumpRequest, f FeeFunction,
+	logger btclog.Logger) (*sweepTxCtx, error) {
+
+	// Make sure the extra outputs are valid.
+	extraOutputs := req.extraOutputs()
+	if err := validateExtraOutputs(extraOutputs); err != nil {
+		return nil, err
+	}
+	if err := req.validateInputOutputs(); err != nil {
+		return nil, err
+	}
+
+	// Get the address to send the change output to, which may be a fresh
+	// one for each build.
+	deliveryAddr, err := req.deliveryAddress()
+	if err != nil {
+		return nil, err
+	}
+	if req.RejectChangeReuse {
+		err := checkChangeReuse(
+			req.Inputs, deliveryAddr.DeliveryAddress,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create the sweep tx with max fee rate of 0 as the fee function
+	// guarantees the fee rate used here won't exceed the max fee rate.
+	sweepCtx, err := t.createSweepTx(
+		req.Inputs, deliveryAddr, f.FeeRate(), req.LockTime,
+		req.AnchorParent, extraOutputs, req.NoBump,
+	)
+	if err != nil {
+		return sweepCtx, fmt.Errorf("create sweep tx: %w", err)
+	}
+
+	// Sanity check the budget still covers the fee, and the fee doesn't
+	// exceed the absolute fee cap.
+	if sweepCtx.fee > req.maxFee() &&
+		!canShrinkChange(req, sweepCtx, deliveryAddr, logger) {
+
+		return sweepCtx, fmt.Errorf("%w: budget=%v, max_fee=%v, fee=%v",
+			ErrNotEnoughBudget, req.Budget, req.MaxFeeAbsolute,
+			sweepCtx.fee)
+	}
+
+	// Make sure the fee rate is not far above what the recent blocks
+	// required.
+	if err := t.checkConfirmedFeeRate(f.FeeRate(), logger); err != nil {
+		return sweepCtx, err
+	}
+
+	// If we had an extra txOut, then we'll update the result to include
+	// it.
+	req.ExtraTxOut = sweepCtx.extraTxOut
+
+	// Validate the tx's mempool acceptance, using the request's own check
+	// if specified. Otherwise, skip the check if configured, in which case
+	// the fee errors will be caught when publishing the tx.
+	switch {
+	case req.MempoolCheckOverride != nil:
+		err = req.MempoolCheckOverride(sweepCtx.tx)
+
+	case t.cfg.SkipMempoolCheck:
+		return sweepCtx, nil
+
+	default:
+		err = t.cfg.Wallet.CheckMempoolAcceptance(sweepCtx.tx)
+	}
+
+	// Exit early if the tx is valid.
+	if err == nil {
+		sweepCtx.mempoolAccepted = true
+		return sweepCtx, nil
+	}
+
+	// Print an error log if the chain backend doesn't support the mempool
+	// acceptance test RPC.
+	if errors.Is(err, rpcclient.ErrBackendVersion) {
+		logger.Errorf("TestMempoolAccept not supported by backend, " +
+			"consider upgrading it to a newer version")
+		return sweepCtx, nil
 	}
 
 	// We are running on a backend that doesn't implement the RPC
 	// testmempoolaccept, eg, neutrino, so we'll skip the check.
 	if errors.Is(err, chain.ErrUnimplemented) {
-		log.Debug("Skipped testmempoolaccept due to not implemented")
+		logger.Debug("Skipped testmempoolaccept due to not implemented")
 		return sweepCtx, nil
 	}
 
-	return sweepCtx, fmt.Errorf("tx=%v failed mempool check: %w",
-		sweepCtx.tx.TxHash(), err)
+	return sweepCtx, fmt.Errorf("tx=%v failed mempool check: %w",
+		sweepCtx.tx.TxHash(), err)
+}
+
+// checkConfirmedFeeRate compares the given fee rate against the fee rate that
+// was sufficient for confirmation in the recent blocks, as reported by the
+// ConfirmedFeeRateSource. If the fee rate exceeds it by more than the
+// ConfirmedFeeRateMultiplier, a warning is logged, or ErrFeeRateAboveConfirmed
+// is returned if RefuseAboveConfirmedFeeRate is set.
+func (t *TxPublisher) checkConfirmedFeeRate(feeRate chainfee.SatPerKWeight,
+	logger btclog.Logger) error {
+
+	source := t.cfg.ConfirmedFeeRateSource.UnwrapOr(nil)
+	if source == nil {
+		return nil
+	}
+
+	recent, err := source.RecentConfirmedFeeRate()
+	if err != nil {
+		logger.Warnf("Unable to get recently confirmed fee rate: %v",
+			err)
+
+		return nil
+	}
+
+	multiplier := t.cfg.ConfirmedFeeRateMultiplier
+	if multiplier == 0 {
+		multiplier = defaultConfirmedFeeRateMultiplier
+	}
+
+	limit := chainfee.SatPerKWeight(float64(recent) * multiplier)
+	if recent <= 0 || feeRate <= limit {
+		return nil
+	}
+
+	if t.cfg.RefuseAboveConfirmedFeeRate {
+		return fmt.Errorf("%w: fee rate %v exceeds %v times the "+
+			"recently confirmed fee rate %v",
+			ErrFeeRateAboveConfirmed, feeRate, multiplier, recent)
+	}
+
+	logger.Warnf("Fee rate %v exceeds %v times the recently confirmed "+
+		"fee rate %v, the budget may be misconfigured", feeRate,
+		multiplier, recent)
+
+	return nil
+}
+
+// broadcast takes a monitored tx and publishes it to the network. Prior to the
+// broadcast, it will subscribe the tx's confirmation notification and attach
+// the event channel to the record. Any broadcast-related errors will not be
+// returned here, instead, they will be put inside the `BumpResult` and
+// returned to the caller.
+//
+// If the tx is rejected for not meeting the min relay fee, which may have
+// risen since the tx was created, its fee rate is bumped once and the rebuilt
+// tx is published instead.
+func (t *TxPublisher) broadcast(requestID uint64) (*BumpResult, error) {
+	return t.broadcastAttempt(requestID, true)
+}
+
+// broadcastAttempt publishes the monitored tx. If allowRelayBump is true, and
+// the tx fails to meet the min relay fee, the tx will be rebuilt using an
+// increased fee rate and published again.
+func (t *TxPublisher) broadcastAttempt(requestID uint64,
+	allowRelayBump bool) (*BumpResult, error) {
+
+	// Never publish anything once halted.
+	if errPtr := t.haltErr.Load(); errPtr != nil {
+		return nil, *errPtr
+	}
+
+	// Get the record being monitored.
+	record, ok := t.records.Load(requestID)
+	if !ok {
+		return nil, fmt.Errorf("tx record %v not found", requestID)
+	}
+
+	txid := record.tx.TxHash()
+
+	tx := record.tx
+	record.log().Debugf("Publishing sweep tx %v, num_inputs=%v, height=%v",
+		txid, len(tx.TxIn), t.currentHeight.Load())
+
+	// Give the pre-broadcast hook a chance to inspect and veto the tx.
+	// This must happen before notifying the aux sweeper, so it's not told
+	// about a broadcast that never happens.
+	if t.cfg.PreBroadcastHook != nil {
+		if err := t.cfg.PreBroadcastHook(tx); err != nil {
+			record.log().Warnf("Broadcast of tx %v vetoed by "+
+				"pre-broadcast hook: %v", txid, err)
+
+			hookErr := fmt.Errorf("pre-broadcast hook: %w", err)
+
+			return &BumpResult{
+				Event:     TxFailed,
+				Tx:        tx,
+				Fee:       record.fee,
+				FeeRate:   record.feeFunction.FeeRate(),
+				Err:       hookErr,
+				requestID: requestID,
+			}, nil
+		}
+	}
+
+	// Before we go to broadcast, we'll notify the aux sweeper, if it's
+	// present of this new broadcast attempt.
+	err := fn.MapOptionZ(t.cfg.AuxSweeper, func(aux AuxSweeper) error {
+		return aux.NotifyBroadcast(
+			record.req, tx, record.fee, record.outpointToTxIndex,
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to notify aux sweeper: %w", err)
+	}
+
+	// Wait for our turn to broadcast if a rate limit is configured.
+	if err := t.waitBroadcastLimit(); err != nil {
+		return nil, err
+	}
+
+	// Let the caller know the tx has passed the mempool acceptance check
+	// before publishing it, if requested.
+	if record.req.EmitMempoolAccept && record.mempoolAccepted {
+		t.notifyResult(&BumpResult{
+			Event:     TxMempoolAccepted,
+			Tx:        tx,
+			Fee:       record.fee,
+			FeeRate:   record.feeFunction.FeeRate(),
+			requestID: requestID,
+		})
+	}
+
+	// Set the event, and change it to TxFailed if the wallet fails to
+	// publish it.
+	event := TxPublished
+
+	// Publish the sweeping tx with customized label. If the publish fails,
+	// this error will be saved in the `BumpResult` and it will be removed
+	// from being monitored.
+	err = t.publish(record)
+	if err != nil {
+		// NOTE: we decide to attach this error to the result instead
+		// of returning it here because by the time the tx reaches
+		// here, it should have passed the mempool acceptance check. If
+		// it still fails to be broadcast, it's likely a non-RBF
+		// related error happened. So we send this error back to the
+		// caller so that it can handle it properly.
+		//
+		// TODO(yy): find out which input is causing the failure.
+		record.log().Errorf("Failed to publish tx %v: %v", txid, err)
+		event = TxFailed
+
+		switch {
+		// When the mempool check is skipped, the fee errors are only
+		// caught here, so we increase the fee rate and retry with the
+		// rebuilt tx until it's accepted or the budget is used up.
+		case t.cfg.SkipMempoolCheck && isFeeErr(err):
+			bumpErr := t.bumpForPublishFee(requestID, record, err)
+			if bumpErr == nil {
+				return t.broadcastAttempt(
+					requestID, allowRelayBump,
+				)
+			}
+
+			record.log().Warnf("Failed to bump tx %v for publish "+
+				"fee: %v", txid, bumpErr)
+
+		// If the min relay fee is not met, bump the fee rate once and
+		// retry with the rebuilt tx.
+		case allowRelayBump && isMinRelayFeeErr(err):
+			bumpErr := t.bumpForRelayFee(requestID, record, err)
+			if bumpErr == nil {
+				return t.broadcastAttempt(requestID, false)
+			}
+
+			record.log().Warnf("Failed to bump tx %v for min "+
+				"relay fee: %v", txid, bumpErr)
+		}
+	}
+
+	// Record the broadcast in the history of the request, and notify the
+	// caller if it's the first one.
+	if err == nil {
+		first := len(record.history) == 0
+		record = t.addHistory(requestID, record)
+
+		if first && record.req.OnFirstBroadcast != nil {
+			go record.req.OnFirstBroadcast(txid)
+		}
+	}
+
+	result := &BumpResult{
+		Event:       event,
+		Tx:          record.tx,
+		Fee:         record.fee,
+		FeeRate:     record.feeFunction.FeeRate(),
+		FeeSchedule: boundFeeSchedule(record.feeFunction),
+		Err:         err,
+		requestID:   requestID,
+	}
+	if err == nil {
+		result.setWeight(record.estimatedWeight())
+	}
+
+	return result, nil
+}
+
+// canShrinkChange returns true if the request allows its change output to be
+// shrunk to pay a fee above the budget, and the tx still has a change output
+// above the dust limit after paying the fee. The absolute fee cap is always
+// enforced.
+func canShrinkChange(req *BumpRequest, sweepCtx *sweepTxCtx,
+	deliveryAddr lnwallet.AddrWithKey, logger btclog.Logger) bool {
+
+	if !req.ShrinkChangeForFee {
+		return false
+	}
+
+	if req.MaxFeeAbsolute > 0 && sweepCtx.fee > req.MaxFeeAbsolute {
+		return false
+	}
+
+	// The change output is dropped by the tx builder once it's below the
+	// dust limit, so we only need to check it's still there.
+	for _, txOut := range sweepCtx.tx.TxOut {
+		if !bytes.Equal(txOut.PkScript, deliveryAddr.DeliveryAddress) {
+			continue
+		}
+
+		logger.Infof("Fee %v exceeds budget %v, paid by shrinking "+
+			"change to %v", sweepCtx.fee, req.Budget,
+			btcutil.Amount(txOut.Value))
+
+		return true
+	}
+
+	return false
+}
+
+// isMinRelayFeeErr returns true if the given error indicates the tx doesn't
+// meet the min relay fee required by the mempool.
+func isMinRelayFeeErr(err error) bool {
+	return errors.Is(err, lnwallet.ErrMempoolFee) ||
+		errors.Is(err, chain.ErrMempoolMinFeeNotMet)
+}
+
+// bumpForRelayFee increments the fee rate of the given record, rebuilds its tx
+// and stores the updated record. The given publish error is used to find the
+// fee rate required by the mempool.
+func (t *TxPublisher) bumpForRelayFee(requestID uint64, r *monitorRecord,
+	publishErr error) error {
+
+	shortfall := mempoolShortfall(r.log(), r.tx, publishErr)
+
+	increased, err := r.feeFunction.Increment(weightOf(r.tx))
+	if err != nil {
+		return fmt.Errorf("increment fee rate: %w", err)
+	}
+
+	if !increased {
+		return fmt.Errorf("fee rate not increased: %v",
+			r.feeFunction.FeeRate())
+	}
+
+	t.jumpToRequiredFeeRate(
+		r.feeFunction, weightOf(r.tx), shortfall, r.log(),
+	)
+
+	return t.rebuildRecord(requestID, r, ReplaceReasonMempoolFee)
+}
+
+// isFeeErr returns true if the given publish error indicates the tx doesn't
+// pay enough fee to be accepted by the mempool.
+func isFeeErr(err error) bool {
+	return errors.Is(err, chain.ErrInsufficientFee) ||
+		isMinRelayFeeErr(err)
+}
+
+// bumpForPublishFee keeps incrementing the fee rate of the given record until
+// it's increased, rebuilds its tx and stores the updated record. An error is
+// returned if the fee rate cannot be increased. The given publish error is
+// used to find the fee rate required by the mempool.
+func (t *TxPublisher) bumpForPublishFee(requestID uint64, r *monitorRecord,
+	publishErr error) error {
+
+	// A fixed fee rate can never be increased.
+	if _, ok := r.feeFunction.(*ConstantFeeFunction); ok {
+		return fmt.Errorf("%w: fixed fee rate %v cannot be increased",
+			ErrMaxPosition, r.feeFunction.FeeRate())
+	}
+
+	shortfall := mempoolShortfall(r.log(), r.tx, publishErr)
+
+	for increased := false; !increased; {
+		var err error
+		increased, err = r.feeFunction.Increment(weightOf(r.tx))
+		if err != nil {
+			return fmt.Errorf("increment fee rate: %w", err)
+		}
+	}
+
+	t.jumpToRequiredFeeRate(
+		r.feeFunction, weightOf(r.tx), shortfall, r.log(),
+	)
+
+	return t.rebuildRecord(requestID, r, ReplaceReasonMempoolFee)
+}
+
+var (
+	// feeShortfallRe matches the rejections that carry the fee paid by
+	// the tx and the fee required by the mempool in sats, such as
+	// bitcoind's "min relay fee not met, 100 < 1000".
+	feeShortfallRe = regexp.MustCompile(
+		`(?:min relay fee|mempool min fee) not met, (\d+) < (\d+)`,
+	)
+
+	// btcdFeeShortfallRe matches the btcd rejection that carries the fee
+	// paid by the tx and the fee required by the mempool in sats.
+	btcdFeeShortfallRe = regexp.MustCompile(
+		`has (\d+) fees which is under the required amount of (\d+)`,
+	)
+
+	// replacementFeeRateRe matches the bitcoind rejection of a
+	// replacement that doesn't pay a higher fee rate than the replaced
+	// tx, whose fee rates are given in BTC/kvB.
+	replacementFeeRateRe = regexp.MustCompile(
+		`new feerate ([\d.]+) BTC/kvB <= old feerate ([\d.]+) BTC/kvB`,
+	)
+
+	// btcdReplacementFeeRateRe matches the btcd rejection of a replacement
+	// that doesn't pay a higher fee rate than the replaced tx, whose fee
+	// rates are given in sat/kB.
+	btcdReplacementFeeRateRe = regexp.MustCompile(
+		`insufficient fee rate: needs more than (\d+), has (\d+)`,
+	)
+)
+
+// feeShortfall describes by how much a tx missed the fee rate required by the
+// mempool.
+type feeShortfall struct {
+	// actual is the fee rate paid by the rejected tx.
+	actual chainfee.SatPerKWeight
+
+	// required is the min fee rate required by the mempool.
+	required chainfee.SatPerKWeight
+}
+
+// String returns a human-readable string for the shortfall.
+func (s feeShortfall) String() string {
+	return fmt.Sprintf("actual=%v, required=%v, shortfall=%v", s.actual,
+		s.required, s.required-s.actual)
+}
+
+// parseFeeShortfall parses the fee rate paid by a tx of the given weight and
+// the fee rate required by the mempool from the given fee-related rejection.
+// None is returned if the rejection doesn't carry the fees.
+func parseFeeShortfall(err error,
+	weight lntypes.WeightUnit) fn.Option[feeShortfall] {
+
+	if err == nil {
+		return fn.None[feeShortfall]()
+	}
+	reason := err.Error()
+
+	// Parse the absolute fees, which are converted into fee rates using
+	// the weight of the tx.
+	feeRes := []*regexp.Regexp{feeShortfallRe, btcdFeeShortfallRe}
+	for _, re := range feeRes {
+		m := re.FindStringSubmatch(reason)
+		if m == nil || weight <= 0 {
+			continue
+		}
+
+		actual, err1 := strconv.ParseInt(m[1], 10, 64)
+		required, err2 := strconv.ParseInt(m[2], 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		return fn.Some(feeShortfall{
+			actual: chainfee.NewSatPerKWeight(
+				btcutil.Amount(actual), weight,
+			),
+			required: chainfee.NewSatPerKWeight(
+				btcutil.Amount(required), weight,
+			),
+		})
+	}
+
+	// The replacement must pay a fee rate strictly above the replaced tx.
+	if m := replacementFeeRateRe.FindStringSubmatch(reason); m != nil {
+		actual, err1 := strconv.ParseFloat(m[1], 64)
+		replaced, err2 := strconv.ParseFloat(m[2], 64)
+		actualAmt, err3 := btcutil.NewAmount(actual)
+		replacedAmt, err4 := btcutil.NewAmount(replaced)
+		if err := errors.Join(err1, err2, err3, err4); err == nil {
+			return fn.Some(feeShortfall{
+				actual: chainfee.SatPerKVByte(
+					actualAmt,
+				).FeePerKWeight(),
+				required: chainfee.SatPerKVByte(
+					replacedAmt,
+				).FeePerKWeight() + 1,
+			})
+		}
+	}
+
+	if m := btcdReplacementFeeRateRe.FindStringSubmatch(reason); m != nil {
+		replaced, err1 := strconv.ParseInt(m[1], 10, 64)
+		actual, err2 := strconv.ParseInt(m[2], 10, 64)
+		if err1 == nil && err2 == nil {
+			return fn.Some(feeShortfall{
+				actual: chainfee.SatPerKVByte(
+					actual,
+				).FeePerKWeight(),
+				required: chainfee.SatPerKVByte(
+					replaced,
+				).FeePerKWeight() + 1,
+			})
+		}
+	}
+
+	return fn.None[feeShortfall]()
+}
+
+// weightOf returns the weight of the given tx, or zero if it's nil.
+func weightOf(tx *wire.MsgTx) lntypes.WeightUnit {
+	if tx == nil {
+		return 0
+	}
+
+	return lntypes.WeightUnit(
+		blockchain.GetTransactionWeight(btcutil.NewTx(tx)),
+	)
+}
+
+// mempoolShortfall parses the fee shortfall of the given tx from its mempool
+// rejection, and logs it if found.
+func mempoolShortfall(logger btclog.Logger, tx *wire.MsgTx,
+	err error) fn.Option[feeShortfall] {
+
+	if tx == nil {
+		return fn.None[feeShortfall]()
+	}
+
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+	shortfall := parseFeeShortfall(err, lntypes.WeightUnit(weight))
+	shortfall.WhenSome(func(s feeShortfall) {
+		logger.Infof("Tx %v rejected by mempool for fee: %v",
+			tx.TxHash(), s)
+	})
+
+	return shortfall
+}
+
+// jumpToRequiredFeeRate keeps incrementing the fee function until its fee rate
+// reaches the fee rate required by the mempool, if configured, so the next tx
+// skips straight to a fee rate that can be accepted. The txWeight is the weight
+// of the rejected tx.
+func (t *TxPublisher) jumpToRequiredFeeRate(f FeeFunction,
+	txWeight lntypes.WeightUnit, shortfall fn.Option[feeShortfall],
+	logger btclog.Logger) {
+
+	if !t.cfg.JumpToRequiredFeeRate {
+		return
+	}
+
+	// A fixed fee rate can never be increased.
+	if _, ok := f.(*ConstantFeeFunction); ok {
+		return
+	}
+
+	shortfall.WhenSome(func(s feeShortfall) {
+		for f.FeeRate() < s.required {
+			if _, err := f.Increment(txWeight); err != nil {
+				logger.Debugf("Failed to reach required fee "+
+					"rate %v: %v", s.required, err)
+
+				return
+			}
+		}
+
+		logger.Infof("Jumped fee rate to %v to meet required fee "+
+			"rate %v", f.FeeRate(), s.required)
+	})
+}
+
+// rebuildRecord rebuilds the tx of the given record using the current fee
+// rate of its fee function, and stores the updated record along with the
+// reason of the rebuild.
+func (t *TxPublisher) rebuildRecord(requestID uint64, r *monitorRecord,
+	reason ReplaceReason) error {
+
+	// The new tx is checked using a logger without the txid of the old
+	// tx, as the lines are about the new one.
+	logger := newRequestLogger(requestID, r.req.Label, nil)
+	sweepCtx, err := t.createAndCheckTx(r.req, r.feeFunction, logger)
+	if err != nil {
+		return err
+	}
+
+	// Store the rebuilt tx, along with a logger prefixed with its txid.
+	rebuilt := *r
+	rebuilt.tx = sweepCtx.tx
+	rebuilt.fee = sweepCtx.fee
+	rebuilt.outpointToTxIndex = sweepCtx.outpointToTxIndex
+	rebuilt.replaceReason = reason
+	rebuilt.mempoolAccepted = sweepCtx.mempoolAccepted
+	rebuilt.logger = newRequestLogger(
+		requestID, r.req.Label, sweepCtx.tx,
+	)
+	t.records.Store(requestID, &rebuilt)
+
+	rebuilt.log().Infof("Rebuilt tx %v as %v with fee rate %v, reason=%v",
+		r.tx.TxHash(), sweepCtx.tx.TxHash(), r.feeFunction.FeeRate(),
+		reason)
+
+	return nil
+}
+
+// waitBroadcastLimit blocks until a broadcast is allowed by the rate limiter,
+// or returns an error if the publisher is shutting down.
+func (t *TxPublisher) waitBroadcastLimit() error {
+	if t.broadcastLimiter == nil {
+		return nil
+	}
+
+	now := t.cfg.Clock.Now()
+	r := t.broadcastLimiter.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+
+	log.Debugf("Broadcast rate limited, waiting %v", delay)
+
+	select {
+	case <-t.cfg.Clock.TickAfter(delay):
+		return nil
+
+	case <-t.quit:
+		r.CancelAt(t.cfg.Clock.Now())
+
+		return ErrPublisherStopped
+	}
+}
+
+// publish publishes the tx of the given record. If the request carries
+// parents, the txns are submitted as a package, and we fall back to
+// publishing them one by one if the backend doesn't support package relay.
+func (t *TxPublisher) publish(r *monitorRecord) error {
+	if len(r.req.Parents) == 0 {
+		return t.publishWithRetry(r.tx)
+	}
+
+	pkg := make([]*wire.MsgTx, 0, len(r.req.Parents)+1)
+	pkg = append(pkg, r.req.Parents...)
+	pkg = append(pkg, r.tx)
+
+	err := t.cfg.Wallet.SubmitPackage(pkg)
+	if !errors.Is(err, ErrPackageRelayUnsupported) {
+		return err
+	}
+
+	r.log().Debugf("Package relay unsupported, publishing %v parents "+
+		"sequentially", len(r.req.Parents))
+
+	label := labels.MakeLabel(labels.LabelTypeSweepTransaction, nil)
+	for _, parent := range r.req.Parents {
+		err := t.publishTx(parent, label)
+		if err == nil || isTxKnownErr(err) {
+			continue
+		}
+
+		return fmt.Errorf("publish parent %v: %w", parent.TxHash(), err)
+	}
+
+	return t.publishWithRetry(r.tx)
+}
+
+// isTooManyReplacementsErr returns true if the given mempool rejection
+// indicates the replacement evicts more txns than allowed by BIP125 rule 5.
+func isTooManyReplacementsErr(err error) bool {
+	if errors.Is(err, chain.ErrTooManyReplacements) {
+		return true
+	}
+
+	// The reason may only be available as a string, depending on the
+	// backend.
+	reason := err.Error()
+	for _, msg := range []string{
+		"too many potential replacements",
+		"evicts more transactions than permitted",
+	} {
+		if strings.Contains(reason, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTxKnownErr returns true if the given publish error indicates the tx is
+// already in the mempool or the chain.
+func isTxKnownErr(err error) bool {
+	return errors.Is(err, chain.ErrTxAlreadyKnown) ||
+		errors.Is(err, chain.ErrTxAlreadyInMempool) ||
+		errors.Is(err, chain.ErrTxAlreadyConfirmed)
+}
+
+// publishTx publishes the given tx using the Wallet and the extra Broadcasters,
+// if configured, concurrently. It succeeds if any of them accepts the tx or
+// reports it as already known. Otherwise, the error from the Wallet is
+// returned.
+func (t *TxPublisher) publishTx(tx *wire.MsgTx, label string) error {
+	if len(t.cfg.Broadcasters) == 0 {
+		return t.cfg.Wallet.PublishTransaction(tx, label)
+	}
+
+	broadcasters := make([]Broadcaster, 0, len(t.cfg.Broadcasters)+1)
+	broadcasters = append(broadcasters, t.cfg.Wallet)
+	broadcasters = append(broadcasters, t.cfg.Broadcasters...)
+
+	// Fan out the publish to all the backends and wait for their results.
+	errs := make([]error, len(broadcasters))
+
+	var wg sync.WaitGroup
+	for i, b := range broadcasters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs[i] = b.PublishTransaction(tx, label)
+		}()
+	}
+	wg.Wait()
+
+	accepted := false
+	for i, err := range errs {
+		if err == nil || isTxKnownErr(err) {
+			accepted = true
+			continue
+		}
+
+		log.Debugf("Broadcaster %v failed to publish tx %v: %v", i,
+			tx.TxHash(), err)
+	}
+
+	if accepted {
+		return nil
+	}
+
+	return errs[0]
+}
+
+// publishWithRetry publishes the given tx, and retries with an exponential
+// backoff if the publish fails with one of the configured transient errors.
+func (t *TxPublisher) publishWithRetry(tx *wire.MsgTx) error {
+	label := labels.MakeLabel(labels.LabelTypeSweepTransaction, nil)
+
+	backoff := t.cfg.PublishRetryBackoff
+	if backoff == 0 {
+		backoff = defaultPublishRetryBackoff
+	}
+
+	maxBackoff := t.cfg.MaxPublishRetryBackoff
+	if maxBackoff == 0 {
+		maxBackoff = max(defaultMaxPublishRetryBackoff, backoff)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := t.publishTx(tx, label)
+		if err == nil {
+			return nil
+		}
+
+		// Exit early if we've reached the max retries or the error is
+		// not a transient one.
+		if attempt >= t.cfg.MaxPublishRetries ||
+			!t.isTransientPublishErr(err) {
+
+			return err
+		}
+
+		log.Warnf("Failed to publish tx %v (attempt=%v), retrying in "+
+			"%v: %v", tx.TxHash(), attempt+1, backoff, err)
+
+		select {
+		case <-t.cfg.Clock.TickAfter(backoff):
+			backoff = min(backoff*2, maxBackoff)
+
+		case <-t.quit:
+			return err
+		}
+	}
+}
+
+// isTransientPublishErr returns a boolean to indicate whether the given publish
+// error is a transient one that's worth retrying. Fee related errors and
+// errors indicating the tx is already known are always treated as permanent.
+func (t *TxPublisher) isTransientPublishErr(err error) bool {
+	switch {
+	case errors.Is(err, chain.ErrInsufficientFee),
+		errors.Is(err, lnwallet.ErrMempoolFee),
+		errors.Is(err, chain.ErrTxAlreadyKnown),
+		errors.Is(err, chain.ErrTxAlreadyInMempool),
+		errors.Is(err, chain.ErrTxAlreadyConfirmed):
+
+		return false
+	}
+
+	for _, retryErr := range t.cfg.PublishRetryErrors {
+		if errors.Is(err, retryErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notifyResult sends the result to the resultChan specified by the requestID.
+// This channel is expected to be read by the caller.
+func (t *TxPublisher) notifyResult(result *BumpResult) {
+	id := result.requestID
+	subscriber, ok := t.subscriberChans.Load(id)
+	if !ok {
+		log.Errorf("Result chan for id=%v not found", id)
+		return
+	}
+
+	t.attachLabel(result)
+	t.notifyGlobal(result)
+
+	log.Debugf("Sending result %v for requestID=%v", result, id)
+
+	// Make room for the result instead of blocking if configured. The
+	// results that cannot be dropped are sent below if there's still no
+	// room for them.
+	results := []*BumpResult{result}
+	if t.cfg.DropOldestResult {
+		results = t.sendDropOldest(subscriber, result)
+	}
+
+	for _, r := range results {
+		t.sendBlocking(subscriber, r)
+	}
+}
+
+// sendBlocking sends the result to the subscriber, blocking until it's
+// received or the publisher is shutting down.
+func (t *TxPublisher) sendBlocking(subscriber chan *BumpResult,
+	result *BumpResult) {
+
+	// Track the pending result so a graceful stop can wait for it to be
+	// delivered.
+	t.pendingResults.Add(1)
+	defer t.donePendingResult()
+
+	select {
+	// Send the result to the subscriber.
+	//
+	// TODO(yy): Add timeout in case it's blocking?
+	case subscriber <- result:
+	case <-t.quit:
+		log.Warnf("Fee bumper stopped, result %v for requestID=%v not "+
+			"delivered", result, result.requestID)
+	}
+}
+
+// sendDropOldest sends the result to the subscriber without blocking. If the
+// subscriber's chan is full, the oldest informational result in the chan is
+// dropped to make room for the new one. If there's none, the new result is
+// dropped if it's informational. Results that change the state of the request
+// are never dropped, and those that don't fit in the chan are returned in
+// order so the caller can send them blocking.
+func (t *TxPublisher) sendDropOldest(subscriber chan *BumpResult,
+	result *BumpResult) []*BumpResult {
+
+	t.dropOldestMtx.Lock()
+	defer t.dropOldestMtx.Unlock()
+
+	select {
+	case subscriber <- result:
+		return nil
+
+	default:
+	}
+
+	// The chan is full, take out the buffered results so the oldest
+	// informational one can be found.
+	var buffered []*BumpResult
+
+drain:
+	for {
+		select {
+		case r := <-subscriber:
+			buffered = append(buffered, r)
+
+		default:
+			break drain
+		}
+	}
+
+	// Drop the oldest informational result, or the new one if none of the
+	// buffered results can be dropped.
+	buffered = append(buffered, result)
+	for i, r := range buffered {
+		if !r.Event.informational() {
+			continue
+		}
+
+		log.Warnf("Subscriber of requestID=%v not keeping up, dropped "+
+			"result %v", result.requestID, r)
+
+		buffered = append(buffered[:i], buffered[i+1:]...)
+
+		break
+	}
+
+	// Put the results back in order, returning the ones that don't fit.
+	for i, r := range buffered {
+		select {
+		case subscriber <- r:
+		default:
+			return buffered[i:]
+		}
+	}
+
+	return nil
+}
+
+// removeResult removes the tracking of the result if the result contains a
+// non-nil error, or the tx is confirmed, the record will be removed from the
+// maps.
+func (t *TxPublisher) removeResult(result *BumpResult) {
+	id := result.requestID
+
+	var txid chainhash.Hash
+	if result.Tx != nil {
+		txid = result.Tx.TxHash()
+	}
+
+	// Remove the record from the maps if there's an error or the tx is
+	// confirmed. When there's an error, it means this tx has failed its
+	// broadcast and cannot be retried. There are two cases it may fail,
+	// - when the budget cannot cover the increased fee calculated by the
+	//   fee function, hence the budget is used up.
+	// - when a non-fee related error returned from PublishTransaction.
+	switch result.Event {
+	case TxFailed:
+		log.Errorf("Removing monitor record=%v, tx=%v, due to err: %v",
+			id, txid, result.Err)
+
+		// Save the failed request so it can be retried later.
+		if r, ok := t.records.Load(id); ok {
+			t.addFailure(r.req, result)
+		}
+
+	case TxConfirmed:
+		// Remove the record if the tx is confirmed.
+		log.Debugf("Removing confirmed monitor record=%v, tx=%v", id,
+			txid)
+
+	case TxFatal:
+		// Remove the record if there's an error.
+		log.Debugf("Removing monitor record=%v due to fatal err: %v",
+			id, result.Err)
+
+	case TxInputSpent:
+		// Remove the record if its input has been spent.
+		log.Warnf("Removing monitor record=%v, tx=%v, due to spent "+
+			"input: %v", id, txid, result.Err)
+
+	case TxLostRBFRace:
+		// Remove the record if it cannot outbid a conflicting tx.
+		log.Warnf("Removing monitor record=%v, tx=%v, due to lost "+
+			"RBF race: %v", id, txid, result.Err)
+
+	case TxTimedOut:
+		// Remove the record if the tx is not confirmed in time.
+		log.Warnf("Removing monitor record=%v, tx=%v, due to timeout: "+
+			"%v", id, txid, result.Err)
+
+	case TxCancelled:
+		// Remove the record if the request is cancelled.
+		log.Debugf("Removing cancelled monitor record=%v, tx=%v", id,
+			txid)
+
+	// Do nothing if it's neither failed or confirmed.
+	default:
+		log.Tracef("Skipping record removal for id=%v, event=%v", id,
+			result.Event)
+
+		return
+	}
+
+	t.deleteRecord(id)
+	t.subscriberChans.Delete(id)
+
+	// Signal the request is no longer monitored.
+	if done, ok := t.doneChans.LoadAndDelete(id); ok {
+		close(done)
+	}
+}
+
+// acquireInFlight takes a slot for a new request if MaxInFlight is set. When
+// no slot is available, ErrTooManyInFlight is returned, unless BlockWhenFull
+// is set, in which case it waits until a slot is freed, the context is done,
+// or the publisher is shutting down.
+func (t *TxPublisher) acquireInFlight(ctx context.Context) error {
+	if t.inFlight == nil {
+		return nil
+	}
+
+	select {
+	case t.inFlight <- struct{}{}:
+		return nil
+
+	default:
+	}
+
+	if !t.cfg.BlockWhenFull {
+		return fmt.Errorf("%w: max=%v", ErrTooManyInFlight,
+			t.cfg.MaxInFlight)
+	}
+
+	log.Debugf("Max in flight requests %v reached, waiting for a slot",
+		t.cfg.MaxInFlight)
+
+	select {
+	case t.inFlight <- struct{}{}:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-t.quit:
+		return ErrPublisherStopped
+	}
+}
+
+// deleteRecord removes the record of the given request along with its
+// idempotency key, and frees its slot if it was tracked.
+func (t *TxPublisher) deleteRecord(requestID uint64) {
+	r, ok := t.records.LoadAndDelete(requestID)
+	if !ok {
+		return
+	}
+
+	t.lastEstimates.Delete(requestID)
+
+	// Remove the idempotency key of the request, unless it's been taken
+	// by a new request.
+	if r.req != nil && r.req.IdempotencyKey != "" {
+		id, ok := t.idempotencyKeys.Load(r.req.IdempotencyKey)
+		if ok && id == requestID {
+			t.idempotencyKeys.Delete(r.req.IdempotencyKey)
+		}
+	}
+
+	t.releaseInFlight()
+}
+
+// releaseInFlight frees a slot taken by acquireInFlight if MaxInFlight is set.
+func (t *TxPublisher) releaseInFlight() {
+	if t.inFlight == nil {
+		return
+	}
+
+	// A record may be stored again after it's removed, e.g., by a fee
+	// bump racing with the removal, so we never block here.
+	select {
+	case <-t.inFlight:
+	default:
+	}
+}
+
+// watchCancel waits for the given context to be done, and cancels the request
+// by removing its record and sending a TxCancelled event. It exits once the
+// request is no longer monitored or the publisher is shutting down.
+//
+// NOTE: must be run as a goroutine.
+func (t *TxPublisher) watchCancel(ctx context.Context, requestID uint64,
+	done <-chan struct{}) {
+
+	defer t.wg.Done()
+
+	select {
+	case <-ctx.Done():
+		result := &BumpResult{
+			Event:     TxCancelled,
+			Err:       ctx.Err(),
+			requestID: requestID,
+		}
+
+		// Attach the latest tx if it's been broadcast.
+		if r, ok := t.records.Load(requestID); ok {
+			result.Label = r.req.Label
+			if r.tx != nil {
+				result.Tx = r.tx
+				result.Fee = r.fee
+				result.FeeRate = r.feeFunction.FeeRate()
+			}
+		}
+
+		log.Infof("Cancelling requestID=%v: %v", requestID, ctx.Err())
+
+		// Remove the record first so it won't be bumped again.
+		t.deleteRecord(requestID)
+
+		t.handleResult(result)
+
+	case <-done:
+	case <-t.quit:
+	}
+}
+
+// watchTimeout waits for the given timeout to elapse, and fails the request by
+// removing its record and sending a TxTimedOut event, unless its tx has been
+// confirmed by then. It exits once the request is no longer monitored or the
+// publisher is shutting down.
+//
+// NOTE: must be run as a goroutine.
+func (t *TxPublisher) watchTimeout(requestID uint64, timeout time.Duration,
+	done <-chan struct{}) {
+
+	defer t.wg.Done()
+
+	select {
+	case <-t.cfg.Clock.TickAfter(timeout):
+		r, ok := t.records.Load(requestID)
+		if !ok {
+			return
+		}
+
+		// A confirmed tx is only watched for reorgs, which is not
+		// subject to the timeout.
+		if r.confirmed {
+			log.Debugf("Skip timing out requestID=%v as its tx is "+
+				"confirmed", requestID)
+
+			return
+		}
+
+		result := &BumpResult{
+			Event: TxTimedOut,
+			Err: fmt.Errorf("%w: not confirmed after %v",
+				ErrTimedOut, timeout),
+			Label:     r.req.Label,
+			requestID: requestID,
+		}
+
+		// Attach the latest tx if it's been broadcast.
+		if r.tx != nil {
+			result.Tx = r.tx
+			result.Fee = r.fee
+			result.FeeRate = r.feeFunction.FeeRate()
+		}
+
+		r.log().Warnf("Timing out requestID=%v after %v", requestID,
+			timeout)
+
+		// Remove the record first so it won't be bumped again.
+		t.deleteRecord(requestID)
+
+		t.handleResult(result)
+
+	case <-done:
+	case <-t.quit:
+	}
+}
+
+// handleResult handles the result of a tx broadcast. It will notify the
+// subscriber and remove the record if the tx is confirmed or failed to be
+// broadcast.
+func (t *TxPublisher) handleResult(result *BumpResult) {
+	t.processResult(result, false)
+}
+
+// handleBumpResult is the same as handleResult, but must be called with the
+// bump lock of the request held, so the record can be updated without taking
+// the lock again.
+func (t *TxPublisher) handleBumpResult(result *BumpResult) {
+	t.processResult(result, true)
+}
+
+// processResult notifies the subscriber about the result, and removes the
+// record if the tx is confirmed or failed to be broadcast. The bumpLocked
+// flag indicates whether the caller holds the bump lock of the request.
+func (t *TxPublisher) processResult(result *BumpResult, bumpLocked bool) {
+	// Attach the raw tx to the failed result if configured.
+	if t.cfg.LogFailedTx {
+		t.attachRawTx(result)
+	}
+
+	// Notify the subscriber.
+	t.notifyResult(result)
+
+	// Remove the record if it's failed or confirmed.
+	t.removeResult(result)
+
+	// Check whether the published tx pays less than intended.
+	if t.cfg.FeeUndershootTolerance > 0 {
+		t.checkFeeUndershoot(result, bumpLocked)
+	}
+}
+
+// checkFeeUndershoot compares the fee rate achieved by the published tx found
+// in the result with its intended fee rate. If the achieved fee rate is below
+// the intended one by more than the configured tolerance, a TxFeeUndershoot
+// event is sent to the subscriber, and the record is optionally marked to pad
+// its next fee bump.
+func (t *TxPublisher) checkFeeUndershoot(result *BumpResult,
+	bumpLocked bool) {
+
+	if result.Event != TxPublished && result.Event != TxReplaced {
+		return
+	}
+
+	// Calculate the achieved fee rate using the actual weight of the
+	// signed tx.
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(result.Tx))
+	achieved := chainfee.NewSatPerKWeight(
+		result.Fee, lntypes.WeightUnit(weight),
+	)
+
+	threshold := chainfee.SatPerKWeight(
+		float64(result.FeeRate) * (1 - t.cfg.FeeUndershootTolerance),
+	)
+	if achieved >= threshold {
+		return
+	}
+
+	log.Warnf("Sweep tx=%v for requestID=%v undershoots its fee rate: "+
+		"achieved=%v, intended=%v, weight=%v", result.Tx.TxHash(),
+		result.requestID, achieved, result.FeeRate, weight)
+
+	// Mark the record so its next fee bump is padded.
+	if t.cfg.PadFeeOnUndershoot {
+		t.markPadNextBump(result, bumpLocked)
+	}
+
+	t.notifyResult(&BumpResult{
+		Event:     TxFeeUndershoot,
+		Tx:        result.Tx,
+		Fee:       result.Fee,
+		FeeRate:   achieved,
+		requestID: result.requestID,
+	})
+}
+
+// markPadNextBump marks the record of the given result to pad its next fee
+// bump, as long as the record still tracks the tx found in the result. The
+// bump lock of the request is taken unless bumpLocked is true, so the record
+// is not overwritten by a concurrent fee bump.
+func (t *TxPublisher) markPadNextBump(result *BumpResult, bumpLocked bool) {
+	requestID := result.requestID
+
+	if !bumpLocked {
+		// A concurrent bump is replacing the tx, so there's no need
+		// to pad it.
+		if !t.lockBump(requestID) {
+			log.Debugf("Skip padding next bump of requestID=%v as "+
+				"it's being bumped", requestID)
+
+			return
+		}
+		defer t.unlockBump(requestID)
+	}
+
+	r, ok := t.records.Load(requestID)
+	if !ok || r.tx == nil || r.tx.TxHash() != result.Tx.TxHash() {
+		return
+	}
+
+	padded := *r
+	padded.padNextBump = true
+	t.records.Store(requestID, &padded)
+}
+
+// attachRawTx serializes the tx found in a TxFailed result, logs it and
+// attaches it to the result.
+func (t *TxPublisher) attachRawTx(result *BumpResult) {
+	if result.Event != TxFailed || result.Tx == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := result.Tx.Serialize(&buf); err != nil {
+		log.Errorf("Failed to serialize failed tx %v: %v",
+			result.Tx.TxHash(), err)
+
+		return
+	}
+	result.RawTx = buf.Bytes()
+
+	log.Warnf("Sweep tx=%v for requestID=%v failed with err=%v, raw "+
+		"tx: %v", result.Tx.TxHash(), result.requestID, result.Err,
+		hex.EncodeToString(result.RawTx))
+}
+
+// recordMap is a concurrent map of the monitor records keyed by their
+// requestIDs, which also maintains an index of the outpoints of the inputs of
+// each record's request. The index is updated whenever a record is stored or
+// deleted, so it always reflects the current inputs of the records.
+type recordMap struct {
+	lnutils.SyncMap[uint64, *monitorRecord]
+
+	// mu guards the index, and serializes the writes to the map so the
+	// index stays in sync with it.
+	mu sync.RWMutex
+
+	// index maps the outpoint of each tracked input to the requestID of
+	// the record sweeping it.
+	index map[wire.OutPoint]uint64
+}
+
+// Store stores the record under the given requestID, replacing the previous
+// one if any, and re-indexes its inputs.
+func (m *recordMap) Store(requestID uint64, r *monitorRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(requestID, r)
+}
+
+// StoreUntracked is the same as Store, but returns an ErrInputAlreadyTracked
+// instead if any of the inputs of the record is already indexed by another
+// record. The check and the store are done under the same lock, so two
+// records can never track the same input.
+func (m *recordMap) StoreUntracked(requestID uint64, r *monitorRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.req != nil {
+		for _, inp := range r.req.Inputs {
+			op := inp.OutPoint()
+			id, ok := m.index[op]
+			if ok && id != requestID {
+				return fmt.Errorf("%w: %v in requestID=%v",
+					ErrInputAlreadyTracked, op, id)
+			}
+		}
+	}
+
+	m.store(requestID, r)
+
+	return nil
+}
+
+// store stores and indexes the record under the given requestID, replacing
+// the previous one if any. The caller must hold the write lock.
+func (m *recordMap) store(requestID uint64, r *monitorRecord) {
+	if old, ok := m.SyncMap.Load(requestID); ok {
+		m.unindex(requestID, old)
+	}
+
+	m.SyncMap.Store(requestID, r)
+	m.indexRecord(requestID, r)
+}
+
+// LoadOrStore returns the existing record of the given requestID if present.
+// Otherwise, it stores and indexes the given record. The loaded result is true
+// if the record was loaded, false if stored.
+func (m *recordMap) LoadOrStore(requestID uint64,
+	r *monitorRecord) (*monitorRecord, bool) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	actual, loaded := m.SyncMap.LoadOrStore(requestID, r)
+	if !loaded {
+		m.indexRecord(requestID, r)
+	}
+
+	return actual, loaded
+}
+
+// Delete deletes the record of the given requestID and its indexed inputs.
+func (m *recordMap) Delete(requestID uint64) {
+	m.LoadAndDelete(requestID)
+}
+
+// LoadAndDelete deletes the record of the given requestID and its indexed
+// inputs, returning the deleted record if present.
+func (m *recordMap) LoadAndDelete(requestID uint64) (*monitorRecord, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.SyncMap.LoadAndDelete(requestID)
+	if ok {
+		m.unindex(requestID, r)
+	}
+
+	return r, ok
+}
+
+// lookup returns the requestID of the record sweeping the given outpoint.
+func (m *recordMap) lookup(op wire.OutPoint) (uint64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	requestID, ok := m.index[op]
+
+	return requestID, ok
+}
+
+// outpoints returns a copy of the index.
+func (m *recordMap) outpoints() map[wire.OutPoint]uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	outpoints := make(map[wire.OutPoint]uint64, len(m.index))
+	for op, requestID := range m.index {
+		outpoints[op] = requestID
+	}
+
+	return outpoints
+}
+
+// indexRecord adds the inputs of the given record to the index. The caller
+// must hold the write lock.
+func (m *recordMap) indexRecord(requestID uint64, r *monitorRecord) {
+	if r == nil || r.req == nil {
+		return
+	}
+
+	if m.index == nil {
+		m.index = make(map[wire.OutPoint]uint64)
+	}
+
+	for _, inp := range r.req.Inputs {
+		m.index[inp.OutPoint()] = requestID
+	}
+}
+
+// unindex removes the inputs of the given record from the index, unless they
+// have been indexed by another record since. The caller must hold the write
+// lock.
+func (m *recordMap) unindex(requestID uint64, r *monitorRecord) {
+	if r == nil || r.req == nil {
+		return
+	}
+
+	for _, inp := range r.req.Inputs {
+		op := inp.OutPoint()
+		if m.index[op] == requestID {
+			delete(m.index, op)
+		}
+	}
+}
+
+// monitorRecord is used to keep track of the tx being monitored by the
+// publisher internally.
+type monitorRecord struct {
+	// tx is the tx being monitored.
+	tx *wire.MsgTx
+
+	// req is the original request.
+	req *BumpRequest
+
+	// feeFunction is the fee bumping algorithm used by the publisher.
+	feeFunction FeeFunction
+
+	// fee is the fee paid by the tx.
+	fee btcutil.Amount
+
+	// outpointToTxIndex is a map of outpoint to tx index.
+	outpointToTxIndex map[wire.OutPoint]int
+
+	// heightHint is the block height at which the tx was created, which is
+	// used as the height hint when registering its confirmation
+	// notification.
+	heightHint uint32
+
+	// confirmed indicates the tx has been confirmed and the record is now
+	// only kept to watch for potential reorgs.
+	confirmed bool
+
+	// confHeight is the height of the block that confirmed the tx.
+	confHeight uint32
+
+	// confBlockHash is the hash of the block that confirmed the tx.
+	confBlockHash chainhash.Hash
+
+	// padNextBump indicates the fee rate achieved by the tx undershoots
+	// its intended fee rate, and the next fee bump should be padded by an
+	// extra increment.
+	padNextBump bool
+
+	// budgetExhausted indicates a TxBudgetExhausted event has been sent
+	// for this record.
+	budgetExhausted bool
+
+	// budgetAdded indicates the budget of the request has been increased
+	// via AddBudget, and the tx should be bumped using the rebuilt fee
+	// function in the next round.
+	budgetAdded bool
+
+	// replaceReason is the reason the latest tx replaced its previous one.
+	replaceReason ReplaceReason
+
+	// mempoolAccepted is set if the tx has passed the mempool acceptance
+	// check when it was created.
+	mempoolAccepted bool
+
+	// history is the ordered list of txns broadcast for this request.
+	history []BumpHistoryEntry
+
+	// logger is the logger prefixed with the requestID and the txid of
+	// this record.
+	logger btclog.Logger
+}
+
+// estimatedWeight returns the weight of the record's tx estimated using its
+// inputs, extra outputs and change script, which is the request's delivery
+// address, or the script of the tx's last output if not set. Zero is returned
+// if the weight cannot be estimated.
+func (r *monitorRecord) estimatedWeight() lntypes.WeightUnit {
+	changeScript := r.req.DeliveryAddress.DeliveryAddress
+	if len(changeScript) == 0 && len(r.tx.TxOut) > 0 {
+		changeScript = r.tx.TxOut[len(r.tx.TxOut)-1].PkScript
+	}
+
+	weight, err := calcSweepTxWeight(
+		r.req.Inputs, [][]byte{changeScript}, r.req.extraOutputs(),
+	)
+	if err != nil {
+		r.log().Warnf("Unable to estimate weight of tx %v: %v",
+			r.tx.TxHash(), err)
+
+		return 0
+	}
+
+	return weight
+}
+
+// confPkScript returns the pkScript used to register for the confirmation of
+// the record's tx, which helps the backends that filter by script to match
+// the tx. The script supplied by the request is preferred, otherwise the
+// script of the first output is used. Nil is returned if the tx has no
+// outputs.
+func (r *monitorRecord) confPkScript() []byte {
+	if len(r.req.ConfPkScript) > 0 {
+		return r.req.ConfPkScript
+	}
+
+	if len(r.tx.TxOut) > 0 {
+		return r.tx.TxOut[0].PkScript
+	}
+
+	return nil
+}
+
+// log returns the prefixed logger of the record, or the package logger if
+// it's not set.
+func (r *monitorRecord) log() btclog.Logger {
+	if r.logger == nil {
+		return log
+	}
+
+	return r.logger
+}
+
+// newRequestLogger returns a logger whose lines are prefixed with the given
+// requestID, the label if it's not empty, and the txid of the given tx if it's
+// not nil, so the logs of a single sweep can be traced.
+func newRequestLogger(requestID uint64, label string,
+	tx *wire.MsgTx) btclog.Logger {
+
+	prefix := fmt.Sprintf("reqID=%d", requestID)
+	if label != "" {
+		prefix += fmt.Sprintf(", label=%q", label)
+	}
+	if tx != nil {
+		prefix += fmt.Sprintf(", txid=%v", tx.TxHash())
+	}
+
+	return log.WithPrefix(fmt.Sprintf("TxPublisher(%s):", prefix))
+}
+
+// Start starts the publisher by subscribing to block epoch updates and kicking
+// off the monitor loop.
+func (t *TxPublisher) Start(beat chainio.Blockbeat) error {
+	log.Info("TxPublisher starting...")
+
+	if t.started.Swap(true) {
+		return fmt.Errorf("TxPublisher started more than once")
+	}
+
+	if err := t.cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	// Set the current height.
+	t.currentHeight.Store(beat.Height())
+
+	t.wg.Add(1)
+	go t.monitor()
+
+	log.Debugf("TxPublisher started")
+
+	return nil
+}
+
+// Stop gracefully stops the publisher. It stops accepting new broadcast
+// requests, and attempts to deliver the pending results to their subscribers
+// until the context is done, after which the monitor loop is torn down. The
+// context's error is returned if not all the pending results are delivered.
+func (t *TxPublisher) Stop(ctx context.Context) error {
+	log.Info("TxPublisher stopping...")
+
+	if t.stopped.Swap(true) {
+		return fmt.Errorf("TxPublisher stopped more than once")
+	}
+
+	// Wait for the pending results to be delivered.
+	drainErr := t.drainPendingResults(ctx)
+	if drainErr != nil {
+		log.Warnf("Failed to deliver %v pending results: %v",
+			t.pendingResults.Load(), drainErr)
+	}
+
+	close(t.quit)
+	t.wg.Wait()
+
+	log.Debug("TxPublisher stopped")
+
+	return drainErr
+}
+
+// drainPendingResults blocks until all the pending results have been
+// delivered to their subscribers, or the context is done.
+func (t *TxPublisher) drainPendingResults(ctx context.Context) error {
+	for t.pendingResults.Load() > 0 {
+		select {
+		case <-t.resultDelivered:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// donePendingResult marks a pending result as no longer pending, and wakes up
+// the drain loop if it's waiting.
+func (t *TxPublisher) donePendingResult() {
+	t.pendingResults.Add(-1)
+
+	// The chan is buffered so the signal is not lost if the drain loop is
+	// about to wait on it.
+	select {
+	case t.resultDelivered <- struct{}{}:
+	default:
+	}
+}
+
+// attachLabel copies the label of the request into the result if it's not
+// already set.
+func (t *TxPublisher) attachLabel(result *BumpResult) {
+	if result.Label != "" {
+		return
+	}
+
+	if r, ok := t.records.Load(result.requestID); ok && r.req != nil {
+		result.Label = r.req.Label
+	}
+}
+
+// FailedBump describes a request that ended in a TxFailed event.
+type FailedBump struct {
+	// Request is the failed request, which can be passed to Retry.
+	Request *BumpRequest
+
+	// Tx is the last tx created for the request, nil if none was created.
+	Tx *wire.MsgTx
+
+	// Err is the reason the request failed.
+	Err error
+
+	// FailedAt is the time the request failed.
+	FailedAt time.Time
+}
+
+// addFailure saves the failed request, dropping the oldest failure if the
+// history is full.
+func (t *TxPublisher) addFailure(req *BumpRequest, result *BumpResult) {
+	size := t.cfg.FailureHistorySize
+	if size == 0 {
+		size = defaultFailureHistorySize
+	}
+
+	t.failuresMtx.Lock()
+	defer t.failuresMtx.Unlock()
+
+	if len(t.failures) >= size {
+		t.failures = t.failures[len(t.failures)-size+1:]
+	}
+
+	t.failures = append(t.failures, FailedBump{
+		Request:  req,
+		Tx:       result.Tx,
+		Err:      result.Err,
+		FailedAt: t.cfg.Clock.Now(),
+	})
+}
+
+// RecentFailures returns the recently failed requests, ordered from the oldest
+// to the newest.
+func (t *TxPublisher) RecentFailures() []FailedBump {
+	t.failuresMtx.Lock()
+	defer t.failuresMtx.Unlock()
+
+	failures := make([]FailedBump, len(t.failures))
+	copy(failures, t.failures)
+
+	return failures
+}
+
+// Retry re-broadcasts a request found in RecentFailures, and removes it from
+// the failures. ErrRequestNotFound is returned if the request is not a recent
+// failure.
+func (t *TxPublisher) Retry(req *BumpRequest) (<-chan *BumpResult, error) {
+	t.failuresMtx.Lock()
+
+	idx := -1
+	for i, f := range t.failures {
+		if f.Request == req {
+			idx = i
+			break
+		}
+	}
+
+	if idx == -1 {
+		t.failuresMtx.Unlock()
+
+		return nil, fmt.Errorf("%w: not a recent failure",
+			ErrRequestNotFound)
+	}
+
+	t.failures = append(t.failures[:idx], t.failures[idx+1:]...)
+	t.failuresMtx.Unlock()
+
+	log.Infof("Retrying failed bump request with %v inputs",
+		len(req.Inputs))
+
+	return t.Broadcast(req), nil
+}
+
+// SubscribeAll returns a chan that receives the results of all requests, and a
+// func to cancel the subscription, which closes the chan. Use the RequestID of
+// a result to tell which request it belongs to. The chan is buffered, and
+// results are dropped if the subscriber cannot keep up so the delivery to the
+// per-request subscribers is never stalled.
+func (t *TxPublisher) SubscribeAll() (<-chan *BumpResult, func()) {
+	t.globalSubsMtx.Lock()
+	defer t.globalSubsMtx.Unlock()
+
+	if t.globalSubscribers == nil {
+		t.globalSubscribers = make(map[uint64]chan *BumpResult)
+	}
+
+	id := t.globalSubCounter
+	t.globalSubCounter++
+
+	results := make(chan *BumpResult, globalSubscriberBufferSize)
+	t.globalSubscribers[id] = results
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			t.globalSubsMtx.Lock()
+			defer t.globalSubsMtx.Unlock()
+
+			delete(t.globalSubscribers, id)
+			close(results)
+		})
+	}
+
+	return results, unsubscribe
+}
+
+// DroppedGlobalResults returns the number of results that were not delivered
+// to the subscribers created via SubscribeAll as their buffers were full.
+func (t *TxPublisher) DroppedGlobalResults() uint64 {
+	return t.droppedGlobalResults.Load()
+}
+
+// notifyGlobal sends the result to all the subscribers created via
+// SubscribeAll without blocking.
+func (t *TxPublisher) notifyGlobal(result *BumpResult) {
+	t.globalSubsMtx.RLock()
+	defer t.globalSubsMtx.RUnlock()
+
+	for id, results := range t.globalSubscribers {
+		select {
+		case results <- result:
+		default:
+			t.droppedGlobalResults.Add(1)
+
+			log.Debugf("Global subscriber %v not ready, dropped "+
+				"result %v for requestID=%v", id, result,
+				result.requestID)
+		}
+	}
+}
+
+// BumpHistoryEntry records a tx broadcast for a bump request, which is either
+// its initial tx or a replacement.
+type BumpHistoryEntry struct {
+	// Height is the block height at which the tx was broadcast.
+	Height int32
+
+	// Txid is the txid of the broadcast tx.
+	Txid chainhash.Hash
+
+	// FeeRate is the fee rate used by the tx.
+	FeeRate chainfee.SatPerKWeight
+
+	// Fee is the fee paid by the tx.
+	Fee btcutil.Amount
+
+	// ReplaceReason is the reason the tx replaced its previous one, which
+	// is ReplaceReasonNone for the initial tx.
+	ReplaceReason ReplaceReason
+}
+
+// addHistory appends the tx of the given record to its history, and stores the
+// updated record. The oldest entries are dropped once the history exceeds
+// maxBumpHistorySize.
+func (t *TxPublisher) addHistory(requestID uint64,
+	r *monitorRecord) *monitorRecord {
+
+	entry := BumpHistoryEntry{
+		Height:        t.currentHeight.Load(),
+		Txid:          r.tx.TxHash(),
+		Fee:           r.fee,
+		ReplaceReason: r.replaceReason,
+	}
+	if r.feeFunction != nil {
+		entry.FeeRate = r.feeFunction.FeeRate()
+	}
+
+	// Copy the history as records are shared with other goroutines.
+	start := max(len(r.history)-maxBumpHistorySize+1, 0)
+	history := make([]BumpHistoryEntry, 0, len(r.history)-start+1)
+	history = append(history, r.history[start:]...)
+	history = append(history, entry)
+
+	updated := *r
+	updated.history = history
+	t.records.Store(requestID, &updated)
+
+	return &updated
+}
+
+// History returns the txns broadcast for the given request, ordered from the
+// oldest to the newest. At most maxBumpHistorySize entries are kept.
+// ErrRequestNotFound is returned if the request is not tracked by the
+// publisher.
+func (t *TxPublisher) History(requestID uint64) ([]BumpHistoryEntry, error) {
+	r, ok := t.records.Load(requestID)
+	if !ok {
+		return nil, fmt.Errorf("%w: requestID=%v", ErrRequestNotFound,
+			requestID)
+	}
+
+	history := make([]BumpHistoryEntry, len(r.history))
+	copy(history, r.history)
+
+	return history, nil
+}
+
+// RequestStatus describes the current state of a tracked bump request.
+type RequestStatus struct {
+	// Label is the label of the request.
+	Label string
+
+	// Tx is the latest tx broadcast for the request, nil if no tx has
+	// been broadcast yet.
+	Tx *wire.MsgTx
+
+	// FeeRate is the current fee rate used by the request.
+	FeeRate chainfee.SatPerKWeight
+
+	// Fee is the fee paid by the latest tx.
+	Fee btcutil.Amount
+
+	// Budget is the max fee the request is allowed to pay.
+	Budget btcutil.Amount
+
+	// Confirmed indicates the latest tx has been confirmed.
+	Confirmed bool
+
+	// Paused indicates the publisher is paused, so the request won't be
+	// broadcast or fee bumped until it's resumed.
+	Paused bool
+
+	// ReplaceReason is the reason the latest tx replaced its previous
+	// one.
+	ReplaceReason ReplaceReason
+}
+
+// Status returns the current status of the given request. ErrRequestNotFound
+// is returned if the request is not tracked by the publisher.
+func (t *TxPublisher) Status(requestID uint64) (*RequestStatus, error) {
+	r, ok := t.records.Load(requestID)
+	if !ok {
+		return nil, fmt.Errorf("%w: requestID=%v", ErrRequestNotFound,
+			requestID)
+	}
+
+	status := &RequestStatus{
+		Tx:            r.tx,
+		Fee:           r.fee,
+		Confirmed:     r.confirmed,
+		Paused:        t.paused.Load(),
+		ReplaceReason: r.replaceReason,
+	}
+	if r.req != nil {
+		status.Label = r.req.Label
+		status.Budget = r.req.Budget
+	}
+	if r.feeFunction != nil {
+		status.FeeRate = r.feeFunction.FeeRate()
+	}
+
+	return status, nil
+}
+
+// CurrentHeight returns the best known height of the publisher.
+func (t *TxPublisher) CurrentHeight() int32 {
+	return t.currentHeight.Load()
+}
+
+// SetCurrentHeight updates the best known height of the publisher, which is
+// used to calculate the conf targets of the records. The records are only
+// processed when a new block is received by the monitor loop.
+func (t *TxPublisher) SetCurrentHeight(height int32) {
+	t.currentHeight.Store(height)
+}
+
+// HealthCheck checks the dependencies of the publisher are responsive. The fee
+// estimator is queried for the relay fee and a fee estimate, and the wallet is
+// pinged by listing its unconfirmed utxos. The error returned wraps either
+// ErrEstimatorUnhealthy or ErrWalletUnhealthy to name the failed dependency,
+// including when the check times out via the given context.
+func (t *TxPublisher) HealthCheck(ctx context.Context) error {
+	err := runHealthCheck(ctx, ErrEstimatorUnhealthy, func() error {
+		if t.cfg.Estimator.RelayFeePerKW() == 0 {
+			return errors.New("zero relay fee")
+		}
+
+		_, err := t.cfg.Estimator.EstimateFeePerKW(
+			healthCheckConfTarget,
+		)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return runHealthCheck(ctx, ErrWalletUnhealthy, func() error {
+		_, err := t.cfg.Wallet.ListUnspentWitnessFromDefaultAccount(
+			0, 0,
+		)
+
+		return err
+	})
+}
+
+// runHealthCheck runs the given check, and wraps its error with the given
+// component error. If the context is done before the check returns, the
+// context error is wrapped instead.
+func runHealthCheck(ctx context.Context, component error,
+	check func() error) error {
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- check()
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return fmt.Errorf("%w: %w", component, err)
+		}
+
+		return nil
+
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", component, ctx.Err())
+	}
+}
+
+// monitor is the main loop driven by new blocks. Whevenr a new block arrives,
+// it will examine all the txns being monitored, and check if any of them needs
+// to be bumped. If so, it will attempt to bump the fee of the tx. When a
+// BlockDebounce is configured, the blocks are coalesced and the txns are only
+// examined once the chain settles.
+//
+// NOTE: Must be run as a goroutine.
+func (t *TxPublisher) monitor() {
+	defer t.wg.Done()
+
+	// lastHeight is the height of the last processed block, which makes
+	// sure the records are processed once per new block.
+	lastHeight := t.currentHeight.Load()
+
+	// settled is the timer that fires once no new block has arrived for
+	// the debounce window, which is nil if there's no pending block.
+	var settled <-chan time.Time
+
+	// moved is set when a beat received during the debounce window has a
+	// different height than the last processed block. This makes sure a
+	// reorg that settles at the same height is still processed.
+	moved := false
+
+	// process checks all monitored txns to see if any of them needs to be
+	// bumped at the current height, unless the chain hasn't moved since
+	// the last processed block.
+	process := func(changed bool) {
+		height := t.currentHeight.Load()
+		if !changed {
+			log.Debugf("Skipped processing records for processed "+
+				"block %v", height)
+
+			return
+		}
+
+		t.processRecords()
+		lastHeight = height
+	}
+
+	for {
+		select {
+		case beat := <-t.BlockbeatChan:
+			height := beat.Height()
+			log.Debugf("TxPublisher received new block: %v", height)
+
+			// Update the best known height for the publisher.
+			t.SetCurrentHeight(height)
+
+			// Without a debounce window, process the block right
+			// away and notify we've processed it.
+			if t.cfg.BlockDebounce <= 0 {
+				process(height != lastHeight)
+				t.NotifyBlockProcessed(beat, nil)
+
+				continue
+			}
+
+			// Otherwise ack the beat right away so the dispatcher
+			// can send the next block, and restart the debounce
+			// window. The records are processed once the window is
+			// over.
+			if height != lastHeight {
+				moved = true
+			}
+			t.NotifyBlockProcessed(beat, nil)
+			settled = t.cfg.Clock.TickAfter(t.cfg.BlockDebounce)
+
+		case <-settled:
+			settled = nil
+
+			log.Debugf("Chain settled at height %v",
+				t.currentHeight.Load())
+
+			process(moved)
+			moved = false
+
+		case <-t.quit:
+			log.Debug("Fee bumper stopped, exit monitor")
+			return
+		}
+	}
+}
+
+// processRecords checks all the txns being monitored, and checks if any of
+// them needs to be bumped. If so, it will attempt to bump the fee of the tx.
+func (t *TxPublisher) processRecords() {
+	// confirmedRecords stores a map of the records which have been
+	// confirmed.
+	confirmedRecords := make(map[uint64]*monitorRecord)
+
+	// feeBumpRecords stores a map of records which need to be bumped.
+	feeBumpRecords := make(map[uint64]*monitorRecord)
+
+	// failedRecords stores a map of records which has inputs being spent
+	// by a third party.
+	//
+	// NOTE: this is only used for neutrino backend.
+	failedRecords := make(map[uint64]*monitorRecord)
+
+	// initialRecords stores a map of records which are being created and
+	// published for the first time.
+	initialRecords := make(map[uint64]*monitorRecord)
+
+	// Once halted, no records should be processed.
+	if t.haltErr.Load() != nil {
+		log.Debug("TxPublisher halted, skipped processing records")
+		return
+	}
+
+	// visitor is a helper closure that visits each record and divides them
+	// into two groups.
+	visitor := func(requestID uint64, r *monitorRecord) error {
+		if r.tx == nil {
+			initialRecords[requestID] = r
+			return nil
+		}
+
+		// If the tx has already been confirmed, the record is only kept
+		// to watch for reorgs, which is handled in its own goroutine.
+		if r.confirmed {
+			return nil
+		}
+
+		r.log().Tracef("Checking monitor recordID=%v for tx=%v",
+			requestID, r.tx.TxHash())
+
+		// If the tx has reached the requested number of confirmations,
+		// we can stop monitoring it.
+		details := t.txDetails(r.tx.TxHash())
+		confs := details.NumConfirmations
+		if confs >= int32(r.req.numConfs()) {
+			// Save the block that confirmed the tx so it can be
+			// reported to the subscriber.
+			confirmed := *r
+			confirmed.confHeight = uint32(details.BlockHeight)
+			if details.BlockHash != nil {
+				confirmed.confBlockHash = *details.BlockHash
+			}
+			confirmedRecords[requestID] = &confirmed
+
+			// Move to the next record.
+			return nil
+		}
+
+		// If the tx is confirmed but not deep enough yet, we wait for
+		// more confirmations without bumping its fee. Should it be
+		// reorged out, it will be fee bumped again.
+		if confs > 0 {
+			r.log().Debugf("Tx=%v has %v/%v confirmations, "+
+				"waiting for more", r.tx.TxHash(), confs,
+				r.req.numConfs())
+
+			return nil
+		}
+
+		// Check whether the inputs has been spent by a third party.
+		//
+		// NOTE: this check is only done for neutrino backend.
+		if t.isThirdPartySpent(r.tx.TxHash(), r.req.Inputs) {
+			failedRecords[requestID] = r
+
+			// Move to the next record.
+			return nil
+		}
+
+		feeBumpRecords[requestID] = r
+
+		// Return nil to move to the next record.
+		return nil
+	}
+
+	// Iterate through all the records and divide them into four groups.
+	t.records.ForEach(visitor)
+
+	// While paused, hold back the initial broadcasts and fee bumps so no
+	// tx is published, and only process the confirmations and spends.
+	if t.paused.Load() {
+		log.Debugf("TxPublisher paused, held back %v initial "+
+			"broadcasts and %v fee bumps", len(initialRecords),
+			len(feeBumpRecords))
+
+		initialRecords = nil
+		feeBumpRecords = nil
+	}
+
+	// Handle the initial broadcast.
+	for requestID, r := range initialRecords {
+		t.handleInitialBroadcast(r, requestID)
+	}
+
+	// For records that are confirmed, we'll notify the caller about this
+	// result.
+	for requestID, r := range confirmedRecords {
+		r.log().Debugf("Tx=%v is confirmed", r.tx.TxHash())
+
+		// Mark the record as confirmed so it won't be processed again
+		// while we are watching for reorgs.
+		confirmedRecord := *r
+		confirmedRecord.confirmed = true
+		t.records.Store(requestID, &confirmedRecord)
+
+		t.wg.Add(1)
+		go t.handleTxConfirmed(&confirmedRecord, requestID)
+	}
+
+	// Get the current height to be used in the following goroutines.
+	currentHeight := t.currentHeight.Load()
+
+	// For records that are not confirmed, we perform a fee bump if needed.
+	for requestID, r := range feeBumpRecords {
+		r.log().Debugf("Attempting to fee bump Tx=%v", r.tx.TxHash())
+		t.wg.Add(1)
+		go t.handleFeeBumpTx(requestID, r, currentHeight)
+	}
+
+	// For records that are failed, we'll notify the caller about this
+	// result.
+	for requestID, r := range failedRecords {
+		r.log().Debugf("Tx=%v has inputs been spent by a third party, "+
+			"failing it now", r.tx.TxHash())
+		t.wg.Add(1)
+		go t.handleThirdPartySpent(r, requestID)
+	}
+}
+
+// handleTxConfirmed is called when a monitored tx is confirmed. It will
+// notify the subscriber, then watch the tx for potential reorgs until it's
+// buried deep enough in the chain, at which point the record will be removed
+// from the maps.
+//
+// NOTE: Must be run as a goroutine to avoid blocking on sending the result.
+func (t *TxPublisher) handleTxConfirmed(r *monitorRecord, requestID uint64) {
+	defer t.wg.Done()
+
+	// Create a result that will be sent to the resultChan which is
+	// listened by the caller.
+	result := &BumpResult{
+		Event:         TxConfirmed,
+		Tx:            r.tx,
+		requestID:     requestID,
+		Fee:           r.fee,
+		FeeRate:       r.feeFunction.FeeRate(),
+		FeeSchedule:   boundFeeSchedule(r.feeFunction),
+		ConfHeight:    r.confHeight,
+		ConfBlockHash: r.confBlockHash,
+	}
+	result.setWeight(r.estimatedWeight())
+
+	// Create a chan so the subscriber can tell when the tx is no longer
+	// watched for reorgs.
+	reorgSafe := make(chan struct{})
+	result.reorgSafe = reorgSafe
+
+	// Notify that this tx is confirmed.
+	t.notifyResult(result)
+
+	// Watch the confirmed tx for reorgs. The record will be removed once
+	// the tx is no longer under the risk of being reorged out.
+	if !t.watchReorg(r, requestID, result) {
+		close(reorgSafe)
+	}
+}
+
+// watchReorg registers a confirmation notification for the confirmed tx and
+// waits until either the tx is buried deep enough, or it's reorged out of the
+// chain. In the latter case, a TxReorged event is sent to the subscriber and
+// the record will be monitored for fee bumping again. If the notifier closes
+// the notification, it's re-registered up to the configured max attempts. It
+// returns true if the tx has been reorged out of the chain.
+func (t *TxPublisher) watchReorg(r *monitorRecord, requestID uint64,
+	result *BumpResult) bool {
+
+	txid := r.tx.TxHash()
+
+	// Get the chan that's closed once the request is cancelled, and exit
+	// early if it's been cancelled already.
+	//
+	// NOTE: the record is removed before the chan is closed and deleted,
+	// so we check the record after getting the chan.
+	done := t.requestDone(requestID)
+	if _, ok := t.records.Load(requestID); !ok {
+		r.log().Debugf("Record removed, skip watching reorg for "+
+			"tx=%v", txid)
+
+		return false
+	}
+
+	pkScript := r.confPkScript()
+	for attempt := uint32(0); ; attempt++ {
+		confEvent, err := t.cfg.Notifier.RegisterConfirmationsNtfn(
+			&txid, pkScript, r.req.numConfs(), r.heightHint,
+		)
+		if err != nil {
+			// We cannot watch for reorgs without the notification,
+			// so we stop monitoring the tx now.
+			r.log().Errorf("Failed to register conf ntfn for "+
+				"tx=%v, unable to watch for reorgs: %v", txid,
+				err)
+
+			t.removeResult(result)
+
+			return false
+		}
+
+		closed, reorged := t.waitConfEvent(
+			r, requestID, result, confEvent, done,
+		)
+		if !closed {
+			return reorged
+		}
+
+		// The notifier closed the subscription, we re-register it
+		// unless the max attempts have been reached.
+		maxAttempts := t.maxConfReregistrations()
+		if attempt >= maxAttempts {
+			r.log().Errorf("Conf ntfn for tx=%v closed after %v "+
+				"re-registrations, unable to watch for reorgs",
+				txid, maxAttempts)
+
+			t.removeResult(result)
+
+			return false
+		}
+
+		r.log().Warnf("Conf ntfn for tx=%v closed, re-registering "+
+			"(attempt %v/%v)", txid, attempt+1, maxAttempts)
+	}
+}
+
+// waitConfEvent waits on the given conf event of a confirmed tx until it's
+// buried deep enough or reorged out of the chain. The first returned value is
+// true if the notifier closed the subscription, in which case it must be
+// re-registered to keep watching the tx. The second is true if the tx has
+// been reorged out of the chain.
+func (t *TxPublisher) waitConfEvent(r *monitorRecord, requestID uint64,
+	result *BumpResult, confEvent *chainntnfs.ConfirmationEvent,
+	done <-chan struct{}) (bool, bool) {
+
+	defer confEvent.Cancel()
+
+	txid := r.tx.TxHash()
+
+	select {
+	// The tx is now buried deep enough, we can stop monitoring it.
+	case _, ok := <-confEvent.Done:
+		if !ok {
+			return true, false
+		}
+
+		r.log().Debugf("Tx=%v is safe from reorgs", txid)
+
+		t.removeResult(result)
+
+	// The tx has been reorged out of the chain, we now mark the record as
+	// unconfirmed so it will be monitored for fee bumping again.
+	case depth, ok := <-confEvent.NegativeConf:
+		if !ok {
+			return true, false
+		}
+
+		r.log().Warnf("Tx=%v was reorged out of the chain with "+
+			"depth=%v, resume monitoring it", txid, depth)
+
+		unconfirmedRecord := *r
+		unconfirmedRecord.confirmed = false
+		t.records.Store(requestID, &unconfirmedRecord)
+
+		t.handleResult(&BumpResult{
+			Event:     TxReorged,
+			Tx:        r.tx,
+			Fee:       r.fee,
+			FeeRate:   r.feeFunction.FeeRate(),
+			requestID: requestID,
+		})
+
+		return false, true
+
+	// The request has been cancelled, we stop watching the tx.
+	case <-done:
+		r.log().Debugf("Request cancelled, exit watching reorg for "+
+			"tx=%v", txid)
+
+	case <-t.quit:
+		r.log().Debugf("Fee bumper stopped, exit watching reorg for "+
+			"tx=%v", txid)
+	}
+
+	return false, false
 }
 
-// broadcast takes a monitored tx and publishes it to the network. Prior to the
-// broadcast, it will subscribe the tx's confirmation notification and attach
-// the event channel to the record. Any broadcast-related errors will not be
-// returned here, instead, they will be put inside the `BumpResult` and
-// returned to the caller.
-func (t *TxPublisher) broadcast(requestID uint64) (*BumpResult, error) {
-	// Get the record being monitored.
-	record, ok := t.records.Load(requestID)
+// maxConfReregistrations returns the max number of times the conf ntfn of a
+// confirmed tx is re-registered after being closed by the notifier.
+func (t *TxPublisher) maxConfReregistrations() uint32 {
+	if t.cfg.MaxConfReregistrations == 0 {
+		return defaultMaxConfReregistrations
+	}
+
+	return t.cfg.MaxConfReregistrations
+}
+
+// requestDone returns the chan that's closed once the given request is no
+// longer monitored. A nil chan is returned if the request cannot be
+// cancelled, which blocks forever when being read.
+func (t *TxPublisher) requestDone(requestID uint64) <-chan struct{} {
+	done, ok := t.doneChans.Load(requestID)
 	if !ok {
-		return nil, fmt.Errorf("tx record %v not found", requestID)
+		return nil
 	}
 
-	txid := record.tx.TxHash()
+	return done
+}
 
-	tx := record.tx
-	log.Debugf("Publishing sweep tx %v, num_inputs=%v, height=%v",
-		txid, len(tx.TxIn), t.currentHeight.Load())
+// handleInitialTxError takes the error from `initializeTx` and decides the
+// bump event. It will construct a BumpResult and handles it.
+func (t *TxPublisher) handleInitialTxError(requestID uint64, err error) {
+	// We now decide what type of event to send.
+	var event BumpEvent
 
-	// Before we go to broadcast, we'll notify the aux sweeper, if it's
-	// present of this new broadcast attempt.
-	err := fn.MapOptionZ(t.cfg.AuxSweeper, func(aux AuxSweeper) error {
-		return aux.NotifyBroadcast(
-			record.req, tx, record.fee, record.outpointToTxIndex,
-		)
-	})
-	if err != nil {
-		return nil, fmt.Errorf("unable to notify aux sweeper: %w", err)
-	}
+	switch {
+	// When the error is due to a dust output, we'll send a TxFailed so
+	// these inputs can be retried with a different group in the next
+	// block.
+	case errors.Is(err, ErrTxNoOutput):
+		event = TxFailed
 
-	// Set the event, and change it to TxFailed if the wallet fails to
-	// publish it.
-	event := TxPublished
+	// When the error is due to budget being used up, we'll send a TxFailed
+	// so these inputs can be retried with a different group in the next
+	// block.
+	case errors.Is(err, ErrMaxPosition):
+		event = TxFailed
 
-	// Publish the sweeping tx with customized label. If the publish fails,
-	// this error will be saved in the `BumpResult` and it will be removed
-	// from being monitored.
-	err = t.cfg.Wallet.PublishTransaction(
-		tx, labels.MakeLabel(labels.LabelTypeSweepTransaction, nil),
-	)
-	if err != nil {
-		// NOTE: we decide to attach this error to the result instead
-		// of returning it here because by the time the tx reaches
-		// here, it should have passed the mempool acceptance check. If
-		// it still fails to be broadcast, it's likely a non-RBF
-		// related error happened. So we send this error back to the
-		// caller so that it can handle it properly.
-		//
-		// TODO(yy): find out which input is causing the failure.
-		log.Errorf("Failed to publish tx %v: %v", txid, err)
+	// When the error is due to zero fee rate delta, we'll send a TxFailed
+	// so these inputs can be retried in the next block.
+	case errors.Is(err, ErrZeroFeeRateDelta):
+		event = TxFailed
+
+	// When the requested locktime is not reached yet, we'll send a
+	// TxFailed so these inputs can be retried in a later block.
+	case errors.Is(err, ErrLocktimeInFuture):
+		event = TxFailed
+
+	// When the fixed fee rate cannot be covered by the budget, we'll send
+	// a TxFailed so these inputs can be retried with a different group.
+	case errors.Is(err, ErrFixedFeeRateTooHigh):
+		event = TxFailed
+
+	// When the fee rate is refused for being far above the recently
+	// confirmed fee rates, we'll send a TxFailed so these inputs can be
+	// retried later.
+	case errors.Is(err, ErrFeeRateAboveConfirmed):
 		event = TxFailed
+
+	// Otherwise this is not a fee-related error and the tx cannot be
+	// retried. In that case we will fail ALL the inputs in this tx, which
+	// means they will be removed from the sweeper and never be tried
+	// again.
+	//
+	// TODO(yy): Find out which input is causing the failure and fail that
+	// one only.
+	default:
+		event = TxFatal
 	}
 
 	result := &BumpResult{
 		Event:     event,
-		Tx:        record.tx,
-		Fee:       record.fee,
-		FeeRate:   record.feeFunction.FeeRate(),
 		Err:       err,
 		requestID: requestID,
 	}
 
-	return result, nil
+	t.handleResult(result)
 }
 
-// notifyResult sends the result to the resultChan specified by the requestID.
-// This channel is expected to be read by the caller.
-func (t *TxPublisher) notifyResult(result *BumpResult) {
-	id := result.requestID
-	subscriber, ok := t.subscriberChans.Load(id)
-	if !ok {
-		log.Errorf("Result chan for id=%v not found", id)
+// handleInitialBroadcast is called when a new request is received. It will
+// handle the initial tx creation and broadcast. In details,
+// 1. init a fee function based on the given strategy.
+// 2. create an RBF-compliant tx and monitor it for confirmation.
+// 3. notify the initial broadcast result back to the caller.
+func (t *TxPublisher) handleInitialBroadcast(r *monitorRecord,
+	requestID uint64) {
+
+	r.log().Debugf("Initial broadcast for requestID=%v", requestID)
+
+	var (
+		result *BumpResult
+		err    error
+	)
+
+	// If the inputs have already been swept by one of our txns that's
+	// confirmed, e.g., when the input was offered twice, publishing would
+	// fail with missing inputs, so we report the confirmed tx instead.
+	confirmed := t.findConfirmedSpend(r, requestID)
+	if confirmed.IsSome() {
+		t.handleResult(confirmed.UnsafeFromSome())
+
 		return
 	}
 
-	log.Debugf("Sending result %v for requestID=%v", result, id)
-
-	select {
-	// Send the result to the subscriber.
+	// Attempt an initial broadcast which is guaranteed to comply with the
+	// RBF rules.
 	//
-	// TODO(yy): Add timeout in case it's blocking?
-	case subscriber <- result:
-	case <-t.quit:
-		log.Debug("Fee bumper stopped")
+	// Create the initial tx to be broadcasted.
+	err = t.initializeTx(requestID, r.req)
+	if err != nil {
+		r.log().Errorf("Initial broadcast failed: %v", err)
+
+		// We now handle the initialization error and exit.
+		t.handleInitialTxError(requestID, err)
+
+		return
+	}
+
+	// Successfully created the first tx, now broadcast it.
+	result, err = t.broadcast(requestID)
+	if err != nil {
+		// The broadcast failed, which can only happen if the tx record
+		// cannot be found or the aux sweeper returns an error. In
+		// either case, we will send back a TxFail event so these
+		// inputs can be retried.
+		result = &BumpResult{
+			Event:     TxFailed,
+			Err:       err,
+			requestID: requestID,
+		}
+	}
+
+	t.handleResult(result)
+
+	// Warn the subscriber if the tx was published at the max fee rate
+	// allowed as its deadline cannot be reached.
+	if result.Event == TxPublished && r.req.deadlineUnreachable {
+		t.notifyResult(&BumpResult{
+			Event:     TxDeadlineUnreachable,
+			Tx:        result.Tx,
+			Fee:       result.Fee,
+			FeeRate:   result.FeeRate,
+			requestID: requestID,
+		})
+	}
+
+	// Also warn if the deadline is unreachable only because the fee is
+	// capped at a fraction of the input value.
+	if result.Event == TxPublished && r.req.uneconomical {
+		t.notifyResult(&BumpResult{
+			Event:     TxUneconomical,
+			Tx:        result.Tx,
+			Fee:       result.Fee,
+			FeeRate:   result.FeeRate,
+			requestID: requestID,
+		})
 	}
 }
 
-// removeResult removes the tracking of the result if the result contains a
-// non-nil error, or the tx is confirmed, the record will be removed from the
-// maps.
-func (t *TxPublisher) removeResult(result *BumpResult) {
-	id := result.requestID
+// inGracePeriod returns true if the record's tx is the initial one, and it was
+// broadcast no more than FirstBumpGracePeriod blocks ago. The grace period
+// ends early once the deadline is reached.
+func (t *TxPublisher) inGracePeriod(r *monitorRecord, currentHeight int32,
+	confTarget uint32) bool {
 
-	var txid chainhash.Hash
-	if result.Tx != nil {
-		txid = result.Tx.TxHash()
+	grace := t.cfg.FirstBumpGracePeriod
+	if grace == 0 || r.replaceReason != ReplaceReasonNone ||
+		confTarget <= 1 {
+
+		return false
 	}
 
-	// Remove the record from the maps if there's an error or the tx is
-	// confirmed. When there's an error, it means this tx has failed its
-	// broadcast and cannot be retried. There are two cases it may fail,
-	// - when the budget cannot cover the increased fee calculated by the
-	//   fee function, hence the budget is used up.
-	// - when a non-fee related error returned from PublishTransaction.
-	switch result.Event {
-	case TxFailed:
-		log.Errorf("Removing monitor record=%v, tx=%v, due to err: %v",
-			id, txid, result.Err)
+	return int64(currentHeight) <= int64(r.heightHint)+int64(grace)
+}
 
-	case TxConfirmed:
-		// Remove the record if the tx is confirmed.
-		log.Debugf("Removing confirmed monitor record=%v, tx=%v", id,
-			txid)
+// maybeResetTrajectory estimates the fee rate for the given conf target, and
+// resets the trajectory of the record's fee function if the estimate has
+// dropped by at least the TrajectoryResetThreshold since the last block. The
+// first estimate of a request is only remembered.
+func (t *TxPublisher) maybeResetTrajectory(requestID uint64, r *monitorRecord,
+	confTarget uint32) {
+
+	if t.cfg.TrajectoryResetThreshold == 0 || confTarget <= 1 ||
+		confTarget >= chainfee.MaxBlockTarget {
+
+		return
+	}
+
+	estimate, err := t.estimator(r.req).EstimateFeePerKW(confTarget)
+	if err != nil {
+		r.log().Warnf("Failed to estimate fee rate for conf target "+
+			"%v: %v", confTarget, err)
+
+		return
+	}
+
+	prev, ok := t.lastEstimates.Load(requestID)
+	t.lastEstimates.Store(requestID, estimate)
+	if !ok {
+		return
+	}
+
+	threshold := float64(prev) * (1 - t.cfg.TrajectoryResetThreshold)
+	if float64(estimate) > threshold {
+		return
+	}
+
+	r.log().Infof("Estimated fee rate for conf target %v dropped from "+
+		"%v to %v, resetting fee function trajectory", confTarget,
+		prev, estimate)
+
+	r.feeFunction.ResetTrajectory(estimate)
+}
+
+// handleFeeBumpTx checks if the tx needs to be bumped, and if so, it will
+// attempt to bump the fee of the tx.
+//
+// NOTE: Must be run as a goroutine to avoid blocking on sending the result.
+func (t *TxPublisher) handleFeeBumpTx(requestID uint64, r *monitorRecord,
+	currentHeight int32) {
+
+	defer t.wg.Done()
+
+	oldTxid := r.tx.TxHash()
+
+	// The tx of a request specifying NoBump is only monitored for its
+	// confirmation.
+	if r.req.NoBump {
+		r.log().Tracef("Skip bumping tx %v as fee bumping is disabled",
+			oldTxid)
+
+		return
+	}
+
+	// Skip the bump if the tx is already being bumped.
+	if !t.lockBump(requestID) {
+		r.log().Debugf("Skip bumping tx %v as it's already being "+
+			"bumped", oldTxid)
+
+		return
+	}
+	defer t.unlockBump(requestID)
+
+	// Load the record again as it may have been updated, e.g. by
+	// AddBudget, while we were waiting for the lock.
+	latest, ok := t.records.Load(requestID)
+	if !ok {
+		return
+	}
+
+	// The tx may have been replaced by a forced bump since the record was
+	// loaded, in which case the new tx will be checked in the next round.
+	if latest.tx == nil || latest.tx.TxHash() != oldTxid {
+		r.log().Debugf("Skip bumping tx %v as it's been replaced",
+			oldTxid)
+
+		return
+	}
+	r = latest
+
+	// Stop bumping if a conflicting tx pays a fee rate we cannot outbid.
+	if conflict := t.findLostRBFRace(r); conflict.IsSome() {
+		t.handleLostRBFRace(r, requestID, conflict)
+
+		return
+	}
 
-	case TxFatal:
-		// Remove the record if there's an error.
-		log.Debugf("Removing monitor record=%v due to fatal err: %v",
-			id, result.Err)
+	// Get the current conf target for this record.
+	confTarget := calcCurrentConfTarget(
+		currentHeight, r.req.EarliestDeadline(),
+	)
 
-	// Do nothing if it's neither failed or confirmed.
-	default:
-		log.Tracef("Skipping record removal for id=%v, event=%v", id,
-			result.Event)
+	// Give the initial tx some time to confirm before replacing it.
+	if t.inGracePeriod(r, currentHeight, confTarget) {
+		r.log().Debugf("Skip bumping tx %v in grace period, "+
+			"broadcast at height=%v, current height=%v", oldTxid,
+			r.heightHint, currentHeight)
 
 		return
 	}
 
-	t.records.Delete(id)
-	t.subscriberChans.Delete(id)
-}
+	// Re-base the fee function using the current relay fee floor. If the
+	// floor has risen since the tx was broadcast, the tx may be stuck
+	// below it, so we make sure the next bump at least clears the floor.
+	floor := t.cfg.Estimator.RelayFeePerKW()
+	rebased := r.feeFunction.RebaseFloor(floor)
+	if rebased {
+		r.log().Infof("Fee rate of tx %v rebased to floor %v at "+
+			"height=%v", oldTxid, floor, currentHeight)
+	}
 
-// handleResult handles the result of a tx broadcast. It will notify the
-// subscriber and remove the record if the tx is confirmed or failed to be
-// broadcast.
-func (t *TxPublisher) handleResult(result *BumpResult) {
-	// Notify the subscriber.
-	t.notifyResult(result)
+	// Reset the trajectory of the fee function if the estimated fee rate
+	// has dropped significantly since the last block.
+	t.maybeResetTrajectory(requestID, r, confTarget)
 
-	// Remove the record if it's failed or confirmed.
-	t.removeResult(result)
-}
+	// Ask the fee function whether a bump is needed. We expect the fee
+	// function to increase its returned fee rate after calling this
+	// method.
+	//
+	// NOTE: the weight of the current tx is used as the estimated weight
+	// of the replacement, which spends the same inputs.
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(r.tx))
+	increased, err := r.feeFunction.IncreaseFeeRate(
+		confTarget, r.fee, lntypes.WeightUnit(weight),
+	)
+	switch {
+	case err == nil:
 
-// monitorRecord is used to keep track of the tx being monitored by the
-// publisher internally.
-type monitorRecord struct {
-	// tx is the tx being monitored.
-	tx *wire.MsgTx
+	// A fee function rebuilt with more budget after the deadline is
+	// already at its max, which is still higher than the fee rate paid by
+	// the current tx, so we continue to bump it.
+	case errors.Is(err, ErrMaxPosition) && r.budgetAdded:
 
-	// req is the original request.
-	req *BumpRequest
+	// If the deadline has been reached and the fee function is already at
+	// its max, let the subscriber know the budget has been used up.
+	case errors.Is(err, ErrMaxPosition) && confTarget == 0:
+		t.notifyBudgetExhausted(requestID, r)
 
-	// feeFunction is the fee bumping algorithm used by the publisher.
-	feeFunction FeeFunction
+		return
 
-	// fee is the fee paid by the tx.
-	fee btcutil.Amount
+	default:
+		// TODO(yy): send this error back to the sweeper so it can
+		// re-group the inputs?
+		r.log().Errorf("Failed to increase fee rate for tx %v at "+
+			"height=%v: %v", oldTxid, t.currentHeight.Load(), err)
 
-	// outpointToTxIndex is a map of outpoint to tx index.
-	outpointToTxIndex map[wire.OutPoint]int
-}
+		return
+	}
 
-// Start starts the publisher by subscribing to block epoch updates and kicking
-// off the monitor loop.
-func (t *TxPublisher) Start(beat chainio.Blockbeat) error {
-	log.Info("TxPublisher starting...")
+	// A rebased fee rate, or a fee function rebuilt with more budget, also
+	// requires a fee bump.
+	increased = increased || rebased || r.budgetAdded
 
-	if t.started.Swap(true) {
-		return fmt.Errorf("TxPublisher started more than once")
+	// Pad the fee rate by an extra increment if the previous tx undershot
+	// its intended fee rate.
+	if r.padNextBump {
+		padded, err := r.feeFunction.Increment(
+			lntypes.WeightUnit(weight),
+		)
+		if err != nil {
+			r.log().Debugf("Failed to pad fee rate for tx %v: %v",
+				oldTxid, err)
+		}
+
+		increased = increased || padded
+
+		// Clear the flag as the padding has been applied to the fee
+		// function, so it's not applied again in the next round.
+		cleared := *r
+		cleared.padNextBump = false
+		t.records.Store(requestID, &cleared)
+		r = &cleared
 	}
 
-	// Set the current height.
-	t.currentHeight.Store(beat.Height())
+	// If the fee rate was not increased, there's no need to bump the fee.
+	if !increased {
+		r.log().Tracef("Skip bumping tx %v at height=%v", oldTxid,
+			t.currentHeight.Load())
 
-	t.wg.Add(1)
-	go t.monitor()
+		return
+	}
 
-	log.Debugf("TxPublisher started")
+	// Skip the bump if the new fee rate is not a meaningful improvement,
+	// unless the tx must be bumped to clear the relay fee floor.
+	if !rebased && !t.isMeaningfulBump(r, lntypes.WeightUnit(weight)) {
+		return
+	}
 
-	return nil
-}
+	// Make sure the inputs are still unspent, otherwise the replacement
+	// can never be confirmed.
+	spent := t.findSpentInput(r)
+	if spent.IsSome() {
+		t.handleInputSpent(r, requestID, spent)
 
-// Stop stops the publisher and waits for the monitor loop to exit.
-func (t *TxPublisher) Stop() error {
-	log.Info("TxPublisher stopping...")
+		return
+	}
 
-	if t.stopped.Swap(true) {
-		return fmt.Errorf("TxPublisher stopped more than once")
+	// Record why the tx is being replaced.
+	reason := ReplaceReasonDeadline
+	switch {
+	case rebased:
+		reason = ReplaceReasonRelayFloor
+
+	case r.budgetAdded:
+		reason = ReplaceReasonBudgetAdded
 	}
 
-	close(t.quit)
-	t.wg.Wait()
+	// The fee function now has a new fee rate, we will use it to bump the
+	// fee of the tx.
+	resultOpt := t.createAndPublishTx(requestID, r, reason)
 
-	log.Debug("TxPublisher stopped")
+	// If there's a result, we will notify the caller about the result.
+	resultOpt.WhenSome(func(result BumpResult) {
+		// Notify the new result.
+		t.handleBumpResult(&result)
 
-	return nil
+		// Let the subscriber know about the new fee rate if the tx
+		// has been replaced.
+		if result.Event == TxReplaced {
+			t.notifyFeeBumped(&result)
+		}
+	})
 }
 
-// monitor is the main loop driven by new blocks. Whevenr a new block arrives,
-// it will examine all the txns being monitored, and check if any of them needs
-// to be bumped. If so, it will attempt to bump the fee of the tx.
-//
-// NOTE: Must be run as a goroutine.
-func (t *TxPublisher) monitor() {
-	defer t.wg.Done()
+// AddBudget increases the budget of the given request by the extra amount. The
+// fee function of the request is rebuilt using the new max fee rate allowed,
+// starting from its current fee rate, so it can resume increasing the fee rate
+// in the next round, even if its budget has been exhausted.
+func (t *TxPublisher) AddBudget(requestID uint64, extra btcutil.Amount) error {
+	if extra <= 0 {
+		return fmt.Errorf("extra budget must be positive, got %v",
+			extra)
+	}
 
-	for {
-		select {
-		case beat := <-t.BlockbeatChan:
-			height := beat.Height()
-			log.Debugf("TxPublisher received new block: %v", height)
+	if _, ok := t.records.Load(requestID); !ok {
+		return fmt.Errorf("%w: requestID=%v", ErrRequestNotFound,
+			requestID)
+	}
 
-			// Update the best known height for the publisher.
-			t.currentHeight.Store(height)
+	// Make sure the monitor loop is not bumping the same tx, otherwise
+	// the bump and the new budget may overwrite each other.
+	if !t.lockBump(requestID) {
+		return fmt.Errorf("%w: requestID=%v", ErrBumpInProgress,
+			requestID)
+	}
+	defer t.unlockBump(requestID)
 
-			// Check all monitored txns to see if any of them needs
-			// to be bumped.
-			t.processRecords()
+	// Load the record again as it may have been updated while we were
+	// waiting for the lock.
+	r, ok := t.records.Load(requestID)
+	if !ok {
+		return fmt.Errorf("%w: requestID=%v", ErrRequestNotFound,
+			requestID)
+	}
 
-			// Notify we've processed the block.
-			t.NotifyBlockProcessed(beat, nil)
+	// Make a copy of the request so the record being used by the monitor
+	// loop is not mutated.
+	req := *r.req
+	req.Budget += extra
 
-		case <-t.quit:
-			log.Debug("Fee bumper stopped, exit monitor")
-			return
+	maxFeeRate, err := req.maxFeeRateAllowed(t.feeRateSanityCap())
+	if err != nil {
+		return err
+	}
+
+	updated := *r
+	updated.req = &req
+	updated.budgetExhausted = false
+
+	// The fee function has not been initialized if the tx has not been
+	// broadcast yet, in which case it will be initialized using the new
+	// budget.
+	if r.feeFunction != nil {
+		// Start the new fee function from the current fee rate.
+		//
+		// NOTE: the deadline has already been resolved, so the conf
+		// target is removed to avoid it being resolved again using the
+		// current height.
+		req.StartingFeeRate = fn.Some(r.feeFunction.FeeRate())
+		req.ConfTarget = 0
+
+		f, err := t.initializeFeeFunction(&req)
+		if err != nil {
+			return fmt.Errorf("init fee function: %w", err)
 		}
+
+		updated.feeFunction = f
+		updated.budgetAdded = true
 	}
-}
 
-// processRecords checks all the txns being monitored, and checks if any of
-// them needs to be bumped. If so, it will attempt to bump the fee of the tx.
-func (t *TxPublisher) processRecords() {
-	// confirmedRecords stores a map of the records which have been
-	// confirmed.
-	confirmedRecords := make(map[uint64]*monitorRecord)
+	r.log().Infof("Added budget %v to requestID=%v: budget=%v, "+
+		"maxFeeRateAllowed=%v", extra, requestID, req.Budget,
+		maxFeeRate)
 
-	// feeBumpRecords stores a map of records which need to be bumped.
-	feeBumpRecords := make(map[uint64]*monitorRecord)
+	t.records.Store(requestID, &updated)
 
-	// failedRecords stores a map of records which has inputs being spent
-	// by a third party.
-	//
-	// NOTE: this is only used for neutrino backend.
-	failedRecords := make(map[uint64]*monitorRecord)
+	return nil
+}
 
-	// initialRecords stores a map of records which are being created and
-	// published for the first time.
-	initialRecords := make(map[uint64]*monitorRecord)
+// BumpNow forces an immediate fee bump of the given request outside the normal
+// per-block cadence. The fee function of the request is incremented by one
+// step, and the tx is rebuilt and rebroadcast. ErrFeeRateAtMax is returned if
+// the fee rate cannot be increased further, in which case nothing is
+// broadcast.
+func (t *TxPublisher) BumpNow(requestID uint64) error {
+	if errPtr := t.haltErr.Load(); errPtr != nil {
+		return *errPtr
+	}
+	if t.stopped.Load() {
+		return ErrPublisherStopped
+	}
 
-	// visitor is a helper closure that visits each record and divides them
-	// into two groups.
-	visitor := func(requestID uint64, r *monitorRecord) error {
-		if r.tx == nil {
-			initialRecords[requestID] = r
-			return nil
-		}
+	if _, ok := t.records.Load(requestID); !ok {
+		return fmt.Errorf("%w: requestID=%v", ErrRequestNotFound,
+			requestID)
+	}
 
-		log.Tracef("Checking monitor recordID=%v for tx=%v", requestID,
-			r.tx.TxHash())
+	// Make sure the monitor loop is not bumping the same tx.
+	if !t.lockBump(requestID) {
+		return fmt.Errorf("%w: requestID=%v", ErrBumpInProgress,
+			requestID)
+	}
+	defer t.unlockBump(requestID)
 
-		// If the tx is already confirmed, we can stop monitoring it.
-		if t.isConfirmed(r.tx.TxHash()) {
-			confirmedRecords[requestID] = r
+	// Load the record again as it may have been updated while we were
+	// waiting for the lock.
+	r, ok := t.records.Load(requestID)
+	if !ok {
+		return fmt.Errorf("%w: requestID=%v", ErrRequestNotFound,
+			requestID)
+	}
 
-			// Move to the next record.
-			return nil
-		}
+	switch {
+	case r.tx == nil:
+		return fmt.Errorf("requestID=%v has not been broadcast yet",
+			requestID)
 
-		// Check whether the inputs has been spent by a third party.
-		//
-		// NOTE: this check is only done for neutrino backend.
-		if t.isThirdPartySpent(r.tx.TxHash(), r.req.Inputs) {
-			failedRecords[requestID] = r
+	case r.confirmed:
+		return fmt.Errorf("requestID=%v has already been confirmed",
+			requestID)
 
-			// Move to the next record.
-			return nil
-		}
+	case r.req.NoBump:
+		return fmt.Errorf("%w: requestID=%v", ErrBumpDisabled,
+			requestID)
+	}
 
-		feeBumpRecords[requestID] = r
+	oldTxid := r.tx.TxHash()
 
-		// Return nil to move to the next record.
-		return nil
+	increased, err := r.feeFunction.Increment(weightOf(r.tx))
+	switch {
+	case errors.Is(err, ErrMaxPosition) || (err == nil && !increased):
+		return fmt.Errorf("%w: requestID=%v, fee rate=%v",
+			ErrFeeRateAtMax, requestID, r.feeFunction.FeeRate())
+
+	case err != nil:
+		return fmt.Errorf("increment fee rate: %w", err)
 	}
 
-	// Iterate through all the records and divide them into four groups.
-	t.records.ForEach(visitor)
+	r.log().Infof("Forcing fee bump of tx %v to fee rate %v", oldTxid,
+		r.feeFunction.FeeRate())
 
-	// Handle the initial broadcast.
-	for requestID, r := range initialRecords {
-		t.handleInitialBroadcast(r, requestID)
+	// Make sure the inputs are still unspent, otherwise the replacement
+	// can never be confirmed.
+	spent := t.findSpentInput(r)
+	if spent.IsSome() {
+		t.handleInputSpent(r, requestID, spent)
+
+		return fmt.Errorf("%w: requestID=%v", ErrInputSpent, requestID)
 	}
 
-	// For records that are confirmed, we'll notify the caller about this
-	// result.
-	for requestID, r := range confirmedRecords {
-		log.Debugf("Tx=%v is confirmed", r.tx.TxHash())
-		t.wg.Add(1)
-		go t.handleTxConfirmed(r, requestID)
+	// The fee function now has a new fee rate, we will use it to bump the
+	// fee of the tx.
+	resultOpt := t.createAndPublishTx(requestID, r, ReplaceReasonForced)
+	result, err := resultOpt.UnwrapOrErr(
+		fmt.Errorf("replacement of tx %v not published", oldTxid),
+	)
+	if err != nil {
+		return err
 	}
 
-	// Get the current height to be used in the following goroutines.
-	currentHeight := t.currentHeight.Load()
+	t.handleBumpResult(&result)
 
-	// For records that are not confirmed, we perform a fee bump if needed.
-	for requestID, r := range feeBumpRecords {
-		log.Debugf("Attempting to fee bump Tx=%v", r.tx.TxHash())
-		t.wg.Add(1)
-		go t.handleFeeBumpTx(requestID, r, currentHeight)
+	if result.Event == TxReplaced {
+		t.notifyFeeBumped(&result)
 	}
 
-	// For records that are failed, we'll notify the caller about this
-	// result.
-	for requestID, r := range failedRecords {
-		log.Debugf("Tx=%v has inputs been spent by a third party, "+
-			"failing it now", r.tx.TxHash())
-		t.wg.Add(1)
-		go t.handleThirdPartySpent(r, requestID)
-	}
+	return result.Err
 }
 
-// handleTxConfirmed is called when a monitored tx is confirmed. It will
-// notify the subscriber then remove the record from the maps .
-//
-// NOTE: Must be run as a goroutine to avoid blocking on sending the result.
-func (t *TxPublisher) handleTxConfirmed(r *monitorRecord, requestID uint64) {
-	defer t.wg.Done()
+// lockBump marks the given request as being bumped. It returns false if the
+// request is already being bumped.
+func (t *TxPublisher) lockBump(requestID uint64) bool {
+	_, loaded := t.bumping.LoadOrStore(requestID, struct{}{})
 
-	// Create a result that will be sent to the resultChan which is
-	// listened by the caller.
-	result := &BumpResult{
-		Event:     TxConfirmed,
-		Tx:        r.tx,
-		requestID: requestID,
-		Fee:       r.fee,
-		FeeRate:   r.feeFunction.FeeRate(),
-	}
+	return !loaded
+}
 
-	// Notify that this tx is confirmed and remove the record from the map.
-	t.handleResult(result)
+// unlockBump unmarks the given request as being bumped.
+func (t *TxPublisher) unlockBump(requestID uint64) {
+	t.bumping.Delete(requestID)
 }
 
-// handleInitialTxError takes the error from `initializeTx` and decides the
-// bump event. It will construct a BumpResult and handles it.
-func (t *TxPublisher) handleInitialTxError(requestID uint64, err error) {
-	// We now decide what type of event to send.
-	var event BumpEvent
+// findSpentInput uses the configured UtxoChecker to find an input of the
+// record that's already spent. None is returned if all the inputs are unspent,
+// or no checker is configured.
+func (t *TxPublisher) findSpentInput(
+	r *monitorRecord) fn.Option[wire.OutPoint] {
 
-	switch {
-	// When the error is due to a dust output, we'll send a TxFailed so
-	// these inputs can be retried with a different group in the next
-	// block.
-	case errors.Is(err, ErrTxNoOutput):
-		event = TxFailed
+	checker := t.cfg.UtxoChecker.UnwrapOr(nil)
+	if checker == nil {
+		return fn.None[wire.OutPoint]()
+	}
 
-	// When the error is due to budget being used up, we'll send a TxFailed
-	// so these inputs can be retried with a different group in the next
-	// block.
-	case errors.Is(err, ErrMaxPosition):
-		event = TxFailed
+	for _, inp := range r.req.Inputs {
+		op := inp.OutPoint()
+		pkScript := inp.SignDesc().Output.PkScript
 
-	// When the error is due to zero fee rate delta, we'll send a TxFailed
-	// so these inputs can be retried in the next block.
-	case errors.Is(err, ErrZeroFeeRateDelta):
-		event = TxFailed
+		unspent, err := checker.IsUnspent(
+			op, pkScript, inp.HeightHint(),
+		)
+		if err != nil {
+			// We don't want a failed lookup to stop the fee bump,
+			// so we log it and move on.
+			r.log().Warnf("Failed to check input %v: %v", op, err)
 
-	// Otherwise this is not a fee-related error and the tx cannot be
-	// retried. In that case we will fail ALL the inputs in this tx, which
-	// means they will be removed from the sweeper and never be tried
-	// again.
-	//
-	// TODO(yy): Find out which input is causing the failure and fail that
-	// one only.
-	default:
-		event = TxFatal
+			continue
+		}
+
+		if !unspent {
+			r.log().Warnf("Input %v of tx %v has been spent", op,
+				r.tx.TxHash())
+
+			return fn.Some(op)
+		}
 	}
 
-	result := &BumpResult{
-		Event:     event,
-		Err:       err,
-		requestID: requestID,
-	}
+	return fn.None[wire.OutPoint]()
+}
 
-	t.handleResult(result)
+// handleInputSpent sends a TxInputSpent event for the record and removes it.
+func (t *TxPublisher) handleInputSpent(r *monitorRecord, requestID uint64,
+	spent fn.Option[wire.OutPoint]) {
+
+	op := spent.UnwrapOr(wire.OutPoint{})
+
+	t.handleResult(&BumpResult{
+		Event:      TxInputSpent,
+		Tx:         r.tx,
+		Fee:        r.fee,
+		FeeRate:    r.feeFunction.FeeRate(),
+		Err:        fmt.Errorf("%w: %v", ErrInputSpent, op),
+		SpentInput: spent,
+		requestID:  requestID,
+	})
 }
 
-// handleInitialBroadcast is called when a new request is received. It will
-// handle the initial tx creation and broadcast. In details,
-// 1. init a fee function based on the given strategy.
-// 2. create an RBF-compliant tx and monitor it for confirmation.
-// 3. notify the initial broadcast result back to the caller.
-func (t *TxPublisher) handleInitialBroadcast(r *monitorRecord,
-	requestID uint64) {
+// rbfConflict describes a conflicting mempool tx spending an input of a
+// request.
+type rbfConflict struct {
+	// op is the input spent by the conflicting tx.
+	op wire.OutPoint
 
-	log.Debugf("Initial broadcast for requestID=%v", requestID)
+	// txid is the txid of the conflicting tx.
+	txid chainhash.Hash
 
-	var (
-		result *BumpResult
-		err    error
-	)
+	// feeRate is the fee rate paid by the conflicting tx.
+	feeRate chainfee.SatPerKWeight
 
-	// Attempt an initial broadcast which is guaranteed to comply with the
-	// RBF rules.
-	//
-	// Create the initial tx to be broadcasted.
-	err = t.initializeTx(requestID, r.req)
-	if err != nil {
-		log.Errorf("Initial broadcast failed: %v", err)
+	// maxFeeRate is the max fee rate allowed by the request.
+	maxFeeRate chainfee.SatPerKWeight
+}
 
-		// We now handle the initialization error and exit.
-		t.handleInitialTxError(requestID, err)
+// findLostRBFRace looks up the mempool for a conflicting tx spending an input
+// of the record, whose fee rate is above the max fee rate allowed by the
+// request. The fee of a conflicting tx can only be calculated when it spends
+// no inputs other than the inputs of the request, otherwise it's skipped.
+func (t *TxPublisher) findLostRBFRace(
+	r *monitorRecord) fn.Option[rbfConflict] {
 
-		return
+	mempool := t.cfg.Mempool.UnwrapOr(nil)
+	if mempool == nil {
+		return fn.None[rbfConflict]()
 	}
 
-	// Successfully created the first tx, now broadcast it.
-	result, err = t.broadcast(requestID)
+	maxFeeRate, err := r.req.maxFeeRateAllowed(t.feeRateSanityCap())
 	if err != nil {
-		// The broadcast failed, which can only happen if the tx record
-		// cannot be found or the aux sweeper returns an error. In
-		// either case, we will send back a TxFail event so these
-		// inputs can be retried.
-		result = &BumpResult{
-			Event:     TxFailed,
-			Err:       err,
-			requestID: requestID,
+		r.log().Warnf("Failed to get max fee rate allowed: %v", err)
+
+		return fn.None[rbfConflict]()
+	}
+
+	txid := r.tx.TxHash()
+	for _, inp := range r.req.Inputs {
+		op := inp.OutPoint()
+
+		spend := mempool.LookupInputMempoolSpend(op)
+		if spend.IsNone() {
+			continue
+		}
+
+		spendTx := spend.UnwrapOr(wire.MsgTx{})
+		if spendTx.TxHash() == txid {
+			continue
+		}
+
+		fee := spentInputsFee(r.req.Inputs, &spendTx)
+		if fee <= 0 {
+			r.log().Debugf("Input %v spent by conflicting tx %v "+
+				"with unknown fee", op, spendTx.TxHash())
+
+			continue
+		}
+
+		weight := blockchain.GetTransactionWeight(
+			btcutil.NewTx(&spendTx),
+		)
+		feeRate := chainfee.NewSatPerKWeight(
+			fee, lntypes.WeightUnit(weight),
+		)
+		if feeRate <= maxFeeRate {
+			continue
 		}
+
+		return fn.Some(rbfConflict{
+			op:         op,
+			txid:       spendTx.TxHash(),
+			feeRate:    feeRate,
+			maxFeeRate: maxFeeRate,
+		})
 	}
 
-	t.handleResult(result)
+	return fn.None[rbfConflict]()
 }
 
-// handleFeeBumpTx checks if the tx needs to be bumped, and if so, it will
-// attempt to bump the fee of the tx.
-//
-// NOTE: Must be run as a goroutine to avoid blocking on sending the result.
-func (t *TxPublisher) handleFeeBumpTx(requestID uint64, r *monitorRecord,
-	currentHeight int32) {
+// handleLostRBFRace sends a TxLostRBFRace event for the record and removes it.
+func (t *TxPublisher) handleLostRBFRace(r *monitorRecord, requestID uint64,
+	conflict fn.Option[rbfConflict]) {
+
+	c := conflict.UnwrapOr(rbfConflict{})
+
+	r.log().Warnf("Input %v of tx %v spent by conflicting tx %v with fee "+
+		"rate %v above max fee rate allowed %v, stop bumping", c.op,
+		r.tx.TxHash(), c.txid, c.feeRate, c.maxFeeRate)
+
+	t.handleResult(&BumpResult{
+		Event:   TxLostRBFRace,
+		Tx:      r.tx,
+		Fee:     r.fee,
+		FeeRate: r.feeFunction.FeeRate(),
+		Err: fmt.Errorf("%w: input=%v, conflicting tx=%v, fee "+
+			"rate=%v, max fee rate allowed=%v", ErrLostRBFRace,
+			c.op, c.txid, c.feeRate, c.maxFeeRate),
+		SpentInput: fn.Some(c.op),
+		requestID:  requestID,
+	})
+}
 
-	defer t.wg.Done()
+// isMeaningfulBump checks whether the current fee rate of the record's fee
+// function exceeds the fee rate paid by its tx by at least the configured
+// MinBumpIncrementPercent. A fee function that has reached its max fee rate
+// always gives a meaningful bump, so the tx is never under-bumped.
+func (t *TxPublisher) isMeaningfulBump(r *monitorRecord,
+	weight lntypes.WeightUnit) bool {
 
-	oldTxid := r.tx.TxHash()
+	if t.cfg.MinBumpIncrementPercent == 0 {
+		return true
+	}
 
-	// Get the current conf target for this record.
-	confTarget := calcCurrentConfTarget(currentHeight, r.req.DeadlineHeight)
+	newFeeRate := r.feeFunction.FeeRate()
 
-	// Ask the fee function whether a bump is needed. We expect the fee
-	// function to increase its returned fee rate after calling this
-	// method.
-	increased, err := r.feeFunction.IncreaseFeeRate(confTarget)
-	if err != nil {
-		// TODO(yy): send this error back to the sweeper so it can
-		// re-group the inputs?
-		log.Errorf("Failed to increase fee rate for tx %v at "+
-			"height=%v: %v", oldTxid, t.currentHeight.Load(), err)
+	// Always bump if the fee function cannot go any higher.
+	schedule := r.feeFunction.Schedule()
+	if len(schedule) == 0 || newFeeRate >= schedule[len(schedule)-1] {
+		return true
+	}
 
-		return
+	feeRate := chainfee.NewSatPerKWeight(r.fee, weight)
+	minFeeRate := chainfee.SatPerKWeight(
+		float64(feeRate) * (1 + t.cfg.MinBumpIncrementPercent/100),
+	)
+	if newFeeRate >= minFeeRate {
+		return true
 	}
 
-	// If the fee rate was not increased, there's no need to bump the fee.
-	if !increased {
-		log.Tracef("Skip bumping tx %v at height=%v", oldTxid,
-			t.currentHeight.Load())
+	r.log().Debugf("Skip bumping tx %v: new fee rate %v is less than "+
+		"%v%% above current fee rate %v", r.tx.TxHash(), newFeeRate,
+		t.cfg.MinBumpIncrementPercent, feeRate)
+
+	return false
+}
 
+// notifyBudgetExhausted sends a TxBudgetExhausted event for the given record
+// if it hasn't been sent yet.
+func (t *TxPublisher) notifyBudgetExhausted(requestID uint64,
+	r *monitorRecord) {
+
+	if r.budgetExhausted {
 		return
 	}
 
-	// The fee function now has a new fee rate, we will use it to bump the
-	// fee of the tx.
-	resultOpt := t.createAndPublishTx(requestID, r)
+	r.log().Warnf("Budget exhausted for tx %v at height=%v: fee rate=%v, "+
+		"budget=%v", r.tx.TxHash(), t.currentHeight.Load(),
+		r.feeFunction.FeeRate(), r.req.Budget)
 
-	// If there's a result, we will notify the caller about the result.
-	resultOpt.WhenSome(func(result BumpResult) {
-		// Notify the new result.
-		t.handleResult(&result)
+	// Mark the record so the event is only sent once.
+	exhausted := *r
+	exhausted.budgetExhausted = true
+	t.records.Store(requestID, &exhausted)
+
+	t.notifyResult(&BumpResult{
+		Event:     TxBudgetExhausted,
+		Tx:        r.tx,
+		Fee:       r.fee,
+		FeeRate:   r.feeFunction.FeeRate(),
+		requestID: requestID,
 	})
 }
 
+// notifyFeeBumped sends a TxFeeBumped event, carrying the new tx and its fee
+// rate found in the given replacement result, to the subscriber. The event is
+// sent on a best-effort basis - it's dropped if the subscriber is not ready to
+// receive it so a slow subscriber won't stall the fee bumping.
+func (t *TxPublisher) notifyFeeBumped(replaced *BumpResult) {
+	id := replaced.requestID
+	subscriber, ok := t.subscriberChans.Load(id)
+	if !ok {
+		log.Errorf("Result chan for id=%v not found", id)
+		return
+	}
+
+	result := &BumpResult{
+		Event:     TxFeeBumped,
+		Tx:        replaced.Tx,
+		FeeRate:   replaced.FeeRate,
+		Fee:       replaced.Fee,
+		Label:     replaced.Label,
+		requestID: id,
+	}
+	t.attachLabel(result)
+
+	// Attach the reason recorded for the latest replacement.
+	if r, ok := t.records.Load(id); ok {
+		result.ReplaceReason = r.replaceReason
+	}
+	t.notifyGlobal(result)
+
+	select {
+	case subscriber <- result:
+		log.Debugf("Sent result %v for requestID=%v", result, id)
+
+	default:
+		log.Debugf("Subscriber not ready, dropped result %v for "+
+			"requestID=%v", result, id)
+	}
+}
+
 // handleThirdPartySpent is called when the inputs in an unconfirmed tx is
 // spent. It will notify the subscriber then remove the record from the maps
 // and send a TxFailed event to the subscriber.
@@ -1138,8 +6309,9 @@ func (t *TxPublisher) handleThirdPartySpent(r *monitorRecord,
 // createAndPublishTx creates a new tx with a higher fee rate and publishes it
 // to the network. It will update the record with the new tx and fee rate if
 // successfully created, and return the result when published successfully.
-func (t *TxPublisher) createAndPublishTx(requestID uint64,
-	r *monitorRecord) fn.Option[BumpResult] {
+// The reason is recorded on the updated record.
+func (t *TxPublisher) createAndPublishTx(requestID uint64, r *monitorRecord,
+	reason ReplaceReason) fn.Option[BumpResult] {
 
 	// Fetch the old tx.
 	oldTx := r.tx
@@ -1149,7 +6321,7 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 	// NOTE: The fee function is expected to have increased its returned
 	// fee rate after calling the SkipFeeBump method. So we can use it
 	// directly here.
-	sweepCtx, err := t.createAndCheckTx(r.req, r.feeFunction)
+	sweepCtx, err := t.createAndCheckTx(r.req, r.feeFunction, r.log())
 
 	// If the error is fee related, we will return no error and let the fee
 	// bumper retry it at next block.
@@ -1164,7 +6336,21 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 	if errors.Is(err, chain.ErrInsufficientFee) ||
 		errors.Is(err, lnwallet.ErrMempoolFee) {
 
-		log.Debugf("Failed to bump tx %v: %v", oldTx.TxHash(), err)
+		r.log().Debugf("Failed to bump tx %v: %v", oldTx.TxHash(), err)
+
+		// Log by how much the replacement missed the required fee.
+		if sweepCtx != nil {
+			mempoolShortfall(r.log(), sweepCtx.tx, err)
+		}
+
+		return fn.None[BumpResult]()
+	}
+
+	// If the replacement pays a fee rate far above the recently confirmed
+	// ones, we keep the current tx and retry at next block.
+	if errors.Is(err, ErrFeeRateAboveConfirmed) {
+		r.log().Warnf("Refused to bump tx %v: %v", oldTx.TxHash(), err)
+
 		return fn.None[BumpResult]()
 	}
 
@@ -1175,14 +6361,14 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 		// result so the sweeper can handle it by re-clustering the
 		// utxos.
 		if errors.Is(err, ErrNotEnoughBudget) {
-			log.Warnf("Fail to fee bump tx %v: %v", oldTx.TxHash(),
-				err)
+			r.log().Warnf("Fail to fee bump tx %v: %v",
+				oldTx.TxHash(), err)
 		} else {
 			// Otherwise, an unexpected error occurred, we will
 			// fail the tx and let the sweeper retry the whole
 			// process.
-			log.Errorf("Failed to bump tx %v: %v", oldTx.TxHash(),
-				err)
+			r.log().Errorf("Failed to bump tx %v: %v",
+				oldTx.TxHash(), err)
 		}
 
 		return fn.Some(BumpResult{
@@ -1201,12 +6387,21 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 		feeFunction:       r.feeFunction,
 		fee:               sweepCtx.fee,
 		outpointToTxIndex: sweepCtx.outpointToTxIndex,
+		heightHint:        uint32(t.currentHeight.Load()),
+		replaceReason:     reason,
+		mempoolAccepted:   sweepCtx.mempoolAccepted,
+		history:           r.history,
+		logger: newRequestLogger(
+			requestID, r.req.Label, sweepCtx.tx,
+		),
 	})
 
+	r.log().Debugf("Replacing tx %v, reason=%v", oldTx.TxHash(), reason)
+
 	// Attempt to broadcast this new tx.
 	result, err := t.broadcast(requestID)
 	if err != nil {
-		log.Infof("Failed to broadcast replacement tx %v: %v",
+		r.log().Infof("Failed to broadcast replacement tx %v: %v",
 			sweepCtx.tx.TxHash(), err)
 
 		return fn.None[BumpResult]()
@@ -1220,7 +6415,7 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 	if errors.Is(result.Err, chain.ErrInsufficientFee) ||
 		errors.Is(result.Err, lnwallet.ErrMempoolFee) {
 
-		log.Debugf("Failed to bump tx %v: %v", oldTx.TxHash(), err)
+		r.log().Debugf("Failed to bump tx %v: %v", oldTx.TxHash(), err)
 		return fn.None[BumpResult]()
 	}
 
@@ -1233,7 +6428,7 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 		return fn.Some(*result)
 	}
 
-	log.Infof("Replaced tx=%v with new tx=%v", oldTx.TxHash(),
+	r.log().Infof("Replaced tx=%v with new tx=%v", oldTx.TxHash(),
 		sweepCtx.tx.TxHash())
 
 	// Otherwise, it's a successful RBF, set the event and return.
@@ -1242,15 +6437,125 @@ func (t *TxPublisher) createAndPublishTx(requestID uint64,
 	return fn.Some(*result)
 }
 
-// isConfirmed checks the btcwallet to see whether the tx is confirmed.
-func (t *TxPublisher) isConfirmed(txid chainhash.Hash) bool {
+// txDetails checks the btcwallet to see how many confirmations the tx has,
+// and which block confirmed it. Empty details with zero confirmations are
+// returned if the details cannot be fetched.
+func (t *TxPublisher) txDetails(
+	txid chainhash.Hash) *lnwallet.TransactionDetail {
+
 	details, err := t.cfg.Wallet.GetTransactionDetails(&txid)
 	if err != nil {
 		log.Warnf("Failed to get tx details for %v: %v", txid, err)
-		return false
+		return &lnwallet.TransactionDetail{}
+	}
+
+	return details
+}
+
+// findConfirmedSpend checks whether an input of the request has already been
+// spent by a confirmed tx that's known to our wallet. If so, a TxConfirmed
+// result pointing at that tx is returned. Wallet inputs, which have no height
+// hint, are skipped.
+func (t *TxPublisher) findConfirmedSpend(r *monitorRecord,
+	requestID uint64) fn.Option[*BumpResult] {
+
+	for _, inp := range r.req.Inputs {
+		op := inp.OutPoint()
+
+		heightHint := inp.HeightHint()
+		if heightHint == 0 {
+			continue
+		}
+
+		spendEvent, err := t.cfg.Notifier.RegisterSpendNtfn(
+			&op, inp.SignDesc().Output.PkScript, heightHint,
+		)
+		if err != nil {
+			r.log().Warnf("Failed to register spend ntfn for "+
+				"input=%v: %v", op, err)
+
+			continue
+		}
+
+		// Do a non-blocking read to see if the output has been spent,
+		// and remove the subscription right after.
+		var spend *chainntnfs.SpendDetail
+		select {
+		case spend = <-spendEvent.Spend:
+		default:
+		}
+		spendEvent.Cancel()
+
+		if spend == nil {
+			continue
+		}
+
+		// Only a confirmed tx from our wallet is treated as the sweep
+		// of this request.
+		spendTx := spend.SpendingTx
+		txid := spendTx.TxHash()
+		details, err := t.cfg.Wallet.GetTransactionDetails(&txid)
+		if err != nil || details.NumConfirmations <= 0 {
+			r.log().Debugf("Input %v spent by unconfirmed or "+
+				"foreign tx %v", op, txid)
+
+			continue
+		}
+
+		fee := btcutil.Amount(details.TotalFees)
+		if fee == 0 {
+			fee = spentInputsFee(r.req.Inputs, spendTx)
+		}
+		weight := blockchain.GetTransactionWeight(
+			btcutil.NewTx(spendTx),
+		)
+
+		r.log().Infof("Input %v already spent by confirmed tx %v at "+
+			"height=%v, skipped broadcast", op, txid,
+			details.BlockHeight)
+
+		result := &BumpResult{
+			Event: TxConfirmed,
+			Tx:    spendTx,
+			Fee:   fee,
+			FeeRate: chainfee.NewSatPerKWeight(
+				fee, lntypes.WeightUnit(weight),
+			),
+			ConfHeight: uint32(details.BlockHeight),
+			requestID:  requestID,
+		}
+		if details.BlockHash != nil {
+			result.ConfBlockHash = *details.BlockHash
+		}
+
+		return fn.Some(result)
+	}
+
+	return fn.None[*BumpResult]()
+}
+
+// spentInputsFee calculates the fee paid by the given tx using the values of
+// the given inputs. Zero is returned if the tx spends any other input, as its
+// value is unknown.
+func spentInputsFee(inputs []input.Input, tx *wire.MsgTx) btcutil.Amount {
+	values := make(map[wire.OutPoint]int64, len(inputs))
+	for _, inp := range inputs {
+		values[inp.OutPoint()] = inp.SignDesc().Output.Value
+	}
+
+	var fee int64
+	for _, txIn := range tx.TxIn {
+		value, ok := values[txIn.PreviousOutPoint]
+		if !ok {
+			return 0
+		}
+		fee += value
+	}
+	for _, txOut := range tx.TxOut {
+		fee -= txOut.Value
 	}
 
-	return details.NumConfirmations > 0
+	return btcutil.Amount(fee)
 }
 
 // isThirdPartySpent checks whether the inputs of the tx has already been spent
@@ -1358,18 +6663,24 @@ type sweepTxCtx struct {
 	// outpointToTxIndex maps the outpoint of the inputs to their index in
 	// the sweep transaction.
 	outpointToTxIndex map[wire.OutPoint]int
+
+	// mempoolAccepted is set if the tx has passed the mempool acceptance
+	// check.
+	mempoolAccepted bool
 }
 
 // createSweepTx creates a sweeping tx based on the given inputs, change
-// address and fee rate.
+// address, fee rate and an optional locktime. When noRBF is set, the inputs
+// use non-signaling sequences so the tx opts out of RBF.
 func (t *TxPublisher) createSweepTx(inputs []input.Input,
-	changePkScript lnwallet.AddrWithKey,
-	feeRate chainfee.SatPerKWeight) (*sweepTxCtx, error) {
+	changePkScript lnwallet.AddrWithKey, feeRate chainfee.SatPerKWeight,
+	lockTime uint32, anchorParent fn.Option[input.TxInfo],
+	extraOutputs []*wire.TxOut, noRBF bool) (*sweepTxCtx, error) {
 
 	// Validate and calculate the fee and change amount.
 	txFee, changeOutputsOpt, locktimeOpt, err := prepareSweepTx(
 		inputs, changePkScript, feeRate, t.currentHeight.Load(),
-		t.cfg.AuxSweeper,
+		t.cfg.AuxSweeper, lockTime, anchorParent, extraOutputs,
 	)
 	if err != nil {
 		return nil, err
@@ -1397,7 +6708,7 @@ func (t *TxPublisher) createSweepTx(inputs []input.Input,
 		idxs = append(idxs, o)
 		sweepTx.AddTxIn(&wire.TxIn{
 			PreviousOutPoint: o.OutPoint(),
-			Sequence:         o.BlocksToMaturity(),
+			Sequence:         inputSequence(o),
 		})
 		sweepTx.AddTxOut(o.RequiredTxOut())
 
@@ -1414,10 +6725,15 @@ func (t *TxPublisher) createSweepTx(inputs []input.Input,
 		idxs = append(idxs, o)
 		sweepTx.AddTxIn(&wire.TxIn{
 			PreviousOutPoint: o.OutPoint(),
-			Sequence:         o.BlocksToMaturity(),
+			Sequence:         inputSequence(o),
 		})
 	}
 
+	// Add the extra outputs requested by the caller.
+	for _, txOut := range extraOutputs {
+		sweepTx.AddTxOut(txOut)
+	}
+
 	// If we have change outputs to add, then add it the sweep transaction
 	// here.
 	changeOutputsOpt.WhenSome(func(changeOuts []SweepOutput) {
@@ -1427,9 +6743,24 @@ func (t *TxPublisher) createSweepTx(inputs []input.Input,
 	})
 
 	// We'll default to using the current block height as locktime, if none
-	// of the inputs commits to a different locktime.
+	// of the inputs or the request commits to a different locktime.
 	sweepTx.LockTime = uint32(locktimeOpt.UnwrapOr(t.currentHeight.Load()))
 
+	// Opt out of RBF if requested, otherwise the sequences required by
+	// the inputs may prevent the tx from being replaced, which we want to
+	// know about.
+	if noRBF {
+		disableRBF(sweepTx)
+	} else {
+		warnIfNotReplaceable(sweepTx)
+	}
+
+	// The locktime is only enforced when at least one of the inputs has a
+	// non-final sequence, so we make sure that's the case.
+	if lockTime != 0 {
+		ensureLockTimeEnforced(sweepTx)
+	}
+
 	prevInputFetcher, err := input.MultiPrevOutFetcher(inputs)
 	if err != nil {
 		return nil, fmt.Errorf("error creating prev input fetcher "+
@@ -1441,7 +6772,8 @@ func (t *TxPublisher) createSweepTx(inputs []input.Input,
 	// function to generate the final witness required for spending.
 	addInputScript := func(idx int, tso input.Input) error {
 		inputScript, err := tso.CraftInputScript(
-			t.cfg.Signer, sweepTx, hashCache, prevInputFetcher, idx,
+			t.signerFor(tso), sweepTx, hashCache, prevInputFetcher,
+			idx,
 		)
 		if err != nil {
 			return err
@@ -1458,8 +6790,11 @@ func (t *TxPublisher) createSweepTx(inputs []input.Input,
 		return nil
 	}
 
-	for idx, inp := range idxs {
-		if err := addInputScript(idx, inp); err != nil {
+	// Sign the inputs at their tx indexes, optionally in an order grouped
+	// by their witness types.
+	order := signingOrder(idxs, t.cfg.GroupSigningByWitnessType)
+	for _, idx := range order {
+		if err := addInputScript(idx, idxs[idx]); err != nil {
 			return nil, err
 		}
 	}
@@ -1500,17 +6835,173 @@ func (t *TxPublisher) createSweepTx(inputs []input.Input,
 	}, nil
 }
 
+// validateExtraOutputs checks the given extra outputs are valid. An OP_RETURN
+// output must carry zero value and no more than 80 bytes of data, while other
+// outputs must not be dust.
+func validateExtraOutputs(outputs []*wire.TxOut) error {
+	for i, txOut := range outputs {
+		script := txOut.PkScript
+
+		// Check the outputs other than OP_RETURN are not dust.
+		if len(script) == 0 || script[0] != txscript.OP_RETURN {
+			dustLimit := lnwallet.DustLimitForSize(len(script))
+			if btcutil.Amount(txOut.Value) < dustLimit {
+				return fmt.Errorf("%w: output %d has value %v "+
+					"below dust limit %v",
+					ErrInvalidExtraOutput, i, txOut.Value,
+					dustLimit)
+			}
+
+			continue
+		}
+
+		if txOut.Value != 0 {
+			return fmt.Errorf("%w: OP_RETURN output %d has "+
+				"non-zero value %v", ErrInvalidExtraOutput, i,
+				txOut.Value)
+		}
+
+		pushes, err := txscript.PushedData(script[1:])
+		if err != nil {
+			return fmt.Errorf("%w: OP_RETURN output %d: %v",
+				ErrInvalidExtraOutput, i, err)
+		}
+
+		var dataSize int
+		for _, push := range pushes {
+			dataSize += len(push)
+		}
+
+		if dataSize > txscript.MaxDataCarrierSize {
+			return fmt.Errorf("%w: OP_RETURN output %d carries %d "+
+				"bytes, max allowed is %d",
+				ErrInvalidExtraOutput, i, dataSize,
+				txscript.MaxDataCarrierSize)
+		}
+	}
+
+	return nil
+}
+
+// signingOrder returns the order in which the inputs should be signed, as a
+// list of indexes into the given inputs. When grouping is not requested, the
+// inputs are signed in their original order. Otherwise inputs of the same
+// witness type are signed consecutively, with the groups ordered by the first
+// appearance of their witness types.
+func signingOrder(inputs []input.Input, groupByType bool) []int {
+	order := make([]int, len(inputs))
+	for i := range inputs {
+		order[i] = i
+	}
+
+	if !groupByType {
+		return order
+	}
+
+	// Find the rank of each witness type based on its first appearance.
+	ranks := make(map[input.WitnessType]int)
+	for _, inp := range inputs {
+		wt := inp.WitnessType()
+		if _, ok := ranks[wt]; !ok {
+			ranks[wt] = len(ranks)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		wi := inputs[order[i]].WitnessType()
+		wj := inputs[order[j]].WitnessType()
+
+		return ranks[wi] < ranks[wj]
+	})
+
+	return order
+}
+
+// inputSequence returns the sequence used by the given input. The sequence
+// required by a SequenceInput is used if specified, otherwise the relative
+// locktime given by BlocksToMaturity is used.
+func inputSequence(o input.Input) uint32 {
+	if s, ok := o.(SequenceInput); ok {
+		if sequence, required := s.RequiredSequence(); required {
+			return sequence
+		}
+	}
+
+	return o.BlocksToMaturity()
+}
+
+// warnIfNotReplaceable logs a warning if the tx doesn't signal replaceability
+// as defined in BIP125, which requires at least one input to have a sequence
+// below MaxTxInSequenceNum-1.
+//
+// NOTE: the inputs without a required sequence use their relative locktimes
+// or zero as their sequences, which always signal RBF, so this can only
+// happen when all the inputs require a final sequence.
+func warnIfNotReplaceable(tx *wire.MsgTx) {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return
+		}
+	}
+
+	log.Warnf("Sweep tx %v doesn't signal RBF as all of its inputs "+
+		"require a final sequence", tx.TxHash())
+}
+
+// signerFor returns the signer used to sign the given input, which is the one
+// given by the SignerResolver if configured, or the default Signer.
+func (t *TxPublisher) signerFor(inp input.Input) input.Signer {
+	if t.cfg.SignerResolver == nil {
+		return t.cfg.Signer
+	}
+
+	if signer := t.cfg.SignerResolver(inp); signer != nil {
+		return signer
+	}
+
+	return t.cfg.Signer
+}
+
+// disableRBF sets the sequences of the tx's inputs to MaxTxInSequenceNum-1,
+// which doesn't signal replaceability as defined in BIP125 while keeping the
+// locktime of the tx enforced.
+func disableRBF(tx *wire.MsgTx) {
+	for _, txIn := range tx.TxIn {
+		txIn.Sequence = wire.MaxTxInSequenceNum - 1
+	}
+}
+
+// ensureLockTimeEnforced makes sure the tx's locktime is enforced by setting
+// the sequence of the first input to a non-final value when all the inputs
+// have final sequences.
+func ensureLockTimeEnforced(tx *wire.MsgTx) {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence != wire.MaxTxInSequenceNum {
+			return
+		}
+	}
+
+	if len(tx.TxIn) > 0 {
+		tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+	}
+}
+
 // prepareSweepTx returns the tx fee, a set of optional change outputs and an
 // optional locktime after a series of validations:
 // 1. check the locktime has been reached.
 // 2. check the locktimes are the same.
 // 3. check the inputs cover the outputs.
 //
+// A non-zero lockTime specifies the locktime requested by the caller, which
+// must have been reached and must match the locktimes required by the inputs.
+//
 // NOTE: if the change amount is below dust, it will be added to the tx fee.
 func prepareSweepTx(inputs []input.Input, changePkScript lnwallet.AddrWithKey,
 	feeRate chainfee.SatPerKWeight, currentHeight int32,
-	auxSweeper fn.Option[AuxSweeper]) (
-	btcutil.Amount, fn.Option[[]SweepOutput], fn.Option[int32], error) {
+	auxSweeper fn.Option[AuxSweeper], lockTime uint32,
+	anchorParent fn.Option[input.TxInfo],
+	extraOutputs []*wire.TxOut) (btcutil.Amount, fn.Option[[]SweepOutput],
+	fn.Option[int32], error) {
 
 	noChange := fn.None[[]SweepOutput]()
 	noLocktime := fn.None[int32]()
@@ -1537,17 +7028,19 @@ func prepareSweepTx(inputs []input.Input, changePkScript lnwallet.AddrWithKey,
 		return 0, noChange, noLocktime, err
 	}
 
-	// Creating a weight estimator with nil outputs and zero max fee rate.
-	// We don't allow adding customized outputs in the sweeping tx, and the
-	// fee rate is already being managed before we get here.
+	// Creating a weight estimator with the extra outputs and zero max fee
+	// rate, as the fee rate is already being managed before we get here.
 	inputs, estimator, err := getWeightEstimate(
-		inputs, nil, feeRate, 0, changePkScripts,
+		inputs, extraOutputs, feeRate, 0, changePkScripts,
 	)
 	if err != nil {
 		return 0, noChange, noLocktime, err
 	}
 
-	txFee := estimator.fee()
+	// The fee rate is the package fee rate, so the sweeping tx also pays
+	// for the fee deficit of its unconfirmed parents, if any.
+	anchorParent.WhenSome(estimator.setParent)
+	txFee := estimator.feeWithParent()
 
 	var (
 		// Track whether any of the inputs require a certain locktime.
@@ -1559,12 +7052,36 @@ func prepareSweepTx(inputs []input.Input, changePkScript lnwallet.AddrWithKey,
 		requiredOutput btcutil.Amount
 	)
 
+	// If the caller specified a locktime, we'll make sure it's a block
+	// height that has been reached, and use it as the starting point so
+	// any input committing to a different locktime is rejected below.
+	if lockTime != 0 {
+		if lockTime >= txscript.LockTimeThreshold {
+			return 0, noChange, noLocktime, fmt.Errorf("timestamp "+
+				"locktime %v is not supported", lockTime)
+		}
+
+		if lockTime > uint32(currentHeight) {
+			return 0, noChange, noLocktime, fmt.Errorf("%w: "+
+				"current height is %v, locktime is %v",
+				ErrLocktimeInFuture, currentHeight, lockTime)
+		}
+
+		locktime = int32(lockTime)
+	}
+
 	// If we have an extra change output, then we'll add it as a required
 	// output amt.
 	extraChangeOut.WhenSome(func(o SweepOutput) {
 		requiredOutput += btcutil.Amount(o.Value)
 	})
 
+	// The extra outputs requested by the caller are also paid from the
+	// inputs.
+	for _, txOut := range extraOutputs {
+		requiredOutput += btcutil.Amount(txOut.Value)
+	}
+
 	// Go through each input and check if the required lock times have
 	// reached and are the same.
 	for _, o := range inputs {
@@ -0,0 +1,820 @@
+package sweep
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// ErrUnsupportedState is returned when the publisher's state cannot be
+// exported or imported, such as when a request sweeps an input that cannot be
+// reconstructed from its serialized form.
+var ErrUnsupportedState = errors.New("unsupported publisher state")
+
+const (
+	// feeFunctionLinear identifies a serialized LinearFeeFunction.
+	feeFunctionLinear = "linear"
+
+	// feeFunctionConstant identifies a serialized ConstantFeeFunction.
+	feeFunctionConstant = "constant"
+)
+
+// publisherState is the JSON form of the records monitored by the publisher.
+type publisherState struct {
+	Records []recordState `json:"records"`
+}
+
+// recordState is the JSON form of a monitorRecord.
+type recordState struct {
+	RequestID uint64       `json:"request_id"`
+	Request   requestState `json:"request"`
+
+	// Tx is the hex-encoded tx, which is empty if the record is waiting
+	// for its initial broadcast.
+	Tx          string            `json:"tx"`
+	Fee         btcutil.Amount    `json:"fee"`
+	FeeFunction *feeFunctionState `json:"fee_function"`
+	HeightHint  uint32            `json:"height_hint"`
+
+	Confirmed     bool          `json:"confirmed"`
+	ConfHeight    uint32        `json:"conf_height"`
+	ConfBlockHash string        `json:"conf_block_hash"`
+	ReplaceReason ReplaceReason `json:"replace_reason"`
+
+	History []historyState `json:"history"`
+}
+
+// requestState is the JSON form of a BumpRequest. The function fields, the
+// aux sweeper's extra output and the unconfirmed parents of the inputs are
+// not included.
+type requestState struct {
+	Budget          btcutil.Amount `json:"budget"`
+	BudgetFraction  float64        `json:"budget_fraction"`
+	Inputs          []inputState   `json:"inputs"`
+	DeliveryAddress string         `json:"delivery_address"`
+	Label           string         `json:"label"`
+	IdempotencyKey  string         `json:"idempotency_key"`
+
+	// The deadline params of the request.
+	DeadlineHeight int32            `json:"deadline_height"`
+	InputDeadlines map[string]int32 `json:"input_deadlines"`
+	NumConfs       uint32           `json:"num_confs"`
+	ConfTarget     uint32           `json:"conf_target"`
+	AltConfTarget  uint32           `json:"alt_conf_target"`
+	CLTVExpiry     int32            `json:"cltv_expiry"`
+	CLTVBuffer     int32            `json:"cltv_buffer"`
+
+	// The fee params of the request.
+	MaxFeeRate         chainfee.SatPerKWeight  `json:"max_fee_rate"`
+	MaxFeeRateVByte    chainfee.SatPerVByte    `json:"max_fee_rate_vb"`
+	MaxFeeAbsolute     btcutil.Amount          `json:"max_fee_absolute"`
+	MaxFeeFraction     float64                 `json:"max_fee_fraction"`
+	ShrinkChangeForFee bool                    `json:"shrink_change"`
+	StartingFeeRate    *chainfee.SatPerKWeight `json:"start_fee_rate"`
+	FixedFeeRate       chainfee.SatPerKWeight  `json:"fixed_fee_rate"`
+	BypassFeeCache     bool                    `json:"bypass_cache"`
+	MempoolPercentile  *float64                `json:"mempool_pct"`
+
+	// The outputs and the other txns related to the sweeping tx.
+	ExtraOutputs []txOutState          `json:"extra_outputs"`
+	InputOutputs map[string]txOutState `json:"input_outputs"`
+	Parents      []string              `json:"parents"`
+	AnchorParent *txInfoState          `json:"anchor_parent"`
+	ConfPkScript string                `json:"conf_pk_script"`
+	Immediate    bool                  `json:"immediate"`
+	RejectReuse  bool                  `json:"reject_change_reuse"`
+	NoBump       bool                  `json:"no_bump"`
+	EmitAccept   bool                  `json:"emit_mempool_accept"`
+	CPFPFallback bool                  `json:"cpfp_fallback"`
+	Timeout      time.Duration         `json:"timeout"`
+	LockTime     uint32                `json:"lock_time"`
+}
+
+// inputState is the JSON form of an input, which is restored as a BaseInput.
+type inputState struct {
+	OutPoint         string `json:"outpoint"`
+	WitnessType      uint16 `json:"witness_type"`
+	SignDesc         string `json:"sign_desc"`
+	HeightHint       uint32 `json:"height_hint"`
+	BlocksToMaturity uint32 `json:"blocks_to_maturity"`
+	CLTVExpiry       uint32 `json:"cltv_expiry"`
+	ResolutionBlob   string `json:"resolution_blob"`
+}
+
+// txOutState is the JSON form of a tx output.
+type txOutState struct {
+	Value    int64  `json:"value"`
+	PkScript string `json:"pk_script"`
+}
+
+// txInfoState is the JSON form of an input.TxInfo.
+type txInfoState struct {
+	Fee    btcutil.Amount     `json:"fee"`
+	Weight lntypes.WeightUnit `json:"weight"`
+}
+
+// historyState is the JSON form of a BumpHistoryEntry.
+type historyState struct {
+	Height        int32                  `json:"height"`
+	Txid          string                 `json:"txid"`
+	FeeRate       chainfee.SatPerKWeight `json:"fee_rate"`
+	Fee           btcutil.Amount         `json:"fee"`
+	ReplaceReason ReplaceReason          `json:"replace_reason"`
+}
+
+// feeFunctionState is the JSON form of a fee function. Only the linear and
+// constant fee functions are serialized, other fee functions are initialized
+// again when imported.
+type feeFunctionState struct {
+	Kind           string                 `json:"kind"`
+	CurrentFeeRate chainfee.SatPerKWeight `json:"current_fee_rate"`
+
+	// The params of the linear fee function.
+	StartingFeeRate chainfee.SatPerKWeight `json:"starting_fee_rate"`
+	EndingFeeRate   chainfee.SatPerKWeight `json:"ending_fee_rate"`
+	Width           uint32                 `json:"width"`
+	Position        uint32                 `json:"position"`
+	DeltaFeeRate    mSatPerKWeight         `json:"delta_fee_rate"`
+	PrevFee         btcutil.Amount         `json:"prev_fee"`
+	TxWeight        lntypes.WeightUnit     `json:"tx_weight"`
+
+	// The conf target of the constant fee function.
+	ConfTarget uint32 `json:"conf_target"`
+}
+
+// ExportState serializes the records monitored by the publisher to JSON,
+// including their requests, current txns, fees and fee function positions,
+// which can be restored using ImportState. It's meant for migrations and
+// debugging, and the publisher should be paused to get a consistent snapshot.
+// An ErrUnsupportedState is returned if a request sweeps an input that cannot
+// be serialized, such as an input requiring a specific output.
+func (t *TxPublisher) ExportState() ([]byte, error) {
+	var (
+		state publisherState
+		err   error
+	)
+	t.records.Range(func(requestID uint64, r *monitorRecord) bool {
+		var rs *recordState
+		rs, err = encodeRecord(requestID, r)
+		if err != nil {
+			err = fmt.Errorf("requestID=%v: %w", requestID, err)
+			return false
+		}
+
+		state.Records = append(state.Records, *rs)
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(state)
+}
+
+// ImportState restores the records serialized by ExportState using their
+// original requestIDs, whose results can be received using Subscriber. The
+// fee functions are reconstructed from their serialized params, while the
+// confirmed txns are watched for reorgs again by re-registering their
+// confirmation notifications. The unconfirmed txns are checked for
+// confirmation in the next block as usual. Nothing is imported if any of the
+// records is invalid or already monitored.
+func (t *TxPublisher) ImportState(data []byte) error {
+	// Reject the import if the publisher has been halted or is shutting
+	// down.
+	if errPtr := t.haltErr.Load(); errPtr != nil {
+		return *errPtr
+	}
+	if t.stopped.Load() {
+		return ErrPublisherStopped
+	}
+
+	var state publisherState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("decode state: %w", err)
+	}
+
+	// Decode all the records first, so an invalid one won't leave us with
+	// a partial import.
+	records := make(map[uint64]*monitorRecord, len(state.Records))
+	for _, rs := range state.Records {
+		_, exists := t.records.Load(rs.RequestID)
+		if _, ok := records[rs.RequestID]; ok || exists {
+			return fmt.Errorf("%w: requestID=%v already exists",
+				ErrUnsupportedState, rs.RequestID)
+		}
+
+		r, err := t.decodeRecord(&rs)
+		if err != nil {
+			return fmt.Errorf("requestID=%v: %w", rs.RequestID, err)
+		}
+
+		if err := t.checkTrackedInputs(r.req.Inputs); err != nil {
+			return err
+		}
+
+		records[rs.RequestID] = r
+	}
+
+	// Take a slot for each of the records, which may wait for one to be
+	// freed, before restoring any of them, so running out of slots won't
+	// leave us with a partial import.
+	for i := 0; i < len(records); i++ {
+		if err := t.acquireInFlight(context.Background()); err != nil {
+			// Free the slots taken so far.
+			for j := 0; j < i; j++ {
+				t.releaseInFlight()
+			}
+
+			return err
+		}
+	}
+
+	for requestID, r := range records {
+		t.restoreRecord(requestID, r)
+	}
+
+	return nil
+}
+
+// restoreRecord stores the given imported record and its subscriber, and
+// watches its tx for reorgs if it's confirmed. Otherwise, the timeout of the
+// request, if any, is restarted from now.
+func (t *TxPublisher) restoreRecord(requestID uint64, r *monitorRecord) {
+	t.records.Store(requestID, r)
+	t.subscriberChans.Store(requestID, t.newSubscriber())
+
+	if r.req.IdempotencyKey != "" {
+		t.idempotencyKeys.Store(r.req.IdempotencyKey, requestID)
+	}
+
+	// Make sure the new requests won't reuse the imported requestID.
+	for {
+		counter := t.requestCounter.Load()
+		if counter >= requestID ||
+			t.requestCounter.CompareAndSwap(counter, requestID) {
+
+			break
+		}
+	}
+
+	r.log().Infof("Imported record, confirmed=%v", r.confirmed)
+
+	if !r.confirmed {
+		if r.req.Timeout > 0 {
+			done := make(chan struct{})
+			t.doneChans.Store(requestID, done)
+
+			t.wg.Add(1)
+			go t.watchTimeout(requestID, r.req.Timeout, done)
+		}
+
+		return
+	}
+
+	result := &BumpResult{
+		Event:         TxConfirmed,
+		Tx:            r.tx,
+		requestID:     requestID,
+		Fee:           r.fee,
+		FeeRate:       r.feeFunction.FeeRate(),
+		ConfHeight:    r.confHeight,
+		ConfBlockHash: r.confBlockHash,
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		t.watchReorg(r, requestID, result)
+	}()
+}
+
+// encodeRecord converts the given record into its JSON form.
+func encodeRecord(requestID uint64, r *monitorRecord) (*recordState, error) {
+	req, err := encodeRequest(r.req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &recordState{
+		RequestID:     requestID,
+		Request:       *req,
+		Fee:           r.fee,
+		HeightHint:    r.heightHint,
+		Confirmed:     r.confirmed,
+		ConfHeight:    r.confHeight,
+		ReplaceReason: r.replaceReason,
+	}
+
+	if r.confirmed {
+		rs.ConfBlockHash = r.confBlockHash.String()
+	}
+
+	for _, entry := range r.history {
+		rs.History = append(rs.History, historyState{
+			Height:        entry.Height,
+			Txid:          entry.Txid.String(),
+			FeeRate:       entry.FeeRate,
+			Fee:           entry.Fee,
+			ReplaceReason: entry.ReplaceReason,
+		})
+	}
+
+	if r.tx != nil {
+		rs.Tx, err = encodeTx(r.tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.feeFunction != nil {
+		rs.FeeFunction = encodeFeeFunction(r.feeFunction)
+	}
+
+	return rs, nil
+}
+
+// decodeRecord converts the given JSON form into a monitor record, and
+// reconstructs its fee function.
+func (t *TxPublisher) decodeRecord(rs *recordState) (*monitorRecord, error) {
+	req, err := decodeRequest(&rs.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &monitorRecord{
+		req:           req,
+		fee:           rs.Fee,
+		heightHint:    rs.HeightHint,
+		confirmed:     rs.Confirmed,
+		confHeight:    rs.ConfHeight,
+		replaceReason: rs.ReplaceReason,
+	}
+
+	if rs.ConfBlockHash != "" {
+		hash, err := chainhash.NewHashFromStr(rs.ConfBlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("decode conf block hash: %w",
+				err)
+		}
+		r.confBlockHash = *hash
+	}
+
+	for _, hs := range rs.History {
+		txid, err := chainhash.NewHashFromStr(hs.Txid)
+		if err != nil {
+			return nil, fmt.Errorf("decode history txid: %w", err)
+		}
+
+		r.history = append(r.history, BumpHistoryEntry{
+			Height:        hs.Height,
+			Txid:          *txid,
+			FeeRate:       hs.FeeRate,
+			Fee:           hs.Fee,
+			ReplaceReason: hs.ReplaceReason,
+		})
+	}
+
+	// A record without a tx is waiting for its initial broadcast, which
+	// will initialize its fee function.
+	if rs.Tx == "" {
+		if r.confirmed {
+			return nil, fmt.Errorf("%w: confirmed record has no tx",
+				ErrUnsupportedState)
+		}
+
+		r.logger = newRequestLogger(rs.RequestID, req.Label, nil)
+
+		return r, nil
+	}
+
+	r.tx, err = decodeTx(rs.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.outpointToTxIndex = make(map[wire.OutPoint]int, len(r.tx.TxIn))
+	for i, txIn := range r.tx.TxIn {
+		r.outpointToTxIndex[txIn.PreviousOutPoint] = i
+	}
+
+	r.feeFunction, err = t.decodeFeeFunction(rs.FeeFunction, req)
+	if err != nil {
+		return nil, fmt.Errorf("decode fee function: %w", err)
+	}
+
+	r.logger = newRequestLogger(rs.RequestID, req.Label, r.tx)
+
+	return r, nil
+}
+
+// encodeFeeFunction converts the given fee function into its JSON form. For a
+// fee function that cannot be serialized, only its current fee rate is kept.
+func encodeFeeFunction(f FeeFunction) *feeFunctionState {
+	switch f := f.(type) {
+	case *LinearFeeFunction:
+		return &feeFunctionState{
+			Kind:            feeFunctionLinear,
+			StartingFeeRate: f.startingFeeRate,
+			EndingFeeRate:   f.endingFeeRate,
+			CurrentFeeRate:  f.currentFeeRate,
+			Width:           f.width,
+			Position:        f.position,
+			DeltaFeeRate:    f.deltaFeeRate,
+			PrevFee:         f.prevFee,
+			TxWeight:        f.txWeight,
+		}
+
+	case *ConstantFeeFunction:
+		return &feeFunctionState{
+			Kind:           feeFunctionConstant,
+			CurrentFeeRate: f.feeRate,
+			ConfTarget:     f.confTarget,
+		}
+
+	default:
+		return &feeFunctionState{
+			CurrentFeeRate: f.FeeRate(),
+		}
+	}
+}
+
+// decodeFeeFunction reconstructs the fee function from its JSON form. A fee
+// function that wasn't serialized is initialized again for the request,
+// starting from its previous fee rate.
+func (t *TxPublisher) decodeFeeFunction(fs *feeFunctionState,
+	req *BumpRequest) (FeeFunction, error) {
+
+	if fs == nil {
+		return nil, fmt.Errorf("%w: missing fee function",
+			ErrUnsupportedState)
+	}
+
+	switch fs.Kind {
+	case feeFunctionLinear:
+		return &LinearFeeFunction{
+			startingFeeRate: fs.StartingFeeRate,
+			endingFeeRate:   fs.EndingFeeRate,
+			currentFeeRate:  fs.CurrentFeeRate,
+			width:           fs.Width,
+			position:        fs.Position,
+			deltaFeeRate:    fs.DeltaFeeRate,
+			estimator:       t.estimator(req),
+			prevFee:         fs.PrevFee,
+			txWeight:        fs.TxWeight,
+			curve:           t.cfg.AllocationCurve,
+		}, nil
+
+	case feeFunctionConstant:
+		return NewConstantFeeFunction(fs.CurrentFeeRate, fs.ConfTarget)
+
+	default:
+		if req.StartingFeeRate.IsNone() {
+			req.StartingFeeRate = fn.Some(fs.CurrentFeeRate)
+		}
+
+		return t.initializeFeeFunction(req)
+	}
+}
+
+// encodeRequest converts the given request into its JSON form.
+func encodeRequest(req *BumpRequest) (*requestState, error) {
+	rs := &requestState{
+		Budget:         req.Budget,
+		BudgetFraction: req.BudgetFraction,
+		DeadlineHeight: req.DeadlineHeight,
+		NumConfs:       req.NumConfs,
+		ConfTarget:     req.ConfTarget,
+		CLTVExpiry:     req.CLTVExpiry,
+		CLTVBuffer:     req.CLTVBuffer,
+		AltConfTarget:  req.AltConfTarget,
+		DeliveryAddress: hex.EncodeToString(
+			req.DeliveryAddress.DeliveryAddress,
+		),
+		MaxFeeRate:         req.MaxFeeRate,
+		MaxFeeRateVByte:    req.MaxFeeRateVByte,
+		MaxFeeAbsolute:     req.MaxFeeAbsolute,
+		MaxFeeFraction:     req.MaxFeeFractionOfValue,
+		ShrinkChangeForFee: req.ShrinkChangeForFee,
+		BypassFeeCache:     req.BypassFeeCache,
+		ConfPkScript:       hex.EncodeToString(req.ConfPkScript),
+		Label:              req.Label,
+		IdempotencyKey:     req.IdempotencyKey,
+		FixedFeeRate:       req.FixedFeeRate,
+		Immediate:          req.Immediate,
+		RejectReuse:        req.RejectChangeReuse,
+		NoBump:             req.NoBump,
+		EmitAccept:         req.EmitMempoolAccept,
+		CPFPFallback:       req.CPFPFallback,
+		Timeout:            req.Timeout,
+		LockTime:           req.LockTime,
+	}
+
+	for _, inp := range req.Inputs {
+		is, err := encodeInput(inp)
+		if err != nil {
+			return nil, err
+		}
+
+		rs.Inputs = append(rs.Inputs, *is)
+	}
+
+	if len(req.InputDeadlines) > 0 {
+		rs.InputDeadlines = make(map[string]int32)
+		for op, height := range req.InputDeadlines {
+			rs.InputDeadlines[op.String()] = height
+		}
+	}
+
+	for _, txOut := range req.ExtraOutputs {
+		rs.ExtraOutputs = append(rs.ExtraOutputs, encodeTxOut(txOut))
+	}
+
+	if len(req.InputOutputs) > 0 {
+		rs.InputOutputs = make(map[string]txOutState)
+		for op, txOut := range req.InputOutputs {
+			rs.InputOutputs[op.String()] = encodeTxOut(txOut)
+		}
+	}
+
+	req.StartingFeeRate.WhenSome(func(feeRate chainfee.SatPerKWeight) {
+		rs.StartingFeeRate = &feeRate
+	})
+
+	req.MempoolFeePercentile.WhenSome(func(percentile float64) {
+		rs.MempoolPercentile = &percentile
+	})
+
+	for _, parent := range req.Parents {
+		tx, err := encodeTx(parent)
+		if err != nil {
+			return nil, err
+		}
+
+		rs.Parents = append(rs.Parents, tx)
+	}
+
+	req.AnchorParent.WhenSome(func(info input.TxInfo) {
+		rs.AnchorParent = &txInfoState{
+			Fee:    info.Fee,
+			Weight: info.Weight,
+		}
+	})
+
+	return rs, nil
+}
+
+// decodeRequest converts the given JSON form into a request.
+func decodeRequest(rs *requestState) (*BumpRequest, error) {
+	deliveryAddr, err := hex.DecodeString(rs.DeliveryAddress)
+	if err != nil {
+		return nil, fmt.Errorf("decode delivery address: %w", err)
+	}
+
+	confPkScript, err := hex.DecodeString(rs.ConfPkScript)
+	if err != nil {
+		return nil, fmt.Errorf("decode conf pk script: %w", err)
+	}
+
+	req := &BumpRequest{
+		Budget:         rs.Budget,
+		BudgetFraction: rs.BudgetFraction,
+		DeadlineHeight: rs.DeadlineHeight,
+		NumConfs:       rs.NumConfs,
+		ConfTarget:     rs.ConfTarget,
+		CLTVExpiry:     rs.CLTVExpiry,
+		CLTVBuffer:     rs.CLTVBuffer,
+		AltConfTarget:  rs.AltConfTarget,
+		DeliveryAddress: lnwallet.AddrWithKey{
+			DeliveryAddress: deliveryAddr,
+		},
+		MaxFeeRate:            rs.MaxFeeRate,
+		MaxFeeRateVByte:       rs.MaxFeeRateVByte,
+		MaxFeeAbsolute:        rs.MaxFeeAbsolute,
+		MaxFeeFractionOfValue: rs.MaxFeeFraction,
+		ShrinkChangeForFee:    rs.ShrinkChangeForFee,
+		BypassFeeCache:        rs.BypassFeeCache,
+		Label:                 rs.Label,
+		IdempotencyKey:        rs.IdempotencyKey,
+		FixedFeeRate:          rs.FixedFeeRate,
+		Immediate:             rs.Immediate,
+		RejectChangeReuse:     rs.RejectReuse,
+		NoBump:                rs.NoBump,
+		EmitMempoolAccept:     rs.EmitAccept,
+		CPFPFallback:          rs.CPFPFallback,
+		Timeout:               rs.Timeout,
+		LockTime:              rs.LockTime,
+	}
+
+	if len(confPkScript) > 0 {
+		req.ConfPkScript = confPkScript
+	}
+
+	for _, is := range rs.Inputs {
+		inp, err := decodeInput(&is)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Inputs = append(req.Inputs, inp)
+	}
+
+	if len(rs.InputDeadlines) > 0 {
+		req.InputDeadlines = make(map[wire.OutPoint]int32)
+		for opStr, height := range rs.InputDeadlines {
+			op, err := wire.NewOutPointFromString(opStr)
+			if err != nil {
+				return nil, fmt.Errorf("decode outpoint: %w",
+					err)
+			}
+
+			req.InputDeadlines[*op] = height
+		}
+	}
+
+	for _, os := range rs.ExtraOutputs {
+		txOut, err := decodeTxOut(os)
+		if err != nil {
+			return nil, err
+		}
+
+		req.ExtraOutputs = append(req.ExtraOutputs, txOut)
+	}
+
+	if len(rs.InputOutputs) > 0 {
+		req.InputOutputs = make(map[wire.OutPoint]*wire.TxOut)
+		for opStr, os := range rs.InputOutputs {
+			op, err := wire.NewOutPointFromString(opStr)
+			if err != nil {
+				return nil, fmt.Errorf("decode outpoint: %w",
+					err)
+			}
+
+			req.InputOutputs[*op], err = decodeTxOut(os)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if rs.StartingFeeRate != nil {
+		req.StartingFeeRate = fn.Some(*rs.StartingFeeRate)
+	}
+
+	if rs.MempoolPercentile != nil {
+		req.MempoolFeePercentile = fn.Some(*rs.MempoolPercentile)
+	}
+
+	for _, parent := range rs.Parents {
+		tx, err := decodeTx(parent)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Parents = append(req.Parents, tx)
+	}
+
+	if rs.AnchorParent != nil {
+		req.AnchorParent = fn.Some(input.TxInfo{
+			Fee:    rs.AnchorParent.Fee,
+			Weight: rs.AnchorParent.Weight,
+		})
+	}
+
+	return req, nil
+}
+
+// encodeInput converts the given input into its JSON form. Only the inputs
+// using a standard witness type, which don't require a specific output or a
+// preimage, can be serialized.
+func encodeInput(inp input.Input) (*inputState, error) {
+	op := inp.OutPoint()
+
+	witnessType, ok := inp.WitnessType().(input.StandardWitnessType)
+	if !ok {
+		return nil, fmt.Errorf("%w: input %v has non-standard "+
+			"witness type %v", ErrUnsupportedState, op,
+			inp.WitnessType())
+	}
+
+	if inp.RequiredTxOut() != nil || inp.Preimage().IsSome() {
+		return nil, fmt.Errorf("%w: input %v requires a specific "+
+			"output or a preimage", ErrUnsupportedState, op)
+	}
+
+	var b bytes.Buffer
+	if err := input.WriteSignDescriptor(&b, inp.SignDesc()); err != nil {
+		return nil, fmt.Errorf("encode sign desc of %v: %w", op, err)
+	}
+
+	cltvExpiry, _ := inp.RequiredLockTime()
+
+	return &inputState{
+		OutPoint:         op.String(),
+		WitnessType:      uint16(witnessType),
+		SignDesc:         hex.EncodeToString(b.Bytes()),
+		HeightHint:       inp.HeightHint(),
+		BlocksToMaturity: inp.BlocksToMaturity(),
+		CLTVExpiry:       cltvExpiry,
+		ResolutionBlob: fn.MapOptionZ(
+			inp.ResolutionBlob(), func(b tlv.Blob) string {
+				return hex.EncodeToString(b)
+			},
+		),
+	}, nil
+}
+
+// decodeInput converts the given JSON form into a BaseInput.
+func decodeInput(is *inputState) (input.Input, error) {
+	op, err := wire.NewOutPointFromString(is.OutPoint)
+	if err != nil {
+		return nil, fmt.Errorf("decode outpoint: %w", err)
+	}
+
+	signDescBytes, err := hex.DecodeString(is.SignDesc)
+	if err != nil {
+		return nil, fmt.Errorf("decode sign desc of %v: %w", op, err)
+	}
+
+	var signDesc input.SignDescriptor
+	err = input.ReadSignDescriptor(
+		bytes.NewReader(signDescBytes), &signDesc,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("decode sign desc of %v: %w", op, err)
+	}
+
+	var opts []input.InputOpt
+	if is.ResolutionBlob != "" {
+		blob, err := hex.DecodeString(is.ResolutionBlob)
+		if err != nil {
+			return nil, fmt.Errorf("decode resolution blob of "+
+				"%v: %w", op, err)
+		}
+
+		opts = append(opts, input.WithResolutionBlob(fn.Some(blob)))
+	}
+
+	return input.NewCsvInputWithCltv(
+		op, input.StandardWitnessType(is.WitnessType), &signDesc,
+		is.HeightHint, is.BlocksToMaturity, is.CLTVExpiry, opts...,
+	), nil
+}
+
+// encodeTxOut converts the given tx output into its JSON form.
+func encodeTxOut(txOut *wire.TxOut) txOutState {
+	return txOutState{
+		Value:    txOut.Value,
+		PkScript: hex.EncodeToString(txOut.PkScript),
+	}
+}
+
+// decodeTxOut converts the given JSON form into a tx output.
+func decodeTxOut(os txOutState) (*wire.TxOut, error) {
+	pkScript, err := hex.DecodeString(os.PkScript)
+	if err != nil {
+		return nil, fmt.Errorf("decode pk script: %w", err)
+	}
+
+	return wire.NewTxOut(os.Value, pkScript), nil
+}
+
+// encodeTx serializes the given tx into a hex string.
+func encodeTx(tx *wire.MsgTx) (string, error) {
+	var b bytes.Buffer
+	if err := tx.Serialize(&b); err != nil {
+		return "", fmt.Errorf("encode tx %v: %w", tx.TxHash(), err)
+	}
+
+	return hex.EncodeToString(b.Bytes()), nil
+}
+
+// decodeTx deserializes a tx from the given hex string.
+func decodeTx(txHex string) (*wire.MsgTx, error) {
+	txBytes, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("decode tx: %w", err)
+	}
+
+	tx := &wire.MsgTx{}
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("decode tx: %w", err)
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,222 @@
+package sweep
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// createStateTestTx creates a tx spending the given inputs to the change
+// script.
+func createStateTestTx(inputs ...input.Input) (*wire.MsgTx,
+	map[wire.OutPoint]int) {
+
+	tx := wire.NewMsgTx(2)
+	outpointToTxIndex := make(map[wire.OutPoint]int)
+	for i, inp := range inputs {
+		op := inp.OutPoint()
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		outpointToTxIndex[op] = i
+	}
+	tx.AddTxOut(wire.NewTxOut(90_000, changePkScript.DeliveryAddress))
+
+	return tx, outpointToTxIndex
+}
+
+// TestExportImportState checks the records exported from a publisher are
+// restored when imported into a fresh publisher, along with their
+// subscriptions and fee functions.
+func TestExportImportState(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := createTestPublisher(t)
+
+	// Create an unconfirmed record using a linear fee function, which is
+	// at position 2.
+	inp1 := createTestInput(100_000, input.WitnessKeyHash)
+	req1 := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp1},
+		Budget:          10_000,
+		DeadlineHeight:  120,
+		MaxFeeRate:      9000,
+		Label:           "record-1",
+		IdempotencyKey:  "key-1",
+		InputDeadlines: map[wire.OutPoint]int32{
+			inp1.OutPoint(): 110,
+		},
+	}
+	f1 := &LinearFeeFunction{
+		startingFeeRate: 1000,
+		endingFeeRate:   9000,
+		currentFeeRate:  3000,
+		position:        2,
+		deltaFeeRate:    1_000_000,
+		width:           8,
+		prevFee:         500,
+		txWeight:        800,
+	}
+	tx1, outpoints1 := createStateTestTx(&inp1)
+	record1 := tp.storeRecord(1, tx1, req1, f1, 1000, outpoints1)
+	record1.history = []BumpHistoryEntry{{
+		Height:  98,
+		Txid:    chainhash.Hash{2},
+		FeeRate: 2000,
+		Fee:     800,
+	}, {
+		Height:        99,
+		Txid:          tx1.TxHash(),
+		FeeRate:       3000,
+		Fee:           1000,
+		ReplaceReason: ReplaceReasonDeadline,
+	}}
+
+	// Create a confirmed record using a constant fee function.
+	inp2 := createTestInput(200_000, input.CommitmentTimeLock)
+	req2 := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp2},
+		Budget:          20_000,
+		DeadlineHeight:  130,
+		FixedFeeRate:    2000,
+		Label:           "record-2",
+	}
+	f2, err := NewConstantFeeFunction(2000, 10)
+	require.NoError(t, err)
+
+	tx2, outpoints2 := createStateTestTx(&inp2)
+	record2 := tp.storeRecord(2, tx2, req2, f2, 2000, outpoints2)
+	record2.confirmed = true
+	record2.confHeight = 100
+	record2.confBlockHash = chainhash.Hash{1}
+
+	data, err := tp.ExportState()
+	require.NoError(t, err)
+
+	// Import the state into a fresh publisher. The confirmed tx should be
+	// watched for reorgs again.
+	tp2, m2 := createTestPublisher(t)
+
+	txid2 := tx2.TxHash()
+	confEvent := chainntnfs.NewConfirmationEvent(1, func() {})
+	m2.notifier.On("RegisterConfirmationsNtfn", &txid2, mock.Anything,
+		uint32(1), mock.Anything).Return(confEvent, nil).Once()
+
+	require.NoError(t, tp2.ImportState(data))
+
+	// The unconfirmed record should be restored with its fee function at
+	// the same position.
+	r1, ok := tp2.records.Load(1)
+	require.True(t, ok)
+	require.Equal(t, tx1.TxHash(), r1.tx.TxHash())
+	require.EqualValues(t, 1000, r1.fee)
+	require.Equal(t, outpoints1, r1.outpointToTxIndex)
+	require.False(t, r1.confirmed)
+	require.Equal(t, record1.history, r1.history)
+
+	require.Equal(t, req1.Budget, r1.req.Budget)
+	require.Equal(t, req1.DeadlineHeight, r1.req.DeadlineHeight)
+	require.Equal(t, req1.MaxFeeRate, r1.req.MaxFeeRate)
+	require.Equal(t, req1.Label, r1.req.Label)
+	require.Equal(t, req1.InputDeadlines, r1.req.InputDeadlines)
+	require.Equal(t, changePkScript.DeliveryAddress,
+		r1.req.DeliveryAddress.DeliveryAddress)
+
+	require.Len(t, r1.req.Inputs, 1)
+	restored := r1.req.Inputs[0]
+	require.Equal(t, inp1.OutPoint(), restored.OutPoint())
+	require.Equal(t, inp1.WitnessType(), restored.WitnessType())
+	require.Equal(t, inp1.SignDesc().Output.Value,
+		restored.SignDesc().Output.Value)
+	require.True(t, inp1.SignDesc().KeyDesc.PubKey.IsEqual(
+		restored.SignDesc().KeyDesc.PubKey,
+	))
+
+	restoredF1, ok := r1.feeFunction.(*LinearFeeFunction)
+	require.True(t, ok)
+	require.Equal(t, f1.FeeRate(), restoredF1.FeeRate())
+	require.Equal(t, f1.position, restoredF1.position)
+	require.Equal(t, f1.width, restoredF1.width)
+	require.Equal(t, f1.deltaFeeRate, restoredF1.deltaFeeRate)
+	require.Equal(t, f1.prevFee, restoredF1.prevFee)
+	require.Equal(t, f1.txWeight, restoredF1.txWeight)
+
+	// The confirmed record should be restored too.
+	r2, ok := tp2.records.Load(2)
+	require.True(t, ok)
+	require.Equal(t, txid2, r2.tx.TxHash())
+	require.True(t, r2.confirmed)
+	require.EqualValues(t, 100, r2.confHeight)
+	require.Equal(t, chainhash.Hash{1}, r2.confBlockHash)
+	require.Equal(t, chainfee.SatPerKWeight(2000), r2.feeFunction.FeeRate())
+	require.IsType(t, &ConstantFeeFunction{}, r2.feeFunction)
+
+	// The subscriptions and the idempotency key should be restored, and
+	// new requests shouldn't reuse the imported requestIDs.
+	_, ok = tp2.Subscriber(1)
+	require.True(t, ok)
+	_, ok = tp2.Subscriber(2)
+	require.True(t, ok)
+
+	requestID, ok := tp2.RequestIDByKey("key-1")
+	require.True(t, ok)
+	require.EqualValues(t, 1, requestID)
+	require.EqualValues(t, 2, tp2.requestCounter.Load())
+
+	// Importing the same state again should fail.
+	require.ErrorIs(t, tp2.ImportState(data), ErrUnsupportedState)
+
+	// Once the confirmed tx is buried deep enough, its record should be
+	// removed.
+	confEvent.Done <- struct{}{}
+	require.Eventually(t, func() bool {
+		_, ok := tp2.records.Load(2)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestImportStateNoSlots checks nothing is imported when there aren't enough
+// in-flight slots for all the records, and the slots taken are freed.
+func TestImportStateNoSlots(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := createTestPublisher(t)
+
+	// Create two unconfirmed records to be exported.
+	for i := uint64(1); i <= 2; i++ {
+		inp := createTestInput(100_000, input.WitnessKeyHash)
+		req := &BumpRequest{
+			DeliveryAddress: changePkScript,
+			Inputs:          []input.Input{&inp},
+			Budget:          10_000,
+			DeadlineHeight:  120,
+			FixedFeeRate:    2000,
+		}
+		f, err := NewConstantFeeFunction(2000, 10)
+		require.NoError(t, err)
+
+		tx, outpoints := createStateTestTx(&inp)
+		tp.storeRecord(i, tx, req, f, 1000, outpoints)
+	}
+
+	data, err := tp.ExportState()
+	require.NoError(t, err)
+
+	// Import the state into a fresh publisher which only has one slot.
+	tp2, _ := createTestPublisher(t)
+	tp2.cfg.MaxInFlight = 1
+	tp2.inFlight = make(chan struct{}, tp2.cfg.MaxInFlight)
+
+	require.ErrorIs(t, tp2.ImportState(data), ErrTooManyInFlight)
+
+	// No record should be imported, and the slot should be freed.
+	require.Zero(t, tp2.records.Len())
+	require.Empty(t, tp2.inFlight)
+}
@@ -1,23 +1,37 @@
 package sweep
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btclog/v2"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/lightningnetwork/lnd/chainio"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/fn/v2"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -104,6 +118,38 @@ func TestBumpResultValidate(t *testing.T) {
 	}
 	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
 
+	// A confirmed event without the conf height will give an error.
+	b = BumpResult{
+		Tx:      &wire.MsgTx{},
+		Event:   TxConfirmed,
+		Fee:     1000,
+		FeeRate: chainfee.FeePerKwFloor,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	b.ConfHeight = 100
+	require.NoError(t, b.Validate())
+
+	// A fee bumped event without a tx will give an error.
+	b = BumpResult{
+		Event:   TxFeeBumped,
+		FeeRate: chainfee.FeePerKwFloor,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	// A fee bumped event without a fee rate will give an error.
+	b = BumpResult{
+		Tx:    &wire.MsgTx{},
+		Event: TxFeeBumped,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	// A budget exhausted event without a tx will give an error.
+	b = BumpResult{
+		Event: TxBudgetExhausted,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
 	// Test a valid result.
 	b = BumpResult{
 		Tx:    &wire.MsgTx{},
@@ -111,6 +157,72 @@ func TestBumpResultValidate(t *testing.T) {
 	}
 	require.NoError(t, b.Validate())
 
+	// Test a valid budget exhausted result.
+	b = BumpResult{
+		Tx:    &wire.MsgTx{},
+		Event: TxBudgetExhausted,
+	}
+	require.NoError(t, b.Validate())
+
+	// Test a valid fee bumped result.
+	b = BumpResult{
+		Tx:      &wire.MsgTx{},
+		Event:   TxFeeBumped,
+		FeeRate: chainfee.FeePerKwFloor,
+	}
+	require.NoError(t, b.Validate())
+
+	// A mempool accepted event without a fee rate will give an error.
+	b = BumpResult{
+		Tx:    &wire.MsgTx{},
+		Event: TxMempoolAccepted,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	b.FeeRate = chainfee.FeePerKwFloor
+	require.NoError(t, b.Validate())
+
+	// A cancelled event without an error will give an error.
+	b = BumpResult{
+		Event: TxCancelled,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	// Tx is allowed to be nil in a TxCancelled event.
+	b = BumpResult{
+		Event: TxCancelled,
+		Err:   context.Canceled,
+	}
+	require.NoError(t, b.Validate())
+
+	// An input spent event without the spent input will give an error.
+	b = BumpResult{
+		Tx:    &wire.MsgTx{},
+		Event: TxInputSpent,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	// A lost RBF race event without the spent input will give an error.
+	b = BumpResult{
+		Tx:    &wire.MsgTx{},
+		Event: TxLostRBFRace,
+		Err:   ErrLostRBFRace,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	// A timed out event without an error will give an error.
+	b = BumpResult{
+		Event: TxTimedOut,
+	}
+	require.ErrorIs(t, b.Validate(), ErrInvalidBumpResult)
+
+	// Tx is allowed to be nil in a TxTimedOut event.
+	b = BumpResult{
+		Event: TxTimedOut,
+		Err:   ErrTimedOut,
+	}
+	require.NoError(t, b.Validate())
+
 	// Tx is allowed to be nil in a TxFailed event.
 	b = BumpResult{
 		Event: TxFailed,
@@ -137,6 +249,7 @@ func TestCalcSweepTxWeight(t *testing.T) {
 	// Use a wrong change script to test the error case.
 	weight, err := calcSweepTxWeight(
 		[]input.Input{&inp}, [][]byte{{0x00}},
+		nil,
 	)
 	require.Error(t, err)
 	require.Zero(t, weight)
@@ -144,6 +257,7 @@ func TestCalcSweepTxWeight(t *testing.T) {
 	// Use a correct change script to test the success case.
 	weight, err = calcSweepTxWeight(
 		[]input.Input{&inp}, [][]byte{changePkScript.DeliveryAddress},
+		nil,
 	)
 	require.NoError(t, err)
 
@@ -155,6 +269,97 @@ func TestCalcSweepTxWeight(t *testing.T) {
 	require.EqualValuesf(t, 487, weight, "unexpected weight %v", weight)
 }
 
+// TestCalcSweepTxWeightScriptTypes checks the size of the change output is
+// derived from the script type of the delivery address.
+func TestCalcSweepTxWeightScriptTypes(t *testing.T) {
+	t.Parallel()
+
+	// Create an input.
+	inp := createTestInput(100, input.WitnessKeyHash)
+
+	// newScript builds a script using the given opcodes and data pushes.
+	newScript := func(build func(*txscript.ScriptBuilder)) []byte {
+		b := txscript.NewScriptBuilder()
+		build(b)
+		script, err := b.Script()
+		require.NoError(t, err)
+
+		return script
+	}
+
+	hash20 := make([]byte, 20)
+	hash32 := make([]byte, 32)
+
+	testCases := []struct {
+		name       string
+		pkScript   []byte
+		outputSize lntypes.VByte
+	}{
+		{
+			name: "p2wkh",
+			pkScript: newScript(func(b *txscript.ScriptBuilder) {
+				b.AddOp(txscript.OP_0).AddData(hash20)
+			}),
+			outputSize: input.P2WKHOutputSize,
+		},
+		{
+			name: "p2wsh",
+			pkScript: newScript(func(b *txscript.ScriptBuilder) {
+				b.AddOp(txscript.OP_0).AddData(hash32)
+			}),
+			outputSize: input.P2WSHOutputSize,
+		},
+		{
+			name:       "p2tr",
+			pkScript:   changePkScript.DeliveryAddress,
+			outputSize: input.P2TROutputSize,
+		},
+		{
+			name: "p2sh",
+			pkScript: newScript(func(b *txscript.ScriptBuilder) {
+				b.AddOp(txscript.OP_HASH160).AddData(hash20).
+					AddOp(txscript.OP_EQUAL)
+			}),
+			outputSize: input.P2SHOutputSize,
+		},
+		{
+			name: "p2pkh",
+			pkScript: newScript(func(b *txscript.ScriptBuilder) {
+				b.AddOp(txscript.OP_DUP).
+					AddOp(txscript.OP_HASH160).
+					AddData(hash20).
+					AddOp(txscript.OP_EQUALVERIFY).
+					AddOp(txscript.OP_CHECKSIG)
+			}),
+			outputSize: input.P2PKHOutputSize,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			weight, err := calcSweepTxWeight(
+				[]input.Input{&inp}, [][]byte{tc.pkScript},
+				nil,
+			)
+			require.NoError(t, err)
+
+			// BaseTxSize 8 bytes
+			// InputSize 1+41 bytes
+			// One output of 1+outputSize bytes
+			// One P2WKHWitnessSize 2+109 bytes
+			expected := (8+42+1+tc.outputSize)*4 + 111
+			require.EqualValues(t, expected, weight)
+		})
+	}
+
+	// An unknown script type should be rejected.
+	_, err := calcSweepTxWeight(
+		[]input.Input{&inp}, [][]byte{{txscript.OP_RETURN}},
+		nil,
+	)
+	require.ErrorIs(t, err, ErrUnknownScriptType)
+}
+
 // TestBumpRequestMaxFeeRateAllowed tests the max fee rate allowed for a bump
 // request.
 func TestBumpRequestMaxFeeRateAllowed(t *testing.T) {
@@ -166,6 +371,7 @@ func TestBumpRequestMaxFeeRateAllowed(t *testing.T) {
 	// The weight is 487.
 	weight, err := calcSweepTxWeight(
 		[]input.Input{&inp}, [][]byte{changePkScript.DeliveryAddress},
+		nil,
 	)
 	require.NoError(t, err)
 
@@ -216,6 +422,43 @@ func TestBumpRequestMaxFeeRateAllowed(t *testing.T) {
 			},
 			expectedMaxFeeRate: budgetFeeRate - 1,
 		},
+		{
+			// When the max fee rate is given in sat/vbyte, it's
+			// converted and used as the cap.
+			name: "use vbyte max fee rate",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          budget,
+				MaxFeeRateVByte: 2,
+			},
+			expectedMaxFeeRate: 500,
+		},
+		{
+			// Setting both fields to the same value is allowed.
+			name: "matching vbyte and kw max fee rates",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          budget,
+				MaxFeeRate:      500,
+				MaxFeeRateVByte: 2,
+			},
+			expectedMaxFeeRate: 500,
+		},
+		{
+			// Setting both fields to different values is an
+			// error.
+			name: "conflicting vbyte and kw max fee rates",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          budget,
+				MaxFeeRate:      501,
+				MaxFeeRateVByte: 2,
+			},
+			expectedErr: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -241,6 +484,96 @@ func TestBumpRequestMaxFeeRateAllowed(t *testing.T) {
 	}
 }
 
+// TestBumpRequestMaxFeeRateSanityCap checks the budget fee rate is clamped to
+// the sanity cap when a tiny weight gives an implausibly high fee rate.
+func TestBumpRequestMaxFeeRateSanityCap(t *testing.T) {
+	t.Parallel()
+
+	// Create a request whose budget is huge compared to its weight, with
+	// no meaningful MaxFeeRate.
+	inp := createTestInput(10_000_000_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000_000_000),
+		MaxFeeRate:      math.MaxInt64,
+	}
+
+	// The default sanity cap is used by the exported method.
+	maxFeeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+	require.Equal(t, DefaultFeeRateSanityCap, maxFeeRate)
+
+	// A custom sanity cap is used when given.
+	sanityCap := chainfee.SatPerKWeight(10_000)
+	maxFeeRate, err = req.maxFeeRateAllowed(sanityCap)
+	require.NoError(t, err)
+	require.Equal(t, sanityCap, maxFeeRate)
+
+	// The MaxFeeRate still applies when it's lower than the cap.
+	req.MaxFeeRate = sanityCap - 1
+	maxFeeRate, err = req.maxFeeRateAllowed(sanityCap)
+	require.NoError(t, err)
+	require.Equal(t, sanityCap-1, maxFeeRate)
+
+	// The publisher uses the configured cap, or the default if not set.
+	tp, _ := createTestPublisher(t)
+	require.Equal(t, DefaultFeeRateSanityCap, tp.feeRateSanityCap())
+
+	tp.cfg.FeeRateSanityCap = sanityCap
+	require.Equal(t, sanityCap, tp.feeRateSanityCap())
+}
+
+// TestBumpRequestMaxFeeAbsolute checks the absolute fee cap is applied when
+// calculating the max fee rate allowed, and the smaller of the budget and the
+// cap is used.
+func TestBumpRequestMaxFeeAbsolute(t *testing.T) {
+	t.Parallel()
+
+	// Create a large sweep with many inputs.
+	const numInputs = 50
+	inputs := make([]input.Input, 0, numInputs)
+	for i := 0; i < numInputs; i++ {
+		inp := createTestInput(100_000, input.WitnessKeyHash)
+		inputs = append(inputs, &inp)
+	}
+
+	weight, err := calcSweepTxWeight(
+		inputs, [][]byte{changePkScript.DeliveryAddress},
+		nil,
+	)
+	require.NoError(t, err)
+
+	// Create a request whose fee rate cap gives a fee much larger than the
+	// absolute fee cap.
+	maxFeeRate := chainfee.SatPerKWeight(50_000)
+	maxFeeAbsolute := btcutil.Amount(5_000)
+	require.Greater(t, maxFeeRate.FeeForWeight(weight), maxFeeAbsolute)
+
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          inputs,
+		Budget:          1_000_000,
+		MaxFeeRate:      maxFeeRate,
+		MaxFeeAbsolute:  maxFeeAbsolute,
+	}
+
+	// The absolute cap binds before the rate cap.
+	feeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+	require.Equal(t, chainfee.SatPerKWeight(
+		maxFeeAbsolute*1000/btcutil.Amount(weight),
+	), feeRate)
+	require.LessOrEqual(t, feeRate.FeeForWeight(weight), maxFeeAbsolute)
+
+	// When the budget is below the absolute cap, the budget is used.
+	req.Budget = maxFeeAbsolute / 2
+	feeRate, err = req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+	require.Equal(t, chainfee.NewSatPerKWeight(req.Budget, weight),
+		feeRate)
+}
+
 // TestCalcCurrentConfTarget checks that the current confirmation target is
 // calculated correctly.
 func TestCalcCurrentConfTarget(t *testing.T) {
@@ -257,6 +590,90 @@ func TestCalcCurrentConfTarget(t *testing.T) {
 	require.EqualValues(t, 0, conf)
 }
 
+// TestDeadlineFromCLTV checks the deadline derived from a CLTV expiry leaves
+// the safety buffer, and the conf target reaches zero at the buffer edge.
+func TestDeadlineFromCLTV(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		cltvExpiry       int32
+		currentHeight    int32
+		buffer           int32
+		expectedDeadline int32
+	}{
+		{
+			name:             "buffer subtracted",
+			cltvExpiry:       200,
+			currentHeight:    100,
+			buffer:           20,
+			expectedDeadline: 180,
+		},
+		{
+			name:             "no buffer",
+			cltvExpiry:       200,
+			currentHeight:    100,
+			expectedDeadline: 200,
+		},
+		{
+			name:             "negative buffer ignored",
+			cltvExpiry:       200,
+			currentHeight:    100,
+			buffer:           -5,
+			expectedDeadline: 200,
+		},
+		{
+			name:             "inside buffer",
+			cltvExpiry:       200,
+			currentHeight:    190,
+			buffer:           20,
+			expectedDeadline: 190,
+		},
+		{
+			name:             "expired",
+			cltvExpiry:       200,
+			currentHeight:    210,
+			buffer:           20,
+			expectedDeadline: 210,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deadline := DeadlineFromCLTV(
+				tc.cltvExpiry, tc.currentHeight, tc.buffer,
+			)
+			require.Equal(t, tc.expectedDeadline, deadline)
+		})
+	}
+
+	// The conf target counts down to the buffer edge, and stays at zero
+	// once inside the buffer.
+	deadline := DeadlineFromCLTV(200, 100, 20)
+	require.EqualValues(t, 80, calcCurrentConfTarget(100, deadline))
+	require.EqualValues(t, 1, calcCurrentConfTarget(179, deadline))
+	require.EqualValues(t, 0, calcCurrentConfTarget(180, deadline))
+	require.EqualValues(t, 0, calcCurrentConfTarget(190, deadline))
+
+	// The fee function should reach its max fee rate right before the
+	// buffer edge.
+	estimator := &chainfee.MockEstimator{}
+	maxFeeRate := chainfee.SatPerKWeight(10_000)
+	f, err := NewLinearFeeFunction(
+		maxFeeRate, calcCurrentConfTarget(100, deadline), estimator,
+		fn.Some(chainfee.SatPerKWeight(1000)),
+	)
+	require.NoError(t, err)
+
+	_, err = f.IncreaseFeeRate(calcCurrentConfTarget(178, deadline), 0, 0)
+	require.NoError(t, err)
+	require.Less(t, f.FeeRate(), maxFeeRate)
+
+	_, err = f.IncreaseFeeRate(calcCurrentConfTarget(179, deadline), 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, maxFeeRate, f.FeeRate())
+}
+
 // TestInitializeFeeFunction tests the initialization of the fee function.
 func TestInitializeFeeFunction(t *testing.T) {
 	t.Parallel()
@@ -313,379 +730,819 @@ func TestInitializeFeeFunction(t *testing.T) {
 	require.Equal(t, feerate, f.FeeRate())
 }
 
-// TestStoreRecord correctly increases the request counter and saves the
-// record.
-func TestStoreRecord(t *testing.T) {
+// TestBumpRequestResolveDeadline checks the conf target is translated into a
+// deadline height correctly.
+func TestBumpRequestResolveDeadline(t *testing.T) {
 	t.Parallel()
 
-	// Create a test input.
-	inp := createTestInput(1000, input.WitnessKeyHash)
+	const currentHeight = int32(100)
 
-	// Create a bump request.
-	req := &BumpRequest{
-		DeliveryAddress: changePkScript,
-		Inputs:          []input.Input{&inp},
-		Budget:          btcutil.Amount(1000),
+	testCases := []struct {
+		name             string
+		confTarget       uint32
+		deadline         int32
+		cltvExpiry       int32
+		cltvBuffer       int32
+		expectedDeadline int32
+		expectedErr      error
+	}{
+		{
+			name:             "conf target only",
+			confTarget:       6,
+			expectedDeadline: currentHeight + 6,
+		},
+		{
+			name:             "deadline height only",
+			deadline:         110,
+			expectedDeadline: 110,
+		},
+		{
+			name:             "both matching",
+			confTarget:       10,
+			deadline:         110,
+			expectedDeadline: 110,
+		},
+		{
+			name:             "both conflicting",
+			confTarget:       6,
+			deadline:         110,
+			expectedDeadline: 110,
+			expectedErr:      ErrConflictingDeadline,
+		},
+		{
+			name:             "cltv expiry only",
+			cltvExpiry:       140,
+			cltvBuffer:       10,
+			expectedDeadline: 130,
+		},
+		{
+			name:             "cltv expiry caps deadline",
+			deadline:         140,
+			cltvExpiry:       140,
+			cltvBuffer:       10,
+			expectedDeadline: 130,
+		},
+		{
+			name:             "earlier deadline kept",
+			confTarget:       6,
+			cltvExpiry:       140,
+			cltvBuffer:       10,
+			expectedDeadline: currentHeight + 6,
+		},
 	}
 
-	// Create a naive fee function.
-	feeFunc := &LinearFeeFunction{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &BumpRequest{
+				ConfTarget:     tc.confTarget,
+				DeadlineHeight: tc.deadline,
+				CLTVExpiry:     tc.cltvExpiry,
+				CLTVBuffer:     tc.cltvBuffer,
+			}
 
-	// Create a test fee and tx.
-	fee := btcutil.Amount(1000)
-	tx := &wire.MsgTx{}
+			err := req.resolveDeadline(currentHeight)
+			require.ErrorIs(t, err, tc.expectedErr)
+			require.Equal(t, tc.expectedDeadline,
+				req.DeadlineHeight)
+		})
+	}
+}
 
-	// Create a publisher using the mocks.
-	tp := NewTxPublisher(TxPublisherConfig{
-		AuxSweeper: fn.Some[AuxSweeper](&MockAuxSweeper{}),
-	})
+// TestBumpRequestResolveBudget checks the budget is derived from the budget
+// fraction and the total value of the inputs.
+func TestBumpRequestResolveBudget(t *testing.T) {
+	t.Parallel()
 
-	// Get the current counter and check it's increased later.
-	initialCounter := tp.requestCounter.Load()
+	// The inputs are worth 300,000 sats in total.
+	inp1 := createTestInput(100_000, input.WitnessKeyHash)
+	inp2 := createTestInput(200_000, input.WitnessKeyHash)
 
-	op := wire.OutPoint{
-		Hash:  chainhash.Hash{1},
-		Index: 0,
-	}
-	utxoIndex := map[wire.OutPoint]int{
-		op: 0,
+	testCases := []struct {
+		name           string
+		budget         btcutil.Amount
+		fraction       float64
+		expectedBudget btcutil.Amount
+		expectedErr    error
+	}{
+		{
+			name:           "budget only",
+			budget:         1000,
+			expectedBudget: 1000,
+		},
+		{
+			name:           "fraction only",
+			fraction:       0.05,
+			expectedBudget: 15_000,
+		},
+		{
+			name:           "both matching",
+			budget:         15_000,
+			fraction:       0.05,
+			expectedBudget: 15_000,
+		},
+		{
+			name:           "both conflicting",
+			budget:         1000,
+			fraction:       0.05,
+			expectedBudget: 1000,
+			expectedErr:    ErrConflictingBudget,
+		},
+		{
+			name:        "fraction of one",
+			fraction:    1,
+			expectedErr: ErrInvalidBudgetFraction,
+		},
+		{
+			name:        "negative fraction",
+			fraction:    -0.1,
+			expectedErr: ErrInvalidBudgetFraction,
+		},
 	}
 
-	// Call the method under test.
-	tp.storeRecord(initialCounter, tx, req, feeFunc, fee, utxoIndex)
-
-	// Read the saved record and compare.
-	record, ok := tp.records.Load(initialCounter)
-	require.True(t, ok)
-	require.Equal(t, tx, record.tx)
-	require.Equal(t, feeFunc, record.feeFunction)
-	require.Equal(t, fee, record.fee)
-	require.Equal(t, req, record.req)
-	require.Equal(t, utxoIndex, record.outpointToTxIndex)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &BumpRequest{
+				Budget:         tc.budget,
+				BudgetFraction: tc.fraction,
+				Inputs:         []input.Input{&inp1, &inp2},
+			}
+
+			err := req.resolveBudget()
+			require.ErrorIs(t, err, tc.expectedErr)
+			require.Equal(t, tc.expectedBudget, req.Budget)
+		})
+	}
 }
 
-// mockers wraps a list of mocked interfaces used inside tx publisher.
-type mockers struct {
-	signer    *input.MockInputSigner
-	wallet    *MockWallet
-	estimator *chainfee.MockEstimator
-	notifier  *chainntnfs.MockChainNotifier
+// TestBroadcastBudgetFraction checks Broadcast rejects a request with an
+// invalid budget fraction.
+func TestBroadcastBudgetFraction(t *testing.T) {
+	t.Parallel()
 
-	feeFunc *MockFeeFunction
+	tp, _ := createTestPublisher(t)
+
+	req := createTestBumpRequest()
+	req.Budget = 0
+	req.BudgetFraction = 1.5
+
+	result := <-tp.Broadcast(req)
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, ErrInvalidBudgetFraction)
 }
 
-// createTestPublisher creates a new tx publisher using the provided mockers.
-func createTestPublisher(t *testing.T) (*TxPublisher, *mockers) {
-	// Create a mock fee estimator.
-	estimator := &chainfee.MockEstimator{}
+// TestMinBudgetUtilizationByDeadline checks a fee function whose schedule ends
+// below the min budget utilization is shaped to reach it by the deadline,
+// without exceeding the max fee rate allowed.
+func TestMinBudgetUtilizationByDeadline(t *testing.T) {
+	t.Parallel()
 
-	// Create a mock fee function.
-	feeFunc := &MockFeeFunction{}
+	tp, m := createTestPublisher(t)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
 
-	// Create a mock signer.
-	signer := &input.MockInputSigner{}
+	// Use a factory creating fee functions which only use half of the max
+	// fee rate allowed.
+	tp.cfg.FeeFunctionFactory = func(p FeeFunctionParams) (FeeFunction,
+		error) {
 
-	// Create a mock wallet.
-	wallet := &MockWallet{}
+		return NewLinearFeeFunction(
+			p.MaxFeeRate/2, p.ConfTarget, nil,
+			fn.Some(chainfee.SatPerKWeight(1000)),
+		)
+	}
 
-	// Create a mock chain notifier.
-	notifier := &chainntnfs.MockChainNotifier{}
+	maxFeeRate := chainfee.SatPerKWeight(10_000)
+	newReq := func() *BumpRequest {
+		req := createTestBumpRequest()
+		req.Budget = 1_000_000
+		req.MaxFeeRate = maxFeeRate
+		req.DeadlineHeight = 10
 
-	t.Cleanup(func() {
-		estimator.AssertExpectations(t)
-		feeFunc.AssertExpectations(t)
-		signer.AssertExpectations(t)
-		wallet.AssertExpectations(t)
-		notifier.AssertExpectations(t)
-	})
+		return req
+	}
 
-	m := &mockers{
-		signer:    signer,
-		wallet:    wallet,
-		estimator: estimator,
-		notifier:  notifier,
-		feeFunc:   feeFunc,
+	// When disabled, the fee function ends at half of the max fee rate.
+	f, err := tp.initializeFeeFunction(newReq())
+	require.NoError(t, err)
+	_, err = f.IncreaseFeeRate(1, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, maxFeeRate/2, f.FeeRate())
+
+	// Require 80% of the max fee rate by the deadline.
+	tp.cfg.MinBudgetUtilizationByDeadline = 0.8
+	f, err = tp.initializeFeeFunction(newReq())
+	require.NoError(t, err)
+	require.EqualValues(t, 1000, f.FeeRate())
+
+	// The schedule should be shaped to reach the required fee rate.
+	schedule := f.Schedule()
+	require.EqualValues(t, 8000, schedule[len(schedule)-1])
+
+	// Half way to the deadline, the fee rate should be above the one
+	// given by the wrapped function.
+	increased, err := f.IncreaseFeeRate(5, 0, 0)
+	require.NoError(t, err)
+	require.True(t, increased)
+
+	inner := f.(*utilizationFeeFunction).FeeFunction
+	require.Greater(t, f.FeeRate(), inner.FeeRate())
+
+	// By the deadline, the required fee rate should be reached.
+	increased, err = f.IncreaseFeeRate(1, 0, 0)
+	require.NoError(t, err)
+	require.True(t, increased)
+	require.EqualValues(t, 8000, f.FeeRate())
+
+	// Requiring the whole budget should never exceed the max fee rate.
+	tp.cfg.MinBudgetUtilizationByDeadline = 1
+	f, err = tp.initializeFeeFunction(newReq())
+	require.NoError(t, err)
+	_, err = f.IncreaseFeeRate(1, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, maxFeeRate, f.FeeRate())
+
+	_, err = f.Increment(0)
+	require.ErrorIs(t, err, ErrMaxPosition)
+	require.Equal(t, maxFeeRate, f.FeeRate())
+
+	// A fee function already reaching the max fee rate is not wrapped.
+	linear, err := NewLinearFeeFunction(
+		maxFeeRate, 10, nil, fn.Some(chainfee.SatPerKWeight(1000)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, FeeFunction(linear), withMinUtilization(
+		linear, 1, maxFeeRate, 10,
+	))
+}
+
+// TestInitializeFeeFunctionFallbackEstimator checks the fallback estimators
+// and the relay fee rate are used when the primary estimator fails.
+func TestInitializeFeeFunctionFallbackEstimator(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks with a fallback estimator.
+	tp, m := createTestPublisher(t)
+
+	fallback := &chainfee.MockEstimator{}
+	defer fallback.AssertExpectations(t)
+
+	margin := chainfee.SatPerKWeight(100)
+	tp.cfg.FallbackEstimators = []chainfee.Estimator{fallback}
+	tp.cfg.RelayFeeFallbackMargin = fn.Some(margin)
+
+	// Create a testing bump request.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 10
+
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Mock the primary estimator to fail and the fallback to succeed.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		chainfee.SatPerKWeight(0), errDummy).Twice()
+	fallback.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+
+	// The fee rate from the fallback estimator should be used.
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, feerate, f.FeeRate())
+
+	// Now mock the fallback estimator to fail too. The relay fee rate plus
+	// the margin should be used.
+	fallback.On("EstimateFeePerKW", mock.Anything).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+
+	f, err = tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.FeePerKwFloor+margin, f.FeeRate())
+}
+
+// TestInitializeFeeFunctionEstimatorCache checks two initializations for the
+// same conf target within the cache TTL only make one estimator call, unless
+// the request bypasses the cache.
+func TestInitializeFeeFunctionEstimatorCache(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks with the estimator cache.
+	tp, m := createTestPublisher(t)
+	tp.feeCache = newCachedEstimator(
+		tp.uncachedEstimator(), time.Minute, defaultEstimatorCacheSize,
+	)
+
+	// Create a testing bump request.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 10
+
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Mock the estimator to be called only once.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		f, err := tp.initializeFeeFunction(req)
+		require.NoError(t, err)
+		require.Equal(t, feerate, f.FeeRate())
 	}
 
-	// Create a publisher using the mocks.
-	tp := NewTxPublisher(TxPublisherConfig{
-		Estimator:  m.estimator,
-		Signer:     m.signer,
-		Wallet:     m.wallet,
-		Notifier:   m.notifier,
-		AuxSweeper: fn.Some[AuxSweeper](&MockAuxSweeper{}),
-	})
+	// A request bypassing the cache should hit the estimator.
+	req.BypassFeeCache = true
+	newFeerate := chainfee.SatPerKWeight(2000)
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		newFeerate, nil).Once()
 
-	return tp, m
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, newFeerate, f.FeeRate())
 }
 
-// TestCreateAndCheckTx checks `createAndCheckTx` behaves as expected.
-func TestCreateAndCheckTx(t *testing.T) {
+// TestInitializeFeeFunctionFactory checks the configured fee function factory
+// is used to create the fee function with the expected params.
+func TestInitializeFeeFunctionFactory(t *testing.T) {
 	t.Parallel()
 
-	// Create a test request.
-	inp := createTestInput(1000, input.WitnessKeyHash)
+	// Create a publisher using the mocks at height 100.
+	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(100)
 
-	// Create a publisher using the mocks.
+	// Create a testing bump request.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 106
+	req.StartingFeeRate = fn.Some(chainfee.SatPerKWeight(1000))
+
+	maxFeeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+
+	// Configure an allocation curve to be passed to the factory.
+	tp.cfg.AllocationCurve = func(progress float64) float64 {
+		return progress * progress
+	}
+
+	// Use a factory returning the mocked fee function.
+	var params FeeFunctionParams
+	tp.cfg.FeeFunctionFactory = func(p FeeFunctionParams) (FeeFunction,
+		error) {
+
+		params = p
+
+		return m.feeFunc, nil
+	}
+
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, m.feeFunc, f)
+
+	// The params should be derived from the request.
+	require.Equal(t, req.StartingFeeRate, params.StartingFeeRate)
+	require.Equal(t, maxFeeRate, params.MaxFeeRate)
+	require.EqualValues(t, 6, params.ConfTarget)
+	require.EqualValues(t, 100, params.CurrentHeight)
+	require.Equal(t, m.estimator, params.Estimator)
+	require.NotNil(t, params.AllocationCurve)
+	require.Equal(t, 0.25, params.AllocationCurve(0.5))
+
+	// An error from the factory should be returned.
+	tp.cfg.FeeFunctionFactory = func(FeeFunctionParams) (FeeFunction,
+		error) {
+
+		return nil, errDummy
+	}
+
+	_, err = tp.initializeFeeFunction(req)
+	require.ErrorIs(t, err, errDummy)
+}
+
+// TestEstimateStartFeeRate checks the estimated start fee rate matches the
+// fee rate the fee function starts with, and the request is not mutated.
+func TestEstimateStartFeeRate(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks at height 100.
 	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(100)
 
-	// Create a test feerate and return it from the mock fee function.
+	// Create a request using a conf target.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.ConfTarget = 6
+
+	// Mock the estimator to return a fee rate.
 	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	m.estimator.On("EstimateFeePerKW", uint32(6)).Return(
+		feerate, nil).Twice()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
 
-	// Mock the wallet to fail on testmempoolaccept on the first call, and
-	// succeed on the second.
-	m.wallet.On("CheckMempoolAcceptance",
-		mock.Anything).Return(errDummy).Once()
-	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	// The estimated start fee rate should match the fee function's.
+	estimated, err := tp.EstimateStartFeeRate(req)
+	require.NoError(t, err)
+	require.Equal(t, feerate, estimated)
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
+	// The request should not be mutated.
+	require.Zero(t, req.DeadlineHeight)
+
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, f.FeeRate(), estimated)
+
+	// No record should be created.
+	require.Zero(t, tp.records.Len())
+
+	// A fee rate above the max fee rate allowed should be capped.
+	req = createTestBumpRequest()
+	req.MaxFeeRate = feerate / 2
+	req.DeadlineHeight = 102
+	m.estimator.On("EstimateFeePerKW", uint32(2)).Return(
+		feerate, nil).Once()
+
+	estimated, err = tp.EstimateStartFeeRate(req)
+	require.NoError(t, err)
+	require.Equal(t, req.MaxFeeRate, estimated)
+
+	// A request using a budget fraction should get its budget derived as
+	// when it's broadcast, without mutating the request.
+	req = createTestBumpRequest()
+	req.Budget = 0
+	req.BudgetFraction = 0.5
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 110
+	req.StartingFeeRate = fn.Some(feerate)
+
+	estimated, err = tp.EstimateStartFeeRate(req)
+	require.NoError(t, err)
+	require.Equal(t, feerate, estimated)
+	require.Zero(t, req.Budget)
+
+	// An invalid request should be rejected as when it's broadcast.
+	req.Label = strings.Repeat("a", MaxLabelLength+1)
+	_, err = tp.EstimateStartFeeRate(req)
+	require.ErrorIs(t, err, ErrLabelTooLong)
+}
+
+// TestEstimateTotalFee checks the estimated total fee is the projected fee rate
+// times the estimated weight of the tx, and no record is created.
+func TestEstimateTotalFee(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks at height 100.
+	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(100)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Create a request using a starting fee rate with its deadline ten
+	// blocks away.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 110
+	req.StartingFeeRate = fn.Some(chainfee.SatPerKWeight(1000))
+
+	weight, err := calcSweepTxWeight(
+		req.Inputs, [][]byte{req.DeliveryAddress.DeliveryAddress},
+		nil,
+	)
+	require.NoError(t, err)
+
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	schedule := f.Schedule()
 
 	testCases := []struct {
-		name        string
-		req         *BumpRequest
-		expectedErr error
+		name       string
+		projection FeeProjection
+		feeRate    chainfee.SatPerKWeight
 	}{
 		{
-			// When the budget cannot cover the fee, an error
-			// should be returned.
-			name: "not enough budget",
-			req: &BumpRequest{
-				DeliveryAddress: changePkScript,
-				Inputs:          []input.Input{&inp},
-			},
-			expectedErr: ErrNotEnoughBudget,
-		},
-		{
-			// When the mempool rejects the transaction, an error
-			// should be returned.
-			name: "testmempoolaccept fail",
-			req: &BumpRequest{
-				DeliveryAddress: changePkScript,
-				Inputs:          []input.Input{&inp},
-				Budget:          btcutil.Amount(1000),
-			},
-			expectedErr: errDummy,
+			name:       "deadline",
+			projection: FeeProjectionDeadline,
+			feeRate:    schedule[len(schedule)-1],
 		},
 		{
-			// When the mempool accepts the transaction, no error
-			// should be returned.
-			name: "testmempoolaccept pass",
-			req: &BumpRequest{
-				DeliveryAddress: changePkScript,
-				Inputs:          []input.Input{&inp},
-				Budget:          btcutil.Amount(1000),
-			},
-			expectedErr: nil,
+			name:       "midpoint",
+			projection: FeeProjectionMidpoint,
+			feeRate:    schedule[(len(schedule)-1)/2],
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
+		tp.cfg.TotalFeeProjection = tc.projection
 
-		t.Run(tc.name, func(t *testing.T) {
-			// Call the method under test.
-			_, err := tp.createAndCheckTx(tc.req, m.feeFunc)
+		fee, err := tp.EstimateTotalFee(req)
+		require.NoError(t, err, tc.name)
 
-			// Check the result is as expected.
-			require.ErrorIs(t, err, tc.expectedErr)
-		})
+		expected := tc.feeRate.FeeForWeight(weight)
+		require.InDelta(t, int64(expected), int64(fee), 1, tc.name)
 	}
-}
 
-// createTestBumpRequest creates a new bump request.
-func createTestBumpRequest() *BumpRequest {
-	// Create a test input.
-	inp := createTestInput(1000, input.WitnessKeyHash)
+	// The midpoint should give a lower fee than the deadline.
+	require.Less(
+		t, schedule[(len(schedule)-1)/2], schedule[len(schedule)-1],
+	)
 
-	return &BumpRequest{
-		DeliveryAddress: changePkScript,
-		Inputs:          []input.Input{&inp},
-		Budget:          btcutil.Amount(1000),
-	}
+	// A request using a budget fraction should get the same estimate as
+	// the one specifying the derived budget.
+	budgetReq := *req
+	budgetReq.Budget = req.inputValue().MulF64(0.5)
+
+	expected, err := tp.EstimateTotalFee(&budgetReq)
+	require.NoError(t, err)
+
+	fractionReq := *req
+	fractionReq.Budget = 0
+	fractionReq.BudgetFraction = 0.5
+
+	fee, err := tp.EstimateTotalFee(&fractionReq)
+	require.NoError(t, err)
+	require.Equal(t, expected, fee)
+	require.Zero(t, fractionReq.Budget)
+
+	// No record should be created.
+	require.Zero(t, tp.records.Len())
 }
 
-// TestCreateRBFCompliantTx checks that `createRBFCompliantTx` behaves as
-// expected.
-func TestCreateRBFCompliantTx(t *testing.T) {
+// TestInitializeFeeFunctionJitter checks the fee function is jittered when
+// FeeJitterPercent is configured, except for a fixed fee rate.
+func TestInitializeFeeFunctionJitter(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
+	// Create a publisher using the mocks with jitter enabled.
 	tp, m := createTestPublisher(t)
+	tp.cfg.FeeJitterPercent = 10
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
 
-	// Create a test bump request.
+	// Create a testing bump request.
 	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 10
+	req.StartingFeeRate = fn.Some(chainfee.SatPerKWeight(1000))
 
-	// Create a test feerate and return it from the mock fee function.
-	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.IsType(t, &jitterFeeFunction{}, f)
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
+	// The same request should give the same jittered fee rate.
+	f2, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, f.FeeRate(), f2.FeeRate())
 
-	testCases := []struct {
-		name        string
-		setupMock   func()
-		expectedErr error
-	}{
-		{
-			// When testmempoolaccept accepts the tx, no error
-			// should be returned.
-			name: "success case",
-			setupMock: func() {
-				// Mock the testmempoolaccept to pass.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(nil).Once()
-			},
-			expectedErr: nil,
-		},
-		{
-			// When testmempoolaccept fails due to a non-fee
-			// related error, an error should be returned.
-			name: "non-fee related testmempoolaccept fail",
-			setupMock: func() {
-				// Mock the testmempoolaccept to fail.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(errDummy).Once()
-			},
-			expectedErr: errDummy,
-		},
-		{
-			// When increase feerate gives an error, the error
-			// should be returned.
-			name: "fail on increase fee",
-			setupMock: func() {
-				// Mock the testmempoolaccept to fail on fee.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(
-					lnwallet.ErrMempoolFee).Once()
+	// A fixed fee rate should not be jittered.
+	req.FixedFeeRate = chainfee.SatPerKWeight(1000)
+	f, err = tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.IsType(t, &ConstantFeeFunction{}, f)
+}
 
-				// Mock the fee function to return an error.
-				m.feeFunc.On("Increment").Return(
-					false, errDummy).Once()
-			},
-			expectedErr: errDummy,
-		},
-		{
-			// Test that after one round of increasing the feerate
-			// the tx passes testmempoolaccept.
-			name: "increase fee and success on min mempool fee",
-			setupMock: func() {
-				// Mock the testmempoolaccept to fail on fee
-				// for the first call.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(
-					lnwallet.ErrMempoolFee).Once()
+// TestInitializeFeeFunctionConfTarget checks the fee function is initialized
+// using the deadline translated from the conf target, and a conflicting
+// deadline height is rejected.
+func TestInitializeFeeFunctionConfTarget(t *testing.T) {
+	t.Parallel()
 
-				// Mock the fee function to increase feerate.
-				m.feeFunc.On("Increment").Return(
-					true, nil).Once()
+	// Create a publisher using the mocks at height 100.
+	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(100)
 
-				// Mock the testmempoolaccept to pass on the
-				// second call.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(nil).Once()
-			},
-			expectedErr: nil,
-		},
-		{
-			// Test that after one round of increasing the feerate
-			// the tx passes testmempoolaccept.
-			name: "increase fee and success on insufficient fee",
-			setupMock: func() {
-				// Mock the testmempoolaccept to fail on fee
-				// for the first call.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(
-					chain.ErrInsufficientFee).Once()
+	// Create a request that specifies a conflicting deadline height.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.ConfTarget = 6
+	req.DeadlineHeight = 110
 
-				// Mock the fee function to increase feerate.
-				m.feeFunc.On("Increment").Return(
-					true, nil).Once()
+	// The request should be rejected.
+	_, err := tp.initializeFeeFunction(req)
+	require.ErrorIs(t, err, ErrConflictingDeadline)
 
-				// Mock the testmempoolaccept to pass on the
-				// second call.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(nil).Once()
-			},
-			expectedErr: nil,
-		},
-		{
-			// Test that the fee function increases the fee rate
-			// after one round.
-			name: "increase fee on second round",
-			setupMock: func() {
-				// Mock the testmempoolaccept to fail on fee
-				// for the first call.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(
-					chain.ErrInsufficientFee).Once()
+	// Now use the conf target only. We expect the fee estimator to be
+	// queried using the conf target.
+	req.DeadlineHeight = 0
+	m.estimator.On("EstimateFeePerKW", uint32(6)).Return(
+		chainfee.FeePerKwFloor, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
 
-				// Mock the fee function to NOT increase
-				// feerate on the first round.
-				m.feeFunc.On("Increment").Return(
-					false, nil).Once()
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, chainfee.FeePerKwFloor, f.FeeRate())
 
-				// Mock the fee function to increase feerate.
-				m.feeFunc.On("Increment").Return(
-					true, nil).Once()
+	// The deadline height should now be set so the following fee bumps
+	// use the same deadline.
+	require.EqualValues(t, 106, req.DeadlineHeight)
+}
 
-				// Mock the testmempoolaccept to pass on the
-				// second call.
-				m.wallet.On("CheckMempoolAcceptance",
-					mock.Anything).Return(nil).Once()
-			},
-			expectedErr: nil,
-		},
-	}
+// TestInitializeFeeFunctionMempoolPercentile checks a request specifying a
+// mempool fee percentile uses the mempool fee source, and is rejected when no
+// source is configured.
+func TestInitializeFeeFunctionMempoolPercentile(t *testing.T) {
+	t.Parallel()
 
-	var requestCounter atomic.Uint64
-	for _, tc := range testCases {
-		tc := tc
+	// Create a publisher using the mocks without a mempool fee source.
+	tp, m := createTestPublisher(t)
 
-		rid := requestCounter.Add(1)
-		t.Run(tc.name, func(t *testing.T) {
-			tc.setupMock()
+	// Create a request that tracks the median mempool fee rate.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 10
+	req.MempoolFeePercentile = fn.Some(0.5)
 
-			// Call the method under test.
-			err := tp.createRBFCompliantTx(rid, req, m.feeFunc)
+	// The request should be rejected.
+	_, err := tp.initializeFeeFunction(req)
+	require.ErrorIs(t, err, ErrNoMempoolFeeSource)
 
-			// Check the result is as expected.
-			require.ErrorIs(t, err, tc.expectedErr)
-		})
-	}
+	// Now configure a mempool fee source, the fee rate at the percentile
+	// should be used.
+	source := &MockMempoolFeeSource{}
+	defer source.AssertExpectations(t)
+	tp.cfg.MempoolFeeSource = fn.Some[MempoolFeeSource](source)
+
+	feeRate := chainfee.SatPerKWeight(1000)
+	source.On("FeeRateAtPercentile", 0.5).Return(feeRate, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.IsType(t, &MempoolPercentileFeeFunction{}, f)
+	require.Equal(t, feeRate, f.FeeRate())
 }
 
-// TestTxPublisherBroadcast checks the internal `broadcast` method behaves as
-// expected.
-func TestTxPublisherBroadcast(t *testing.T) {
+// TestInitializeFeeFunctionStartMultiplier checks the start fee rate
+// multiplier is applied to the estimated fee rate, and the result is capped by
+// the max fee rate allowed.
+func TestInitializeFeeFunctionStartMultiplier(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
-	tp, m := createTestPublisher(t)
+	// Create a test input.
+	inp := createTestInput(100, input.WitnessKeyHash)
 
-	// Create a test bump request.
-	req := createTestBumpRequest()
+	// Create a mock fee estimator.
+	estimator := &chainfee.MockEstimator{}
+	defer estimator.AssertExpectations(t)
 
-	// Create a test tx.
-	tx := &wire.MsgTx{LockTime: 1}
+	// Create a publisher using a multiplier of 1.5.
+	tp := NewTxPublisher(TxPublisherConfig{
+		Estimator:              estimator,
+		AuxSweeper:             fn.Some[AuxSweeper](&MockAuxSweeper{}),
+		StartFeeRateMultiplier: 1.5,
+	})
 
-	// Create a test feerate and return it from the mock fee function.
+	// Create a testing bump request.
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
+		DeadlineHeight:  10,
+	}
+	maxFeeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+
+	// Mock the fee estimator to return a fee rate whose scaled value is
+	// below the cap.
 	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+	estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+
+	// The initial fee rate should be 1.5x the estimate.
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, feerate*3/2, f.FeeRate())
+
+	// Mock the fee estimator to return a fee rate whose scaled value is
+	// above the cap. We use a deadline that gives a conf target of 2 here
+	// so the fee function can start at its ending fee rate.
+	req.DeadlineHeight = 2
+	estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		maxFeeRate*3/4, nil).Once()
+	estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+
+	// The initial fee rate should be capped.
+	f, err = tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.Equal(t, maxFeeRate, f.FeeRate())
+}
+
+// TestTxPublisherConfigValidate checks the config validation.
+func TestTxPublisherConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	// An unset multiplier is valid.
+	cfg := &TxPublisherConfig{}
+	require.NoError(t, cfg.Validate())
+
+	// A multiplier no less than 1.0 is valid.
+	cfg.StartFeeRateMultiplier = 1.0
+	require.NoError(t, cfg.Validate())
+
+	// A multiplier below 1.0 is rejected.
+	cfg.StartFeeRateMultiplier = 0.9
+	require.ErrorContains(t, cfg.Validate(), "start fee rate multiplier")
+
+	// A negative broadcast rate limit is rejected.
+	cfg.StartFeeRateMultiplier = 0
+	cfg.BroadcastRateLimit = -1
+	require.ErrorContains(t, cfg.Validate(), "broadcast rate limit")
+
+	// A negative min bump increment is rejected.
+	cfg.BroadcastRateLimit = 0
+	cfg.MinBumpIncrementPercent = -1
+	require.ErrorContains(t, cfg.Validate(), "min bump increment")
+
+	// A fee jitter percent out of range is rejected.
+	cfg.MinBumpIncrementPercent = 0
+	cfg.FeeJitterPercent = 100
+	require.ErrorContains(t, cfg.Validate(), "fee jitter percent")
+
+	// A negative estimator cache ttl or size is rejected.
+	cfg.FeeJitterPercent = 0
+	cfg.EstimatorCacheTTL = -1
+	require.ErrorContains(t, cfg.Validate(), "estimator cache ttl")
+
+	cfg.EstimatorCacheTTL = 0
+	cfg.EstimatorCacheSize = -1
+	require.ErrorContains(t, cfg.Validate(), "estimator cache size")
+
+	// A negative failure history size is rejected.
+	cfg.EstimatorCacheSize = 0
+	cfg.FailureHistorySize = -1
+	require.ErrorContains(t, cfg.Validate(), "failure history size")
+
+	// A negative max in flight is rejected.
+	cfg.FailureHistorySize = 0
+	cfg.MaxInFlight = -1
+	require.ErrorContains(t, cfg.Validate(), "max in flight")
+
+	// A negative fee rate sanity cap is rejected.
+	cfg.MaxInFlight = 0
+	cfg.FailureHistorySize = 0
+	cfg.FeeRateSanityCap = -1
+	require.ErrorContains(t, cfg.Validate(), "fee rate sanity cap")
+
+	// A negative subscriber buffer size is rejected.
+	cfg.FeeRateSanityCap = 0
+	cfg.SubscriberBufferSize = -1
+	require.ErrorContains(t, cfg.Validate(), "subscriber buffer size")
+
+	// A trajectory reset threshold out of range is rejected.
+	cfg.SubscriberBufferSize = 0
+	cfg.TrajectoryResetThreshold = 1
+	require.ErrorContains(t, cfg.Validate(), "trajectory reset threshold")
+
+	// A confirmed fee rate multiplier below 1 is rejected.
+	cfg.TrajectoryResetThreshold = 0
+	cfg.ConfirmedFeeRateMultiplier = 0.5
+	require.ErrorContains(
+		t, cfg.Validate(), "confirmed fee rate multiplier",
+	)
+
+	// A min budget utilization above 1 is rejected.
+	cfg.ConfirmedFeeRateMultiplier = 0
+	cfg.MinBudgetUtilizationByDeadline = 1.5
+	require.ErrorContains(t, cfg.Validate(), "min budget utilization")
+
+	// A negative max publish retry backoff is rejected.
+	cfg.MinBudgetUtilizationByDeadline = 0
+	cfg.MaxPublishRetryBackoff = -1
+	require.ErrorContains(t, cfg.Validate(), "max publish retry backoff")
+
+	// A max publish retry backoff below the initial backoff is rejected.
+	cfg.PublishRetryBackoff = time.Second
+	cfg.MaxPublishRetryBackoff = time.Millisecond
+	require.ErrorContains(t, cfg.Validate(), "max publish retry backoff")
+
+	cfg.MaxPublishRetryBackoff = time.Second
+	require.NoError(t, cfg.Validate())
+}
+
+// TestStoreRecord correctly increases the request counter and saves the
+// record.
+func TestStoreRecord(t *testing.T) {
+	t.Parallel()
+
+	// Create a test input.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	// Create a bump request.
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+	}
+
+	// Create a naive fee function.
+	feeFunc := &LinearFeeFunction{}
+
+	// Create a test fee and tx.
+	fee := btcutil.Amount(1000)
+	tx := &wire.MsgTx{}
+
+	// Create a publisher using the mocks.
+	tp := NewTxPublisher(TxPublisherConfig{
+		AuxSweeper: fn.Some[AuxSweeper](&MockAuxSweeper{}),
+	})
+
+	// Get the current counter and check it's increased later.
+	initialCounter := tp.requestCounter.Load()
 
 	op := wire.OutPoint{
 		Hash:  chainhash.Hash{1},
@@ -695,978 +1552,7393 @@ func TestTxPublisherBroadcast(t *testing.T) {
 		op: 0,
 	}
 
-	// Create a testing record and put it in the map.
-	fee := btcutil.Amount(1000)
-	requestID := uint64(1)
-	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+	// Call the method under test.
+	tp.storeRecord(initialCounter, tx, req, feeFunc, fee, utxoIndex)
 
-	// Quickly check when the requestID cannot be found, an error is
-	// returned.
-	result, err := tp.broadcast(uint64(1000))
-	require.Error(t, err)
-	require.Nil(t, result)
+	// Read the saved record and compare.
+	record, ok := tp.records.Load(initialCounter)
+	require.True(t, ok)
+	require.Equal(t, tx, record.tx)
+	require.Equal(t, feeFunc, record.feeFunction)
+	require.Equal(t, fee, record.fee)
+	require.Equal(t, req, record.req)
+	require.Equal(t, utxoIndex, record.outpointToTxIndex)
+}
 
-	testCases := []struct {
-		name           string
-		setupMock      func()
-		expectedErr    error
-		expectedResult *BumpResult
-	}{
-		{
-			// When the wallet cannot publish this tx, the error
-			// should be put inside the result.
-			name: "fail to publish",
-			setupMock: func() {
-				// Mock the wallet to fail to publish.
-				m.wallet.On("PublishTransaction",
-					tx, mock.Anything).Return(
-					errDummy).Once()
+// mockers wraps a list of mocked interfaces used inside tx publisher.
+type mockers struct {
+	signer    *input.MockInputSigner
+	wallet    *MockWallet
+	estimator *chainfee.MockEstimator
+	notifier  *chainntnfs.MockChainNotifier
+
+	feeFunc *MockFeeFunction
+}
+
+// createTestPublisher creates a new tx publisher using the provided mockers.
+func createTestPublisher(t *testing.T) (*TxPublisher, *mockers) {
+	// Create a mock fee estimator.
+	estimator := &chainfee.MockEstimator{}
+
+	// Create a mock fee function.
+	feeFunc := &MockFeeFunction{}
+
+	// Create a mock signer.
+	signer := &input.MockInputSigner{}
+
+	// Create a mock wallet.
+	wallet := &MockWallet{}
+
+	// Create a mock chain notifier.
+	notifier := &chainntnfs.MockChainNotifier{}
+
+	t.Cleanup(func() {
+		estimator.AssertExpectations(t)
+		feeFunc.AssertExpectations(t)
+		signer.AssertExpectations(t)
+		wallet.AssertExpectations(t)
+		notifier.AssertExpectations(t)
+	})
+
+	m := &mockers{
+		signer:    signer,
+		wallet:    wallet,
+		estimator: estimator,
+		notifier:  notifier,
+		feeFunc:   feeFunc,
+	}
+
+	// Create a publisher using the mocks.
+	tp := NewTxPublisher(TxPublisherConfig{
+		Estimator:  m.estimator,
+		Signer:     m.signer,
+		Wallet:     m.wallet,
+		Notifier:   m.notifier,
+		AuxSweeper: fn.Some[AuxSweeper](&MockAuxSweeper{}),
+	})
+
+	return tp, m
+}
+
+// TestCreateAndCheckTx checks `createAndCheckTx` behaves as expected.
+func TestCreateAndCheckTx(t *testing.T) {
+	t.Parallel()
+
+	// Create a test request.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the wallet to fail on testmempoolaccept on the first call, and
+	// succeed on the second.
+	m.wallet.On("CheckMempoolAcceptance",
+		mock.Anything).Return(errDummy).Once()
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	testCases := []struct {
+		name        string
+		req         *BumpRequest
+		expectedErr error
+	}{
+		{
+			// When the budget cannot cover the fee, an error
+			// should be returned.
+			name: "not enough budget",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+			},
+			expectedErr: ErrNotEnoughBudget,
+		},
+		{
+			// When the fee exceeds the absolute fee cap, an error
+			// should be returned even if the budget covers it.
+			name: "exceeds max fee absolute",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          btcutil.Amount(1000),
+				MaxFeeAbsolute:  btcutil.Amount(1),
+			},
+			expectedErr: ErrNotEnoughBudget,
+		},
+		{
+			// When the mempool rejects the transaction, an error
+			// should be returned.
+			name: "testmempoolaccept fail",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          btcutil.Amount(1000),
+			},
+			expectedErr: errDummy,
+		},
+		{
+			// When the mempool accepts the transaction, no error
+			// should be returned.
+			name: "testmempoolaccept pass",
+			req: &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          btcutil.Amount(1000),
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Call the method under test.
+			_, err := tp.createAndCheckTx(tc.req, m.feeFunc, log)
+
+			// Check the result is as expected.
+			require.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// TestCreateAndCheckTxShrinkChange checks a fee above the budget is allowed
+// when ShrinkChangeForFee is set and the change output can pay for it.
+func TestCreateAndCheckTxShrinkChange(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks. The aux sweeper is removed so
+	// the tx only has the change output.
+	tp, m := createTestPublisher(t)
+	tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	newReq := func(value int64, shrink bool) *BumpRequest {
+		inp := createTestInput(value, input.WitnessKeyHash)
+
+		return &BumpRequest{
+			DeliveryAddress:    changePkScript,
+			Inputs:             []input.Input{&inp},
+			Budget:             btcutil.Amount(100),
+			ShrinkChangeForFee: shrink,
+		}
+	}
+
+	// Without shrinking the change, the budget cannot cover the fee.
+	_, err := tp.createAndCheckTx(newReq(100_000, false), m.feeFunc, log)
+	require.ErrorIs(t, err, ErrNotEnoughBudget)
+
+	// Shrinking the change allows the fee to exceed the budget.
+	sweepCtx, err := tp.createAndCheckTx(
+		newReq(100_000, true), m.feeFunc, log,
+	)
+	require.NoError(t, err)
+	require.Greater(t, sweepCtx.fee, btcutil.Amount(100))
+	require.Len(t, sweepCtx.tx.TxOut, 1)
+	require.EqualValues(t, 100_000-sweepCtx.fee,
+		sweepCtx.tx.TxOut[0].Value)
+	fee := int64(sweepCtx.fee)
+
+	// The absolute fee cap is still enforced.
+	req := newReq(100_000, true)
+	req.MaxFeeAbsolute = btcutil.Amount(fee - 1)
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, ErrNotEnoughBudget)
+
+	// When the change would be shrunk below the dust limit, it's dropped,
+	// leaving the tx without any outputs.
+	_, err = tp.createAndCheckTx(newReq(fee+100, true), m.feeFunc, log)
+	require.ErrorIs(t, err, ErrTxNoOutput)
+
+	// When even a zero change cannot pay the fee, an error is returned.
+	_, err = tp.createAndCheckTx(newReq(fee-1, true), m.feeFunc, log)
+	require.Error(t, err)
+}
+
+// TestCreateAndCheckTxLockTime checks the locktime specified in the request
+// is used by `createAndCheckTx`, and a future locktime is rejected.
+func TestCreateAndCheckTxLockTime(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Set the current height.
+	tp.currentHeight.Store(100)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the signer to always return a valid script.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+
+	// Create a test request with a valid locktime.
+	req := createTestBumpRequest()
+	req.LockTime = 90
+
+	// Mock the testmempoolaccept to pass.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Call the method under test and assert the locktime is used.
+	sweepCtx, err := tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
+	require.EqualValues(t, 90, sweepCtx.tx.LockTime)
+
+	// The locktime should be enforced by a non-final sequence.
+	require.NotEqual(t, wire.MaxTxInSequenceNum,
+		sweepCtx.tx.TxIn[0].Sequence)
+
+	// Now use a locktime that's in the future, which should be rejected
+	// without checking the mempool.
+	req.LockTime = 101
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, ErrLocktimeInFuture)
+}
+
+// TestCreateAndCheckTxDeliveryAddrFn checks that when a delivery address
+// generator is specified, each tx build uses a fresh script.
+func TestCreateAndCheckTxDeliveryAddrFn(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the signer to always return a valid script.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+
+	// Mock the testmempoolaccept to pass.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Create a counter-based generator that returns a new p2tr script on
+	// each call.
+	counter := byte(0)
+	genScript := func() ([]byte, error) {
+		counter++
+
+		pkScript := make([]byte, 34)
+		pkScript[0], pkScript[1] = 0x51, 0x20
+		pkScript[33] = counter
+
+		return pkScript, nil
+	}
+
+	req := createTestBumpRequest()
+	req.DeliveryAddrFn = genScript
+
+	// hasOutput checks whether the tx pays to the script.
+	hasOutput := func(tx *wire.MsgTx, pkScript []byte) bool {
+		for _, txOut := range tx.TxOut {
+			if bytes.Equal(txOut.PkScript, pkScript) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Build the tx a few times and assert each build uses a new script.
+	for i := byte(1); i <= 3; i++ {
+		sweepCtx, err := tp.createAndCheckTx(req, m.feeFunc, log)
+		require.NoError(t, err)
+
+		expected := make([]byte, 34)
+		expected[0], expected[1] = 0x51, 0x20
+		expected[33] = i
+		require.True(t, hasOutput(sweepCtx.tx, expected))
+	}
+
+	// A generator error should abort the build.
+	req.DeliveryAddrFn = func() ([]byte, error) {
+		return nil, errDummy
+	}
+	_, err := tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, errDummy)
+
+	// An unknown script type should abort the build.
+	req.DeliveryAddrFn = func() ([]byte, error) {
+		return []byte{0x00}, nil
+	}
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorContains(t, err, "invalid delivery address")
+}
+
+// TestCreateAndCheckTxSigHash checks the sighash flag carried on an input is
+// passed to the signer, and the tx passes the mempool check.
+func TestCreateAndCheckTxSigHash(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Create an input that's signed using SIGHASH_SINGLE|ANYONECANPAY.
+	hashType := txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
+	inp := input.MakeBaseInput(
+		&wire.OutPoint{Hash: chainhash.Hash{1}}, input.WitnessKeyHash,
+		&input.SignDescriptor{
+			Output: &wire.TxOut{Value: 100_000},
+			KeyDesc: keychain.KeyDescriptor{
+				PubKey: testPubKey,
+			},
+			HashType: hashType,
+		}, 0, nil,
+	)
+	req := createTestBumpRequest()
+	req.Inputs = []input.Input{&inp}
+
+	// Mock the signer and assert the sighash flag is passed through.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.MatchedBy(func(desc *input.SignDescriptor) bool {
+			return desc.HashType == hashType
+		})).Return(&input.Script{}, nil).Once()
+
+	// Mock the testmempoolaccept to pass.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Call the method under test and assert the tx is created.
+	sweepCtx, err := tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
+	require.Len(t, sweepCtx.tx.TxIn, 1)
+}
+
+// TestCreateSweepTxGroupSigning checks that when grouping is enabled, the
+// signer is called with the inputs grouped by their witness types, while the
+// input order in the final tx is preserved.
+func TestCreateSweepTxGroupSigning(t *testing.T) {
+	t.Parallel()
+
+	// Create three inputs with interleaved witness types.
+	inp1 := createTestInput(1000, input.WitnessKeyHash)
+	inp2 := createTestInput(1000, input.NestedWitnessKeyHash)
+	inp3 := createTestInput(1000, input.WitnessKeyHash)
+	inputs := []input.Input{&inp1, &inp2, &inp3}
+
+	testCases := []struct {
+		name          string
+		group         bool
+		expectedOrder []int
+	}{
+		{
+			name:          "no grouping",
+			group:         false,
+			expectedOrder: []int{0, 1, 2},
+		},
+		{
+			name:          "group by witness type",
+			group:         true,
+			expectedOrder: []int{0, 2, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a publisher using the mocks.
+			tp, m := createTestPublisher(t)
+			tp.cfg.GroupSigningByWitnessType = tc.group
+
+			// Mock the signer to record the signing order.
+			var order []int
+			m.signer.On("ComputeInputScript", mock.Anything,
+				mock.Anything).Return(&input.Script{}, nil).Run(
+				func(args mock.Arguments) {
+					desc := args.Get(1).(*input.SignDescriptor)
+					order = append(order, desc.InputIndex)
+				})
+
+			sweepCtx, err := tp.createSweepTx(
+				inputs, changePkScript, 1000, 0,
+				fn.None[input.TxInfo](), nil, false,
+			)
+			require.NoError(t, err)
+
+			// Check the signer calls are made in the expected
+			// order.
+			require.Equal(t, tc.expectedOrder, order)
+
+			// Check the input order in the tx is preserved.
+			for i, inp := range inputs {
+				require.Equal(t, inp.OutPoint(),
+					sweepCtx.tx.TxIn[i].PreviousOutPoint)
+			}
+		})
+	}
+}
+
+// TestCreateSweepTxSignerResolver checks each input is signed by the signer
+// given by the SignerResolver, falling back to the default signer.
+func TestCreateSweepTxSignerResolver(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Route the second input to another signer, while the first one uses
+	// the default signer.
+	inp1 := createTestInput(1000, input.WitnessKeyHash)
+	inp2 := createTestInput(1000, input.WitnessKeyHash)
+	inputs := []input.Input{&inp1, &inp2}
+
+	otherSigner := &input.MockInputSigner{}
+	tp.cfg.SignerResolver = func(inp input.Input) input.Signer {
+		if inp.OutPoint() == inp2.OutPoint() {
+			return otherSigner
+		}
+
+		return nil
+	}
+
+	// signedBy mocks the signer to record the inputs it signs.
+	signedBy := func(signer *input.MockInputSigner) *[]int {
+		var indexes []int
+		signer.On("ComputeInputScript", mock.Anything,
+			mock.Anything).Return(&input.Script{}, nil).Run(
+			func(args mock.Arguments) {
+				desc := args.Get(1).(*input.SignDescriptor)
+				indexes = append(indexes, desc.InputIndex)
+			})
+
+		return &indexes
+	}
+	defaultSigned := signedBy(m.signer)
+	otherSigned := signedBy(otherSigner)
+
+	_, err := tp.createSweepTx(
+		inputs, changePkScript, 1000, 0, fn.None[input.TxInfo](), nil,
+		false,
+	)
+	require.NoError(t, err)
+
+	// Each signer should only sign its own input.
+	require.Equal(t, []int{0}, *defaultSigned)
+	require.Equal(t, []int{1}, *otherSigned)
+}
+
+// createTestBumpRequest creates a new bump request.
+func createTestBumpRequest() *BumpRequest {
+	// Create a test input.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	return &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+	}
+}
+
+// TestCreateRBFCompliantTx checks that `createRBFCompliantTx` behaves as
+// expected.
+func TestCreateRBFCompliantTx(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test bump request.
+	req := createTestBumpRequest()
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	testCases := []struct {
+		name        string
+		setupMock   func()
+		expectedErr error
+	}{
+		{
+			// When testmempoolaccept accepts the tx, no error
+			// should be returned.
+			name: "success case",
+			setupMock: func() {
+				// Mock the testmempoolaccept to pass.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(nil).Once()
+			},
+			expectedErr: nil,
+		},
+		{
+			// When testmempoolaccept fails due to a non-fee
+			// related error, an error should be returned.
+			name: "non-fee related testmempoolaccept fail",
+			setupMock: func() {
+				// Mock the testmempoolaccept to fail.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(errDummy).Once()
+			},
+			expectedErr: errDummy,
+		},
+		{
+			// When increase feerate gives an error, the error
+			// should be returned.
+			name: "fail on increase fee",
+			setupMock: func() {
+				// Mock the testmempoolaccept to fail on fee.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(
+					lnwallet.ErrMempoolFee).Once()
+
+				// Mock the fee function to return an error.
+				m.feeFunc.On("Increment", mock.Anything).Return(
+					false, errDummy).Once()
+			},
+			expectedErr: errDummy,
+		},
+		{
+			// Test that after one round of increasing the feerate
+			// the tx passes testmempoolaccept.
+			name: "increase fee and success on min mempool fee",
+			setupMock: func() {
+				// Mock the testmempoolaccept to fail on fee
+				// for the first call.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(
+					lnwallet.ErrMempoolFee).Once()
+
+				// Mock the fee function to increase feerate.
+				m.feeFunc.On("Increment", mock.Anything).Return(
+					true, nil).Once()
+
+				// Mock the testmempoolaccept to pass on the
+				// second call.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(nil).Once()
+			},
+			expectedErr: nil,
+		},
+		{
+			// Test that after one round of increasing the feerate
+			// the tx passes testmempoolaccept.
+			name: "increase fee and success on insufficient fee",
+			setupMock: func() {
+				// Mock the testmempoolaccept to fail on fee
+				// for the first call.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(
+					chain.ErrInsufficientFee).Once()
+
+				// Mock the fee function to increase feerate.
+				m.feeFunc.On("Increment", mock.Anything).Return(
+					true, nil).Once()
+
+				// Mock the testmempoolaccept to pass on the
+				// second call.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(nil).Once()
+			},
+			expectedErr: nil,
+		},
+		{
+			// Test that the fee function increases the fee rate
+			// after one round.
+			name: "increase fee on second round",
+			setupMock: func() {
+				// Mock the testmempoolaccept to fail on fee
+				// for the first call.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(
+					chain.ErrInsufficientFee).Once()
+
+				// Mock the fee function to NOT increase
+				// feerate on the first round.
+				m.feeFunc.On("Increment", mock.Anything).Return(
+					false, nil).Once()
+
+				// Mock the fee function to increase feerate.
+				m.feeFunc.On("Increment", mock.Anything).Return(
+					true, nil).Once()
+
+				// Mock the testmempoolaccept to pass on the
+				// second call.
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(nil).Once()
+			},
+			expectedErr: nil,
+		},
+	}
+
+	var requestCounter atomic.Uint64
+	for _, tc := range testCases {
+		tc := tc
+
+		rid := requestCounter.Add(1)
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+
+			// Call the method under test.
+			err := tp.createRBFCompliantTx(rid, req, m.feeFunc)
+
+			// Check the result is as expected.
+			require.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// TestCreateRBFCompliantTxTooManyReplacements checks a BIP125 rule 5 rejection
+// stops the RBF attempts, and the request falls back to bumping the conflicting
+// tx via a child if permitted.
+func TestCreateRBFCompliantTxTooManyReplacements(t *testing.T) {
+	t.Parallel()
+
+	// The rejection is recognized from either the mapped error or the
+	// reason string.
+	require.True(t, isTooManyReplacementsErr(chain.ErrTooManyReplacements))
+	require.True(t, isTooManyReplacementsErr(errors.New("replacement "+
+		"transaction evicts more transactions than permitted")))
+	require.False(t, isTooManyReplacementsErr(errDummy))
+
+	for _, permitted := range []bool{true, false} {
+		name := fmt.Sprintf("permitted=%v", permitted)
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			testCreateRBFCompliantTxTooManyReplacements(
+				t, permitted,
+			)
+		})
+	}
+}
+
+func testCreateRBFCompliantTxTooManyReplacements(t *testing.T,
+	permitted bool) {
+
+	// Create a publisher using the mocks with a mempool watcher.
+	tp, m := createTestPublisher(t)
+
+	mempool := chainntnfs.NewMockMempoolWatcher()
+	defer mempool.AssertExpectations(t)
+	tp.cfg.Mempool = fn.Some[chainntnfs.MempoolWatcher](mempool)
+
+	// Create a request whose input is spent by a conflicting mempool tx
+	// paying 1000 sats in fees. Its deadline is resolved from the conf
+	// target at height 100.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
+		ConfTarget:      10,
+		CPFPFallback:    permitted,
+	}
+	require.NoError(t, req.resolveDeadline(100))
+	require.EqualValues(t, 110, req.DeadlineHeight)
+
+	// A block has passed since then.
+	tp.currentHeight.Store(101)
+
+	op := inp.OutPoint()
+	conflict := wire.NewMsgTx(2)
+	conflict.AddTxIn(wire.NewTxIn(&op, nil, nil))
+	conflict.AddTxOut(wire.NewTxOut(99_000, changePkScript.DeliveryAddress))
+
+	// The CPFP input spends an output of the conflicting tx.
+	childInput := createTestInput(99_000, input.WitnessKeyHash)
+	tp.cfg.CPFPInputSource = func(parent *wire.MsgTx) (input.Input,
+		error) {
+
+		require.Equal(t, conflict.TxHash(), parent.TxHash())
+
+		return &childInput, nil
+	}
+
+	// Mock the signer to always return a valid script, and the mempool to
+	// reject the replacement for evicting too many txns.
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000))
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(
+		chain.ErrTooManyReplacements).Once()
+
+	// The RBF attempts should stop immediately.
+	if !permitted {
+		err := tp.createRBFCompliantTx(1, req, m.feeFunc)
+		require.ErrorIs(t, err, ErrTooManyReplacements)
+		require.ErrorIs(t, err, chain.ErrTooManyReplacements)
+		require.ErrorContains(t, err, "not permitted")
+
+		return
+	}
+
+	// Mock the conflicting tx to be found, and the child tx to be
+	// accepted.
+	mempool.On("LookupInputMempoolSpend", op).Return(
+		fn.Some(*conflict)).Once()
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		chainfee.SatPerKWeight(1000), nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	err := tp.createRBFCompliantTx(1, req, m.feeFunc)
+	require.NoError(t, err)
+
+	// The child tx should be stored in place of the replacement, paying
+	// for the conflicting tx as its parent.
+	r, ok := tp.records.Load(1)
+	require.True(t, ok)
+	require.Equal(t, []input.Input{&childInput}, r.req.Inputs)
+	require.Equal(t, fn.Some(input.TxInfo{
+		Fee:    1000,
+		Weight: weightOf(conflict),
+	}), r.req.AnchorParent)
+	require.Len(t, r.tx.TxIn, 1)
+	require.Equal(t, childInput.OutPoint(), r.tx.TxIn[0].PreviousOutPoint)
+
+	// The caller's request is not mutated.
+	require.Equal(t, []input.Input{&inp}, req.Inputs)
+	require.True(t, req.AnchorParent.IsNone())
+}
+
+// TestTxPublisherBroadcast checks the internal `broadcast` method behaves as
+// expected.
+func TestTxPublisherBroadcast(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test bump request.
+	req := createTestBumpRequest()
+
+	// Create a test tx.
+	tx := &wire.MsgTx{LockTime: 1}
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	op := wire.OutPoint{
+		Hash:  chainhash.Hash{1},
+		Index: 0,
+	}
+	utxoIndex := map[wire.OutPoint]int{
+		op: 0,
+	}
+
+	// Create a testing record and put it in the map.
+	fee := btcutil.Amount(1000)
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+
+	// The published tx should report its weight estimated from the
+	// inputs and change script of the request.
+	weight, err := calcSweepTxWeight(
+		req.Inputs, [][]byte{changePkScript.DeliveryAddress},
+		nil,
+	)
+	require.NoError(t, err)
+
+	// Quickly check when the requestID cannot be found, an error is
+	// returned.
+	result, err := tp.broadcast(uint64(1000))
+	require.Error(t, err)
+	require.Nil(t, result)
+
+	testCases := []struct {
+		name           string
+		setupMock      func()
+		expectedErr    error
+		expectedResult *BumpResult
+	}{
+		{
+			// When the wallet cannot publish this tx, the error
+			// should be put inside the result.
+			name: "fail to publish",
+			setupMock: func() {
+				// Mock the wallet to fail to publish.
+				m.wallet.On("PublishTransaction",
+					tx, mock.Anything).Return(
+					errDummy).Once()
+			},
+			expectedErr: nil,
+			expectedResult: &BumpResult{
+				Event:     TxFailed,
+				Tx:        tx,
+				Fee:       fee,
+				FeeRate:   feerate,
+				Err:       errDummy,
+				requestID: requestID,
+			},
+		},
+		{
+			// When nothing goes wrong, the result is returned.
+			name: "publish success",
+			setupMock: func() {
+				// Mock the wallet to publish successfully.
+				m.wallet.On("PublishTransaction",
+					tx, mock.Anything).Return(nil).Once()
+			},
+			expectedErr: nil,
+			expectedResult: &BumpResult{
+				Event:     TxPublished,
+				Tx:        tx,
+				Fee:       fee,
+				FeeRate:   feerate,
+				Err:       nil,
+				Weight:    int64(weight),
+				VSize:     int64(weight.ToVB()),
+				requestID: requestID,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+
+			// Call the method under test.
+			result, err := tp.broadcast(requestID)
+
+			// Check the result is as expected.
+			require.ErrorIs(t, err, tc.expectedErr)
+			require.Equal(t, tc.expectedResult, result)
+		})
+	}
+}
+
+// TestTxPublisherBroadcastRetry checks the internal `broadcast` method retries
+// publishing the tx when it fails with a transient error.
+func TestTxPublisherBroadcastRetry(t *testing.T) {
+	t.Parallel()
+
+	// Create a dummy transient error.
+	errTransient := errors.New("backend unreachable")
+
+	testCases := []struct {
+		name          string
+		errs          []error
+		expectedEvent BumpEvent
+		expectedErr   error
+	}{
+		{
+			// When the publish fails twice with a transient error
+			// then succeeds, the tx should be published.
+			name:          "retry then succeed",
+			errs:          []error{errTransient, errTransient, nil},
+			expectedEvent: TxPublished,
+		},
+		{
+			// When the publish keeps failing with a transient
+			// error, we should give up after the max retries.
+			name: "retries exhausted",
+			errs: []error{
+				errTransient, errTransient, errTransient,
+				errTransient,
+			},
+			expectedEvent: TxFailed,
+			expectedErr:   errTransient,
+		},
+		{
+			// When the publish fails with a permanent error, we
+			// should not retry even if it's in the whitelist.
+			name:          "permanent error",
+			errs:          []error{chain.ErrInsufficientFee},
+			expectedEvent: TxFailed,
+			expectedErr:   chain.ErrInsufficientFee,
+		},
+		{
+			// When the publish fails with an unknown error, we
+			// should not retry.
+			name:          "unknown error",
+			errs:          []error{errDummy},
+			expectedEvent: TxFailed,
+			expectedErr:   errDummy,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a publisher using the mocks.
+			tp, m := createTestPublisher(t)
+			tp.cfg.MaxPublishRetries = 3
+			tp.cfg.PublishRetryBackoff = time.Millisecond
+			tp.cfg.PublishRetryErrors = []error{
+				errTransient, chain.ErrInsufficientFee,
+			}
+
+			// Create a testing record and put it in the map.
+			tx := &wire.MsgTx{LockTime: 1}
+			req := createTestBumpRequest()
+			requestID := uint64(1)
+			tp.storeRecord(
+				requestID, tx, req, m.feeFunc, 1000,
+				map[wire.OutPoint]int{},
+			)
+			m.feeFunc.On("FeeRate").Return(
+				chainfee.SatPerKWeight(1000))
+
+			// Mock the wallet to return the specified errors in
+			// order.
+			for _, err := range tc.errs {
+				m.wallet.On("PublishTransaction",
+					tx, mock.Anything).Return(err).Once()
+			}
+
+			// Call the method under test.
+			result, err := tp.broadcast(requestID)
+			require.NoError(t, err)
+
+			// Check the result is as expected.
+			require.Equal(t, tc.expectedEvent, result.Event)
+			require.ErrorIs(t, result.Err, tc.expectedErr)
+		})
+	}
+}
+
+// TestPublishWithRetryMaxBackoff checks the delay between the retries of a
+// publish attempt is doubled until it reaches the max backoff.
+func TestPublishWithRetryMaxBackoff(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using a mocked clock.
+	tp, m := createTestPublisher(t)
+	startTime := time.Unix(1_000_000, 0)
+	tickSignal := make(chan time.Duration)
+	testClock := clock.NewTestClockWithTickSignal(startTime, tickSignal)
+	tp.cfg.Clock = testClock
+
+	errTransient := errors.New("backend unreachable")
+	tp.cfg.MaxPublishRetries = 4
+	tp.cfg.PublishRetryBackoff = time.Second
+	tp.cfg.MaxPublishRetryBackoff = 3 * time.Second
+	tp.cfg.PublishRetryErrors = []error{errTransient}
+
+	// Mock the wallet to fail four times before the tx is published.
+	tx := &wire.MsgTx{LockTime: 1}
+	m.wallet.On("PublishTransaction", tx, mock.Anything).Return(
+		errTransient).Times(4)
+	m.wallet.On("PublishTransaction", tx, mock.Anything).Return(
+		nil).Once()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- tp.publishWithRetry(tx)
+	}()
+
+	// The backoff should be doubled and capped by the max backoff.
+	now := startTime
+	expected := []time.Duration{
+		time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second,
+	}
+	for _, backoff := range expected {
+		select {
+		case d := <-tickSignal:
+			require.Equal(t, backoff, d)
+
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for backoff")
+		}
+
+		now = now.Add(backoff)
+		testClock.SetTime(now)
+	}
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for publish")
+	}
+}
+
+// TestTxPublisherBroadcastRelayFeeBump checks the internal `broadcast` method
+// bumps the fee rate once and publishes the rebuilt tx when the min relay fee
+// is not met.
+func TestTxPublisherBroadcastRelayFeeBump(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{},
+	)
+
+	// Mock the fee function to be incremented once.
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000))
+	m.feeFunc.On("Increment", mock.Anything).Return(true, nil).Once()
+
+	// Mock the signer and the mempool check so the tx can be rebuilt.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Mock the wallet to reject the original tx due to the min relay fee,
+	// and accept the rebuilt tx.
+	m.wallet.On("PublishTransaction", tx, mock.Anything).Return(
+		lnwallet.ErrMempoolFee).Once()
+	m.wallet.On("PublishTransaction", mock.Anything, mock.Anything).Return(
+		nil).Once()
+
+	// Call the method under test.
+	result, err := tp.broadcast(requestID)
+	require.NoError(t, err)
+
+	// We expect the rebuilt tx to be published.
+	require.Equal(t, TxPublished, result.Event)
+	require.NoError(t, result.Err)
+	require.NotEqual(t, tx.TxHash(), result.Tx.TxHash())
+
+	// The record should be updated with the rebuilt tx, along with the
+	// reason of the replacement.
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, result.Tx, record.tx)
+	require.Equal(t, ReplaceReasonMempoolFee, record.replaceReason)
+}
+
+// TestTxPublisherBroadcastRelayFeeBumpLogger checks the log lines about the
+// tx rebuilt for the min relay fee are prefixed with its own txid.
+//
+// NOTE: this test must not run in parallel as it replaces the package logger.
+func TestTxPublisherBroadcastRelayFeeBumpLogger(t *testing.T) {
+	// Capture the log lines using a buffer.
+	var buf bytes.Buffer
+	logger := btclog.NewSLogger(btclog.NewDefaultHandler(&buf))
+	logger.SetLevel(btclog.LevelTrace)
+
+	oldLogger := log
+	UseLogger(logger)
+	t.Cleanup(func() {
+		UseLogger(oldLogger)
+	})
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{},
+	)
+
+	// Mock the fee function to be incremented once.
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000))
+	m.feeFunc.On("Increment", mock.Anything).Return(true, nil).Once()
+
+	// Mock the signer and the mempool check so the tx can be rebuilt.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Mock the wallet to reject the original tx due to the min relay fee,
+	// and accept the rebuilt tx.
+	m.wallet.On("PublishTransaction", tx, mock.Anything).Return(
+		lnwallet.ErrMempoolFee).Once()
+	m.wallet.On("PublishTransaction", mock.Anything, mock.Anything).Return(
+		nil).Once()
+
+	// Call the method under test.
+	result, err := tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxPublished, result.Event)
+
+	// The lines about the rebuilt tx should carry its txid.
+	prefix := fmt.Sprintf("TxPublisher(reqID=%d, txid=%v):", requestID,
+		result.Tx.TxHash())
+
+	var rebuiltLines int
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "Rebuilt tx") ||
+			strings.Contains(line, "Publishing sweep tx "+
+				result.Tx.TxHash().String()) {
+
+			require.Contains(t, line, prefix)
+			rebuiltLines++
+		}
+	}
+	require.Equal(t, 2, rebuiltLines)
+}
+
+// recordingAuxSweeper is an aux sweeper that records the txns it's notified
+// of.
+type recordingAuxSweeper struct {
+	MockAuxSweeper
+
+	notified []*wire.MsgTx
+}
+
+// NotifyBroadcast records the tx being broadcast.
+func (r *recordingAuxSweeper) NotifyBroadcast(_ *BumpRequest, tx *wire.MsgTx,
+	_ btcutil.Amount, _ map[wire.OutPoint]int) error {
+
+	r.notified = append(r.notified, tx)
+
+	return nil
+}
+
+// TestTxPublisherBroadcastPreBroadcastHook checks the pre-broadcast hook can
+// veto or allow the broadcast of a tx, and the aux sweeper is only notified
+// of the allowed broadcast.
+func TestTxPublisherBroadcastPreBroadcastHook(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		hookErr       error
+		expectedEvent BumpEvent
+	}{
+		{
+			// When the hook vetoes the tx, it should not be
+			// published and the hook's error is returned.
+			name:          "vetoed",
+			hookErr:       errDummy,
+			expectedEvent: TxFailed,
+		},
+		{
+			// When the hook allows the tx, it should be published.
+			name:          "allowed",
+			expectedEvent: TxPublished,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a publisher using the mocks.
+			tp, m := createTestPublisher(t)
+
+			// Create a testing record and put it in the map.
+			tx := &wire.MsgTx{LockTime: 1}
+			req := createTestBumpRequest()
+			requestID := uint64(1)
+			tp.storeRecord(
+				requestID, tx, req, m.feeFunc, 1000,
+				map[wire.OutPoint]int{},
+			)
+			m.feeFunc.On("FeeRate").Return(
+				chainfee.SatPerKWeight(1000))
+
+			// Install a hook that records the tx it's called
+			// with, and an aux sweeper to record the broadcast
+			// notifications.
+			var hookedTx *wire.MsgTx
+			tp.cfg.PreBroadcastHook = func(tx *wire.MsgTx) error {
+				hookedTx = tx
+				return tc.hookErr
+			}
+
+			aux := &recordingAuxSweeper{}
+			tp.cfg.AuxSweeper = fn.Some[AuxSweeper](aux)
+
+			// The tx should only be published when allowed.
+			if tc.hookErr == nil {
+				m.wallet.On("PublishTransaction",
+					tx, mock.Anything).Return(nil).Once()
+			}
+
+			// Call the method under test.
+			result, err := tp.broadcast(requestID)
+			require.NoError(t, err)
+
+			// Check the result is as expected.
+			require.Equal(t, tx, hookedTx)
+			require.Equal(t, tc.expectedEvent, result.Event)
+			require.ErrorIs(t, result.Err, tc.hookErr)
+
+			// The aux sweeper should only be notified when the
+			// broadcast is allowed.
+			if tc.hookErr != nil {
+				require.Empty(t, aux.notified)
+			} else {
+				require.Equal(
+					t, []*wire.MsgTx{tx}, aux.notified,
+				)
+			}
+		})
+	}
+}
+
+// TestTxPublisherBroadcastInputAlreadyTracked checks a request that includes
+// an input already being swept by another request is rejected.
+func TestTxPublisherBroadcastInputAlreadyTracked(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Broadcast the first request, which is now tracked.
+	req1 := createTestBumpRequest()
+	tp.Broadcast(req1)
+
+	shared := req1.Inputs[0].OutPoint()
+	require.Equal(t, map[wire.OutPoint]uint64{shared: 1},
+		tp.TrackedOutpoints())
+
+	// Create a second request that shares the input with the first one.
+	other := createTestInput(1000, input.WitnessKeyHash)
+	req2 := createTestBumpRequest()
+	req2.Inputs = []input.Input{&other, req1.Inputs[0]}
+
+	// The second request should be rejected.
+	resultChan := tp.Broadcast(req2)
+	select {
+	case result := <-resultChan:
+		require.Equal(t, TxFailed, result.Event)
+		require.ErrorIs(t, result.Err, ErrInputAlreadyTracked)
+		require.ErrorContains(t, result.Err, shared.String())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for result")
+	}
+
+	// Only the first request should be tracked.
+	require.Equal(t, map[wire.OutPoint]uint64{shared: 1},
+		tp.TrackedOutpoints())
+}
+
+// TestStoreInitialRecordConcurrent checks that when requests sharing an input
+// are stored concurrently, only one of them is stored, and the others are
+// rejected.
+func TestStoreInitialRecordConcurrent(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Create an input shared by all the requests.
+	shared := createTestInput(1000, input.WitnessKeyHash)
+
+	const numRequests = 10
+
+	var (
+		wg       sync.WaitGroup
+		stored   atomic.Int32
+		rejected atomic.Int32
+	)
+	for i := 0; i < numRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			other := createTestInput(1000, input.WitnessKeyHash)
+			req := createTestBumpRequest()
+			req.Inputs = []input.Input{&other, &shared}
+
+			_, _, err := tp.storeInitialRecord(req)
+			if err == nil {
+				stored.Add(1)
+
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInputAlreadyTracked)
+			rejected.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	// Only one request should be stored.
+	require.EqualValues(t, 1, stored.Load())
+	require.EqualValues(t, numRequests-1, rejected.Load())
+	require.Equal(t, 1, tp.records.Len())
+}
+
+// TestTxPublisherIsTracking checks IsTracking reports the request sweeping a
+// tracked outpoint, and follows the inputs of the records as they change.
+func TestTxPublisherIsTracking(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Broadcast a request, which is now tracked.
+	req := createTestBumpRequest()
+	tp.Broadcast(req)
+	requestID := tp.requestCounter.Load()
+
+	tracked := req.Inputs[0].OutPoint()
+	id, ok := tp.IsTracking(tracked)
+	require.True(t, ok)
+	require.Equal(t, requestID, id)
+
+	// An untracked outpoint is not reported.
+	other := createTestInput(1000, input.WitnessKeyHash)
+	_, ok = tp.IsTracking(other.OutPoint())
+	require.False(t, ok)
+
+	// Replace the inputs of the record, the index should follow.
+	r, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+
+	updatedReq := *r.req
+	updatedReq.Inputs = []input.Input{&other}
+	updated := *r
+	updated.req = &updatedReq
+	tp.records.Store(requestID, &updated)
+
+	_, ok = tp.IsTracking(tracked)
+	require.False(t, ok)
+
+	id, ok = tp.IsTracking(other.OutPoint())
+	require.True(t, ok)
+	require.Equal(t, requestID, id)
+
+	// Once the record is removed, nothing is tracked.
+	tp.records.Delete(requestID)
+
+	_, ok = tp.IsTracking(other.OutPoint())
+	require.False(t, ok)
+	require.Empty(t, tp.TrackedOutpoints())
+}
+
+// TestTxPublisherBroadcastIdempotencyKey checks a request broadcast using the
+// same idempotency key as a monitored request returns the existing
+// subscription, and the key is removed once the request is removed.
+func TestTxPublisherBroadcastIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Broadcast the first request using a key.
+	req1 := createTestBumpRequest()
+	req1.IdempotencyKey = "sweep-1"
+	resultChan1 := tp.Broadcast(req1)
+
+	requestID, ok := tp.RequestIDByKey("sweep-1")
+	require.True(t, ok)
+	require.Equal(t, tp.requestCounter.Load(), requestID)
+
+	// Broadcasting the same logical sweep again should return the same
+	// chan without creating a new record.
+	req2 := createTestBumpRequest()
+	req2.Inputs = req1.Inputs
+	req2.IdempotencyKey = "sweep-1"
+	resultChan2 := tp.Broadcast(req2)
+	require.Equal(t, resultChan1, resultChan2)
+	require.Equal(t, requestID, tp.requestCounter.Load())
+	require.Equal(t, 1, tp.records.Len())
+
+	id, ok := tp.RequestIDByKey("sweep-1")
+	require.True(t, ok)
+	require.Equal(t, requestID, id)
+
+	// A different key creates a new record.
+	req3 := createTestBumpRequest()
+	req3.IdempotencyKey = "sweep-2"
+	resultChan3 := tp.Broadcast(req3)
+	require.NotEqual(t, resultChan1, resultChan3)
+	require.Equal(t, 2, tp.records.Len())
+
+	id, ok = tp.RequestIDByKey("sweep-2")
+	require.True(t, ok)
+	require.Equal(t, requestID+1, id)
+
+	// Once the first request is removed, its key is removed too, so the
+	// same key creates a new record.
+	tp.removeResult(&BumpResult{
+		Event:     TxFatal,
+		Err:       errDummy,
+		requestID: requestID,
+	})
+	_, ok = tp.RequestIDByKey("sweep-1")
+	require.False(t, ok)
+
+	resultChan4 := tp.Broadcast(req2)
+	require.NotEqual(t, resultChan1, resultChan4)
+
+	id, ok = tp.RequestIDByKey("sweep-1")
+	require.True(t, ok)
+	require.Equal(t, requestID+2, id)
+}
+
+// TestTxPublisherBroadcastRequestLogger checks the log lines emitted for a
+// broadcast are prefixed with the requestID and the txid.
+//
+// NOTE: this test must not run in parallel as it replaces the package logger.
+func TestTxPublisherBroadcastRequestLogger(t *testing.T) {
+	// Capture the log lines using a buffer.
+	var buf bytes.Buffer
+	logger := btclog.NewSLogger(btclog.NewDefaultHandler(&buf))
+	logger.SetLevel(btclog.LevelTrace)
+
+	oldLogger := log
+	UseLogger(logger)
+	t.Cleanup(func() {
+		UseLogger(oldLogger)
+	})
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(7)
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{},
+	)
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000))
+
+	// Mock the wallet to publish successfully.
+	m.wallet.On("PublishTransaction", tx, mock.Anything).Return(nil).Once()
+
+	// Call the method under test.
+	result, err := tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxPublished, result.Event)
+
+	// The emitted lines should carry the requestID and the txid.
+	prefix := fmt.Sprintf("TxPublisher(reqID=%d, txid=%v):", requestID,
+		tx.TxHash())
+	require.Contains(t, buf.String(), prefix)
+}
+
+// TestTxPublisherBroadcastMultipleBackends checks a tx is published to the
+// extra broadcasters along with the wallet, and the broadcast succeeds if any
+// of them accepts it.
+func TestTxPublisherBroadcastMultipleBackends(t *testing.T) {
+	t.Parallel()
+
+	errPublish := errors.New("publish failed")
+
+	testCases := []struct {
+		name          string
+		walletErr     error
+		backendErrs   []error
+		expectedEvent BumpEvent
+		expectedErr   error
+	}{
+		{
+			name:          "one backend succeeds",
+			walletErr:     errPublish,
+			backendErrs:   []error{errPublish, nil},
+			expectedEvent: TxPublished,
+		},
+		{
+			name:          "already in mempool",
+			walletErr:     errPublish,
+			backendErrs:   []error{chain.ErrTxAlreadyInMempool},
+			expectedEvent: TxPublished,
+		},
+		{
+			name:          "all fail",
+			walletErr:     errPublish,
+			backendErrs:   []error{errDummy, errDummy},
+			expectedEvent: TxFailed,
+			expectedErr:   errPublish,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a publisher using the mocks.
+			tp, m := createTestPublisher(t)
+
+			tx := &wire.MsgTx{LockTime: 1}
+			m.wallet.On("PublishTransaction", tx,
+				mock.Anything).Return(tc.walletErr).Once()
+
+			// Create the extra broadcasters, which must all be
+			// called.
+			for _, err := range tc.backendErrs {
+				backend := &MockWallet{}
+				backend.On("PublishTransaction", tx,
+					mock.Anything).Return(err).Once()
+				t.Cleanup(func() {
+					backend.AssertExpectations(t)
+				})
+
+				tp.cfg.Broadcasters = append(
+					tp.cfg.Broadcasters, backend,
+				)
+			}
+
+			// Create a testing record and put it in the map.
+			req := createTestBumpRequest()
+			requestID := uint64(1)
+			tp.storeRecord(
+				requestID, tx, req, m.feeFunc, 1000,
+				map[wire.OutPoint]int{},
+			)
+			m.feeFunc.On("FeeRate").Return(
+				chainfee.SatPerKWeight(1000))
+
+			// Call the method under test.
+			result, err := tp.broadcast(requestID)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEvent, result.Event)
+			require.ErrorIs(t, result.Err, tc.expectedErr)
+		})
+	}
+}
+
+// TestTxPublisherBroadcastRateLimit checks that broadcasts are spread out by
+// the rate limiter.
+func TestTxPublisherBroadcastRateLimit(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks with a limit of 20 broadcasts per
+	// second.
+	tp, m := createTestPublisher(t)
+	tp.broadcastLimiter = rate.NewLimiter(20, 1)
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+
+	// Mock the publish to succeed.
+	m.feeFunc.On("FeeRate").Return(chainfee.FeePerKwFloor)
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil)
+
+	// Broadcast the tx five times. The first one is allowed immediately,
+	// and each of the following ones waits for 50ms.
+	const numBroadcasts = 5
+	start := time.Now()
+	for i := 0; i < numBroadcasts; i++ {
+		result, err := tp.broadcast(requestID)
+		require.NoError(t, err)
+		require.Equal(t, TxPublished, result.Event)
+	}
+
+	require.GreaterOrEqual(t, time.Since(start),
+		(numBroadcasts-1)*50*time.Millisecond)
+}
+
+// TestWaitBroadcastLimitQuit checks that waiting for the rate limiter is
+// interrupted when the publisher is shutting down.
+func TestWaitBroadcastLimitQuit(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher with a limit of one broadcast per minute.
+	tp, _ := createTestPublisher(t)
+	tp.broadcastLimiter = rate.NewLimiter(rate.Every(time.Minute), 1)
+
+	// The first broadcast is allowed immediately.
+	require.NoError(t, tp.waitBroadcastLimit())
+
+	// The second one blocks until the publisher is stopped.
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- tp.waitBroadcastLimit()
+	}()
+
+	close(tp.quit)
+
+	select {
+	case err := <-errChan:
+		require.ErrorIs(t, err, ErrPublisherStopped)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for rate limiter to be interrupted")
+	}
+}
+
+// TestBroadcastWithContextCancel checks that cancelling the context used to
+// broadcast a request removes its record, stops watching its confirmation and
+// sends a TxCancelled event.
+func TestBroadcastWithContextCancel(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// A request using a cancelled context should be rejected.
+	req := createTestBumpRequest()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := tp.BroadcastWithContext(ctx, req)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, tp.records.Len())
+
+	// Now broadcast the request using a new context.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	subscriber, err := tp.BroadcastWithContext(ctx, req)
+	require.NoError(t, err)
+
+	// Mock the tx being published and confirmed, so its confirmation is
+	// being watched.
+	requestID := tp.requestCounter.Load()
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+	record := tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	ntfnCancelled := make(chan struct{})
+	confEvent := chainntnfs.NewConfirmationEvent(1, func() {
+		close(ntfnCancelled)
+	})
+	registered := make(chan struct{})
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(confEvent, nil).Once().Run(
+		func(mock.Arguments) {
+			close(registered)
+		})
+
+	reorgWatched := make(chan struct{})
+	go func() {
+		defer close(reorgWatched)
+		tp.watchReorg(record, requestID, &BumpResult{
+			Event:     TxConfirmed,
+			Tx:        tx,
+			requestID: requestID,
+		})
+	}()
+
+	// Wait for the conf ntfn to be registered.
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for conf ntfn registration")
+	}
+
+	// Cancel the context, which should send a TxCancelled event.
+	cancel()
+
+	select {
+	case result := <-subscriber:
+		require.Equal(t, TxCancelled, result.Event)
+		require.ErrorIs(t, result.Err, context.Canceled)
+		require.Equal(t, tx, result.Tx)
+		require.Equal(t, feerate, result.FeeRate)
+		require.NoError(t, result.Validate())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for cancelled result")
+	}
+
+	// The conf ntfn should be cancelled and the reorg watcher exit.
+	select {
+	case <-reorgWatched:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for reorg watcher to exit")
+	}
+
+	select {
+	case <-ntfnCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("conf ntfn not cancelled")
+	}
+
+	// The request should be cleaned up.
+	require.Zero(t, tp.records.Len())
+	require.Zero(t, tp.subscriberChans.Len())
+	require.Zero(t, tp.doneChans.Len())
+}
+
+// TestRemoveResult checks the records and subscriptions are removed when a tx
+// is confirmed or failed.
+func TestRemoveResult(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test bump request.
+	req := createTestBumpRequest()
+
+	// Create a test tx.
+	tx := &wire.MsgTx{LockTime: 1}
+
+	// Create a testing record and put it in the map.
+	fee := btcutil.Amount(1000)
+
+	op := wire.OutPoint{
+		Hash:  chainhash.Hash{1},
+		Index: 0,
+	}
+	utxoIndex := map[wire.OutPoint]int{
+		op: 0,
+	}
+
+	// Create a test request ID counter.
+	requestCounter := atomic.Uint64{}
+
+	testCases := []struct {
+		name        string
+		setupRecord func() uint64
+		result      *BumpResult
+		removed     bool
+	}{
+		{
+			// When the tx is confirmed, the records will be
+			// removed.
+			name: "remove on TxConfirmed",
+			setupRecord: func() uint64 {
+				rid := requestCounter.Add(1)
+				tp.storeRecord(
+					rid, tx, req, m.feeFunc, fee, utxoIndex,
+				)
+				tp.subscriberChans.Store(rid, nil)
+
+				return rid
+			},
+			result: &BumpResult{
+				Event: TxConfirmed,
+				Tx:    tx,
+			},
+			removed: true,
+		},
+		{
+			// When the tx is failed, the records will be removed.
+			name: "remove on TxFailed",
+			setupRecord: func() uint64 {
+				rid := requestCounter.Add(1)
+				tp.storeRecord(
+					rid, tx, req, m.feeFunc, fee, utxoIndex,
+				)
+				tp.subscriberChans.Store(rid, nil)
+
+				return rid
+			},
+			result: &BumpResult{
+				Event: TxFailed,
+				Err:   errDummy,
+				Tx:    tx,
+			},
+			removed: true,
+		},
+		{
+			// Noop when the tx is neither confirmed or failed.
+			name: "noop when tx is not confirmed or failed",
+			setupRecord: func() uint64 {
+				rid := requestCounter.Add(1)
+				tp.storeRecord(
+					rid, tx, req, m.feeFunc, fee, utxoIndex,
+				)
+				tp.subscriberChans.Store(rid, nil)
+
+				return rid
+			},
+			result: &BumpResult{
+				Event: TxPublished,
+				Tx:    tx,
+			},
+			removed: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			requestID := tc.setupRecord()
+
+			// Attach the requestID from the setup.
+			tc.result.requestID = requestID
+
+			// Remove the result.
+			tp.removeResult(tc.result)
+
+			// Check if the record is removed.
+			_, found := tp.records.Load(requestID)
+			require.Equal(t, !tc.removed, found)
+
+			_, found = tp.subscriberChans.Load(requestID)
+			require.Equal(t, !tc.removed, found)
+		})
+	}
+}
+
+// TestNotifyResult checks the subscribers are notified when a result is sent.
+func TestNotifyResult(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test bump request.
+	req := createTestBumpRequest()
+
+	// Create a test tx.
+	tx := &wire.MsgTx{LockTime: 1}
+
+	op := wire.OutPoint{
+		Hash:  chainhash.Hash{1},
+		Index: 0,
+	}
+	utxoIndex := map[wire.OutPoint]int{
+		op: 0,
+	}
+
+	// Create a testing record and put it in the map.
+	fee := btcutil.Amount(1000)
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+
+	// Create a subscription to the event.
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Create a test result.
+	result := &BumpResult{
+		requestID: requestID,
+		Tx:        tx,
+	}
+
+	// Notify the result and expect the subscriber to receive it.
+	//
+	// NOTE: must be done inside a goroutine in case it blocks.
+	go tp.notifyResult(result)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case received := <-subscriber:
+		require.Equal(t, result, received)
+	}
+
+	// Notify two results. This time it should block because the channel is
+	// full. We then shutdown TxPublisher to test the quit behavior.
+	done := make(chan struct{})
+	go func() {
+		// Call notifyResult twice, which blocks at the second call.
+		tp.notifyResult(result)
+		tp.notifyResult(result)
+
+		close(done)
+	}()
+
+	// Shutdown the publisher and expect notifyResult to exit.
+	close(tp.quit)
+
+	// We expect to done chan.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for notifyResult to exit")
+
+	case <-done:
+	}
+}
+
+// TestBroadcast checks the public `Broadcast` method can successfully register
+// a broadcast request.
+func TestBroadcast(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Create a test feerate.
+	feerate := chainfee.SatPerKWeight(1000)
+
+	// Create a test request.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	// Create a testing bump request.
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      feerate * 10,
+		DeadlineHeight:  10,
+	}
+
+	// Send the req and expect no error.
+	resultChan := tp.Broadcast(req)
+	require.NotNil(t, resultChan)
+
+	// Validate the record was stored.
+	require.Equal(t, 1, tp.records.Len())
+	require.Equal(t, 1, tp.subscriberChans.Len())
+
+	// Validate the record.
+	rid := tp.requestCounter.Load()
+	record, found := tp.records.Load(rid)
+	require.True(t, found)
+	require.Equal(t, req, record.req)
+}
+
+// TestBroadcastImmediate checks the public `Broadcast` method can successfully
+// register a broadcast request and publish the tx when `Immediate` flag is
+// set.
+func TestBroadcastImmediate(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate.
+	feerate := chainfee.SatPerKWeight(1000)
+
+	// Create a test request.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	// Create a testing bump request.
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      feerate * 10,
+		DeadlineHeight:  10,
+		Immediate:       true,
+	}
+
+	// Mock the fee estimator to return an error.
+	//
+	// NOTE: We are not testing `handleInitialBroadcast` here, but only
+	// interested in checking that this method is indeed called when
+	// `Immediate` is true. Thus we mock the method to return an error to
+	// quickly abort. As long as this mocked method is called, we know the
+	// `Immediate` flag works.
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+
+	// Send the req and expect no error.
+	resultChan := tp.Broadcast(req)
+	require.NotNil(t, resultChan)
+
+	// Validate the record was removed due to an error returned in initial
+	// broadcast.
+	require.Empty(t, tp.records.Len())
+	require.Empty(t, tp.subscriberChans.Len())
+}
+
+// TestCreateAnPublishFail checks all the error cases are handled properly in
+// the method createAndPublish.
+func TestCreateAnPublishFail(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test requestID.
+	requestID := uint64(1)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Create a testing monitor record.
+	req := createTestBumpRequest()
+
+	// Overwrite the budget to make it smaller than the fee.
+	req.Budget = 100
+	record := &monitorRecord{
+		req:         req,
+		feeFunction: m.feeFunc,
+		tx:          &wire.MsgTx{},
+	}
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	// Call the createAndPublish method.
+	resultOpt := tp.createAndPublishTx(
+		requestID, record, ReplaceReasonDeadline,
+	)
+	result := resultOpt.UnwrapOrFail(t)
+
+	// We expect the result to be TxFailed and the error is set in the
+	// result.
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, ErrNotEnoughBudget)
+	require.Equal(t, requestID, result.requestID)
+
+	// Increase the budget and call it again. This time we will mock an
+	// error to be returned from CheckMempoolAcceptance.
+	req.Budget = 1000
+
+	// Mock the testmempoolaccept to return a fee related error that should
+	// be ignored.
+	m.wallet.On("CheckMempoolAcceptance",
+		mock.Anything).Return(lnwallet.ErrMempoolFee).Once()
+
+	// Call the createAndPublish method and expect a none option.
+	resultOpt = tp.createAndPublishTx(
+		requestID, record, ReplaceReasonDeadline,
+	)
+	require.True(t, resultOpt.IsNone())
+
+	// Mock the testmempoolaccept to return a fee related error that should
+	// be ignored.
+	m.wallet.On("CheckMempoolAcceptance",
+		mock.Anything).Return(chain.ErrInsufficientFee).Once()
+
+	// Call the createAndPublish method and expect a none option.
+	resultOpt = tp.createAndPublishTx(
+		requestID, record, ReplaceReasonDeadline,
+	)
+	require.True(t, resultOpt.IsNone())
+}
+
+// TestCreateAnPublishSuccess checks the expected result is returned from the
+// method createAndPublish.
+func TestCreateAnPublishSuccess(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test requestID.
+	requestID := uint64(1)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Create a testing monitor record.
+	req := createTestBumpRequest()
+	record := &monitorRecord{
+		req:         req,
+		feeFunction: m.feeFunc,
+		tx:          &wire.MsgTx{},
+	}
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	// Mock the testmempoolaccept to return nil.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Mock the wallet to publish and return an error.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(errDummy).Once()
+
+	// Call the createAndPublish method and expect a failure result.
+	resultOpt := tp.createAndPublishTx(
+		requestID, record, ReplaceReasonDeadline,
+	)
+	result := resultOpt.UnwrapOrFail(t)
+
+	// We expect the result to be TxFailed and the error is set.
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, errDummy)
+
+	// Although the replacement tx was failed to be published, the record
+	// should be stored.
+	require.NotNil(t, result.Tx)
+	require.NotNil(t, result.ReplacedTx)
+	_, found := tp.records.Load(requestID)
+	require.True(t, found)
+
+	// We now check a successful RBF.
+	//
+	// Mock the wallet to publish successfully.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Call the createAndPublish method and expect a success result.
+	resultOpt = tp.createAndPublishTx(
+		requestID, record, ReplaceReasonDeadline,
+	)
+	result = resultOpt.UnwrapOrFail(t)
+	require.True(t, resultOpt.IsSome())
+
+	// We expect the result to be TxReplaced and the error is nil.
+	require.Equal(t, TxReplaced, result.Event)
+	require.Nil(t, result.Err)
+
+	// Check the Tx and ReplacedTx are set.
+	require.NotNil(t, result.Tx)
+	require.NotNil(t, result.ReplacedTx)
+
+	// Check the record is stored.
+	_, found = tp.records.Load(requestID)
+	require.True(t, found)
+}
+
+// TestBumpHistory checks the history of a request records its initial tx and
+// each replacement in order, while failed broadcasts are not recorded.
+func TestBumpHistory(t *testing.T) {
+	t.Parallel()
+
+	tp, m := createTestPublisher(t)
+
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Use a linear fee function increasing by 250 sat/kw per step.
+	f, err := NewLinearFeeFunction(
+		2000, 5, nil, fn.Some(chainfee.SatPerKWeight(1000)),
+	)
+	require.NoError(t, err)
+
+	// An unknown request has no history.
+	_, err = tp.History(1)
+	require.ErrorIs(t, err, ErrRequestNotFound)
+
+	// Create and broadcast the initial tx.
+	requestID := uint64(1)
+	req := createTestBumpRequest()
+	sweepCtx, err := tp.createAndCheckTx(req, f, log)
+	require.NoError(t, err)
+
+	tp.currentHeight.Store(100)
+	tp.storeRecord(
+		requestID, sweepCtx.tx, req, f, sweepCtx.fee,
+		sweepCtx.outpointToTxIndex,
+	)
+
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+	result, err := tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxPublished, result.Event)
+
+	expected := []BumpHistoryEntry{{
+		Height:  100,
+		Txid:    sweepCtx.tx.TxHash(),
+		FeeRate: 1000,
+		Fee:     sweepCtx.fee,
+	}}
+
+	// replace bumps the fee rate at the given height and returns the
+	// result of the replacement.
+	replace := func(height int32, reason ReplaceReason) *BumpResult {
+		tp.currentHeight.Store(height)
+
+		_, err := f.Increment(0)
+		require.NoError(t, err)
+
+		record, ok := tp.records.Load(requestID)
+		require.True(t, ok)
+
+		resultOpt := tp.createAndPublishTx(requestID, record, reason)
+		result := resultOpt.UnwrapOrFail(t)
+
+		return &result
+	}
+
+	// Drive two successful replacements.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Twice()
+
+	result = replace(101, ReplaceReasonDeadline)
+	require.Equal(t, TxReplaced, result.Event)
+	expected = append(expected, BumpHistoryEntry{
+		Height:        101,
+		Txid:          result.Tx.TxHash(),
+		FeeRate:       1250,
+		Fee:           result.Fee,
+		ReplaceReason: ReplaceReasonDeadline,
+	})
+
+	result = replace(102, ReplaceReasonForced)
+	require.Equal(t, TxReplaced, result.Event)
+	expected = append(expected, BumpHistoryEntry{
+		Height:        102,
+		Txid:          result.Tx.TxHash(),
+		FeeRate:       1500,
+		Fee:           result.Fee,
+		ReplaceReason: ReplaceReasonForced,
+	})
+
+	history, err := tp.History(requestID)
+	require.NoError(t, err)
+	require.Equal(t, expected, history)
+
+	// A replacement that fails to be published is not recorded.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(errDummy).Once()
+
+	result = replace(103, ReplaceReasonDeadline)
+	require.Equal(t, TxFailed, result.Event)
+
+	history, err = tp.History(requestID)
+	require.NoError(t, err)
+	require.Equal(t, expected, history)
+}
+
+// TestOnFirstBroadcast checks the OnFirstBroadcast callback fires once on the
+// first successful broadcast, and not on the replacements.
+func TestOnFirstBroadcast(t *testing.T) {
+	t.Parallel()
+
+	tp, m := createTestPublisher(t)
+
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	f, err := NewLinearFeeFunction(
+		2000, 5, nil, fn.Some(chainfee.SatPerKWeight(1000)),
+	)
+	require.NoError(t, err)
+
+	// Create a request whose callback sends the txid to a chan.
+	fired := make(chan chainhash.Hash, 3)
+	req := createTestBumpRequest()
+	req.OnFirstBroadcast = func(txid chainhash.Hash) {
+		fired <- txid
+	}
+
+	sweepCtx, err := tp.createAndCheckTx(req, f, log)
+	require.NoError(t, err)
+
+	requestID := uint64(1)
+	tp.storeRecord(
+		requestID, sweepCtx.tx, req, f, sweepCtx.fee,
+		sweepCtx.outpointToTxIndex,
+	)
+
+	// A failed broadcast doesn't fire the callback.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(errDummy).Once()
+	result, err := tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxFailed, result.Event)
+
+	// The first successful broadcast fires the callback.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Times(3)
+	result, err = tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxPublished, result.Event)
+
+	select {
+	case txid := <-fired:
+		require.Equal(t, sweepCtx.tx.TxHash(), txid)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for callback")
+	}
+
+	// Drive two replacements, which shouldn't fire the callback.
+	for i := 0; i < 2; i++ {
+		_, err := f.Increment(0)
+		require.NoError(t, err)
+
+		record, ok := tp.records.Load(requestID)
+		require.True(t, ok)
+
+		resultOpt := tp.createAndPublishTx(
+			requestID, record, ReplaceReasonDeadline,
+		)
+		require.Equal(t, TxReplaced, resultOpt.UnwrapOrFail(t).Event)
+	}
+
+	select {
+	case txid := <-fired:
+		t.Fatalf("callback fired again with txid=%v", txid)
+
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHandleTxConfirmed checks the expected result is returned from the method
+// handleTxConfirmed.
+func TestHandleTxConfirmed(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test bump request.
+	req := createTestBumpRequest()
+
+	// Create a test tx.
+	tx := &wire.MsgTx{LockTime: 1}
+
+	op := wire.OutPoint{
+		Hash:  chainhash.Hash{1},
+		Index: 0,
+	}
+	utxoIndex := map[wire.OutPoint]int{
+		op: 0,
+	}
+
+	// Create a testing record and put it in the map.
+	fee := btcutil.Amount(1000)
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+
+	// Create a subscription to the event.
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Mock the fee function to return a fee rate.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate).Once()
+
+	// Mock the notifier to return a conf event which tells the tx is
+	// already safe from reorgs.
+	txid := tx.TxHash()
+	confEvent := chainntnfs.NewConfirmationEvent(1, func() {})
+	confEvent.Done <- struct{}{}
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(confEvent, nil).Once()
+
+	// Call the method and expect a result to be received.
+	//
+	// NOTE: must be called in a goroutine in case it blocks.
+	var reorgSafe <-chan struct{}
+	tp.wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		tp.handleTxConfirmed(record, requestID)
+		close(done)
+	}()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-subscriber:
+		// We expect the result to be TxConfirmed and the tx is set.
+		require.Equal(t, TxConfirmed, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.Nil(t, result.Err)
+		require.Equal(t, requestID, result.requestID)
+		require.Equal(t, record.fee, result.Fee)
+		require.Equal(t, feerate, result.FeeRate)
+		reorgSafe = result.reorgSafe
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for handleTxConfirmed to return")
+	}
+
+	// The tx is safe from reorgs, so the chan should be closed.
+	select {
+	case <-reorgSafe:
+	default:
+		t.Fatal("expected reorgSafe to be closed")
+	}
+
+	// We expect the record to be removed from the maps.
+	_, found := tp.records.Load(requestID)
+	require.False(t, found)
+	_, found = tp.subscriberChans.Load(requestID)
+	require.False(t, found)
+}
+
+// TestAltConfTargetSchedules checks a request specifying an AltConfTarget
+// tracks both fee schedules, and the confirmed result notes which one bound
+// the fee rate.
+func TestAltConfTargetSchedules(t *testing.T) {
+	t.Parallel()
+
+	tp, m := createTestPublisher(t)
+
+	// The deadline gives a conf target of 10 blocks, while the
+	// alternative conf target is 2 blocks, which requires a higher fee
+	// rate.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 10
+	req.Budget = 100_000
+	req.AltConfTarget = 2
+
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+	m.estimator.On("EstimateFeePerKW", uint32(10)).Return(
+		chainfee.SatPerKWeight(1000), nil)
+	m.estimator.On("EstimateFeePerKW", uint32(2)).Return(
+		chainfee.SatPerKWeight(3000), nil)
+
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+
+	// The record should carry both schedules, using the higher fee rate.
+	dual, ok := f.(*dualFeeFunction)
+	require.True(t, ok)
+	require.EqualValues(t, 1000, dual.primary.FeeRate())
+	require.EqualValues(t, 3000, dual.alt.FeeRate())
+	require.EqualValues(t, 3000, f.FeeRate())
+	require.Equal(t, FeeScheduleAlt, dual.Bound())
+
+	tx := &wire.MsgTx{LockTime: 1}
+	requestID := uint64(1)
+	record := tp.storeRecord(requestID, tx, req, f, 1000, nil)
+
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	txid := tx.TxHash()
+	confEvent := chainntnfs.NewConfirmationEvent(1, func() {})
+	confEvent.Done <- struct{}{}
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(confEvent, nil).Once()
+
+	tp.wg.Add(1)
+	go tp.handleTxConfirmed(record, requestID)
+
+	// The confirmed result should note the alternative schedule bound the
+	// fee rate.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-subscriber:
+		require.Equal(t, TxConfirmed, result.Event)
+		require.EqualValues(t, 3000, result.FeeRate)
+		require.Equal(t, FeeScheduleAlt, result.FeeSchedule)
+	}
+}
+
+// TestWatchReorgConfPkScript checks the expected pkScript is used when
+// registering for the confirmation of the tx.
+func TestWatchReorgConfPkScript(t *testing.T) {
+	t.Parallel()
+
+	outputScript := []byte{1, 2, 3}
+	customScript := []byte{4, 5, 6}
+
+	testCases := []struct {
+		name             string
+		txOuts           []*wire.TxOut
+		confPkScript     []byte
+		expectedPkScript []byte
+	}{
+		{
+			name: "first output",
+			txOuts: []*wire.TxOut{
+				{Value: 1000, PkScript: outputScript},
+				{Value: 1000, PkScript: customScript},
+			},
+			expectedPkScript: outputScript,
+		},
+		{
+			name: "supplied by request",
+			txOuts: []*wire.TxOut{
+				{Value: 1000, PkScript: outputScript},
+				{Value: 1000, PkScript: customScript},
+			},
+			confPkScript:     customScript,
+			expectedPkScript: customScript,
+		},
+		{
+			name:             "no output",
+			expectedPkScript: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tp, m := createTestPublisher(t)
+
+			req := createTestBumpRequest()
+			req.ConfPkScript = tc.confPkScript
+
+			tx := &wire.MsgTx{LockTime: 1, TxOut: tc.txOuts}
+			requestID := uint64(1)
+			tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+			record, ok := tp.records.Load(requestID)
+			require.True(t, ok)
+
+			// Mock the notifier to expect the pkScript, and return
+			// a conf event which tells the tx is safe from reorgs.
+			txid := tx.TxHash()
+			confEvent := chainntnfs.NewConfirmationEvent(
+				1, func() {},
+			)
+			confEvent.Done <- struct{}{}
+			m.notifier.On("RegisterConfirmationsNtfn", &txid,
+				tc.expectedPkScript, uint32(1),
+				mock.Anything).Return(confEvent, nil).Once()
+
+			tp.watchReorg(record, requestID, &BumpResult{
+				Event:     TxConfirmed,
+				Tx:        tx,
+				requestID: requestID,
+			})
+
+			// The record should be removed once the tx is safe.
+			_, found := tp.records.Load(requestID)
+			require.False(t, found)
+		})
+	}
+}
+
+// TestWatchReorgReregister checks the conf ntfn of a confirmed tx is
+// re-registered when it's closed by the notifier, up to the max attempts.
+func TestWatchReorgReregister(t *testing.T) {
+	t.Parallel()
+
+	// closedEvent returns a conf event that's closed by the notifier.
+	closedEvent := func() *chainntnfs.ConfirmationEvent {
+		confEvent := chainntnfs.NewConfirmationEvent(1, func() {})
+		close(confEvent.Done)
+		close(confEvent.NegativeConf)
+
+		return confEvent
+	}
+
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+	requestID := uint64(1)
+
+	// setup creates a publisher watching the confirmed tx, using the
+	// given max re-registrations.
+	setup := func(maxAttempts uint32) (*TxPublisher, *mockers,
+		*monitorRecord) {
+
+		tp, m := createTestPublisher(t)
+		tp.cfg.MaxConfReregistrations = maxAttempts
+
+		record := tp.storeRecord(
+			requestID, tx, createTestBumpRequest(), m.feeFunc,
+			1000, nil,
+		)
+
+		return tp, m, record
+	}
+
+	watch := func(tp *TxPublisher, record *monitorRecord) {
+		tp.watchReorg(record, requestID, &BumpResult{
+			Event:     TxConfirmed,
+			Tx:        tx,
+			requestID: requestID,
+		})
+	}
+
+	// When the subscription is closed, it should be re-registered and
+	// the tx is watched using the new one until it's safe from reorgs.
+	tp, m, record := setup(0)
+
+	resumed := chainntnfs.NewConfirmationEvent(1, func() {})
+	resumed.Done <- struct{}{}
+
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(closedEvent(), nil).Once()
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(resumed, nil).Once()
+
+	watch(tp, record)
+
+	m.notifier.AssertNumberOfCalls(t, "RegisterConfirmationsNtfn", 2)
+	_, found := tp.records.Load(requestID)
+	require.False(t, found)
+
+	// Once the max re-registrations is reached, the tx is no longer
+	// watched.
+	tp, m, record = setup(1)
+
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(closedEvent(), nil).Once()
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(closedEvent(), nil).Once()
+
+	watch(tp, record)
+
+	m.notifier.AssertNumberOfCalls(t, "RegisterConfirmationsNtfn", 2)
+	_, found = tp.records.Load(requestID)
+	require.False(t, found)
+}
+
+// TestHandleTxConfirmedReorg checks that when a confirmed tx is reorged out of
+// the chain, a TxReorged event is sent after the TxConfirmed event and the
+// record is monitored again.
+func TestHandleTxConfirmedReorg(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create testing objects.
+	requestID := uint64(1)
+	req := createTestBumpRequest()
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+
+	// Create a monitor record and a subscription to its events.
+	record := &monitorRecord{
+		req:         req,
+		feeFunction: m.feeFunc,
+		tx:          tx,
+		fee:         btcutil.Amount(1000),
+	}
+	tp.records.Store(requestID, record)
+
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Mock the wallet to report the tx as confirmed.
+	m.wallet.On("GetTransactionDetails", &txid).Return(
+		&lnwallet.TransactionDetail{
+			NumConfirmations: 1,
+		}, nil,
+	).Once()
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the notifier to return a conf event, which is used to watch
+	// for the reorg.
+	confEvent := chainntnfs.NewConfirmationEvent(1, func() {})
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(1), mock.Anything).Return(confEvent, nil).Once()
+
+	// Call processRecords and expect the tx to be confirmed.
+	tp.processRecords()
+
+	var reorgSafe <-chan struct{}
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for TxConfirmed")
+
+	case result := <-subscriber:
+		require.Equal(t, TxConfirmed, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.Equal(t, requestID, result.requestID)
+		require.NotNil(t, result.reorgSafe)
+		reorgSafe = result.reorgSafe
+	}
+
+	// The record should be kept and marked as confirmed.
+	rec, found := tp.records.Load(requestID)
+	require.True(t, found)
+	require.True(t, rec.confirmed)
+
+	// A confirmed record should be skipped in the next round, which
+	// means no more wallet calls are made.
+	tp.processRecords()
+
+	// Now send the reorg notification.
+	confEvent.NegativeConf <- 1
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for TxReorged")
+
+	case result := <-subscriber:
+		require.Equal(t, TxReorged, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.Equal(t, requestID, result.requestID)
+		require.NoError(t, result.Validate())
+	}
+
+	// The record should now be monitored again.
+	rec, found = tp.records.Load(requestID)
+	require.True(t, found)
+	require.False(t, rec.confirmed)
+	_, found = tp.subscriberChans.Load(requestID)
+	require.True(t, found)
+
+	// The tx is not safe from reorgs, so the chan should stay open.
+	select {
+	case <-reorgSafe:
+		t.Fatal("expected reorgSafe to be open")
+	default:
+	}
+}
+
+// TestHandleFeeBumpTx validates handleFeeBumpTx behaves as expected.
+func TestHandleFeeBumpTx(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test tx.
+	tx := &wire.MsgTx{LockTime: 1}
+
+	// Create a test current height.
+	testHeight := int32(800000)
+
+	// Create a testing monitor record.
+	req := createTestBumpRequest()
+	record := &monitorRecord{
+		req:         req,
+		feeFunction: m.feeFunc,
+		tx:          tx,
+	}
+
+	op := wire.OutPoint{
+		Hash:  chainhash.Hash{1},
+		Index: 0,
+	}
+	utxoIndex := map[wire.OutPoint]int{
+		op: 0,
+	}
+
+	// Create a testing record and put it in the map.
+	fee := btcutil.Amount(1000)
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+
+	// Create a subscription to the event.
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the relay fee floor to be cleared by the fee function so no
+	// rebase happens.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false)
+
+	// Mock the fee function to skip the bump due to error.
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, errDummy).Once()
+
+	// Call the method and expect no result received.
+	tp.wg.Add(1)
+	go tp.handleFeeBumpTx(requestID, record, testHeight)
+
+	// Check there's no result sent back.
+	select {
+	case <-time.After(time.Second):
+	case result := <-subscriber:
+		t.Fatalf("unexpected result received: %v", result)
+	}
+
+	// Mock the fee function to skip the bump.
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, nil).Once()
+
+	// Call the method and expect no result received.
+	tp.wg.Add(1)
+	go tp.handleFeeBumpTx(requestID, record, testHeight)
+
+	// Check there's no result sent back.
+	select {
+	case <-time.After(time.Second):
+	case result := <-subscriber:
+		t.Fatalf("unexpected result received: %v", result)
+	}
+
+	// Mock the fee function to perform the fee bump.
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(true, nil)
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	// Mock the testmempoolaccept to return nil.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Mock the wallet to publish successfully.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Call the method and expect a result to be received.
+	//
+	// NOTE: must be called in a goroutine in case it blocks.
+	tp.wg.Add(1)
+	go tp.handleFeeBumpTx(requestID, record, testHeight)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-subscriber:
+		// We expect the result to be TxReplaced.
+		require.Equal(t, TxReplaced, result.Event)
+
+		// The new tx and old tx should be properly set.
+		require.NotEqual(t, tx, result.Tx)
+		require.Equal(t, tx, result.ReplacedTx)
+
+		// No error should be set.
+		require.Nil(t, result.Err)
+		require.Equal(t, requestID, result.requestID)
+	}
+
+	// We expect the record to NOT be removed from the maps.
+	_, found := tp.records.Load(requestID)
+	require.True(t, found)
+	_, found = tp.subscriberChans.Load(requestID)
+	require.True(t, found)
+}
+
+// TestHandleFeeBumpTxFeeBumped checks a TxFeeBumped event carrying the new tx
+// and fee rate is sent after a successful replacement.
+func TestHandleFeeBumpTxFeeBumped(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test tx.
+	tx := &wire.MsgTx{LockTime: 1}
+
+	// Create a testing monitor record.
+	req := createTestBumpRequest()
+	record := &monitorRecord{
+		req:         req,
+		feeFunction: m.feeFunc,
+		tx:          tx,
+	}
+
+	// Create a testing record and put it in the map.
+	op := wire.OutPoint{Hash: chainhash.Hash{1}}
+	requestID := uint64(1)
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{
+			op: 0,
+		},
+	)
+
+	// Create a subscription with enough buffer to hold both the replaced
+	// and fee bumped events.
+	subscriber := make(chan *BumpResult, 2)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// Mock the fee function to perform the fee bump without rebasing.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false)
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(true, nil).Once()
+
+	// Mock the signer, mempool check and publish to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Call the method under test.
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 800000)
+
+	// We expect the replaced event to be sent first.
+	var replaced *BumpResult
+	select {
+	case replaced = <-subscriber:
+		require.Equal(t, TxReplaced, replaced.Event)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for replaced result")
+	}
+
+	// Then a fee bumped event carrying the new tx and fee rate.
+	select {
+	case result := <-subscriber:
+		require.Equal(t, TxFeeBumped, result.Event)
+		require.Equal(t, replaced.Tx, result.Tx)
+		require.Equal(t, feerate, result.FeeRate)
+		require.NoError(t, result.Validate())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for fee bumped result")
+	}
+}
+
+// TestHandleFeeBumpTxReplaceReason checks the reason of a replacement is
+// recorded based on what triggered the fee bump.
+func TestHandleFeeBumpTxReplaceReason(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		rebased        bool
+		budgetAdded    bool
+		expectedReason ReplaceReason
+	}{
+		{
+			name:           "deadline",
+			expectedReason: ReplaceReasonDeadline,
+		},
+		{
+			name:           "relay floor",
+			rebased:        true,
+			expectedReason: ReplaceReasonRelayFloor,
+		},
+		{
+			name:           "budget added",
+			budgetAdded:    true,
+			expectedReason: ReplaceReasonBudgetAdded,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tp, m := createTestPublisher(t)
+
+			// Create a testing record and put it in the map.
+			tx := &wire.MsgTx{LockTime: 1}
+			req := createTestBumpRequest()
+			requestID := uint64(1)
+			r := tp.storeRecord(requestID, tx, req, m.feeFunc, 1000,
+				nil)
+			r.budgetAdded = tc.budgetAdded
+
+			subscriber := make(chan *BumpResult, 2)
+			tp.subscriberChans.Store(requestID, subscriber)
+
+			// Mock the fee function to give a fee bump only via
+			// the tested path.
+			m.feeFunc.On("FeeRate").Return(
+				chainfee.SatPerKWeight(1000))
+			m.estimator.On("RelayFeePerKW").Return(
+				chainfee.FeePerKwFloor)
+			m.feeFunc.On("RebaseFloor", mock.Anything).Return(
+				tc.rebased)
+			m.feeFunc.On("IncreaseFeeRate", mock.Anything,
+				mock.Anything, mock.Anything).Return(
+				!tc.rebased && !tc.budgetAdded, nil).Once()
+
+			m.signer.On("ComputeInputScript", mock.Anything,
+				mock.Anything).Return(&input.Script{}, nil)
+			m.wallet.On("CheckMempoolAcceptance",
+				mock.Anything).Return(nil)
+			m.wallet.On("PublishTransaction",
+				mock.Anything, mock.Anything).Return(nil).Once()
+
+			tp.wg.Add(1)
+			tp.handleFeeBumpTx(requestID, r, 800000)
+
+			require.Equal(t, TxReplaced, (<-subscriber).Event)
+
+			// The fee bumped event carries the reason.
+			result := <-subscriber
+			require.Equal(t, TxFeeBumped, result.Event)
+			require.Equal(
+				t, tc.expectedReason, result.ReplaceReason,
+			)
+
+			// The reason is also recorded and shown in the status.
+			status, err := tp.Status(requestID)
+			require.NoError(t, err)
+			require.Equal(
+				t, tc.expectedReason, status.ReplaceReason,
+			)
+		})
+	}
+}
+
+// TestBumpNow checks a forced fee bump replaces the tx with a new one paying a
+// higher fee, and the expected errors are returned when it cannot be done.
+func TestBumpNow(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks. The aux sweeper is removed so
+	// the fees are calculated using the inputs and outputs only.
+	tp, m := createTestPublisher(t)
+	tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+
+	// An unknown request cannot be bumped.
+	err := tp.BumpNow(1)
+	require.ErrorIs(t, err, ErrRequestNotFound)
+
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Create a request and its initial tx.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
+	}
+
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	f, err := NewLinearFeeFunction(
+		chainfee.SatPerKWeight(10_000), 6, m.estimator,
+		fn.Some(startFeeRate),
+	)
+	require.NoError(t, err)
+
+	requestID := uint64(1)
+	require.NoError(t, tp.createRBFCompliantTx(requestID, req, f))
+
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	oldTx, oldFee := record.tx, record.fee
+
+	subscriber := make(chan *BumpResult, 2)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// The request cannot be bumped while the monitor loop is bumping it.
+	require.True(t, tp.lockBump(requestID))
+	err = tp.BumpNow(requestID)
+	require.ErrorIs(t, err, ErrBumpInProgress)
+	tp.unlockBump(requestID)
+
+	// A forced bump should publish a new tx paying a higher fee.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+	require.NoError(t, tp.BumpNow(requestID))
+
+	result := <-subscriber
+	require.Equal(t, TxReplaced, result.Event)
+	require.Equal(t, oldTx, result.ReplacedTx)
+	require.NotEqual(t, oldTx.TxHash(), result.Tx.TxHash())
+	require.Greater(t, result.Fee, oldFee)
+	require.Greater(t, result.FeeRate, startFeeRate)
+
+	result = <-subscriber
+	require.Equal(t, TxFeeBumped, result.Event)
+	require.Equal(t, ReplaceReasonForced, result.ReplaceReason)
+
+	record, ok = tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, result.Tx, record.tx)
+	require.Greater(t, record.fee, oldFee)
+
+	// The monitor loop should skip bumping a record that's been replaced
+	// by a forced bump, which would otherwise fail the mocks.
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, &monitorRecord{
+		tx:          oldTx,
+		req:         req,
+		feeFunction: f,
+	}, 800000)
+
+	// Once the fee function is at its max, nothing is published.
+	maxedID := uint64(2)
+	tx := &wire.MsgTx{LockTime: 2}
+	tp.storeRecord(maxedID, tx, req, m.feeFunc, 1000, nil)
+	m.feeFunc.On("Increment", mock.Anything).Return(
+		false, ErrMaxPosition).Once()
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(10_000))
+
+	err = tp.BumpNow(maxedID)
+	require.ErrorIs(t, err, ErrFeeRateAtMax)
+
+	record, ok = tp.records.Load(maxedID)
+	require.True(t, ok)
+	require.Equal(t, tx, record.tx)
+}
+
+// TestHandleFeeBumpTxBudgetExhausted checks a TxBudgetExhausted event is sent
+// exactly once when the fee function is at its max past the deadline.
+func TestHandleFeeBumpTxBudgetExhausted(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test tx and a request with a deadline.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	req.DeadlineHeight = 100
+
+	// Create a testing record and put it in the map.
+	op := wire.OutPoint{Hash: chainhash.Hash{1}}
+	requestID := uint64(1)
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{
+			op: 0,
+		},
+	)
+
+	// Create a subscription with enough buffer to catch a duplicate
+	// event.
+	subscriber := make(chan *BumpResult, 2)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Mock the fee function to be at its max.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false)
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, ErrMaxPosition).Twice()
+
+	// Call the method under test twice past the deadline.
+	for _, height := range []int32{101, 102} {
+		record, ok := tp.records.Load(requestID)
+		require.True(t, ok)
+
+		tp.wg.Add(1)
+		tp.handleFeeBumpTx(requestID, record, height)
+	}
+
+	// We expect the budget exhausted event to be sent.
+	select {
+	case result := <-subscriber:
+		require.Equal(t, TxBudgetExhausted, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.Equal(t, feerate, result.FeeRate)
+		require.NoError(t, result.Validate())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for budget exhausted result")
+	}
+
+	// And only once.
+	select {
+	case result := <-subscriber:
+		t.Fatalf("unexpected result: %v", result)
+
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The record should still be monitored.
+	_, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+}
+
+// TestAddBudget checks that adding budget to an exhausted request allows its
+// fee function to increase the fee rate again.
+func TestAddBudget(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(98)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Adding budget to an unknown request should fail.
+	requestID := uint64(1)
+	err := tp.AddBudget(requestID, 1000)
+	require.ErrorIs(t, err, ErrRequestNotFound)
+
+	// Create a request with a deadline.
+	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(100_000)
+	req.DeadlineHeight = 100
+	req.StartingFeeRate = fn.Some(chainfee.FeePerKwFloor)
+
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+
+	// Exhaust the budget by driving the fee function to its max.
+	oldMax, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+
+	for {
+		_, err := f.Increment(0)
+		if errors.Is(err, ErrMaxPosition) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.Equal(t, oldMax, f.FeeRate())
+
+	// Store the exhausted record.
+	tx := &wire.MsgTx{LockTime: 1}
+	record := tp.storeRecord(requestID, tx, req, f, 1000, nil)
+	exhausted := *record
+	exhausted.budgetExhausted = true
+	tp.records.Store(requestID, &exhausted)
+
+	// Add more budget.
+	err = tp.AddBudget(requestID, 1000)
+	require.NoError(t, err)
+
+	// The record should now use the new budget and be reactivated.
+	updated, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, btcutil.Amount(2000), updated.req.Budget)
+	require.False(t, updated.budgetExhausted)
+	require.True(t, updated.budgetAdded)
+
+	// The caller's request should not be mutated.
+	require.Equal(t, btcutil.Amount(1000), req.Budget)
+
+	// The new fee function starts from the old max and can increase the
+	// fee rate again.
+	require.Equal(t, oldMax, updated.feeFunction.FeeRate())
+
+	increased, err := updated.feeFunction.Increment(0)
+	require.NoError(t, err)
+	require.True(t, increased)
+	require.Greater(t, updated.feeFunction.FeeRate(), oldMax)
+
+	newMax, err := updated.req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+	require.Equal(t, newMax, updated.feeFunction.FeeRate())
+}
+
+// TestAddBudgetDuringBump checks AddBudget and a fee bump of the same request
+// don't overwrite each other's updates.
+func TestAddBudgetDuringBump(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+	tp.cfg.MinBumpIncrementPercent = 10
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	weight := lntypes.WeightUnit(
+		blockchain.GetTransactionWeight(btcutil.NewTx(tx)),
+	)
+	fee := chainfee.SatPerKWeight(1000).FeeForWeight(weight)
+
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	stale := tp.storeRecord(requestID, tx, req, m.feeFunc, fee, nil)
+
+	// Adding budget while the tx is being bumped should fail and leave
+	// the record untouched.
+	require.True(t, tp.lockBump(requestID))
+	err := tp.AddBudget(requestID, 1000)
+	require.ErrorIs(t, err, ErrBumpInProgress)
+
+	r, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, req.Budget, r.req.Budget)
+	require.False(t, r.budgetAdded)
+	tp.unlockBump(requestID)
+
+	// Mimic a budget being added after the monitor loop has loaded the
+	// record by storing a record with a new fee function.
+	feeFunc := &MockFeeFunction{}
+	defer feeFunc.AssertExpectations(t)
+
+	updated := *stale
+	updated.feeFunction = feeFunc
+	updated.budgetAdded = true
+	tp.records.Store(requestID, &updated)
+
+	// The bump should use the latest record. The stale fee function has
+	// no mocked calls so using it would fail the test. We mock a small
+	// increase so the bump is skipped without creating a replacement.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	feeFunc.On("RebaseFloor", mock.Anything).Return(false)
+	feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(true, nil)
+	feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1050))
+	feeFunc.On("Schedule").Return([]chainfee.SatPerKWeight{1050, 2000})
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, stale, 800000)
+
+	// The budget added should not be lost.
+	r, ok = tp.records.Load(requestID)
+	require.True(t, ok)
+	require.True(t, r.budgetAdded)
+	require.Equal(t, feeFunc, r.feeFunction)
+}
+
+// TestHandleFeeBumpTxMinBumpIncrement checks a fee bump is skipped unless the
+// new fee rate exceeds the current one by MinBumpIncrementPercent.
+func TestHandleFeeBumpTxMinBumpIncrement(t *testing.T) {
+	t.Parallel()
+
+	// Create a test tx and calculate the fee that gives it a fee rate of
+	// 1000 sat/kw.
+	tx := &wire.MsgTx{LockTime: 1}
+	weight := lntypes.WeightUnit(
+		blockchain.GetTransactionWeight(btcutil.NewTx(tx)),
+	)
+	feeRate := chainfee.SatPerKWeight(1000)
+	fee := feeRate.FeeForWeight(weight)
+
+	testCases := []struct {
+		name       string
+		newFeeRate chainfee.SatPerKWeight
+		maxFeeRate chainfee.SatPerKWeight
+		replaced   bool
+	}{
+		{
+			// A 5% increase is below the 10% threshold.
+			name:       "below threshold",
+			newFeeRate: 1050,
+			maxFeeRate: 2000,
+			replaced:   false,
+		},
+		{
+			// A 20% increase is above the 10% threshold.
+			name:       "above threshold",
+			newFeeRate: 1200,
+			maxFeeRate: 2000,
+			replaced:   true,
+		},
+		{
+			// A 5% increase to the max fee rate is allowed as the
+			// fee function cannot go any higher.
+			name:       "max fee rate",
+			newFeeRate: 1050,
+			maxFeeRate: 1050,
+			replaced:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a publisher using the mocks with a min
+			// increment of 10%.
+			tp, m := createTestPublisher(t)
+			tp.cfg.MinBumpIncrementPercent = 10
+
+			// Create a testing record and put it in the map.
+			req := createTestBumpRequest()
+			requestID := uint64(1)
+			record := tp.storeRecord(
+				requestID, tx, req, m.feeFunc, fee, nil,
+			)
+
+			// Mock the fee function to increase the fee rate.
+			m.estimator.On("RelayFeePerKW").Return(
+				chainfee.FeePerKwFloor)
+			m.feeFunc.On("RebaseFloor", mock.Anything).Return(
+				false)
+			m.feeFunc.On("IncreaseFeeRate", mock.Anything,
+				mock.Anything, mock.Anything).Return(true, nil)
+			m.feeFunc.On("FeeRate").Return(tc.newFeeRate)
+			m.feeFunc.On("Schedule").Return(
+				[]chainfee.SatPerKWeight{
+					tc.newFeeRate, tc.maxFeeRate,
+				})
+
+			// Mock the replacement to fail so we can tell it's
+			// been attempted without going through the broadcast.
+			if tc.replaced {
+				m.wallet.On("CheckMempoolAcceptance",
+					mock.Anything).Return(errDummy).Once()
+				m.signer.On("ComputeInputScript",
+					mock.Anything, mock.Anything).Return(
+					&input.Script{}, nil)
+			}
+
+			tp.wg.Add(1)
+			tp.handleFeeBumpTx(requestID, record, 800000)
+
+			// The mocks assert whether the replacement has been
+			// attempted.
+		})
+	}
+}
+
+// TestHandleFeeBumpTxPadNextBump checks the padding requested by a fee
+// undershoot is applied once, and the flag is cleared from the record even if
+// no replacement is made.
+func TestHandleFeeBumpTxPadNextBump(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing record marked to pad its next bump and put it in
+	// the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	record := tp.storeRecord(requestID, tx, req, m.feeFunc, 100, nil)
+	padded := *record
+	padded.padNextBump = true
+	tp.records.Store(requestID, &padded)
+
+	// Mock the fee function to neither increase nor pad the fee rate, so
+	// no replacement is made.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", mock.Anything).Return(false)
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, nil)
+	m.feeFunc.On("Increment", mock.Anything).Return(false, nil).Once()
+
+	// Bump the tx twice, the padding should only be attempted in the
+	// first round.
+	for i := 0; i < 2; i++ {
+		tp.wg.Add(1)
+		tp.handleFeeBumpTx(requestID, &padded, 800000)
+	}
+	m.feeFunc.AssertNumberOfCalls(t, "Increment", 1)
+
+	// The flag should be cleared from the record.
+	r, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.False(t, r.padNextBump)
+}
+
+// TestHandleFeeBumpTxInputSpent checks a TxInputSpent event is sent instead of
+// a replacement when an input of the tx has been spent.
+func TestHandleFeeBumpTxInputSpent(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks with a utxo checker.
+	tp, m := createTestPublisher(t)
+
+	checker := &MockUtxoChecker{}
+	defer checker.AssertExpectations(t)
+	tp.cfg.UtxoChecker = fn.Some[UtxoChecker](checker)
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	record := tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Mock the fee function to increase the fee rate.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false)
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(true, nil).Once()
+
+	// Mock the checker to report the input as spent.
+	op := req.Inputs[0].OutPoint()
+	checker.On("IsUnspent", op, mock.Anything, mock.Anything).Return(
+		false, nil).Once()
+
+	// Call the method under test. No replacement should be attempted,
+	// which is asserted by the wallet and signer mocks.
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 800000)
+
+	select {
+	case result := <-subscriber:
+		require.Equal(t, TxInputSpent, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.ErrorIs(t, result.Err, ErrInputSpent)
+		require.Equal(t, fn.Some(op), result.SpentInput)
+		require.NoError(t, result.Validate())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for input spent result")
+	}
+
+	// The record should be removed so no further broadcast is made.
+	_, found := tp.records.Load(requestID)
+	require.False(t, found)
+}
+
+// TestHandleFeeBumpTxLostRBFRace checks a TxLostRBFRace event is sent when a
+// conflicting mempool tx pays a fee rate above the max fee rate allowed, while
+// a cheaper conflict doesn't stop the fee bump.
+func TestHandleFeeBumpTxLostRBFRace(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks with a mempool watcher.
+	tp, m := createTestPublisher(t)
+
+	mempool := chainntnfs.NewMockMempoolWatcher()
+	defer mempool.AssertExpectations(t)
+	tp.cfg.Mempool = fn.Some[chainntnfs.MempoolWatcher](mempool)
+
+	// Create a record whose max fee rate allowed is 2000 sat/kw.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000),
+		MaxFeeRate:      chainfee.SatPerKWeight(2000),
+		DeadlineHeight:  120,
+	}
+	tx := &wire.MsgTx{LockTime: 1}
+	requestID := uint64(1)
+	record := tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// newConflict creates a conflicting tx spending the input with the
+	// given fee.
+	op := inp.OutPoint()
+	newConflict := func(fee int64) wire.MsgTx {
+		conflict := wire.NewMsgTx(2)
+		conflict.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		conflict.AddTxOut(wire.NewTxOut(100_000-fee, []byte{0}))
+
+		return *conflict
+	}
+
+	// A conflict paying a fee rate below the max allowed doesn't stop the
+	// fee bump. We return false here to skip the actual replacement.
+	mempool.On("LookupInputMempoolSpend", op).Return(
+		fn.Some(newConflict(100))).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(
+		false).Once()
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, nil).Once()
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 100)
+
+	_, found := tp.records.Load(requestID)
+	require.True(t, found)
+
+	// A conflict paying a fee rate above the max allowed cannot be
+	// outbid, so the request should stop being bumped.
+	mempool.On("LookupInputMempoolSpend", op).Return(
+		fn.Some(newConflict(50_000))).Once()
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000)).Once()
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 101)
+
+	select {
+	case result := <-subscriber:
+		require.Equal(t, TxLostRBFRace, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.ErrorIs(t, result.Err, ErrLostRBFRace)
+		require.Equal(t, fn.Some(op), result.SpentInput)
+		require.NoError(t, result.Validate())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for lost RBF race result")
+	}
+
+	// The record should be removed so no further bump is made.
+	_, found = tp.records.Load(requestID)
+	require.False(t, found)
+}
+
+// TestBumpExisting checks an existing tx is adopted for monitoring using its
+// current fee rate, and can be replaced with a higher fee.
+func TestBumpExisting(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Create an existing tx that pays 500 sats in fees.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	tx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{PreviousOutPoint: inp.OutPoint()}},
+		TxOut: []*wire.TxOut{{
+			Value:    100_000 - 500,
+			PkScript: changePkScript.DeliveryAddress,
+		}},
+	}
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+	feeRate := chainfee.NewSatPerKWeight(500, lntypes.WeightUnit(weight))
+
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Budget:          5000,
+		MaxFeeRate:      chainfee.SatPerKWeight(100_000),
+		DeadlineHeight:  10,
+	}
+
+	// Adopting a tx without its full set of inputs is rejected.
+	_, err := tp.BumpExisting(tx, nil, req)
+	require.ErrorContains(t, err, "inputs")
+
+	// Adopting a tx using inputs it doesn't spend is rejected.
+	other := createTestInput(100_000, input.WitnessKeyHash)
+	_, err = tp.BumpExisting(tx, []input.Input{&other}, req)
+	require.ErrorContains(t, err, "not spent by tx")
+
+	// Adopting a tx whose fee rate is already above the budget is
+	// rejected.
+	lowBudgetReq := *req
+	lowBudgetReq.Budget = 100
+	_, err = tp.BumpExisting(tx, []input.Input{&inp}, &lowBudgetReq)
+	require.ErrorIs(t, err, ErrNotEnoughBudget)
+
+	// Adopt the tx.
+	resultChan, err := tp.BumpExisting(tx, []input.Input{&inp}, req)
+	require.NoError(t, err)
+	require.NotNil(t, resultChan)
+
+	// The caller's request should not be mutated.
+	require.Empty(t, req.Inputs)
+	require.True(t, req.StartingFeeRate.IsNone())
+
+	// The tx should now be monitored, using its fee rate as the starting
+	// point of the fee function.
+	requestID := tp.requestCounter.Load()
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, tx, record.tx)
+	require.Equal(t, btcutil.Amount(500), record.fee)
+	require.Equal(t, feeRate, record.feeFunction.FeeRate())
+	require.Equal(t, map[wire.OutPoint]int{inp.OutPoint(): 0},
+		record.outpointToTxIndex)
+
+	// Mock the signer, mempool check and publish to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Perform a fee bump at a height closer to the deadline.
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 5)
+
+	// We expect the adopted tx to be replaced.
+	select {
+	case result := <-resultChan:
+		require.Equal(t, TxReplaced, result.Event)
+		require.Equal(t, tx, result.ReplacedTx)
+		require.Greater(t, result.FeeRate, feeRate)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for replaced result")
+	}
+}
+
+// TestBroadcastPSBT checks the tx extracted from a finalized PSBT is broadcast
+// and then replaced by the publisher.
+func TestBroadcastPSBT(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Create a PSBT spending the input and paying 500 sats in fees.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	unsignedTx := &wire.MsgTx{
+		Version: 2,
+		TxIn:    []*wire.TxIn{{PreviousOutPoint: inp.OutPoint()}},
+		TxOut: []*wire.TxOut{{
+			Value:    100_000 - 500,
+			PkScript: changePkScript.DeliveryAddress,
+		}},
+	}
+	packet, err := psbt.NewFromUnsignedTx(unsignedTx)
+	require.NoError(t, err)
+	packet.Inputs[0].WitnessUtxo = inp.SignDesc().Output
+
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Budget:          5000,
+		MaxFeeRate:      chainfee.SatPerKWeight(100_000),
+		DeadlineHeight:  10,
+	}
+	inputs := []input.Input{&inp}
+
+	// A PSBT that's not finalized is rejected.
+	_, err = tp.BroadcastPSBT(packet, inputs, req)
+	require.ErrorIs(t, err, psbt.ErrIncompletePSBT)
+
+	// Finalize the PSBT using a dummy witness.
+	packet.Inputs[0].FinalScriptWitness = []byte{1, 1, 1}
+
+	// A PSBT whose witness UTXO doesn't match the input is rejected.
+	packet.Inputs[0].WitnessUtxo = &wire.TxOut{Value: 200_000}
+	_, err = tp.BroadcastPSBT(packet, inputs, req)
+	require.ErrorContains(t, err, "psbt specifies")
+	packet.Inputs[0].WitnessUtxo = inp.SignDesc().Output
+
+	// The extracted tx should be broadcast.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Twice()
+
+	resultChan, err := tp.BroadcastPSBT(packet, inputs, req)
+	require.NoError(t, err)
+
+	var tx *wire.MsgTx
+	select {
+	case result := <-resultChan:
+		require.Equal(t, TxPublished, result.Event)
+		require.Equal(t, unsignedTx.TxHash(), result.Tx.TxHash())
+		require.Equal(t, btcutil.Amount(500), result.Fee)
+		tx = result.Tx
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for published result")
+	}
+
+	// Mock the signer and mempool check to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Perform a fee bump at a height closer to the deadline.
+	requestID := tp.requestCounter.Load()
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 5)
+
+	// We expect the tx to be replaced using the signer.
+	select {
+	case result := <-resultChan:
+		require.Equal(t, TxReplaced, result.Event)
+		require.Equal(t, tx, result.ReplacedTx)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for replaced result")
+	}
+
+	m.signer.AssertCalled(t, "ComputeInputScript", mock.Anything,
+		mock.Anything)
+}
+
+// TestHandleFeeBumpTxGracePeriod checks the initial tx is not bumped during
+// the grace period, and the bumping begins right after it elapses.
+func TestHandleFeeBumpTxGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks with a grace period of 2 blocks.
+	tp, m := createTestPublisher(t)
+	tp.cfg.FirstBumpGracePeriod = 2
+
+	// Create a record whose initial tx is broadcast at height 100.
+	broadcastHeight := int32(100)
+	tp.currentHeight.Store(broadcastHeight)
+
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000),
+		DeadlineHeight:  120,
+	}
+	tx := &wire.MsgTx{LockTime: 1}
+	requestID := uint64(1)
+	record := tp.storeRecord(requestID, tx, req, m.feeFunc, 0, nil)
+
+	// No fee function or estimator methods should be called during the
+	// grace period, as the mocks would fail on unexpected calls.
+	for i := int32(1); i <= 2; i++ {
+		tp.wg.Add(1)
+		tp.handleFeeBumpTx(requestID, record, broadcastHeight+i)
+	}
+	m.feeFunc.AssertNotCalled(t, "Increment")
+	m.feeFunc.AssertNotCalled(
+		t, "IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything,
+	)
+
+	// Once the grace period elapses, the fee function is asked to bump
+	// the fee rate. We return false here to skip the actual replacement.
+	height := broadcastHeight + 3
+	confTarget := calcCurrentConfTarget(height, req.DeadlineHeight)
+
+	m.estimator.On("RelayFeePerKW").Return(
+		chainfee.SatPerKWeight(250)).Once()
+	m.feeFunc.On("RebaseFloor", chainfee.SatPerKWeight(250)).Return(
+		false).Once()
+	m.feeFunc.On("IncreaseFeeRate", confTarget, mock.Anything,
+		mock.Anything).Return(false, nil).Once()
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, height)
+
+	// A replacement tx is bumped as usual even within the grace period.
+	m.estimator.On("RelayFeePerKW").Return(
+		chainfee.SatPerKWeight(250)).Once()
+	m.feeFunc.On("RebaseFloor", chainfee.SatPerKWeight(250)).Return(
+		false).Once()
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, nil).Once()
+
+	replaced := *record
+	replaced.replaceReason = ReplaceReasonDeadline
+	tp.records.Store(requestID, &replaced)
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, &replaced, broadcastHeight+1)
+}
+
+// TestMaybeResetTrajectory checks the trajectory of the fee function is only
+// reset when the estimated fee rate drops by at least the threshold.
+func TestMaybeResetTrajectory(t *testing.T) {
+	t.Parallel()
+
+	tp, m := createTestPublisher(t)
+	tp.cfg.TrajectoryResetThreshold = 0.5
+
+	requestID := uint64(1)
+	confTarget := uint32(6)
+	record := &monitorRecord{
+		req:         &BumpRequest{},
+		feeFunction: m.feeFunc,
+	}
+
+	// The first estimate is only remembered.
+	m.estimator.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(10_000), nil).Once()
+	tp.maybeResetTrajectory(requestID, record, confTarget)
+
+	// A drop below the threshold doesn't reset the trajectory.
+	m.estimator.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(6000), nil).Once()
+	tp.maybeResetTrajectory(requestID, record, confTarget)
+
+	// A drop of half the last estimate resets the trajectory.
+	m.estimator.On("EstimateFeePerKW", confTarget).Return(
+		chainfee.SatPerKWeight(3000), nil).Once()
+	m.feeFunc.On("ResetTrajectory", chainfee.SatPerKWeight(3000)).Once()
+	tp.maybeResetTrajectory(requestID, record, confTarget)
+
+	// The estimate is forgotten once the record is removed.
+	tp.records.Store(requestID, record)
+	tp.deleteRecord(requestID)
+	_, ok := tp.lastEstimates.Load(requestID)
+	require.False(t, ok)
+
+	// Nothing is estimated when the threshold is not configured.
+	tp.cfg.TrajectoryResetThreshold = 0
+	tp.maybeResetTrajectory(requestID, record, confTarget)
+}
+
+// TestHandleFeeBumpTxRebaseFloor checks that when the relay fee floor rises
+// between blocks, the next fee bump clears the new floor even if the fee
+// function's position is unchanged.
+func TestHandleFeeBumpTxRebaseFloor(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a linear fee function which goes from 1000 to 10000 in 9
+	// blocks, with a deadline at height 10.
+	testHeight := int32(0)
+	f := &LinearFeeFunction{
+		startingFeeRate: 1000,
+		endingFeeRate:   10000,
+		currentFeeRate:  1000,
+		deltaFeeRate:    1_000_000,
+		width:           9,
+	}
+
+	// Create a testing monitor record, which has enough budget to cover
+	// the max fee rate.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000),
+		DeadlineHeight:  10,
+	}
+	tx := &wire.MsgTx{LockTime: 1}
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, f, 0, nil)
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+
+	// Create a subscription to the event.
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Mock the relay fee floor to be below the current fee rate first,
+	// which gives no fee bump since the conf target is unchanged.
+	m.estimator.On("RelayFeePerKW").Return(
+		chainfee.SatPerKWeight(500)).Once()
+
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, testHeight)
+
+	select {
+	case result := <-subscriber:
+		t.Fatalf("unexpected result received: %v", result)
+	default:
+	}
+	require.Equal(t, chainfee.SatPerKWeight(1000), f.FeeRate())
+
+	// Now raise the floor above the current fee rate.
+	floor := chainfee.SatPerKWeight(3000)
+	m.estimator.On("RelayFeePerKW").Return(floor).Once()
+
+	// Mock the signer, testmempoolaccept and publish to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	tp.wg.Add(1)
+	go tp.handleFeeBumpTx(requestID, record, testHeight)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-subscriber:
+		// We expect the tx to be replaced with a fee rate that clears
+		// the new floor.
+		require.Equal(t, TxReplaced, result.Event)
+		require.GreaterOrEqual(t, result.FeeRate, floor)
+	}
+
+	// The fee function should keep the same deadline after the rebase.
+	require.EqualValues(t, 9, f.width)
+	require.Equal(t, floor, f.startingFeeRate)
+}
+
+// TestProcessRecords validates processRecords behaves as expected.
+func TestProcessRecords(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create testing objects.
+	requestID1 := uint64(1)
+	req1 := createTestBumpRequest()
+	tx1 := &wire.MsgTx{LockTime: 1}
+	txid1 := tx1.TxHash()
+
+	requestID2 := uint64(2)
+	req2 := createTestBumpRequest()
+	tx2 := &wire.MsgTx{LockTime: 2}
+	txid2 := tx2.TxHash()
+
+	// Create a monitor record that's confirmed.
+	recordConfirmed := &monitorRecord{
+		req:         req1,
+		feeFunction: m.feeFunc,
+		tx:          tx1,
+	}
+	confHash := chainhash.Hash{1, 2, 3}
+	m.wallet.On("GetTransactionDetails", &txid1).Return(
+		&lnwallet.TransactionDetail{
+			NumConfirmations: 1,
+			BlockHash:        &confHash,
+			BlockHeight:      100,
+		}, nil,
+	).Once()
+
+	// Mock the notifier to return a conf event which tells the confirmed
+	// tx is already safe from reorgs.
+	confEvent := chainntnfs.NewConfirmationEvent(1, func() {})
+	confEvent.Done <- struct{}{}
+	m.notifier.On("RegisterConfirmationsNtfn", &txid1, mock.Anything,
+		uint32(1), mock.Anything).Return(confEvent, nil).Once()
+
+	// Create a monitor record that's not confirmed. We know it's not
+	// confirmed because the num of confirms is zero.
+	recordFeeBump := &monitorRecord{
+		req:         req2,
+		feeFunction: m.feeFunc,
+		tx:          tx2,
+	}
+	m.wallet.On("GetTransactionDetails", &txid2).Return(
+		&lnwallet.TransactionDetail{
+			NumConfirmations: 0,
+		}, nil,
+	).Once()
+	m.wallet.On("BackEnd").Return("test-backend").Once()
+
+	// Setup the initial publisher state by adding the records to the maps.
+	subscriberConfirmed := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID1, subscriberConfirmed)
+	tp.records.Store(requestID1, recordConfirmed)
+
+	subscriberReplaced := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID2, subscriberReplaced)
+	tp.records.Store(requestID2, recordFeeBump)
+
+	// Create a test feerate and return it from the mock fee function.
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// The following methods should only be called once when creating the
+	// replacement tx.
+	//
+	// Mock the fee function to NOT skip the fee bump.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false).Once()
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(true, nil).Once()
+
+	// Mock the signer to always return a valid script.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil).Once()
+
+	// Mock the testmempoolaccept to return nil.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Mock the wallet to publish successfully.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Call processRecords and expect the results are notified back.
+	tp.processRecords()
+
+	// We expect two results to be received. One for the confirmed tx and
+	// one for the replaced tx.
+	//
+	// Check the confirmed tx result.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriberConfirmed")
+
+	case result := <-subscriberConfirmed:
+		// We expect the result to be TxConfirmed.
+		require.Equal(t, TxConfirmed, result.Event)
+		require.Equal(t, tx1, result.Tx)
+
+		// The block confirming the tx should be reported.
+		require.EqualValues(t, 100, result.ConfHeight)
+		require.Equal(t, confHash, result.ConfBlockHash)
+
+		// No error should be set.
+		require.Nil(t, result.Err)
+		require.Equal(t, requestID1, result.requestID)
+	}
+
+	// The confirmed record should be removed once it's safe from reorgs.
+	require.Eventually(t, func() bool {
+		_, found := tp.records.Load(requestID1)
+		return !found
+	}, time.Second, 10*time.Millisecond)
+
+	// Now check the replaced tx result.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriberReplaced")
+
+	case result := <-subscriberReplaced:
+		// We expect the result to be TxReplaced.
+		require.Equal(t, TxReplaced, result.Event)
+
+		// The new tx and old tx should be properly set.
+		require.NotEqual(t, tx2, result.Tx)
+		require.Equal(t, tx2, result.ReplacedTx)
+
+		// No error should be set.
+		require.Nil(t, result.Err)
+		require.Equal(t, requestID2, result.requestID)
+	}
+}
+
+// TestProcessRecordsNumConfs checks a tx is not reported as confirmed until it
+// reaches the number of confirmations specified in the request, and is not
+// fee bumped while waiting.
+func TestProcessRecordsNumConfs(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a request that requires three confirmations.
+	req := createTestBumpRequest()
+	req.NumConfs = 3
+
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000))
+
+	// Mock the notifier to expect the requested number of confirmations
+	// when watching for reorgs.
+	confEvent := chainntnfs.NewConfirmationEvent(3, func() {})
+	confEvent.Done <- struct{}{}
+	m.notifier.On("RegisterConfirmationsNtfn", &txid, mock.Anything,
+		uint32(3), mock.Anything).Return(confEvent, nil).Once()
+
+	// Process the records at each confirmation depth.
+	for confs := int32(1); confs <= 3; confs++ {
+		m.wallet.On("GetTransactionDetails", &txid).Return(
+			&lnwallet.TransactionDetail{
+				NumConfirmations: confs,
+			}, nil,
+		).Once()
+
+		tp.processRecords()
+
+		// Before the third confirmation, no result should be sent,
+		// and no fee bump should be attempted as the mocks would
+		// otherwise panic.
+		if confs < 3 {
+			select {
+			case result := <-subscriber:
+				t.Fatalf("unexpected result at %v confs: %v",
+					confs, result)
+
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			continue
+		}
+
+		// At the third confirmation, the tx should be confirmed.
+		select {
+		case result := <-subscriber:
+			require.Equal(t, TxConfirmed, result.Event)
+			require.Equal(t, tx, result.Tx)
+
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for confirmed result")
+		}
+	}
+
+	// The record should be removed once the tx is safe from reorgs.
+	require.Eventually(t, func() bool {
+		_, found := tp.records.Load(requestID)
+		return !found
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestMonitorProcessesNewBlocks checks the monitor loop updates the current
+// height and bumps the fee once per new block.
+func TestMonitorProcessesNewBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks at height 100.
+	tp, m := createTestPublisher(t)
+	tp.SetCurrentHeight(100)
+	require.EqualValues(t, 100, tp.CurrentHeight())
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+	req := createTestBumpRequest()
+	req.DeadlineHeight = 110
+	tp.storeRecord(1, tx, req, m.feeFunc, 1000, nil)
+
+	// Mock the tx being unconfirmed.
+	m.wallet.On("GetTransactionDetails", &txid).Return(
+		&lnwallet.TransactionDetail{}, nil)
+	m.wallet.On("BackEnd").Return("")
+
+	// Mock the fee function to not increase the fee rate, and record the
+	// conf target used for each attempt.
+	confTargets := make(chan uint32, 10)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false)
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, nil).Run(func(args mock.Arguments) {
+		confTargets <- args.Get(0).(uint32)
+	})
+
+	// Start the monitor loop.
+	tp.wg.Add(1)
+	go tp.monitor()
+	defer func() {
+		close(tp.quit)
+		tp.wg.Wait()
+	}()
+
+	// sendBeat sends a synthetic block at the given height.
+	sendBeat := func(height int32) {
+		beat := &chainio.MockBlockbeat{}
+		beat.On("Height").Return(height)
+		beat.On("logger").Return(log)
+
+		require.NoError(t, tp.ProcessBlock(beat))
+		require.Equal(t, height, tp.CurrentHeight())
+	}
+
+	// Send two new blocks and a duplicate one. We expect the fee bump to
+	// be attempted once per new block.
+	sendBeat(101)
+	sendBeat(101)
+	sendBeat(102)
+
+	for _, expected := range []uint32{9, 8} {
+		select {
+		case confTarget := <-confTargets:
+			require.Equal(t, expected, confTarget)
+
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for fee bump")
+		}
+	}
+
+	select {
+	case confTarget := <-confTargets:
+		t.Fatalf("unexpected fee bump with conf target %v", confTarget)
+
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestMonitorDebounceBlocks checks the monitor loop coalesces the blocks that
+// arrive within the debounce window, and only evaluates the fee bump once the
+// chain settles, while each block is acked right away.
+func TestMonitorDebounceBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks at height 100 with a mocked
+	// clock.
+	tp, m := createTestPublisher(t)
+	tp.SetCurrentHeight(100)
+
+	startTime := time.Unix(1_000_000, 0)
+	tickSignal := make(chan time.Duration)
+	testClock := clock.NewTestClockWithTickSignal(startTime, tickSignal)
+	tp.cfg.Clock = testClock
+	tp.cfg.BlockDebounce = time.Second
+
+	// Create a testing record and put it in the map.
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+	req := createTestBumpRequest()
+	req.DeadlineHeight = 110
+	tp.storeRecord(1, tx, req, m.feeFunc, 1000, nil)
+
+	// Mock the tx being unconfirmed.
+	m.wallet.On("GetTransactionDetails", &txid).Return(
+		&lnwallet.TransactionDetail{}, nil)
+	m.wallet.On("BackEnd").Return("")
+
+	// Mock the fee function to not increase the fee rate, and record the
+	// conf target used for each attempt.
+	confTargets := make(chan uint32, 10)
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false)
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(false, nil).Run(func(args mock.Arguments) {
+		confTargets <- args.Get(0).(uint32)
+	})
+
+	// Start the monitor loop.
+	tp.wg.Add(1)
+	go tp.monitor()
+	defer func() {
+		close(tp.quit)
+		tp.wg.Wait()
+	}()
+
+	// newBeat creates a synthetic block at the given height.
+	newBeat := func(height int32) *chainio.MockBlockbeat {
+		beat := &chainio.MockBlockbeat{}
+		beat.On("Height").Return(height)
+		beat.On("logger").Return(log).Maybe()
+
+		return beat
+	}
+
+	// waitDebounce waits for the debounce window to be restarted.
+	waitDebounce := func() {
+		t.Helper()
+
+		select {
+		case d := <-tickSignal:
+			require.Equal(t, tp.cfg.BlockDebounce, d)
+
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for debounce timer")
+		}
+	}
+
+	// sendBeats sends a burst of blocks via ProcessBlock, as done by the
+	// blockbeat dispatcher, which only returns once the block is acked.
+	sendBeats := func(heights ...int32) {
+		t.Helper()
+
+		for _, height := range heights {
+			require.NoError(t, tp.ProcessBlock(newBeat(height)))
+			waitDebounce()
+		}
+	}
+
+	// assertNoBump checks no fee bump is evaluated.
+	assertNoBump := func() {
+		t.Helper()
+
+		select {
+		case confTarget := <-confTargets:
+			t.Fatalf("unexpected fee bump with conf target %v",
+				confTarget)
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// settle elapses the debounce window.
+	now := startTime
+	settle := func() {
+		now = now.Add(tp.cfg.BlockDebounce)
+		testClock.SetTime(now)
+	}
+
+	// Send a rapid disconnect and connect sequence. Each block should be
+	// acked right away, but no fee bump should be evaluated while the
+	// chain is not settled.
+	sendBeats(101, 100, 101, 102)
+	require.EqualValues(t, 102, tp.CurrentHeight())
+	assertNoBump()
+
+	// Once the debounce window elapses, the fee bump should be evaluated
+	// exactly once at the new tip.
+	settle()
+
+	select {
+	case confTarget := <-confTargets:
+		require.EqualValues(t, 8, confTarget)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for fee bump")
+	}
+	assertNoBump()
+
+	// A repeated block at the processed height should not be evaluated
+	// again.
+	sendBeats(102)
+	settle()
+	assertNoBump()
+
+	// A reorg that settles at the same height should be evaluated again.
+	sendBeats(101, 102)
+	settle()
+
+	select {
+	case confTarget := <-confTargets:
+		require.EqualValues(t, 8, confTarget)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for fee bump")
+	}
+	assertNoBump()
+}
+
+// TestHandleInitialBroadcastSuccess checks `handleInitialBroadcast` method can
+// successfully broadcast a tx based on the request.
+func TestHandleInitialBroadcastSuccess(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate.
+	feerate := chainfee.SatPerKWeight(1000)
+
+	// Mock the fee estimator to return the testing fee rate.
+	//
+	// We are not testing `NewLinearFeeFunction` here, so the actual params
+	// used are irrelevant.
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	// Mock the testmempoolaccept to pass.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Mock the wallet to publish successfully.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Create a test request.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	// Create a testing bump request.
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      feerate * 10,
+		DeadlineHeight:  10,
+	}
+
+	// Register the testing record use `Broadcast`.
+	resultChan := tp.Broadcast(req)
+
+	// Grab the monitor record from the map.
+	rid := tp.requestCounter.Load()
+	rec, ok := tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test.
+	tp.wg.Add(1)
+	tp.handleInitialBroadcast(rec, rid)
+
+	// Check the result is sent back.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-resultChan:
+		// We expect the first result to be TxPublished.
+		require.Equal(t, TxPublished, result.Event)
+	}
+
+	// Validate the record was stored.
+	require.Equal(t, 1, tp.records.Len())
+	require.Equal(t, 1, tp.subscriberChans.Len())
+}
+
+// TestHandleInitialBroadcastDeadlineUnreachable checks a TxDeadlineUnreachable
+// event is sent when the fee rate required by the deadline is above the max fee
+// rate allowed, and the tx is published at the max fee rate allowed.
+func TestHandleInitialBroadcastDeadlineUnreachable(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing bump request.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
+		DeadlineHeight:  10,
+	}
+
+	maxFeeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+
+	// Mock the fee estimator to require a fee rate above the max fee rate
+	// allowed for the deadline.
+	m.estimator.On("EstimateFeePerKW", uint32(10)).Return(
+		maxFeeRate*10, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+
+	// Mock the signer, mempool check and publish to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Register the testing record use `Broadcast`.
+	resultChan := tp.Broadcast(req)
+	rid := tp.requestCounter.Load()
+	rec, ok := tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test in a goroutine as the warning is sent
+	// after the published result.
+	go tp.handleInitialBroadcast(rec, rid)
+
+	// We expect the tx to be published at the max fee rate allowed.
+	var published *BumpResult
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for published result")
+
+	case published = <-resultChan:
+		require.Equal(t, TxPublished, published.Event)
+		require.Equal(t, maxFeeRate, published.FeeRate)
+	}
+
+	// Then the warning is sent.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for warning")
+
+	case result := <-resultChan:
+		require.Equal(t, TxDeadlineUnreachable, result.Event)
+		require.Equal(t, published.Tx, result.Tx)
+		require.Equal(t, maxFeeRate, result.FeeRate)
+		require.NoError(t, result.Validate())
+	}
+
+	// The record is still monitored, and its fee function stays at the
+	// max fee rate allowed for the following fee bumps.
+	rec, ok = tp.records.Load(rid)
+	require.True(t, ok)
+	require.Equal(t, maxFeeRate, rec.feeFunction.FeeRate())
+
+	increased, err := rec.feeFunction.IncreaseFeeRate(5, rec.fee, 0)
+	require.NoError(t, err)
+	require.False(t, increased)
+	require.Equal(t, maxFeeRate, rec.feeFunction.FeeRate())
+}
+
+// TestHandleInitialBroadcastUneconomical checks the max fee of a tiny input is
+// capped at the MaxFeeFractionOfValue, and a TxUneconomical event is sent when
+// the deadline cannot be reached within that cap.
+func TestHandleInitialBroadcastUneconomical(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing bump request with a tiny input, whose value cap is
+	// well below the budget.
+	inp := createTestInput(2000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress:       changePkScript,
+		Inputs:                []input.Input{&inp},
+		Budget:                btcutil.Amount(1000),
+		MaxFeeRate:            chainfee.SatPerKWeight(100_000),
+		MaxFeeFractionOfValue: 0.1,
+		DeadlineHeight:        10,
+	}
+
+	// The value cap binds, so the max fee is 10% of the input value.
+	require.Equal(t, btcutil.Amount(200), req.maxFee())
+
+	maxFeeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+
+	// Mock the fee estimator to require a fee rate above the max fee rate
+	// allowed for the deadline.
+	m.estimator.On("EstimateFeePerKW", uint32(10)).Return(
+		maxFeeRate*10, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+
+	// Mock the signer, mempool check and publish to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Register the testing record use `Broadcast`.
+	resultChan := tp.Broadcast(req)
+	rid := tp.requestCounter.Load()
+	rec, ok := tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test in a goroutine as the warnings are sent
+	// after the published result.
+	go tp.handleInitialBroadcast(rec, rid)
+
+	// We expect the tx to be published at the max fee rate allowed, which
+	// doesn't pay more than the value cap.
+	var published *BumpResult
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for published result")
+
+	case published = <-resultChan:
+		require.Equal(t, TxPublished, published.Event)
+		require.Equal(t, maxFeeRate, published.FeeRate)
+		require.LessOrEqual(t, published.Fee, btcutil.Amount(200))
+	}
+
+	// Then the deadline unreachable warning is sent, followed by the
+	// uneconomical warning.
+	for _, event := range []BumpEvent{
+		TxDeadlineUnreachable, TxUneconomical,
+	} {
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", event)
+
+		case result := <-resultChan:
+			require.Equal(t, event, result.Event)
+			require.Equal(t, published.Tx, result.Tx)
+			require.Equal(t, maxFeeRate, result.FeeRate)
+			require.NoError(t, result.Validate())
+		}
+	}
+}
+
+// TestBroadcastMaxFeeFraction checks Broadcast rejects a request with an
+// invalid max fee fraction.
+func TestBroadcastMaxFeeFraction(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := createTestPublisher(t)
+
+	req := createTestBumpRequest()
+	req.MaxFeeFractionOfValue = 1.5
+
+	result := <-tp.Broadcast(req)
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, ErrInvalidMaxFeeFraction)
+}
+
+// TestHandleInitialBroadcastMempoolAccepted checks a TxMempoolAccepted event is
+// sent before the TxPublished event when EmitMempoolAccept is set, and is
+// absent otherwise.
+func TestHandleInitialBroadcastMempoolAccepted(t *testing.T) {
+	t.Parallel()
+
+	for _, emit := range []bool{true, false} {
+		t.Run(fmt.Sprintf("emit=%v", emit), func(t *testing.T) {
+			t.Parallel()
+
+			testHandleInitialBroadcastMempoolAccepted(t, emit)
+		})
+	}
+}
+
+func testHandleInitialBroadcastMempoolAccepted(t *testing.T, emit bool) {
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a testing bump request.
+	feerate := chainfee.SatPerKWeight(1000)
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress:   changePkScript,
+		Inputs:            []input.Input{&inp},
+		Budget:            btcutil.Amount(1000),
+		MaxFeeRate:        feerate * 10,
+		DeadlineHeight:    10,
+		EmitMempoolAccept: emit,
+	}
+
+	// Mock the fee estimator, signer, mempool check and publish to
+	// succeed.
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Register the testing record use `Broadcast`.
+	resultChan := tp.Broadcast(req)
+	rid := tp.requestCounter.Load()
+	rec, ok := tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test in a goroutine as the accept event is
+	// sent before the published result.
+	go tp.handleInitialBroadcast(rec, rid)
+
+	// If enabled, the accept event is sent first.
+	var accepted *BumpResult
+	if emit {
+		select {
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for accept event")
+
+		case accepted = <-resultChan:
+			require.Equal(t, TxMempoolAccepted, accepted.Event)
+			require.NotNil(t, accepted.Tx)
+			require.NotZero(t, accepted.FeeRate)
+			require.NoError(t, accepted.Validate())
+		}
+	}
+
+	// Then the published event is sent.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for published result")
+
+	case result := <-resultChan:
+		require.Equal(t, TxPublished, result.Event)
+
+		if accepted != nil {
+			require.Equal(t, accepted.Tx, result.Tx)
+			require.Equal(t, accepted.FeeRate, result.FeeRate)
+		}
+	}
+
+	// No more events are expected.
+	select {
+	case result := <-resultChan:
+		t.Fatalf("unexpected result: %v", result)
+
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHandleInitialBroadcastFail checks `handleInitialBroadcast` returns the
+// error or a failed result when the broadcast fails.
+func TestHandleInitialBroadcastFail(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate.
+	feerate := chainfee.SatPerKWeight(1000)
+
+	// Create a test request.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+
+	// Create a testing bump request.
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      feerate * 10,
+		DeadlineHeight:  10,
+	}
+
+	// Mock the fee estimator to return the testing fee rate.
+	//
+	// We are not testing `NewLinearFeeFunction` here, so the actual params
+	// used are irrelevant.
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Twice()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Twice()
+
+	// Mock the signer to always return a valid script.
+	//
+	// NOTE: we are not testing the utility of creating valid txes here, so
+	// this is fine to be mocked. This behaves essentially as skipping the
+	// Signer check and alaways assume the tx has a valid sig.
+	script := &input.Script{}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(script, nil)
+
+	// Mock the testmempoolaccept to return an error.
+	m.wallet.On("CheckMempoolAcceptance",
+		mock.Anything).Return(errDummy).Once()
+
+	// Register the testing record use `Broadcast`.
+	resultChan := tp.Broadcast(req)
+
+	// Grab the monitor record from the map.
+	rid := tp.requestCounter.Load()
+	rec, ok := tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test and expect an error returned.
+	tp.wg.Add(1)
+	tp.handleInitialBroadcast(rec, rid)
+
+	// Check the result is sent back.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-resultChan:
+		// We expect the first result to be TxFatal.
+		require.Equal(t, TxFatal, result.Event)
+	}
+
+	// Validate the record was NOT stored.
+	require.Equal(t, 0, tp.records.Len())
+	require.Equal(t, 0, tp.subscriberChans.Len())
+
+	// Mock the testmempoolaccept again, this time it passes.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Mock the wallet to fail on publish.
+	m.wallet.On("PublishTransaction",
+		mock.Anything, mock.Anything).Return(errDummy).Once()
+
+	// Register the testing record use `Broadcast`.
+	resultChan = tp.Broadcast(req)
+
+	// Grab the monitor record from the map.
+	rid = tp.requestCounter.Load()
+	rec, ok = tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test.
+	tp.wg.Add(1)
+	tp.handleInitialBroadcast(rec, rid)
+
+	// Check the result is sent back.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-resultChan:
+		// We expect the result to be TxFailed and the error is set in
+		// the result.
+		require.Equal(t, TxFailed, result.Event)
+		require.ErrorIs(t, result.Err, errDummy)
+	}
+
+	// Validate the record was removed.
+	require.Equal(t, 0, tp.records.Len())
+	require.Equal(t, 0, tp.subscriberChans.Len())
+}
+
+// TestHandleInitialBroadcastAlreadyConfirmed checks that when an input of the
+// request is already spent by a confirmed tx from our wallet, a TxConfirmed
+// result is sent without broadcasting.
+func TestHandleInitialBroadcastAlreadyConfirmed(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test input with a height hint so it's checked for spends.
+	op := wire.OutPoint{Hash: chainhash.Hash{1, 2, 3}, Index: 1}
+	inp := input.MakeBaseInput(
+		&op, input.WitnessKeyHash, &input.SignDescriptor{
+			Output: &wire.TxOut{Value: 10_000},
+			KeyDesc: keychain.KeyDescriptor{
+				PubKey: testPubKey,
+			},
+		}, 100, nil,
+	)
+
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      10_000,
+		DeadlineHeight:  10,
+	}
+
+	// Create the confirmed tx that already swept the input.
+	confTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{PreviousOutPoint: op}},
+		TxOut: []*wire.TxOut{{
+			Value:    9_000,
+			PkScript: changePkScript.DeliveryAddress,
+		}},
+	}
+	confTxid := confTx.TxHash()
+
+	// Mock the notifier to return the spend immediately.
+	spendChan := make(chan *chainntnfs.SpendDetail, 1)
+	spendChan <- &chainntnfs.SpendDetail{
+		SpentOutPoint: &op,
+		SpendingTx:    confTx,
+	}
+	m.notifier.On("RegisterSpendNtfn", &op, mock.Anything,
+		uint32(100)).Return(&chainntnfs.SpendEvent{
+		Spend:  spendChan,
+		Cancel: func() {},
+	}, nil).Once()
+
+	// Mock the wallet to report the tx as confirmed. The wallet doesn't
+	// know the fee, so it's calculated from the inputs.
+	blockHash := chainhash.Hash{9}
+	m.wallet.On("GetTransactionDetails", &confTxid).Return(
+		&lnwallet.TransactionDetail{
+			NumConfirmations: 1,
+			BlockHeight:      900,
+			BlockHash:        &blockHash,
+		}, nil).Once()
+
+	resultChan := tp.Broadcast(req)
+
+	rid := tp.requestCounter.Load()
+	rec, ok := tp.records.Load(rid)
+	require.True(t, ok)
+
+	// Call the method under test. No tx should be created or published.
+	tp.handleInitialBroadcast(rec, rid)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscriber to receive result")
+
+	case result := <-resultChan:
+		require.Equal(t, TxConfirmed, result.Event)
+		require.Equal(t, confTxid, result.Tx.TxHash())
+		require.Equal(t, btcutil.Amount(1_000), result.Fee)
+		require.Equal(t, uint32(900), result.ConfHeight)
+		require.Equal(t, blockHash, result.ConfBlockHash)
+		require.NoError(t, result.Validate())
+	}
+
+	// The record should be removed.
+	require.Zero(t, tp.records.Len())
+	m.wallet.AssertNotCalled(t, "PublishTransaction", mock.Anything,
+		mock.Anything)
+}
+
+// TestDryRun checks that `DryRun` builds a valid tx without publishing it or
+// leaving any records behind.
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate.
+	feerate := chainfee.SatPerKWeight(1000)
+
+	// Mock the fee estimator to return the testing fee rate.
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+
+	// Mock the signer to always return a valid script.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+
+	// Mock the testmempoolaccept to pass. Notice that PublishTransaction
+	// is not mocked, which asserts it's never called.
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Create a testing bump request.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(1000),
+		MaxFeeRate:      feerate * 10,
+		DeadlineHeight:  10,
+	}
+
+	// Get the current counter and check it's unchanged later.
+	initialCounter := tp.requestCounter.Load()
+
+	// Call the method under test.
+	tx, rate, fee, err := tp.DryRun(req)
+	require.NoError(t, err)
+
+	// The tx should spend the input and pay the remaining value to the
+	// outputs.
+	require.NotNil(t, tx)
+	require.Len(t, tx.TxIn, 1)
+	require.Equal(t, inp.OutPoint(), tx.TxIn[0].PreviousOutPoint)
+	require.NotEmpty(t, tx.TxOut)
+
+	var totalOutput int64
+	for _, txOut := range tx.TxOut {
+		require.EqualValues(
+			t, changePkScript.DeliveryAddress, txOut.PkScript,
+		)
+		totalOutput += txOut.Value
+	}
+	require.EqualValues(t, inp.SignDesc().Output.Value-int64(fee),
+		totalOutput)
+	require.Equal(t, feerate, rate)
+
+	// No record should be left behind and the counter is unchanged.
+	require.Zero(t, tp.records.Len())
+	require.Zero(t, tp.subscriberChans.Len())
+	require.Equal(t, initialCounter, tp.requestCounter.Load())
+}
+
+// TestDryRunBudgetFraction checks `DryRun` derives the budget of a request
+// using a budget fraction as when it's broadcast, and rejects the requests
+// that would be rejected by a broadcast.
+func TestDryRunBudgetFraction(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create a test feerate.
+	feerate := chainfee.SatPerKWeight(1000)
+
+	// Mock the fee estimator to return the testing fee rate.
+	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
+		feerate, nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Maybe()
+
+	// Mock the signer and the testmempoolaccept to pass.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+
+	// Create a testing bump request which only specifies a budget
+	// fraction.
+	inp := createTestInput(1000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		BudgetFraction:  0.5,
+		MaxFeeRate:      feerate * 10,
+		DeadlineHeight:  10,
+	}
+
+	// The tx should be built using the derived budget, and the request
+	// should not be mutated.
+	tx, rate, fee, err := tp.DryRun(req)
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+	require.Equal(t, feerate, rate)
+	require.LessOrEqual(t, fee, btcutil.Amount(500))
+	require.Zero(t, req.Budget)
+
+	// A request with a conflicting budget should be rejected.
+	req.Budget = 100
+	_, _, _, err = tp.DryRun(req)
+	require.ErrorIs(t, err, ErrConflictingBudget)
+}
+
+// TestHandleResultRawTx checks that the raw tx is attached to a TxFailed
+// result only when `LogFailedTx` is enabled.
+func TestHandleResultRawTx(t *testing.T) {
+	t.Parallel()
+
+	// Create a test tx and serialize it.
+	tx := &wire.MsgTx{LockTime: 1}
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+
+	testCases := []struct {
+		name        string
+		logFailedTx bool
+		event       BumpEvent
+		expectedRaw []byte
+	}{
+		{
+			name:        "flag enabled",
+			logFailedTx: true,
+			event:       TxFailed,
+			expectedRaw: buf.Bytes(),
+		},
+		{
+			name:        "flag disabled",
+			logFailedTx: false,
+			event:       TxFailed,
+		},
+		{
+			name:        "not a failed event",
+			logFailedTx: true,
+			event:       TxPublished,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Create a publisher using the mocks.
+			tp, _ := createTestPublisher(t)
+			tp.cfg.LogFailedTx = tc.logFailedTx
+
+			// Create a subscription to the event.
+			requestID := uint64(1)
+			subscriber := make(chan *BumpResult, 1)
+			tp.subscriberChans.Store(requestID, subscriber)
+
+			tp.handleResult(&BumpResult{
+				Event:     tc.event,
+				Tx:        tx,
+				Err:       errDummy,
+				requestID: requestID,
+			})
+
+			select {
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for result")
+
+			case result := <-subscriber:
+				require.Equal(t, tc.expectedRaw, result.RawTx)
+			}
+		})
+	}
+}
+
+// TestHandleResultFeeUndershoot checks a TxFeeUndershoot event is sent when the
+// weight of the tx is under-estimated, and the record is marked to pad its
+// next fee bump.
+func TestHandleResultFeeUndershoot(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+	tp.cfg.FeeUndershootTolerance = 0.1
+	tp.cfg.PadFeeOnUndershoot = true
+
+	// Mock the signer to return a witness that's much larger than the
+	// estimated witness size of a p2wkh input, so the tx weight is
+	// under-estimated.
+	m.signer.On("ComputeInputScript", mock.Anything, mock.Anything).Return(
+		&input.Script{Witness: wire.TxWitness{make([]byte, 1000)}}, nil,
+	)
+
+	// Create a sweep tx using the intended fee rate.
+	feeRate := chainfee.SatPerKWeight(1000)
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	sweepCtx, err := tp.createSweepTx(
+		[]input.Input{&inp}, changePkScript, feeRate, 0,
+		fn.None[input.TxInfo](), nil, false,
+	)
+	require.NoError(t, err)
+
+	// Create a testing record and a subscription to the event.
+	requestID := uint64(1)
+	req := createTestBumpRequest()
+	tp.storeRecord(
+		requestID, sweepCtx.tx, req, m.feeFunc, sweepCtx.fee,
+		sweepCtx.outpointToTxIndex,
+	)
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Handle a published result in a goroutine as two results are
+	// expected.
+	go tp.handleResult(&BumpResult{
+		Event:     TxPublished,
+		Tx:        sweepCtx.tx,
+		Fee:       sweepCtx.fee,
+		FeeRate:   feeRate,
+		requestID: requestID,
+	})
+
+	// We expect the published result first, followed by the undershoot
+	// diagnostic.
+	for _, expected := range []BumpEvent{TxPublished, TxFeeUndershoot} {
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %v", expected)
+
+		case result := <-subscriber:
+			require.Equal(t, expected, result.Event)
+			require.NoError(t, result.Validate())
+
+			if expected == TxFeeUndershoot {
+				require.Less(t, result.FeeRate, feeRate)
+			}
+		}
+	}
+
+	// The record should be marked to pad its next bump.
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.True(t, record.padNextBump)
+}
+
+// TestHalt checks that once halted, the in-flight records are failed with the
+// halt reason, and new broadcast requests are rejected.
+func TestHalt(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+
+	// Create an in-flight record and a subscription to the event.
+	tx := &wire.MsgTx{LockTime: 1}
+	req := createTestBumpRequest()
+	requestID := uint64(1)
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{},
+	)
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Mock the wallet to cancel the rebroadcast of the tx.
+	m.wallet.On("CancelRebroadcast", tx.TxHash()).Return().Once()
+
+	// Halt the publisher.
+	reason := "key compromised"
+	tp.Halt(reason)
+
+	// We expect the in-flight record to be failed with the halt reason.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for result")
+
+	case result := <-subscriber:
+		require.Equal(t, TxFailed, result.Event)
+		require.Equal(t, tx, result.Tx)
+		require.ErrorIs(t, result.Err, ErrPublisherHalted)
+		require.ErrorContains(t, result.Err, reason)
+	}
+
+	// The record should be removed.
+	require.Eventually(t, func() bool {
+		_, found := tp.records.Load(requestID)
+		return !found
+	}, time.Second, 10*time.Millisecond)
+
+	// Halting again is a no-op.
+	tp.Halt("another reason")
+
+	// A new broadcast request should be rejected.
+	resultChan := tp.Broadcast(createTestBumpRequest())
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for result")
+
+	case result := <-resultChan:
+		require.Equal(t, TxFailed, result.Event)
+		require.ErrorIs(t, result.Err, ErrPublisherHalted)
+		require.ErrorContains(t, result.Err, reason)
+	}
+	require.Zero(t, tp.records.Len())
+
+	// Nothing should be published once halted.
+	tp.storeRecord(
+		requestID, tx, req, m.feeFunc, 1000, map[wire.OutPoint]int{},
+	)
+	_, err := tp.broadcast(requestID)
+	require.ErrorIs(t, err, ErrPublisherHalted)
+}
+
+// TestStopDrainsPendingResults checks that a graceful stop delivers the pending
+// results to the subscribers and rejects new broadcast requests.
+func TestStopDrainsPendingResults(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Create a subscription with its buffer already filled so the next
+	// result is pending.
+	requestID := uint64(1)
+	subscriber := make(chan *BumpResult, 1)
+	subscriber <- &BumpResult{Event: TxPublished, requestID: requestID}
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Send a pending result.
+	pending := &BumpResult{Event: TxReplaced, requestID: requestID}
+	go tp.notifyResult(pending)
+
+	// Wait for the result to be pending.
+	require.Eventually(t, func() bool {
+		return tp.pendingResults.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Stop the publisher in a goroutine.
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- tp.Stop(context.Background())
+	}()
+
+	// New broadcast requests should be rejected once stopping.
+	require.Eventually(t, tp.stopped.Load, time.Second,
+		10*time.Millisecond)
+	result := <-tp.Broadcast(createTestBumpRequest())
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, ErrPublisherStopped)
+
+	// Read the results, the pending one should be delivered.
+	require.Equal(t, TxPublished, (<-subscriber).Event)
+	require.Equal(t, pending, <-subscriber)
+
+	// Stop should now return without error.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for stop")
+
+	case err := <-errChan:
+		require.NoError(t, err)
+	}
+}
+
+// TestStopContextCancelled checks that a graceful stop gives up delivering the
+// pending results once the context is cancelled.
+func TestStopContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, _ := createTestPublisher(t)
+
+	// Create a subscription with its buffer already filled, and no one
+	// reading from it.
+	requestID := uint64(1)
+	subscriber := make(chan *BumpResult, 1)
+	subscriber <- &BumpResult{Event: TxPublished, requestID: requestID}
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Send a pending result, which is tracked by the wait group so we can
+	// assert it exits once stopped.
+	tp.wg.Add(1)
+	go func() {
+		defer tp.wg.Done()
+		tp.notifyResult(&BumpResult{
+			Event:     TxReplaced,
+			requestID: requestID,
+		})
+	}()
+
+	// Wait for the result to be pending.
+	require.Eventually(t, func() bool {
+		return tp.pendingResults.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Stop the publisher using a context with a short timeout.
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 50*time.Millisecond,
+	)
+	defer cancel()
+
+	err := tp.Stop(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The pending result is dropped once stopped.
+	require.Zero(t, tp.pendingResults.Load())
+}
+
+// TestNotifyResultBlocking checks results are buffered up to the configured
+// size, after which sending a result blocks until the subscriber receives.
+func TestNotifyResultBlocking(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher with a subscriber buffer of two results.
+	tp, _ := createTestPublisher(t)
+	tp.cfg.SubscriberBufferSize = 2
+
+	requestID := uint64(1)
+	subscriber := tp.newSubscriber()
+	require.Equal(t, 2, cap(subscriber))
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// The first two results fill up the buffer without blocking.
+	results := make([]*BumpResult, 3)
+	for i := range results {
+		results[i] = &BumpResult{
+			Event:     TxPublished,
+			Tx:        &wire.MsgTx{LockTime: uint32(i)},
+			requestID: requestID,
+		}
+	}
+	tp.notifyResult(results[0])
+	tp.notifyResult(results[1])
+
+	// The third result blocks until the subscriber receives one.
+	done := make(chan struct{})
+	go func() {
+		tp.notifyResult(results[2])
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected notifyResult to block")
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// All results should be received in order.
+	for _, expected := range results {
+		require.Equal(t, expected, <-subscriber)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for notifyResult")
+	}
+}
+
+// TestNotifyResultDropOldest checks the oldest buffered result is dropped
+// instead of blocking when the subscriber's chan is full.
+func TestNotifyResultDropOldest(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher with a subscriber buffer of two results, which
+	// drops the oldest result when full.
+	tp, _ := createTestPublisher(t)
+	tp.cfg.SubscriberBufferSize = 2
+	tp.cfg.DropOldestResult = true
+
+	requestID := uint64(1)
+	subscriber := tp.newSubscriber()
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	// Send four informational results without receiving any, which
+	// should not block.
+	results := make([]*BumpResult, 4)
+	for i := range results {
+		results[i] = &BumpResult{
+			Event:     TxFeeBumped,
+			Tx:        &wire.MsgTx{LockTime: uint32(i)},
+			requestID: requestID,
+		}
+		tp.notifyResult(results[i])
+	}
+
+	// Only the latest two results should be kept.
+	require.Len(t, subscriber, 2)
+	require.Equal(t, results[2], <-subscriber)
+	require.Equal(t, results[3], <-subscriber)
+	require.Zero(t, tp.pendingResults.Load())
+}
+
+// TestNotifyResultDropOldestKeepsTerminal checks only the informational
+// results are dropped when the subscriber's chan is full, and the results
+// changing the state of the request are delivered in order by blocking.
+func TestNotifyResultDropOldestKeepsTerminal(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher with a subscriber buffer of two results, which
+	// drops the oldest result when full.
+	tp, _ := createTestPublisher(t)
+	tp.cfg.SubscriberBufferSize = 2
+	tp.cfg.DropOldestResult = true
+
+	requestID := uint64(1)
+	subscriber := tp.newSubscriber()
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	newResult := func(event BumpEvent) *BumpResult {
+		return &BumpResult{
+			Event:     event,
+			Tx:        &wire.MsgTx{},
+			requestID: requestID,
+		}
+	}
+
+	// Fill the chan with a published and an informational result.
+	published := newResult(TxPublished)
+	bumped := newResult(TxFeeBumped)
+	tp.notifyResult(published)
+	tp.notifyResult(bumped)
+
+	// A replaced result should take the place of the informational one.
+	replaced := newResult(TxReplaced)
+	tp.notifyResult(replaced)
+	require.Len(t, subscriber, 2)
+
+	// An informational result should be dropped itself as none of the
+	// buffered results can be dropped.
+	tp.notifyResult(newResult(TxMempoolAccepted))
+	require.Len(t, subscriber, 2)
+
+	// A confirmed result cannot be dropped, so it should block until the
+	// subscriber makes room for it.
+	confirmed := newResult(TxConfirmed)
+	done := make(chan struct{})
+	go func() {
+		tp.notifyResult(confirmed)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("notifyResult should block")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// All the results changing the state should be received in order.
+	require.Equal(t, published, <-subscriber)
+	require.Equal(t, replaced, <-subscriber)
+	require.Equal(t, confirmed, <-subscriber)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for notifyResult")
+	}
+}
+
+// TestDrainPendingResultsSignal checks that the drain loop waits for the
+// pending results to be delivered without polling, and returns once they are.
+func TestDrainPendingResultsSignal(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using a mocked clock, which should not be used to
+	// schedule any polls.
+	tp, _ := createTestPublisher(t)
+	startTime := time.Unix(1_000_000, 0)
+	tickSignal := make(chan time.Duration, 1)
+	testClock := clock.NewTestClockWithTickSignal(startTime, tickSignal)
+	tp.cfg.Clock = testClock
+
+	// Mark two results as pending and start draining.
+	tp.pendingResults.Store(2)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- tp.drainPendingResults(context.Background())
+	}()
+
+	// assertWaiting checks the drain is still waiting.
+	assertWaiting := func() {
+		t.Helper()
+
+		select {
+		case err := <-errChan:
+			t.Fatalf("unexpected drain return: %v", err)
+
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	assertWaiting()
+
+	// Delivering one of the results should not end the drain.
+	tp.donePendingResult()
+	assertWaiting()
+
+	// Once the last pending result is delivered, the drain should end.
+	tp.donePendingResult()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for drain")
+	}
+
+	// No polls should have been scheduled.
+	require.Empty(t, tickSignal)
+}
+
+// TestBroadcastTimeout checks a request not confirmed within its timeout is
+// removed and a TxTimedOut event is sent, while a confirmed one is kept.
+func TestBroadcastTimeout(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using a mocked clock.
+	tp, m := createTestPublisher(t)
+	startTime := time.Unix(1_000_000, 0)
+	tickSignal := make(chan time.Duration)
+	testClock := clock.NewTestClockWithTickSignal(startTime, tickSignal)
+	tp.cfg.Clock = testClock
+
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	timeout := time.Minute
+
+	// broadcast sends a request using the timeout, and mocks its tx being
+	// published once the timer is started.
+	broadcast := func(confirmed bool) (uint64, *wire.MsgTx,
+		<-chan *BumpResult) {
+
+		req := createTestBumpRequest()
+		req.Timeout = timeout
+		subscriber := tp.Broadcast(req)
+
+		select {
+		case d := <-tickSignal:
+			require.Equal(t, timeout, d)
+
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for timer")
+		}
+
+		requestID := tp.requestCounter.Load()
+		tx := &wire.MsgTx{LockTime: uint32(requestID)}
+		record := tp.storeRecord(
+			requestID, tx, req, m.feeFunc, 1000, nil,
+		)
+
+		updated := *record
+		updated.confirmed = confirmed
+		tp.records.Store(requestID, &updated)
+
+		return requestID, tx, subscriber
+	}
+
+	// An unconfirmed tx should time out once the timeout elapses.
+	requestID, tx, subscriber := broadcast(false)
+	testClock.SetTime(startTime.Add(timeout))
+
+	select {
+	case result := <-subscriber:
+		require.Equal(t, TxTimedOut, result.Event)
+		require.ErrorIs(t, result.Err, ErrTimedOut)
+		require.Equal(t, tx, result.Tx)
+		require.Equal(t, feerate, result.FeeRate)
+		require.NoError(t, result.Validate())
+
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for timed out result")
+	}
+
+	// The request should no longer be monitored.
+	_, found := tp.records.Load(requestID)
+	require.False(t, found)
+	_, found = tp.subscriberChans.Load(requestID)
+	require.False(t, found)
+	_, found = tp.doneChans.Load(requestID)
+	require.False(t, found)
+
+	// A confirmed tx should not time out.
+	requestID, _, subscriber = broadcast(true)
+	testClock.SetTime(startTime.Add(2 * timeout))
+
+	select {
+	case result := <-subscriber:
+		t.Fatalf("unexpected result: %v", result)
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, found = tp.records.Load(requestID)
+	require.True(t, found)
+}
+
+// TestAnchorParentCPFP checks the fee of a sweeping tx that CPFPs its
+// unconfirmed parents is calculated using the package fee rate.
+func TestAnchorParentCPFP(t *testing.T) {
+	t.Parallel()
+
+	// newAnchor creates an anchor input spending from the given parent.
+	newAnchor := func(hash chainhash.Hash,
+		parent input.TxInfo) input.BaseInput {
+
+		return input.MakeBaseInput(
+			&wire.OutPoint{Hash: hash}, input.CommitmentAnchor,
+			&input.SignDescriptor{
+				Output: &wire.TxOut{Value: 100_000},
+				KeyDesc: keychain.KeyDescriptor{
+					PubKey: testPubKey,
+				},
+			},
+			0, &parent,
+		)
+	}
+
+	// Create two anchor inputs whose parents pay 250 sat/kw, and another
+	// one whose parent already pays 25,000 sat/kw.
+	parent1 := input.TxInfo{Fee: 1000, Weight: 4000}
+	parent2 := input.TxInfo{Fee: 500, Weight: 2000}
+	richParent := input.TxInfo{Fee: 100_000, Weight: 4000}
+
+	anchor1 := newAnchor(chainhash.Hash{1}, parent1)
+	anchor2 := newAnchor(chainhash.Hash{2}, parent2)
+	anchor3 := newAnchor(chainhash.Hash{3}, richParent)
+	inputs := []input.Input{&anchor1, &anchor2, &anchor3}
+
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          inputs,
+		Budget:          btcutil.Amount(10_000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
+	}
+
+	// The package should include both parents paying less than the fee
+	// rate, but not the one already paying enough.
+	sweepAddrs := [][]byte{changePkScript.DeliveryAddress}
+	childWeight, err := calcSweepTxWeight(inputs, sweepAddrs, nil)
+	require.NoError(t, err)
+
+	feeRate := chainfee.SatPerKWeight(5000)
+	estimator, err := estimateSweepPackage(
+		inputs, sweepAddrs, feeRate, fn.None[input.TxInfo](), nil,
+	)
+	require.NoError(t, err)
+
+	parentsFee := parent1.Fee + parent2.Fee
+	packageWeight := childWeight + parent1.Weight + parent2.Weight
+	require.Equal(t, parentsFee, estimator.parentsFee)
+	require.Equal(t, packageWeight, estimator.packageWeight())
+
+	// The max fee rate allowed should count the parents' fees towards the
+	// package.
+	maxFeeRate, err := req.MaxFeeRateAllowed()
+	require.NoError(t, err)
+	require.Equal(t, chainfee.NewSatPerKWeight(
+		req.Budget+parentsFee, packageWeight,
+	), maxFeeRate)
+
+	// Calculate the fee using a target fee rate, the sweeping tx should
+	// pay for the parents' deficit so the package meets the fee rate.
+	fee, _, _, err := prepareSweepTx(
+		inputs, changePkScript, feeRate, 100, fn.None[AuxSweeper](), 0,
+		fn.None[input.TxInfo](), nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, feeRate.FeeForWeight(packageWeight)-parentsFee, fee)
+
+	// An explicitly specified parent is used instead of the parents of
+	// the inputs.
+	explicit := input.TxInfo{Fee: 2000, Weight: 4000}
+	fee, _, _, err = prepareSweepTx(
+		inputs, changePkScript, feeRate, 100, fn.None[AuxSweeper](), 0,
+		fn.Some(explicit), nil,
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t, feeRate.FeeForWeight(childWeight+explicit.Weight)-
+			explicit.Fee, fee,
+	)
+
+	// When the explicit parent already pays more than the target fee
+	// rate, the sweeping tx only pays for itself.
+	fee, _, _, err = prepareSweepTx(
+		inputs, changePkScript, feeRate, 100, fn.None[AuxSweeper](), 0,
+		fn.Some(richParent), nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, feeRate.FeeForWeight(childWeight), fee)
+}
+
+// TestEarliestDeadline checks the conf target used by the publisher follows the
+// earliest deadline among the inputs.
+func TestEarliestDeadline(t *testing.T) {
+	t.Parallel()
+
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(100)
+
+	// Create two inputs with different deadlines.
+	inp1 := createTestInput(100_000, input.WitnessKeyHash)
+	inp2 := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp1, &inp2},
+		Budget:          btcutil.Amount(10_000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
+		DeadlineHeight:  130,
+		InputDeadlines: map[wire.OutPoint]int32{
+			inp1.OutPoint(): 120,
+			inp2.OutPoint(): 110,
+		},
+	}
+
+	// The earliest deadline should be used.
+	require.EqualValues(t, 110, req.EarliestDeadline())
+
+	// Mock the estimator to assert the fee function is initialized using
+	// the conf target derived from the earliest deadline.
+	m.estimator.On("EstimateFeePerKW", uint32(10)).Return(
+		chainfee.SatPerKWeight(1000), nil).Once()
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+
+	_, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+
+	// Remove the urgent input, the next-earliest deadline should now be
+	// used.
+	req.Inputs = []input.Input{&inp1}
+	require.EqualValues(t, 120, req.EarliestDeadline())
+
+	// Mock the fee function to assert the conf target is recomputed
+	// against the next-earliest deadline.
+	m.feeFunc.On("RebaseFloor", mock.Anything).Return(false).Once()
+	m.feeFunc.On("IncreaseFeeRate", uint32(20), mock.Anything,
+		mock.Anything).Return(false, nil).Once()
+
+	record := tp.storeRecord(1, &wire.MsgTx{}, req, m.feeFunc, 0, nil)
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(1, record, 100)
+
+	// An input without a specified deadline uses the default deadline.
+	inp3 := createTestInput(100_000, input.WitnessKeyHash)
+	req.Inputs = []input.Input{&inp1, &inp3}
+	req.DeadlineHeight = 115
+	require.EqualValues(t, 115, req.EarliestDeadline())
+}
+
+// TestExtraOutputs checks the extra outputs are accounted for in the weight
+// and change calculation, and are added to the sweep tx.
+func TestExtraOutputs(t *testing.T) {
+	t.Parallel()
+
+	// Create an OP_RETURN output carrying 32 bytes of data, and a p2wkh
+	// output paying 10k sats.
+	opReturn, err := txscript.NullDataScript(make([]byte, 32))
+	require.NoError(t, err)
+
+	p2wkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+		make([]byte, 20)...)
+
+	extraOutputs := []*wire.TxOut{
+		{Value: 0, PkScript: opReturn},
+		{Value: 10_000, PkScript: p2wkh},
+	}
+	require.NoError(t, validateExtraOutputs(extraOutputs))
+
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	inputs := []input.Input{&inp}
+	sweepAddrs := [][]byte{changePkScript.DeliveryAddress}
+
+	// The weight should include the serialized size of the extra outputs.
+	weight, err := calcSweepTxWeight(
+		inputs, sweepAddrs, nil,
+	)
+	require.NoError(t, err)
+	weightWithExtras, err := calcSweepTxWeight(
+		inputs, sweepAddrs, extraOutputs,
+	)
+	require.NoError(t, err)
+
+	var extraWeight lntypes.WeightUnit
+	for _, txOut := range extraOutputs {
+		extraWeight += lntypes.WeightUnit(
+			txOut.SerializeSize() * blockchain.WitnessScaleFactor,
+		)
+	}
+	require.Equal(t, weight+extraWeight, weightWithExtras)
+
+	// The fee should be calculated using the weight of the extra outputs,
+	// and their values should be subtracted from the change.
+	feeRate := chainfee.SatPerKWeight(1000)
+	fee, changeOutsOpt, _, err := prepareSweepTx(
+		inputs, changePkScript, feeRate, 100, fn.None[AuxSweeper](), 0,
+		fn.None[input.TxInfo](), extraOutputs,
+	)
+	require.NoError(t, err)
+	require.Equal(t, feeRate.FeeForWeight(weightWithExtras), fee)
+
+	changeOuts := changeOutsOpt.UnwrapOr(nil)
+	require.Len(t, changeOuts, 1)
+	require.EqualValues(t, 100_000-10_000-fee, changeOuts[0].Value)
+
+	// The extra outputs should be added to the sweep tx.
+	tp, m := createTestPublisher(t)
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+
+	sweepCtx, err := tp.createSweepTx(
+		inputs, changePkScript, feeRate, 0, fn.None[input.TxInfo](),
+		extraOutputs, false,
+	)
+	require.NoError(t, err)
+	require.Contains(t, sweepCtx.tx.TxOut, extraOutputs[0])
+	require.Contains(t, sweepCtx.tx.TxOut, extraOutputs[1])
+}
+
+// TestInputOutputs checks the outputs mapped to the inputs are accounted for
+// in the weight and fee, and are added to the sweep tx with their values.
+func TestInputOutputs(t *testing.T) {
+	t.Parallel()
+
+	tp, m := createTestPublisher(t)
+	tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// Create two inputs, each mapped to its own address.
+	inp1 := createTestInput(100_000, input.WitnessKeyHash)
+	inp2 := createTestInput(50_000, input.WitnessKeyHash)
+
+	p2wkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+		make([]byte, 20)...)
+	p2tr := append([]byte{txscript.OP_1, txscript.OP_DATA_32},
+		make([]byte, 32)...)
+
+	out1 := &wire.TxOut{Value: 60_000, PkScript: p2wkh}
+	out2 := &wire.TxOut{Value: 40_000, PkScript: p2tr}
+
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp1, &inp2},
+		Budget:          10_000,
+		MaxFeeRate:      chainfee.FeePerKwFloor * 10,
+		InputOutputs: map[wire.OutPoint]*wire.TxOut{
+			inp2.OutPoint(): out2,
+			inp1.OutPoint(): out1,
+		},
+	}
+
+	// The outputs are ordered by their inputs.
+	require.Equal(t, []*wire.TxOut{out1, out2}, req.extraOutputs())
+
+	// The weight should include the serialized size of the outputs.
+	sweepAddrs := [][]byte{changePkScript.DeliveryAddress}
+	weight, err := calcSweepTxWeight(
+		req.Inputs, sweepAddrs, nil,
+	)
+	require.NoError(t, err)
+	weightWithOutputs, err := calcSweepTxWeight(
+		req.Inputs, sweepAddrs, req.extraOutputs(),
+	)
+	require.NoError(t, err)
+
+	outputsWeight := lntypes.WeightUnit(
+		(out1.SerializeSize() + out2.SerializeSize()) *
+			blockchain.WitnessScaleFactor,
+	)
+	require.Equal(t, weight+outputsWeight, weightWithOutputs)
+
+	// Create the tx and check the outputs carry the mapped values, while
+	// the change gets what's left after paying the fee.
+	feeRate := chainfee.FeePerKwFloor
+	m.feeFunc.On("FeeRate").Return(feeRate)
+
+	sweepCtx, err := tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
+	require.Equal(t, feeRate.FeeForWeight(weightWithOutputs), sweepCtx.fee)
+
+	txOuts := sweepCtx.tx.TxOut
+	require.Len(t, txOuts, 3)
+	require.Equal(t, out1, txOuts[0])
+	require.Equal(t, out2, txOuts[1])
+	require.EqualValues(t, 150_000-100_000-sweepCtx.fee, txOuts[2].Value)
+	require.EqualValues(
+		t, changePkScript.DeliveryAddress, txOuts[2].PkScript,
+	)
+
+	// An output mapped to an unknown input is rejected.
+	unknown := createTestInput(10_000, input.WitnessKeyHash)
+	req.InputOutputs[unknown.OutPoint()] = out1
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, ErrInvalidExtraOutput)
+	delete(req.InputOutputs, unknown.OutPoint())
+
+	// Outputs spending more than the inputs are rejected.
+	req.InputOutputs[inp2.OutPoint()] = &wire.TxOut{
+		Value: 90_001, PkScript: p2tr,
+	}
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, ErrInvalidExtraOutput)
+
+	// Outputs that leave nothing to pay the fee fail to create the tx.
+	req.InputOutputs[inp2.OutPoint()] = &wire.TxOut{
+		Value: 90_000, PkScript: p2tr,
+	}
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.Error(t, err)
+}
+
+// sequenceInput is an input which requires a specific sequence.
+type sequenceInput struct {
+	input.BaseInput
+
+	sequence uint32
+}
+
+// RequiredSequence returns the sequence required by the input.
+func (s *sequenceInput) RequiredSequence() (uint32, bool) {
+	return s.sequence, true
+}
+
+// TestCreateSweepTxSequences checks the sequences required by the inputs are
+// preserved, and the tx still signals RBF when possible.
+func TestCreateSweepTxSequences(t *testing.T) {
+	t.Parallel()
+
+	// Create a CSV input which requires a relative locktime.
+	csvDelay := uint32(144)
+	base := createTestInput(100_000, input.WitnessKeyHash)
+	csvInp := input.NewCsvInput(
+		&wire.OutPoint{Hash: chainhash.Hash{1}}, input.WitnessKeyHash,
+		base.SignDesc(), 0, csvDelay,
+	)
+
+	// Create an input that requires a final sequence, which doesn't
+	// signal RBF.
+	finalInp := &sequenceInput{
+		BaseInput: createTestInput(100_000, input.WitnessKeyHash),
+		sequence:  wire.MaxTxInSequenceNum,
+	}
+
+	normalInp := createTestInput(100_000, input.WitnessKeyHash)
+
+	testCases := []struct {
+		name              string
+		inputs            []input.Input
+		expectedSequences []uint32
+		signalsRBF        bool
+	}{
+		{
+			// The CSV sequence is preserved, which signals RBF.
+			name:   "csv input",
+			inputs: []input.Input{csvInp, &normalInp},
+			expectedSequences: []uint32{
+				csvDelay, 0,
 			},
-			expectedErr: nil,
-			expectedResult: &BumpResult{
-				Event:     TxFailed,
-				Tx:        tx,
-				Fee:       fee,
-				FeeRate:   feerate,
-				Err:       errDummy,
-				requestID: requestID,
+			signalsRBF: true,
+		},
+		{
+			// The final sequence is preserved, while another
+			// input is used to signal RBF.
+			name:   "final sequence with free input",
+			inputs: []input.Input{finalInp, &normalInp},
+			expectedSequences: []uint32{
+				wire.MaxTxInSequenceNum, 0,
 			},
+			signalsRBF: true,
 		},
 		{
-			// When nothing goes wrong, the result is returned.
-			name: "publish success",
-			setupMock: func() {
-				// Mock the wallet to publish successfully.
-				m.wallet.On("PublishTransaction",
-					tx, mock.Anything).Return(nil).Once()
+			// The final sequence is preserved, and the other CSV
+			// input signals RBF already.
+			name:   "final sequence with csv input",
+			inputs: []input.Input{finalInp, csvInp},
+			expectedSequences: []uint32{
+				wire.MaxTxInSequenceNum, csvDelay,
 			},
-			expectedErr: nil,
-			expectedResult: &BumpResult{
-				Event:     TxPublished,
-				Tx:        tx,
-				Fee:       fee,
-				FeeRate:   feerate,
-				Err:       nil,
-				requestID: requestID,
+			signalsRBF: true,
+		},
+		{
+			// When all the inputs require a final sequence, the
+			// tx cannot signal RBF.
+			name:   "only final sequence",
+			inputs: []input.Input{finalInp},
+			expectedSequences: []uint32{
+				wire.MaxTxInSequenceNum,
 			},
+			signalsRBF: false,
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
-
 		t.Run(tc.name, func(t *testing.T) {
-			tc.setupMock()
+			t.Parallel()
+
+			tp, m := createTestPublisher(t)
+			tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+			m.signer.On("ComputeInputScript", mock.Anything,
+				mock.Anything).Return(&input.Script{}, nil)
+
+			sweepCtx, err := tp.createSweepTx(
+				tc.inputs, changePkScript,
+				chainfee.SatPerKWeight(1000), 0,
+				fn.None[input.TxInfo](), nil, false,
+			)
+			require.NoError(t, err)
 
-			// Call the method under test.
-			result, err := tp.broadcast(requestID)
+			signalsRBF := false
+			for i, txIn := range sweepCtx.tx.TxIn {
+				require.Equal(t, tc.expectedSequences[i],
+					txIn.Sequence)
 
-			// Check the result is as expected.
-			require.ErrorIs(t, err, tc.expectedErr)
-			require.Equal(t, tc.expectedResult, result)
+				if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+					signalsRBF = true
+				}
+			}
+			require.Equal(t, tc.signalsRBF, signalsRBF)
 		})
 	}
 }
 
-// TestRemoveResult checks the records and subscriptions are removed when a tx
-// is confirmed or failed.
-func TestRemoveResult(t *testing.T) {
+// TestCreateSweepTxDustChange checks a change output below the dust limit of
+// its script type is dropped into the fee, while one at the dust limit is
+// kept.
+func TestCreateSweepTxDustChange(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
-	tp, m := createTestPublisher(t)
+	p2wkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+		make([]byte, 20)...)
+	p2tr := append([]byte{txscript.OP_1, txscript.OP_DATA_32},
+		make([]byte, 32)...)
+	p2pkh := append([]byte{txscript.OP_DUP, txscript.OP_HASH160,
+		txscript.OP_DATA_20}, make([]byte, 20)...)
+	p2pkh = append(p2pkh, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
 
-	// Create a test bump request.
-	req := createTestBumpRequest()
+	// Use an extra output so the tx still has an output when the change
+	// is dropped.
+	extraOutputs := []*wire.TxOut{{Value: 10_000, PkScript: p2wkh}}
+	feeRate := chainfee.SatPerKWeight(1000)
 
-	// Create a test tx.
-	tx := &wire.MsgTx{LockTime: 1}
+	testCases := []struct {
+		name       string
+		script     []byte
+		dustOffset int64
+		hasChange  bool
+	}{
+		{
+			name:       "p2wkh change below dust",
+			script:     p2wkh,
+			dustOffset: -1,
+			hasChange:  false,
+		},
+		{
+			name:       "p2wkh change at dust limit",
+			script:     p2wkh,
+			dustOffset: 0,
+			hasChange:  true,
+		},
+		{
+			name:       "p2tr change below dust",
+			script:     p2tr,
+			dustOffset: -1,
+			hasChange:  false,
+		},
+		{
+			name:       "p2tr change above dust",
+			script:     p2tr,
+			dustOffset: 1,
+			hasChange:  true,
+		},
+		{
+			name:       "p2pkh change below dust",
+			script:     p2pkh,
+			dustOffset: -1,
+			hasChange:  false,
+		},
+		{
+			name:       "p2pkh change at dust limit",
+			script:     p2pkh,
+			dustOffset: 0,
+			hasChange:  true,
+		},
+	}
 
-	// Create a testing record and put it in the map.
-	fee := btcutil.Amount(1000)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 
-	op := wire.OutPoint{
-		Hash:  chainhash.Hash{1},
-		Index: 0,
-	}
-	utxoIndex := map[wire.OutPoint]int{
-		op: 0,
+			// Create a publisher without the aux sweeper so no
+			// extra change output is added.
+			tp, m := createTestPublisher(t)
+			tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+			m.signer.On("ComputeInputScript", mock.Anything,
+				mock.Anything).Return(&input.Script{}, nil)
+
+			change := lnwallet.AddrWithKey{
+				DeliveryAddress: tc.script,
+			}
+
+			// Calculate the fee paid by the sweep tx, which
+			// doesn't depend on the input value.
+			inp := createTestInput(100_000, input.WitnessKeyHash)
+			fee, _, _, err := prepareSweepTx(
+				[]input.Input{&inp}, change, feeRate, 0,
+				fn.None[AuxSweeper](), 0,
+				fn.None[input.TxInfo](), extraOutputs,
+			)
+			require.NoError(t, err)
+
+			// Create an input whose value leaves the change
+			// around the dust limit of its script type.
+			dustLimit := lnwallet.DustLimitForSize(len(tc.script))
+			changeAmt := int64(dustLimit) + tc.dustOffset
+			value := 10_000 + int64(fee) + changeAmt
+			inp = createTestInput(value, input.WitnessKeyHash)
+
+			sweepCtx, err := tp.createSweepTx(
+				[]input.Input{&inp}, change, feeRate, 0,
+				fn.None[input.TxInfo](), extraOutputs, false,
+			)
+			require.NoError(t, err)
+
+			if !tc.hasChange {
+				// The change should be added to the fee.
+				require.Len(t, sweepCtx.tx.TxOut, 1)
+				require.Equal(t, fee+btcutil.Amount(changeAmt),
+					sweepCtx.fee)
+
+				return
+			}
+
+			require.Len(t, sweepCtx.tx.TxOut, 2)
+			require.Equal(t, fee, sweepCtx.fee)
+			require.Contains(t, sweepCtx.tx.TxOut, &wire.TxOut{
+				Value:    changeAmt,
+				PkScript: tc.script,
+			})
+		})
 	}
+}
 
-	// Create a test request ID counter.
-	requestCounter := atomic.Uint64{}
+// TestValidateExtraOutputs checks invalid extra outputs are rejected.
+func TestValidateExtraOutputs(t *testing.T) {
+	t.Parallel()
+
+	// Create an OP_RETURN script with the max allowed data.
+	maxData, err := txscript.NullDataScript(
+		make([]byte, txscript.MaxDataCarrierSize),
+	)
+	require.NoError(t, err)
+
+	// Create an OP_RETURN script with an oversized data push.
+	oversized, err := txscript.NewScriptBuilder(
+		txscript.WithScriptAllocSize(100),
+	).AddOp(txscript.OP_RETURN).AddData(
+		make([]byte, txscript.MaxDataCarrierSize+1),
+	).Script()
+	require.NoError(t, err)
+
+	p2wkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+		make([]byte, 20)...)
 
 	testCases := []struct {
 		name        string
-		setupRecord func() uint64
-		result      *BumpResult
-		removed     bool
+		txOut       *wire.TxOut
+		expectedErr error
 	}{
 		{
-			// When the tx is confirmed, the records will be
-			// removed.
-			name: "remove on TxConfirmed",
-			setupRecord: func() uint64 {
-				rid := requestCounter.Add(1)
-				tp.storeRecord(
-					rid, tx, req, m.feeFunc, fee, utxoIndex,
-				)
-				tp.subscriberChans.Store(rid, nil)
-
-				return rid
-			},
-			result: &BumpResult{
-				Event: TxConfirmed,
-				Tx:    tx,
-			},
-			removed: true,
+			name:  "valid OP_RETURN",
+			txOut: &wire.TxOut{PkScript: maxData},
 		},
 		{
-			// When the tx is failed, the records will be removed.
-			name: "remove on TxFailed",
-			setupRecord: func() uint64 {
-				rid := requestCounter.Add(1)
-				tp.storeRecord(
-					rid, tx, req, m.feeFunc, fee, utxoIndex,
-				)
-				tp.subscriberChans.Store(rid, nil)
-
-				return rid
-			},
-			result: &BumpResult{
-				Event: TxFailed,
-				Err:   errDummy,
-				Tx:    tx,
+			name:        "oversized OP_RETURN",
+			txOut:       &wire.TxOut{PkScript: oversized},
+			expectedErr: ErrInvalidExtraOutput,
+		},
+		{
+			name: "OP_RETURN with value",
+			txOut: &wire.TxOut{
+				Value:    1000,
+				PkScript: maxData,
 			},
-			removed: true,
+			expectedErr: ErrInvalidExtraOutput,
 		},
 		{
-			// Noop when the tx is neither confirmed or failed.
-			name: "noop when tx is not confirmed or failed",
-			setupRecord: func() uint64 {
-				rid := requestCounter.Add(1)
-				tp.storeRecord(
-					rid, tx, req, m.feeFunc, fee, utxoIndex,
-				)
-				tp.subscriberChans.Store(rid, nil)
-
-				return rid
+			name: "valid output",
+			txOut: &wire.TxOut{
+				Value:    1000,
+				PkScript: p2wkh,
 			},
-			result: &BumpResult{
-				Event: TxPublished,
-				Tx:    tx,
+		},
+		{
+			name: "dust output",
+			txOut: &wire.TxOut{
+				Value:    1,
+				PkScript: p2wkh,
 			},
-			removed: false,
+			expectedErr: ErrInvalidExtraOutput,
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
-
 		t.Run(tc.name, func(t *testing.T) {
-			requestID := tc.setupRecord()
-
-			// Attach the requestID from the setup.
-			tc.result.requestID = requestID
-
-			// Remove the result.
-			tp.removeResult(tc.result)
-
-			// Check if the record is removed.
-			_, found := tp.records.Load(requestID)
-			require.Equal(t, !tc.removed, found)
-
-			_, found = tp.subscriberChans.Load(requestID)
-			require.Equal(t, !tc.removed, found)
+			err := validateExtraOutputs([]*wire.TxOut{tc.txOut})
+			require.ErrorIs(t, err, tc.expectedErr)
 		})
 	}
 }
 
-// TestNotifyResult checks the subscribers are notified when a result is sent.
-func TestNotifyResult(t *testing.T) {
+// TestFixedFeeRate checks that a request with a fixed fee rate creates the tx
+// using exactly that fee rate and never replaces it.
+func TestFixedFeeRate(t *testing.T) {
 	t.Parallel()
 
 	// Create a publisher using the mocks.
 	tp, m := createTestPublisher(t)
+	tp.currentHeight.Store(100)
 
-	// Create a test bump request.
+	// Create a request with a fixed fee rate that exceeds the max fee
+	// rate allowed, which should be rejected.
 	req := createTestBumpRequest()
+	req.MaxFeeRate = chainfee.SatPerKWeight(10_000)
+	req.DeadlineHeight = 110
+	req.FixedFeeRate = req.MaxFeeRate + 1
 
-	// Create a test tx.
-	tx := &wire.MsgTx{LockTime: 1}
+	_, err := tp.initializeFeeFunction(req)
+	require.ErrorIs(t, err, ErrFixedFeeRateTooHigh)
 
-	op := wire.OutPoint{
-		Hash:  chainhash.Hash{1},
-		Index: 0,
-	}
-	utxoIndex := map[wire.OutPoint]int{
-		op: 0,
-	}
+	// Now use a valid fixed fee rate. We expect a constant fee function
+	// to be created without querying the fee estimator.
+	feeRate := chainfee.SatPerKWeight(1000)
+	req.FixedFeeRate = feeRate
 
-	// Create a testing record and put it in the map.
-	fee := btcutil.Amount(1000)
-	requestID := uint64(1)
-	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.IsType(t, &ConstantFeeFunction{}, f)
+	require.Equal(t, feeRate, f.FeeRate())
 
-	// Create a subscription to the event.
+	// Mock the signer and mempool check to succeed.
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+
+	// The tx should be created at exactly the fixed fee rate.
+	sweepCtx, err := tp.createAndCheckTx(req, f, log)
+	require.NoError(t, err)
+
+	weight, err := calcSweepTxWeight(
+		req.Inputs, [][]byte{changePkScript.DeliveryAddress},
+		nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, feeRate.FeeForWeight(weight), sweepCtx.fee)
+
+	// Store the record and subscribe to its results.
+	requestID := uint64(1)
+	record := tp.storeRecord(
+		requestID, sweepCtx.tx, req, f, sweepCtx.fee,
+		sweepCtx.outpointToTxIndex,
+	)
 	subscriber := make(chan *BumpResult, 1)
 	tp.subscriberChans.Store(requestID, subscriber)
 
-	// Create a test result.
-	result := &BumpResult{
-		requestID: requestID,
-		Tx:        tx,
-	}
+	// Try to bump the fee in a later block, which should be a no-op as the
+	// fee rate is fixed.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
 
-	// Notify the result and expect the subscriber to receive it.
-	//
-	// NOTE: must be done inside a goroutine in case it blocks.
-	go tp.notifyResult(result)
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 105)
 
+	// No replacement should be made.
 	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriber to receive result")
+	case result := <-subscriber:
+		t.Fatalf("unexpected result: %v", result)
 
-	case received := <-subscriber:
-		require.Equal(t, result, received)
+	case <-time.After(100 * time.Millisecond):
 	}
 
-	// Notify two results. This time it should block because the channel is
-	// full. We then shutdown TxPublisher to test the quit behavior.
-	done := make(chan struct{})
-	go func() {
-		// Call notifyResult twice, which blocks at the second call.
-		tp.notifyResult(result)
-		tp.notifyResult(result)
+	require.Equal(t, feeRate, f.FeeRate())
+}
 
-		close(done)
-	}()
+// TestRequestLabel checks the label of a request is copied into its results
+// and status, and that an oversized label is rejected.
+func TestRequestLabel(t *testing.T) {
+	t.Parallel()
 
-	// Shutdown the publisher and expect notifyResult to exit.
-	close(tp.quit)
+	// Create a publisher using the mocks.
+	tp, m := createTestPublisher(t)
 
-	// We expect to done chan.
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for notifyResult to exit")
+	// A request with an oversized label should be rejected.
+	req := createTestBumpRequest()
+	req.Label = strings.Repeat("a", MaxLabelLength+1)
 
-	case <-done:
-	}
-}
+	resultChan := tp.Broadcast(req)
+	result := <-resultChan
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, ErrLabelTooLong)
+	require.Equal(t, req.Label, result.Label)
+	require.Zero(t, tp.records.Len())
 
-// TestBroadcast checks the public `Broadcast` method can successfully register
-// a broadcast request.
-func TestBroadcast(t *testing.T) {
+	// Querying an unknown request should fail.
+	requestID := uint64(1)
+	_, err := tp.Status(requestID)
+	require.ErrorIs(t, err, ErrRequestNotFound)
+
+	// Store a record with a valid label.
+	req.Label = "channel-close"
+	tx := &wire.MsgTx{LockTime: 1}
+	tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
+
+	// The label should be returned in the status.
+	status, err := tp.Status(requestID)
+	require.NoError(t, err)
+	require.Equal(t, "channel-close", status.Label)
+	require.Equal(t, tx, status.Tx)
+	require.Equal(t, feerate, status.FeeRate)
+	require.Equal(t, btcutil.Amount(1000), status.Fee)
+	require.Equal(t, req.Budget, status.Budget)
+	require.False(t, status.Confirmed)
+
+	// The label should be attached to the results sent to the subscriber.
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
+
+	tp.notifyResult(&BumpResult{
+		Event:     TxPublished,
+		Tx:        tx,
+		requestID: requestID,
+	})
+	result = <-subscriber
+	require.Equal(t, "channel-close", result.Label)
+}
+
+// TestTxPublisherBroadcastPackage checks that a request carrying parents is
+// submitted as a package, and falls back to publishing the txns one by one
+// when package relay is not supported.
+func TestTxPublisherBroadcastPackage(t *testing.T) {
 	t.Parallel()
 
 	// Create a publisher using the mocks.
-	tp, _ := createTestPublisher(t)
+	tp, m := createTestPublisher(t)
 
-	// Create a test feerate.
+	// Create a test bump request with a parent.
+	parent := &wire.MsgTx{LockTime: 2}
+	req := createTestBumpRequest()
+	req.Parents = []*wire.MsgTx{parent}
+
+	// Create a test tx and store its record.
+	tx := &wire.MsgTx{LockTime: 1}
 	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
 
-	// Create a test request.
-	inp := createTestInput(1000, input.WitnessKeyHash)
+	requestID := uint64(1)
+	tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
 
-	// Create a testing bump request.
-	req := &BumpRequest{
-		DeliveryAddress: changePkScript,
-		Inputs:          []input.Input{&inp},
-		Budget:          btcutil.Amount(1000),
-		MaxFeeRate:      feerate * 10,
-		DeadlineHeight:  10,
+	pkg := []*wire.MsgTx{parent, tx}
+
+	testCases := []struct {
+		name          string
+		setupMock     func()
+		expectedEvent BumpEvent
+		expectedErr   error
+	}{
+		{
+			// When package relay is supported, the txns are
+			// submitted together.
+			name: "package relay",
+			setupMock: func() {
+				m.wallet.On("SubmitPackage", pkg).Return(
+					nil).Once()
+			},
+			expectedEvent: TxPublished,
+		},
+		{
+			// When the package is rejected, the error should be
+			// put inside the result.
+			name: "package rejected",
+			setupMock: func() {
+				m.wallet.On("SubmitPackage", pkg).Return(
+					errDummy).Once()
+			},
+			expectedEvent: TxFailed,
+			expectedErr:   errDummy,
+		},
+		{
+			// When package relay is not supported, the parent is
+			// published before the child.
+			name: "fallback to sequential publish",
+			setupMock: func() {
+				m.wallet.On("SubmitPackage", pkg).Return(
+					ErrPackageRelayUnsupported).Once()
+				m.wallet.On("PublishTransaction",
+					parent, mock.Anything).Return(
+					nil).Once()
+				m.wallet.On("PublishTransaction",
+					tx, mock.Anything).Return(nil).Once()
+			},
+			expectedEvent: TxPublished,
+		},
+		{
+			// A parent that's already known is not an error.
+			name: "fallback with known parent",
+			setupMock: func() {
+				m.wallet.On("SubmitPackage", pkg).Return(
+					ErrPackageRelayUnsupported).Once()
+				m.wallet.On("PublishTransaction",
+					parent, mock.Anything).Return(
+					chain.ErrTxAlreadyInMempool).Once()
+				m.wallet.On("PublishTransaction",
+					tx, mock.Anything).Return(nil).Once()
+			},
+			expectedEvent: TxPublished,
+		},
+		{
+			// When the parent fails to be published, the child
+			// is not published.
+			name: "fallback with failed parent",
+			setupMock: func() {
+				m.wallet.On("SubmitPackage", pkg).Return(
+					ErrPackageRelayUnsupported).Once()
+				m.wallet.On("PublishTransaction",
+					parent, mock.Anything).Return(
+					errDummy).Once()
+			},
+			expectedEvent: TxFailed,
+			expectedErr:   errDummy,
+		},
 	}
 
-	// Send the req and expect no error.
-	resultChan := tp.Broadcast(req)
-	require.NotNil(t, resultChan)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
 
-	// Validate the record was stored.
-	require.Equal(t, 1, tp.records.Len())
-	require.Equal(t, 1, tp.subscriberChans.Len())
+			result, err := tp.broadcast(requestID)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedEvent, result.Event)
+			require.Equal(t, tx, result.Tx)
 
-	// Validate the record.
-	rid := tp.requestCounter.Load()
-	record, found := tp.records.Load(rid)
-	require.True(t, found)
-	require.Equal(t, req, record.req)
+			if tc.expectedErr == nil {
+				require.NoError(t, result.Err)
+			} else {
+				require.ErrorIs(t, result.Err, tc.expectedErr)
+			}
+		})
+	}
 }
 
-// TestBroadcastImmediate checks the public `Broadcast` method can successfully
-// register a broadcast request and publish the tx when `Immediate` flag is
-// set.
-func TestBroadcastImmediate(t *testing.T) {
+// TestRecentFailuresRetry checks a failed request is saved in the recent
+// failures, and can be retried.
+func TestRecentFailuresRetry(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
+	// Create a publisher using the mocks which keeps two failures.
 	tp, m := createTestPublisher(t)
+	tp.cfg.FailureHistorySize = 2
 
-	// Create a test feerate.
 	feerate := chainfee.SatPerKWeight(1000)
-
-	// Create a test request.
-	inp := createTestInput(1000, input.WitnessKeyHash)
-
-	// Create a testing bump request.
-	req := &BumpRequest{
-		DeliveryAddress: changePkScript,
-		Inputs:          []input.Input{&inp},
-		Budget:          btcutil.Amount(1000),
-		MaxFeeRate:      feerate * 10,
-		DeadlineHeight:  10,
-		Immediate:       true,
+	m.feeFunc.On("FeeRate").Return(feerate).Maybe()
+
+	// failRequest stores a record for the request and fails it.
+	failRequest := func(requestID uint64, req *BumpRequest) {
+		tx := &wire.MsgTx{LockTime: uint32(requestID)}
+		tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
+		tp.subscriberChans.Store(requestID, make(chan *BumpResult, 1))
+
+		tp.handleResult(&BumpResult{
+			Event:     TxFailed,
+			Tx:        tx,
+			Err:       errDummy,
+			requestID: requestID,
+		})
 	}
 
-	// Mock the fee estimator to return an error.
-	//
-	// NOTE: We are not testing `handleInitialBroadcast` here, but only
-	// interested in checking that this method is indeed called when
-	// `Immediate` is true. Thus we mock the method to return an error to
-	// quickly abort. As long as this mocked method is called, we know the
-	// `Immediate` flag works.
-	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
-		chainfee.SatPerKWeight(0), errDummy).Once()
-
-	// Send the req and expect no error.
-	resultChan := tp.Broadcast(req)
+	// Fail three requests, the first one should be dropped.
+	req1 := createTestBumpRequest()
+	req2 := createTestBumpRequest()
+	req3 := createTestBumpRequest()
+	failRequest(1, req1)
+	failRequest(2, req2)
+	failRequest(3, req3)
+
+	failures := tp.RecentFailures()
+	require.Len(t, failures, 2)
+	require.Equal(t, req2, failures[0].Request)
+	require.Equal(t, req3, failures[1].Request)
+	require.ErrorIs(t, failures[1].Err, errDummy)
+	require.NotNil(t, failures[1].Tx)
+	require.Zero(t, tp.records.Len())
+
+	// A request that's not a recent failure cannot be retried.
+	_, err := tp.Retry(req1)
+	require.ErrorIs(t, err, ErrRequestNotFound)
+
+	// Retry the last failure, which should be tracked again.
+	resultChan, err := tp.Retry(req3)
+	require.NoError(t, err)
 	require.NotNil(t, resultChan)
 
-	// Validate the record was removed due to an error returned in initial
-	// broadcast.
-	require.Empty(t, tp.records.Len())
-	require.Empty(t, tp.subscriberChans.Len())
+	rid := tp.requestCounter.Load()
+	record, found := tp.records.Load(rid)
+	require.True(t, found)
+	require.Equal(t, req3, record.req)
+
+	// The retried request should be removed from the failures.
+	failures = tp.RecentFailures()
+	require.Len(t, failures, 1)
+	require.Equal(t, req2, failures[0].Request)
 }
 
-// TestCreateAnPublishFail checks all the error cases are handled properly in
-// the method createAndPublish.
-func TestCreateAnPublishFail(t *testing.T) {
+// TestSubscribeAll checks the results of all requests are sent to the global
+// subscriber, and the delivery stops once unsubscribed.
+func TestSubscribeAll(t *testing.T) {
 	t.Parallel()
 
 	// Create a publisher using the mocks.
-	tp, m := createTestPublisher(t)
+	tp, _ := createTestPublisher(t)
 
-	// Create a test requestID.
-	requestID := uint64(1)
+	results, unsubscribe := tp.SubscribeAll()
 
-	// Create a test feerate and return it from the mock fee function.
-	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	// Broadcast two requests, which won't be published as they are not
+	// immediate.
+	resultChan1 := tp.Broadcast(createTestBumpRequest())
+	rid1 := tp.requestCounter.Load()
+	resultChan2 := tp.Broadcast(createTestBumpRequest())
+	rid2 := tp.requestCounter.Load()
 
-	// Create a testing monitor record.
-	req := createTestBumpRequest()
+	// Send a result for each of the requests.
+	tx := &wire.MsgTx{}
+	tp.notifyResult(&BumpResult{
+		Event:     TxPublished,
+		Tx:        tx,
+		requestID: rid1,
+	})
+	tp.notifyResult(&BumpResult{
+		Event:     TxPublished,
+		Tx:        tx,
+		requestID: rid2,
+	})
 
-	// Overwrite the budget to make it smaller than the fee.
-	req.Budget = 100
-	record := &monitorRecord{
-		req:         req,
-		feeFunction: m.feeFunc,
-		tx:          &wire.MsgTx{},
+	// Both results should be sent to the per-request subscribers.
+	require.Equal(t, rid1, (<-resultChan1).RequestID())
+	require.Equal(t, rid2, (<-resultChan2).RequestID())
+
+	// Both results should also be sent to the global subscriber.
+	for _, rid := range []uint64{rid1, rid2} {
+		select {
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for global result")
+
+		case result := <-results:
+			require.Equal(t, TxPublished, result.Event)
+			require.Equal(t, rid, result.RequestID())
+		}
 	}
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
+	// A full buffer should drop the results instead of blocking.
+	for i := 0; i <= globalSubscriberBufferSize; i++ {
+		tp.notifyGlobal(&BumpResult{Event: TxPublished, Tx: tx})
+	}
+	require.EqualValues(t, 1, tp.DroppedGlobalResults())
 
-	// Call the createAndPublish method.
-	resultOpt := tp.createAndPublishTx(requestID, record)
-	result := resultOpt.UnwrapOrFail(t)
+	// Unsubscribe, which closes the chan after the buffered results.
+	unsubscribe()
+	unsubscribe()
 
-	// We expect the result to be TxFailed and the error is set in the
-	// result.
-	require.Equal(t, TxFailed, result.Event)
-	require.ErrorIs(t, result.Err, ErrNotEnoughBudget)
-	require.Equal(t, requestID, result.requestID)
+	tp.notifyResult(&BumpResult{
+		Event:     TxPublished,
+		Tx:        tx,
+		requestID: rid1,
+	})
+	require.Equal(t, rid1, (<-resultChan1).RequestID())
 
-	// Increase the budget and call it again. This time we will mock an
-	// error to be returned from CheckMempoolAcceptance.
-	req.Budget = 1000
+	count := 0
+	for range results {
+		count++
+	}
+	require.Equal(t, globalSubscriberBufferSize, count)
+}
 
-	// Mock the testmempoolaccept to return a fee related error that should
-	// be ignored.
-	m.wallet.On("CheckMempoolAcceptance",
-		mock.Anything).Return(lnwallet.ErrMempoolFee).Once()
+// TestMaxInFlight checks new requests are rejected once MaxInFlight is
+// reached, and a slot is freed once a record is removed.
+func TestMaxInFlight(t *testing.T) {
+	t.Parallel()
 
-	// Call the createAndPublish method and expect a none option.
-	resultOpt = tp.createAndPublishTx(requestID, record)
-	require.True(t, resultOpt.IsNone())
+	tp, _ := createTestPublisher(t)
+	tp.cfg.MaxInFlight = 2
+	tp.inFlight = make(chan struct{}, tp.cfg.MaxInFlight)
 
-	// Mock the testmempoolaccept to return a fee related error that should
-	// be ignored.
-	m.wallet.On("CheckMempoolAcceptance",
-		mock.Anything).Return(chain.ErrInsufficientFee).Once()
+	// Fill up the slots.
+	tp.Broadcast(createTestBumpRequest())
+	rid1 := tp.requestCounter.Load()
+	tp.Broadcast(createTestBumpRequest())
+	require.Equal(t, 2, tp.records.Len())
 
-	// Call the createAndPublish method and expect a none option.
-	resultOpt = tp.createAndPublishTx(requestID, record)
-	require.True(t, resultOpt.IsNone())
+	// The next request should be rejected.
+	result := <-tp.Broadcast(createTestBumpRequest())
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, ErrTooManyInFlight)
+	require.Equal(t, 2, tp.records.Len())
+
+	// Remove a record, which frees a slot.
+	tp.removeResult(&BumpResult{
+		Event:     TxConfirmed,
+		requestID: rid1,
+	})
+
+	tp.Broadcast(createTestBumpRequest())
+	require.Equal(t, 2, tp.records.Len())
 }
 
-// TestCreateAnPublishSuccess checks the expected result is returned from the
-// method createAndPublish.
-func TestCreateAnPublishSuccess(t *testing.T) {
+// TestMaxInFlightBlockWhenFull checks new requests wait for a slot once
+// MaxInFlight is reached if BlockWhenFull is set.
+func TestMaxInFlightBlockWhenFull(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
-	tp, m := createTestPublisher(t)
+	tp, _ := createTestPublisher(t)
+	tp.cfg.MaxInFlight = 1
+	tp.cfg.BlockWhenFull = true
+	tp.inFlight = make(chan struct{}, tp.cfg.MaxInFlight)
 
-	// Create a test requestID.
-	requestID := uint64(1)
+	// Fill up the slot.
+	tp.Broadcast(createTestBumpRequest())
+	rid := tp.requestCounter.Load()
 
-	// Create a test feerate and return it from the mock fee function.
-	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	// A request with a context that times out should give up waiting.
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 50*time.Millisecond,
+	)
+	defer cancel()
 
-	// Create a testing monitor record.
-	req := createTestBumpRequest()
-	record := &monitorRecord{
-		req:         req,
-		feeFunction: m.feeFunc,
-		tx:          &wire.MsgTx{},
+	_, err := tp.BroadcastWithContext(ctx, createTestBumpRequest())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, tp.records.Len())
+
+	// The next request should block until the slot is freed.
+	done := make(chan struct{})
+	go func() {
+		tp.Broadcast(createTestBumpRequest())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("broadcast should be blocked")
+	case <-time.After(50 * time.Millisecond):
 	}
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
+	// Remove the record, which unblocks the request.
+	tp.removeResult(&BumpResult{
+		Event:     TxConfirmed,
+		requestID: rid,
+	})
 
-	// Mock the testmempoolaccept to return nil.
-	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast still blocked")
+	}
+	require.Equal(t, 1, tp.records.Len())
+}
 
-	// Mock the wallet to publish and return an error.
-	m.wallet.On("PublishTransaction",
-		mock.Anything, mock.Anything).Return(errDummy).Once()
+// TestHealthCheck checks the health check names the dependency that failed.
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
 
-	// Call the createAndPublish method and expect a failure result.
-	resultOpt := tp.createAndPublishTx(requestID, record)
-	result := resultOpt.UnwrapOrFail(t)
+	feerate := chainfee.SatPerKWeight(1000)
+	listUnspent := "ListUnspentWitnessFromDefaultAccount"
 
-	// We expect the result to be TxFailed and the error is set.
-	require.Equal(t, TxFailed, result.Event)
-	require.ErrorIs(t, result.Err, errDummy)
+	testCases := []struct {
+		name         string
+		setupMocks   func(m *mockers)
+		expectedErrs []error
+	}{
+		{
+			name: "healthy",
+			setupMocks: func(m *mockers) {
+				m.estimator.On("RelayFeePerKW").Return(feerate)
+				m.estimator.On("EstimateFeePerKW",
+					uint32(healthCheckConfTarget)).Return(
+					feerate, nil)
+				m.wallet.On(listUnspent,
+					int32(0), int32(0)).Return(nil, nil)
+			},
+		},
+		{
+			name: "estimator fails",
+			setupMocks: func(m *mockers) {
+				m.estimator.On("RelayFeePerKW").Return(feerate)
+				m.estimator.On("EstimateFeePerKW",
+					mock.Anything).Return(
+					chainfee.SatPerKWeight(0), errDummy)
+			},
+			expectedErrs: []error{ErrEstimatorUnhealthy},
+		},
+		{
+			name: "estimator times out",
+			setupMocks: func(m *mockers) {
+				m.estimator.On("RelayFeePerKW").Return(feerate)
+				m.estimator.On("EstimateFeePerKW",
+					mock.Anything).Return(feerate, nil).
+					After(time.Second)
+			},
+			expectedErrs: []error{
+				ErrEstimatorUnhealthy, context.DeadlineExceeded,
+			},
+		},
+		{
+			name: "wallet fails",
+			setupMocks: func(m *mockers) {
+				m.estimator.On("RelayFeePerKW").Return(feerate)
+				m.estimator.On("EstimateFeePerKW",
+					mock.Anything).Return(feerate, nil)
+				m.wallet.On(listUnspent,
+					mock.Anything, mock.Anything).Return(
+					nil, errDummy)
+			},
+			expectedErrs: []error{ErrWalletUnhealthy},
+		},
+	}
 
-	// Although the replacement tx was failed to be published, the record
-	// should be stored.
-	require.NotNil(t, result.Tx)
-	require.NotNil(t, result.ReplacedTx)
-	_, found := tp.records.Load(requestID)
-	require.True(t, found)
+	for _, tc := range testCases {
+		tc := tc
 
-	// We now check a successful RBF.
-	//
-	// Mock the wallet to publish successfully.
-	m.wallet.On("PublishTransaction",
-		mock.Anything, mock.Anything).Return(nil).Once()
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 
-	// Call the createAndPublish method and expect a success result.
-	resultOpt = tp.createAndPublishTx(requestID, record)
-	result = resultOpt.UnwrapOrFail(t)
-	require.True(t, resultOpt.IsSome())
+			tp, m := createTestPublisher(t)
+			tc.setupMocks(m)
 
-	// We expect the result to be TxReplaced and the error is nil.
-	require.Equal(t, TxReplaced, result.Event)
-	require.Nil(t, result.Err)
+			ctx, cancel := context.WithTimeout(
+				context.Background(), 100*time.Millisecond,
+			)
+			defer cancel()
 
-	// Check the Tx and ReplacedTx are set.
-	require.NotNil(t, result.Tx)
-	require.NotNil(t, result.ReplacedTx)
+			err := tp.HealthCheck(ctx)
+			if len(tc.expectedErrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
 
-	// Check the record is stored.
-	_, found = tp.records.Load(requestID)
-	require.True(t, found)
+			for _, expectedErr := range tc.expectedErrs {
+				require.ErrorIs(t, err, expectedErr)
+			}
+		})
+	}
 }
 
-// TestHandleTxConfirmed checks the expected result is returned from the method
-// handleTxConfirmed.
-func TestHandleTxConfirmed(t *testing.T) {
+// TestTxPublisherSkipMempoolCheck checks the mempool check is skipped when
+// configured, and a tx rejected at publish time for paying too little fee is
+// rebuilt with a higher fee rate and published again.
+func TestTxPublisherSkipMempoolCheck(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
+	// Create a publisher using the mocks which skips the mempool check.
+	// The CheckMempoolAcceptance method is not mocked, so the test fails
+	// if it's called.
 	tp, m := createTestPublisher(t)
+	tp.cfg.SkipMempoolCheck = true
 
-	// Create a test bump request.
-	req := createTestBumpRequest()
-
-	// Create a test tx.
-	tx := &wire.MsgTx{LockTime: 1}
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
 
-	op := wire.OutPoint{
-		Hash:  chainhash.Hash{1},
-		Index: 0,
-	}
-	utxoIndex := map[wire.OutPoint]int{
-		op: 0,
+	// Create a request with enough value to be bumped a few times.
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          btcutil.Amount(10_000),
+		MaxFeeRate:      chainfee.SatPerKWeight(10_000),
 	}
 
-	// Create a testing record and put it in the map.
-	fee := btcutil.Amount(1000)
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	f, err := NewLinearFeeFunction(
+		chainfee.SatPerKWeight(10_000), 6, m.estimator,
+		fn.Some(startFeeRate),
+	)
+	require.NoError(t, err)
+
+	// Create the initial tx without the mempool check.
 	requestID := uint64(1)
-	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+	require.NoError(t, tp.createRBFCompliantTx(requestID, req, f))
+
 	record, ok := tp.records.Load(requestID)
 	require.True(t, ok)
+	initialTx := record.tx
+
+	// Mock the wallet to reject the initial tx and the first rebuilt one
+	// for paying too little fee, then accept the next one.
+	m.wallet.On("PublishTransaction", initialTx, mock.Anything).Return(
+		chain.ErrInsufficientFee).Once()
+	m.wallet.On("PublishTransaction", mock.Anything, mock.Anything).Return(
+		lnwallet.ErrMempoolFee).Once()
+	m.wallet.On("PublishTransaction", mock.Anything, mock.Anything).Return(
+		nil).Once()
+
+	result, err := tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxPublished, result.Event)
+	require.NoError(t, result.Err)
 
-	// Create a subscription to the event.
-	subscriber := make(chan *BumpResult, 1)
-	tp.subscriberChans.Store(requestID, subscriber)
-
-	// Mock the fee function to return a fee rate.
-	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate).Once()
-
-	// Call the method and expect a result to be received.
-	//
-	// NOTE: must be called in a goroutine in case it blocks.
-	tp.wg.Add(1)
-	done := make(chan struct{})
-	go func() {
-		tp.handleTxConfirmed(record, requestID)
-		close(done)
-	}()
+	// The published tx should be a rebuilt one with a higher fee rate.
+	require.NotEqual(t, initialTx.TxHash(), result.Tx.TxHash())
+	require.Greater(t, result.FeeRate, startFeeRate)
 
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriber to receive result")
+	record, ok = tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, result.Tx, record.tx)
 
-	case result := <-subscriber:
-		// We expect the result to be TxConfirmed and the tx is set.
-		require.Equal(t, TxConfirmed, result.Event)
-		require.Equal(t, tx, result.Tx)
-		require.Nil(t, result.Err)
-		require.Equal(t, requestID, result.requestID)
-		require.Equal(t, record.fee, result.Fee)
-		require.Equal(t, feerate, result.FeeRate)
+	// Once the fee function is maxed out, the publish error is returned.
+	for {
+		if _, err := f.Increment(0); err != nil {
+			break
+		}
 	}
 
-	select {
-	case <-done:
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for handleTxConfirmed to return")
-	}
+	m.wallet.On("PublishTransaction", mock.Anything, mock.Anything).Return(
+		chain.ErrInsufficientFee).Once()
 
-	// We expect the record to be removed from the maps.
-	_, found := tp.records.Load(requestID)
-	require.False(t, found)
-	_, found = tp.subscriberChans.Load(requestID)
-	require.False(t, found)
+	result, err = tp.broadcast(requestID)
+	require.NoError(t, err)
+	require.Equal(t, TxFailed, result.Event)
+	require.ErrorIs(t, result.Err, chain.ErrInsufficientFee)
 }
 
-// TestHandleFeeBumpTx validates handleFeeBumpTx behaves as expected.
-func TestHandleFeeBumpTx(t *testing.T) {
+// TestRejectChangeReuse checks a request rejecting change reuse fails when its
+// change output pays to the script of one of its inputs.
+func TestRejectChangeReuse(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
 	tp, m := createTestPublisher(t)
+	tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
 
-	// Create a test tx.
-	tx := &wire.MsgTx{LockTime: 1}
-
-	// Create a test current height.
-	testHeight := int32(800000)
+	// Create an input paying to the change script.
+	inp := createTestInput(1_000_000, input.WitnessKeyHash)
+	inp.SignDesc().Output.PkScript = changePkScript.DeliveryAddress
 
-	// Create a testing monitor record.
-	req := createTestBumpRequest()
-	record := &monitorRecord{
-		req:         req,
-		feeFunction: m.feeFunc,
-		tx:          tx,
+	req := &BumpRequest{
+		DeliveryAddress:   changePkScript,
+		Inputs:            []input.Input{&inp},
+		Budget:            500_000,
+		MaxFeeRate:        chainfee.SatPerKWeight(100_000),
+		RejectChangeReuse: true,
 	}
 
-	op := wire.OutPoint{
-		Hash:  chainhash.Hash{1},
-		Index: 0,
-	}
-	utxoIndex := map[wire.OutPoint]int{
-		op: 0,
-	}
+	// The reused change script should be rejected.
+	_, err := tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, ErrChangeAddressReuse)
 
-	// Create a testing record and put it in the map.
-	fee := btcutil.Amount(1000)
-	requestID := uint64(1)
-	tp.storeRecord(requestID, tx, req, m.feeFunc, fee, utxoIndex)
+	// Using a distinct change script, the tx should be accepted.
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(1000))
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
 
-	// Create a subscription to the event.
-	subscriber := make(chan *BumpResult, 1)
-	tp.subscriberChans.Store(requestID, subscriber)
+	inp.SignDesc().Output.PkScript = []byte{
+		0, 20, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+		17, 18, 19, 20,
+	}
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
 
-	// Create a test feerate and return it from the mock fee function.
-	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	// The reuse is allowed when the request doesn't reject it.
+	inp.SignDesc().Output.PkScript = changePkScript.DeliveryAddress
+	req.RejectChangeReuse = false
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
+}
 
-	// Mock the fee function to skip the bump due to error.
-	m.feeFunc.On("IncreaseFeeRate", mock.Anything).Return(
-		false, errDummy).Once()
+// TestNoBump checks a request specifying NoBump creates a tx that doesn't
+// signal RBF, and the tx is never replaced.
+func TestNoBump(t *testing.T) {
+	t.Parallel()
 
-	// Call the method and expect no result received.
-	tp.wg.Add(1)
-	go tp.handleFeeBumpTx(requestID, record, testHeight)
+	tp, m := createTestPublisher(t)
+	tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
 
-	// Check there's no result sent back.
-	select {
-	case <-time.After(time.Second):
-	case result := <-subscriber:
-		t.Fatalf("unexpected result received: %v", result)
+	inp := createTestInput(1_000_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          500_000,
+		MaxFeeRate:      chainfee.SatPerKWeight(100_000),
+		NoBump:          true,
 	}
 
-	// Mock the fee function to skip the bump.
-	m.feeFunc.On("IncreaseFeeRate", mock.Anything).Return(false, nil).Once()
+	// A fixed fee rate must be provided.
+	require.ErrorIs(t, req.validateNoBump(), ErrInvalidNoBump)
 
-	// Call the method and expect no result received.
-	tp.wg.Add(1)
-	go tp.handleFeeBumpTx(requestID, record, testHeight)
+	// An input requiring a relative locktime would signal RBF, which
+	// should be rejected.
+	req.FixedFeeRate = 1000
+	csvInp := input.NewCsvInput(
+		&wire.OutPoint{Index: 1}, input.CommitmentTimeLock,
+		inp.SignDesc(), 0, 144,
+	)
+	req.Inputs = []input.Input{&inp, csvInp}
+	require.ErrorIs(t, req.validateNoBump(), ErrInvalidNoBump)
 
-	// Check there's no result sent back.
-	select {
-	case <-time.After(time.Second):
-	case result := <-subscriber:
-		t.Fatalf("unexpected result received: %v", result)
-	}
+	req.Inputs = []input.Input{&inp}
+	require.NoError(t, req.validateNoBump())
 
-	// Mock the fee function to perform the fee bump.
-	m.feeFunc.On("IncreaseFeeRate", mock.Anything).Return(true, nil)
+	// The fee function is a constant one using the fixed fee rate.
+	f, err := tp.initializeFeeFunction(req)
+	require.NoError(t, err)
+	require.IsType(t, &ConstantFeeFunction{}, f)
+	require.Equal(t, req.FixedFeeRate, f.FeeRate())
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
+	// The created tx should not signal RBF.
 	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
-
-	// Mock the testmempoolaccept to return nil.
+		mock.Anything).Return(&input.Script{}, nil)
 	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
 
-	// Mock the wallet to publish successfully.
-	m.wallet.On("PublishTransaction",
-		mock.Anything, mock.Anything).Return(nil).Once()
-
-	// Call the method and expect a result to be received.
-	//
-	// NOTE: must be called in a goroutine in case it blocks.
-	tp.wg.Add(1)
-	go tp.handleFeeBumpTx(requestID, record, testHeight)
+	sweepCtx, err := tp.createAndCheckTx(req, f, log)
+	require.NoError(t, err)
+	for _, txIn := range sweepCtx.tx.TxIn {
+		require.Equal(t, wire.MaxTxInSequenceNum-1, txIn.Sequence)
+	}
 
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriber to receive result")
+	// Once broadcast, the tx should never be bumped. The mocked fee
+	// function and wallet would fail the test if they were called.
+	requestID := uint64(1)
+	record := tp.storeRecord(
+		requestID, sweepCtx.tx, req, m.feeFunc, sweepCtx.fee, nil,
+	)
 
-	case result := <-subscriber:
-		// We expect the result to be TxReplaced.
-		require.Equal(t, TxReplaced, result.Event)
+	tp.wg.Add(1)
+	tp.handleFeeBumpTx(requestID, record, 100)
 
-		// The new tx and old tx should be properly set.
-		require.NotEqual(t, tx, result.Tx)
-		require.Equal(t, tx, result.ReplacedTx)
+	m.wallet.AssertNotCalled(t, "PublishTransaction", mock.Anything,
+		mock.Anything)
 
-		// No error should be set.
-		require.Nil(t, result.Err)
-		require.Equal(t, requestID, result.requestID)
-	}
+	// A forced bump should be refused too.
+	err = tp.BumpNow(requestID)
+	require.ErrorIs(t, err, ErrBumpDisabled)
 
-	// We expect the record to NOT be removed from the maps.
-	_, found := tp.records.Load(requestID)
-	require.True(t, found)
-	_, found = tp.subscriberChans.Load(requestID)
-	require.True(t, found)
+	r, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, sweepCtx.tx.TxHash(), r.tx.TxHash())
 }
 
-// TestProcessRecords validates processRecords behaves as expected.
-func TestProcessRecords(t *testing.T) {
+// TestCheckConfirmedFeeRate checks a fee rate far above the recently confirmed
+// fee rates is only warned about, unless the publisher is configured to refuse
+// it.
+func TestCheckConfirmedFeeRate(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
 	tp, m := createTestPublisher(t)
+	tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+
+	// Recent blocks only required 500 sat/kw, which gives a limit of 5000
+	// sat/kw using the default multiplier.
+	source := &MockConfirmedFeeRateSource{}
+	defer source.AssertExpectations(t)
+	source.On("RecentConfirmedFeeRate").Return(
+		chainfee.SatPerKWeight(500), nil)
+	tp.cfg.ConfirmedFeeRateSource = fn.Some[ConfirmedFeeRateSource](
+		source,
+	)
 
-	// Create testing objects.
-	requestID1 := uint64(1)
-	req1 := createTestBumpRequest()
-	tx1 := &wire.MsgTx{LockTime: 1}
-	txid1 := tx1.TxHash()
-
-	requestID2 := uint64(2)
-	req2 := createTestBumpRequest()
-	tx2 := &wire.MsgTx{LockTime: 2}
-	txid2 := tx2.TxHash()
-
-	// Create a monitor record that's confirmed.
-	recordConfirmed := &monitorRecord{
-		req:         req1,
-		feeFunction: m.feeFunc,
-		tx:          tx1,
-	}
-	m.wallet.On("GetTransactionDetails", &txid1).Return(
-		&lnwallet.TransactionDetail{
-			NumConfirmations: 1,
-		}, nil,
-	).Once()
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
 
-	// Create a monitor record that's not confirmed. We know it's not
-	// confirmed because the num of confirms is zero.
-	recordFeeBump := &monitorRecord{
-		req:         req2,
-		feeFunction: m.feeFunc,
-		tx:          tx2,
+	inp := createTestInput(1_000_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress: changePkScript,
+		Inputs:          []input.Input{&inp},
+		Budget:          500_000,
+		MaxFeeRate:      chainfee.SatPerKWeight(100_000),
 	}
-	m.wallet.On("GetTransactionDetails", &txid2).Return(
-		&lnwallet.TransactionDetail{
-			NumConfirmations: 0,
-		}, nil,
-	).Once()
-	m.wallet.On("BackEnd").Return("test-backend").Once()
-
-	// Setup the initial publisher state by adding the records to the maps.
-	subscriberConfirmed := make(chan *BumpResult, 1)
-	tp.subscriberChans.Store(requestID1, subscriberConfirmed)
-	tp.records.Store(requestID1, recordConfirmed)
-
-	subscriberReplaced := make(chan *BumpResult, 1)
-	tp.subscriberChans.Store(requestID2, subscriberReplaced)
-	tp.records.Store(requestID2, recordFeeBump)
 
-	// Create a test feerate and return it from the mock fee function.
-	feerate := chainfee.SatPerKWeight(1000)
-	m.feeFunc.On("FeeRate").Return(feerate)
+	// A fee rate below the limit passes the check.
+	m.feeFunc.On("FeeRate").Return(
+		chainfee.SatPerKWeight(5000)).Times(2)
+	_, err := tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
 
-	// The following methods should only be called once when creating the
-	// replacement tx.
-	//
-	// Mock the fee function to NOT skip the fee bump.
-	m.feeFunc.On("IncreaseFeeRate", mock.Anything).Return(true, nil).Once()
+	// A fee rate above the limit is only warned about by default.
+	m.feeFunc.On("FeeRate").Return(chainfee.SatPerKWeight(50_000))
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
 
-	// Mock the signer to always return a valid script.
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(&input.Script{}, nil).Once()
+	// Once configured to refuse, the tx is rejected.
+	tp.cfg.RefuseAboveConfirmedFeeRate = true
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.ErrorIs(t, err, ErrFeeRateAboveConfirmed)
 
-	// Mock the testmempoolaccept to return nil.
-	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	// A higher multiplier allows the fee rate.
+	tp.cfg.ConfirmedFeeRateMultiplier = 100
+	_, err = tp.createAndCheckTx(req, m.feeFunc, log)
+	require.NoError(t, err)
 
-	// Mock the wallet to publish successfully.
-	m.wallet.On("PublishTransaction",
-		mock.Anything, mock.Anything).Return(nil).Once()
+	// A refused replacement keeps the current tx without failing the
+	// request.
+	tp.cfg.ConfirmedFeeRateMultiplier = 0
+	tx := &wire.MsgTx{LockTime: 1}
+	record := tp.storeRecord(1, tx, req, m.feeFunc, 0, nil)
+	resultOpt := tp.createAndPublishTx(1, record, ReplaceReasonDeadline)
+	require.True(t, resultOpt.IsNone())
 
-	// Call processRecords and expect the results are notified back.
-	tp.processRecords()
+	stored, ok := tp.records.Load(1)
+	require.True(t, ok)
+	require.Equal(t, tx, stored.tx)
+}
 
-	// We expect two results to be received. One for the confirmed tx and
-	// one for the replaced tx.
-	//
-	// Check the confirmed tx result.
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriberConfirmed")
+// TestMempoolCheckOverride checks the request's mempool check is used in
+// place of the wallet's, and a fee error returned from it causes the fee rate
+// to be increased until the tx is accepted.
+func TestMempoolCheckOverride(t *testing.T) {
+	t.Parallel()
 
-	case result := <-subscriberConfirmed:
-		// We expect the result to be TxConfirmed.
-		require.Equal(t, TxConfirmed, result.Event)
-		require.Equal(t, tx1, result.Tx)
+	// Create a publisher using the mocks which skips the mempool check.
+	// The CheckMempoolAcceptance method is not mocked, so the test fails
+	// if it's called.
+	tp, m := createTestPublisher(t)
+	tp.cfg.SkipMempoolCheck = true
 
-		// No error should be set.
-		require.Nil(t, result.Err)
-		require.Equal(t, requestID1, result.requestID)
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+
+	// Reject the first two txns for paying too little fee, then accept
+	// the next one.
+	var checked []*wire.MsgTx
+	override := func(tx *wire.MsgTx) error {
+		checked = append(checked, tx)
+		if len(checked) <= 2 {
+			return lnwallet.ErrMempoolFee
+		}
+
+		return nil
 	}
 
-	// Now check the replaced tx result.
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriberReplaced")
+	inp := createTestInput(100_000, input.WitnessKeyHash)
+	req := &BumpRequest{
+		DeliveryAddress:      changePkScript,
+		Inputs:               []input.Input{&inp},
+		Budget:               btcutil.Amount(10_000),
+		MaxFeeRate:           chainfee.SatPerKWeight(10_000),
+		MempoolCheckOverride: override,
+	}
 
-	case result := <-subscriberReplaced:
-		// We expect the result to be TxReplaced.
-		require.Equal(t, TxReplaced, result.Event)
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	f, err := NewLinearFeeFunction(
+		chainfee.SatPerKWeight(10_000), 6, m.estimator,
+		fn.Some(startFeeRate),
+	)
+	require.NoError(t, err)
 
-		// The new tx and old tx should be properly set.
-		require.NotEqual(t, tx2, result.Tx)
-		require.Equal(t, tx2, result.ReplacedTx)
+	// The tx accepted by the override should be the one stored, which
+	// pays a higher fee rate than the rejected ones.
+	requestID := uint64(1)
+	require.NoError(t, tp.createRBFCompliantTx(requestID, req, f))
+	require.Len(t, checked, 3)
 
-		// No error should be set.
-		require.Nil(t, result.Err)
-		require.Equal(t, requestID2, result.requestID)
+	record, ok := tp.records.Load(requestID)
+	require.True(t, ok)
+	require.Equal(t, checked[2], record.tx)
+	require.Greater(t, record.feeFunction.FeeRate(), startFeeRate)
+
+	// A non-fee error from the override fails the request.
+	req.MempoolCheckOverride = func(*wire.MsgTx) error {
+		return errDummy
 	}
+
+	f, err = NewLinearFeeFunction(
+		chainfee.SatPerKWeight(10_000), 6, m.estimator,
+		fn.Some(startFeeRate),
+	)
+	require.NoError(t, err)
+
+	err = tp.createRBFCompliantTx(requestID+1, req, f)
+	require.ErrorIs(t, err, errDummy)
 }
 
-// TestHandleInitialBroadcastSuccess checks `handleInitialBroadcast` method can
-// successfully broadcast a tx based on the request.
-func TestHandleInitialBroadcastSuccess(t *testing.T) {
+// TestParseFeeShortfall checks the fee rates paid and required are parsed from
+// the fee-related mempool rejections.
+func TestParseFeeShortfall(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
-	tp, m := createTestPublisher(t)
+	const weight = lntypes.WeightUnit(1000)
 
-	// Create a test feerate.
-	feerate := chainfee.SatPerKWeight(1000)
+	testCases := []struct {
+		name     string
+		err      error
+		weight   lntypes.WeightUnit
+		expected fn.Option[feeShortfall]
+	}{
+		{
+			name: "bitcoind min relay fee",
+			err: fmt.Errorf("%w: min relay fee not met, 100 < 300",
+				lnwallet.ErrMempoolFee),
+			weight: weight,
+			expected: fn.Some(feeShortfall{
+				actual:   100,
+				required: 300,
+			}),
+		},
+		{
+			name: "bitcoind mempool min fee",
+			err: fmt.Errorf("%w: mempool min fee not met, 250 < "+
+				"1000", chain.ErrMempoolMinFeeNotMet),
+			weight: weight,
+			expected: fn.Some(feeShortfall{
+				actual:   250,
+				required: 1000,
+			}),
+		},
+		{
+			name: "btcd min relay fee",
+			err: errors.New("transaction abcd has 100 fees which " +
+				"is under the required amount of 500"),
+			weight: weight,
+			expected: fn.Some(feeShortfall{
+				actual:   100,
+				required: 500,
+			}),
+		},
+		{
+			name: "bitcoind replacement",
+			err: fmt.Errorf("%w: insufficient fee, rejecting "+
+				"replacement abcd; new feerate 0.00002000 "+
+				"BTC/kvB <= old feerate 0.00004000 BTC/kvB",
+				chain.ErrInsufficientFee),
+			weight: weight,
+			expected: fn.Some(feeShortfall{
+				actual:   500,
+				required: 1001,
+			}),
+		},
+		{
+			name: "btcd replacement",
+			err: errors.New("replacement transaction abcd has an " +
+				"insufficient fee rate: needs more than " +
+				"4000, has 2000"),
+			weight: weight,
+			expected: fn.Some(feeShortfall{
+				actual:   500,
+				required: 1001,
+			}),
+		},
+		{
+			name:     "no details",
+			err:      chain.ErrInsufficientFee,
+			weight:   weight,
+			expected: fn.None[feeShortfall](),
+		},
+		{
+			name: "unknown weight",
+			err: fmt.Errorf("%w: min relay fee not met, 100 < 300",
+				lnwallet.ErrMempoolFee),
+			expected: fn.None[feeShortfall](),
+		},
+		{
+			name:     "nil error",
+			weight:   weight,
+			expected: fn.None[feeShortfall](),
+		},
+	}
 
-	// Mock the fee estimator to return the testing fee rate.
-	//
-	// We are not testing `NewLinearFeeFunction` here, so the actual params
-	// used are irrelevant.
-	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
-		feerate, nil).Once()
-	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Once()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shortfall := parseFeeShortfall(tc.err, tc.weight)
+			require.Equal(t, tc.expected, shortfall)
+		})
+	}
+}
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
+// TestTxPublisherJumpToRequiredFeeRate checks the fee function skips straight
+// to the fee rate required by the mempool when it's parsed from the rejection.
+func TestTxPublisherJumpToRequiredFeeRate(t *testing.T) {
+	t.Parallel()
 
-	// Mock the testmempoolaccept to pass.
-	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	testCases := []struct {
+		name string
+		jump bool
+	}{
+		{name: "single step", jump: false},
+		{name: "jump", jump: true},
+	}
 
-	// Mock the wallet to publish successfully.
-	m.wallet.On("PublishTransaction",
-		mock.Anything, mock.Anything).Return(nil).Once()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tp, m := createTestPublisher(t)
+			tp.cfg.AuxSweeper = fn.None[AuxSweeper]()
+			tp.cfg.JumpToRequiredFeeRate = tc.jump
+
+			m.signer.On("ComputeInputScript", mock.Anything,
+				mock.Anything).Return(&input.Script{}, nil)
+
+			// Mock the mempool to reject the initial tx as it
+			// requires a fee of 3000 sats, then accept the next
+			// one.
+			rejectErr := fmt.Errorf("%w: mempool min fee not "+
+				"met, 300 < 3000", lnwallet.ErrMempoolFee)
+			m.wallet.On("CheckMempoolAcceptance",
+				mock.Anything).Return(rejectErr).Once()
+			m.wallet.On("CheckMempoolAcceptance",
+				mock.Anything).Return(nil).Once()
+
+			// The fee function increases the fee rate by 1800
+			// sat/kw per step.
+			inp := createTestInput(100_000, input.WitnessKeyHash)
+			req := &BumpRequest{
+				DeliveryAddress: changePkScript,
+				Inputs:          []input.Input{&inp},
+				Budget:          btcutil.Amount(10_000),
+				MaxFeeRate:      chainfee.SatPerKWeight(20_000),
+			}
 
-	// Create a test request.
-	inp := createTestInput(1000, input.WitnessKeyHash)
+			startFeeRate := chainfee.SatPerKWeight(1000)
+			f, err := NewLinearFeeFunction(
+				chainfee.SatPerKWeight(10_000), 6,
+				m.estimator, fn.Some(startFeeRate),
+			)
+			require.NoError(t, err)
 
-	// Create a testing bump request.
-	req := &BumpRequest{
-		DeliveryAddress: changePkScript,
-		Inputs:          []input.Input{&inp},
-		Budget:          btcutil.Amount(1000),
-		MaxFeeRate:      feerate * 10,
-		DeadlineHeight:  10,
-	}
+			requestID := uint64(1)
+			err = tp.createRBFCompliantTx(requestID, req, f)
+			require.NoError(t, err)
 
-	// Register the testing record use `Broadcast`.
-	resultChan := tp.Broadcast(req)
+			record, ok := tp.records.Load(requestID)
+			require.True(t, ok)
 
-	// Grab the monitor record from the map.
-	rid := tp.requestCounter.Load()
-	rec, ok := tp.records.Load(rid)
-	require.True(t, ok)
+			weight := blockchain.GetTransactionWeight(
+				btcutil.NewTx(record.tx),
+			)
+			required := chainfee.NewSatPerKWeight(
+				3000, lntypes.WeightUnit(weight),
+			)
+			singleStep := chainfee.SatPerKWeight(2800)
 
-	// Call the method under test.
-	tp.wg.Add(1)
-	tp.handleInitialBroadcast(rec, rid)
+			// Without the jump, the fee rate is increased by a
+			// single step, which is still below the required fee
+			// rate.
+			if !tc.jump {
+				require.Equal(t, singleStep, f.FeeRate())
+				require.Less(t, f.FeeRate(), required)
 
-	// Check the result is sent back.
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriber to receive result")
+				return
+			}
 
-	case result := <-resultChan:
-		// We expect the first result to be TxPublished.
-		require.Equal(t, TxPublished, result.Event)
+			// With the jump, the fee rate should skip straight to
+			// the required fee rate.
+			require.GreaterOrEqual(t, f.FeeRate(), required)
+			require.GreaterOrEqual(t, record.fee,
+				btcutil.Amount(3000))
+		})
 	}
-
-	// Validate the record was stored.
-	require.Equal(t, 1, tp.records.Len())
-	require.Equal(t, 1, tp.subscriberChans.Len())
 }
 
-// TestHandleInitialBroadcastFail checks `handleInitialBroadcast` returns the
-// error or a failed result when the broadcast fails.
-func TestHandleInitialBroadcastFail(t *testing.T) {
+// TestPauseResume checks no broadcasts or fee bumps happen while the publisher
+// is paused, and they resume once the publisher is resumed.
+func TestPauseResume(t *testing.T) {
 	t.Parallel()
 
-	// Create a publisher using the mocks.
+	// Create a publisher using the mocks and pause it.
 	tp, m := createTestPublisher(t)
+	tp.Pause()
 
-	// Create a test feerate.
-	feerate := chainfee.SatPerKWeight(1000)
-
-	// Create a test request.
-	inp := createTestInput(1000, input.WitnessKeyHash)
-
-	// Create a testing bump request.
-	req := &BumpRequest{
-		DeliveryAddress: changePkScript,
-		Inputs:          []input.Input{&inp},
-		Budget:          btcutil.Amount(1000),
-		MaxFeeRate:      feerate * 10,
-		DeadlineHeight:  10,
-	}
+	// Store a record whose tx is unconfirmed, so it'd normally be fee
+	// bumped.
+	requestID := uint64(100)
+	req := createTestBumpRequest()
+	tx := &wire.MsgTx{LockTime: 1}
+	txid := tx.TxHash()
+	tp.storeRecord(requestID, tx, req, m.feeFunc, 1000, nil)
 
-	// Mock the fee estimator to return the testing fee rate.
-	//
-	// We are not testing `NewLinearFeeFunction` here, so the actual params
-	// used are irrelevant.
-	m.estimator.On("EstimateFeePerKW", mock.Anything).Return(
-		feerate, nil).Twice()
-	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor).Twice()
+	subscriber := make(chan *BumpResult, 1)
+	tp.subscriberChans.Store(requestID, subscriber)
 
-	// Mock the signer to always return a valid script.
-	//
-	// NOTE: we are not testing the utility of creating valid txes here, so
-	// this is fine to be mocked. This behaves essentially as skipping the
-	// Signer check and alaways assume the tx has a valid sig.
-	script := &input.Script{}
-	m.signer.On("ComputeInputScript", mock.Anything,
-		mock.Anything).Return(script, nil)
+	m.wallet.On("GetTransactionDetails", &txid).Return(
+		&lnwallet.TransactionDetail{}, nil,
+	).Twice()
+	m.wallet.On("BackEnd").Return("test-backend").Twice()
 
-	// Mock the testmempoolaccept to return an error.
-	m.wallet.On("CheckMempoolAcceptance",
-		mock.Anything).Return(errDummy).Once()
+	feerate := chainfee.SatPerKWeight(1000)
+	m.feeFunc.On("FeeRate").Return(feerate)
 
-	// Register the testing record use `Broadcast`.
-	resultChan := tp.Broadcast(req)
+	// Broadcast a new request which asks to be published immediately, it
+	// should be queued while paused.
+	immediateReq := createTestBumpRequest()
+	immediateReq.Immediate = true
+	immediateChan := tp.Broadcast(immediateReq)
 
-	// Grab the monitor record from the map.
-	rid := tp.requestCounter.Load()
-	rec, ok := tp.records.Load(rid)
+	immediateID := tp.requestCounter.Load()
+	record, ok := tp.records.Load(immediateID)
 	require.True(t, ok)
+	require.Nil(t, record.tx)
 
-	// Call the method under test and expect an error returned.
-	tp.wg.Add(1)
-	tp.handleInitialBroadcast(rec, rid)
+	// Process the records while paused, no tx should be published.
+	tp.processRecords()
+	tp.wg.Wait()
 
-	// Check the result is sent back.
-	select {
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriber to receive result")
+	m.wallet.AssertNotCalled(t, "PublishTransaction", mock.Anything,
+		mock.Anything)
+	require.Empty(t, subscriber)
+	require.Empty(t, immediateChan)
 
-	case result := <-resultChan:
-		// We expect the first result to be TxFatal.
-		require.Equal(t, TxFatal, result.Event)
-	}
+	// The status should reflect the publisher is paused.
+	status, err := tp.Status(requestID)
+	require.NoError(t, err)
+	require.True(t, status.Paused)
 
-	// Validate the record was NOT stored.
-	require.Equal(t, 0, tp.records.Len())
-	require.Equal(t, 0, tp.subscriberChans.Len())
+	// Resume the publisher.
+	tp.Resume()
 
-	// Mock the testmempoolaccept again, this time it passes.
-	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil).Once()
+	status, err = tp.Status(requestID)
+	require.NoError(t, err)
+	require.False(t, status.Paused)
+
+	// Mock the fee bump of the unconfirmed tx, and the initial broadcast
+	// of the queued request.
+	m.estimator.On("RelayFeePerKW").Return(chainfee.FeePerKwFloor)
+	m.feeFunc.On("RebaseFloor", chainfee.FeePerKwFloor).Return(false).Once()
+	m.feeFunc.On("IncreaseFeeRate", mock.Anything, mock.Anything,
+		mock.Anything).Return(true, nil).Once()
+	m.signer.On("ComputeInputScript", mock.Anything,
+		mock.Anything).Return(&input.Script{}, nil)
+	m.wallet.On("CheckMempoolAcceptance", mock.Anything).Return(nil)
 
-	// Mock the wallet to fail on publish.
+	// Both the replacement and the queued request should be published.
 	m.wallet.On("PublishTransaction",
-		mock.Anything, mock.Anything).Return(errDummy).Once()
+		mock.Anything, mock.Anything).Return(nil).Twice()
 
-	// Register the testing record use `Broadcast`.
-	resultChan = tp.Broadcast(req)
+	tp.processRecords()
 
-	// Grab the monitor record from the map.
-	rid = tp.requestCounter.Load()
-	rec, ok = tp.records.Load(rid)
-	require.True(t, ok)
+	// The unconfirmed tx should now be replaced.
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the replacement")
 
-	// Call the method under test.
-	tp.wg.Add(1)
-	tp.handleInitialBroadcast(rec, rid)
+	case result := <-subscriber:
+		require.Equal(t, TxReplaced, result.Event)
+		require.Equal(t, tx, result.ReplacedTx)
+	}
 
-	// Check the result is sent back.
+	// The queued request should be processed.
 	select {
 	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for subscriber to receive result")
+		t.Fatal("timeout waiting for the queued request")
 
-	case result := <-resultChan:
-		// We expect the result to be TxFailed and the error is set in
-		// the result.
-		require.Equal(t, TxFailed, result.Event)
-		require.ErrorIs(t, result.Err, errDummy)
+	case result := <-immediateChan:
+		require.Equal(t, TxPublished, result.Event)
 	}
-
-	// Validate the record was removed.
-	require.Equal(t, 0, tp.records.Len())
-	require.Equal(t, 0, tp.subscriberChans.Len())
 }
@@ -3,9 +3,12 @@ package sweep
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/lnwire"
 )
@@ -51,19 +54,55 @@ type FeeFunction interface {
 	//
 	// An error is returned when the max fee rate is reached.
 	//
+	// The txWeight specifies the weight of the current tx, which is used
+	// as the estimated weight of the replacement. When non-zero, it
+	// replaces the non-zero weight given to `IncreaseFeeRate`, so the
+	// absolute fee required by BIP125 rule 4 scales with the actual weight
+	// of the tx, which matters for large batches whose weight changes as
+	// inputs are added or removed. A zero weight keeps the previous one.
+	//
 	// NOTE: we intentionally don't return the new fee rate here, so both
 	// the implementation and the caller are aware of the state change.
-	Increment() (bool, error)
+	Increment(txWeight lntypes.WeightUnit) (bool, error)
 
 	// IncreaseFeeRate increases the fee rate to the new position
 	// calculated using (width - confTarget). It returns a boolean to
 	// indicate whether the fee rate is increased, and an error if the
 	// position is greater than the width.
 	//
+	// The prevFee and txWeight specify the absolute fee and the weight of
+	// the tx being replaced. When the weight is non-zero, the increased
+	// fee rate, both from this method and from subsequent calls to
+	// `Increment`, must give an absolute fee for a tx of that weight that
+	// exceeds the prevFee by at least the min relay fee times the weight,
+	// as required by BIP125 rules 3 and 4, unless capped by the max fee
+	// rate.
+	//
 	// NOTE: this method is provided to allow the caller to increase the
 	// fee rate based on a conf target without taking care of the fee
 	// function's current state (position).
-	IncreaseFeeRate(confTarget uint32) (bool, error)
+	IncreaseFeeRate(confTarget uint32, prevFee btcutil.Amount,
+		txWeight lntypes.WeightUnit) (bool, error)
+
+	// RebaseFloor re-bases the fee function using the given fee rate floor
+	// if the current fee rate is below it, such that the fee rate returned
+	// from `FeeRate` is at least the floor, and future increments start
+	// from it. It returns a boolean to indicate whether the fee rate was
+	// changed.
+	RebaseFloor(floor chainfee.SatPerKWeight) bool
+
+	// ResetTrajectory re-bases the fee function using the given estimate,
+	// which is expected to have dropped since the fee function was
+	// created, such that future increments ramp from it instead of the
+	// previous trajectory. The current fee rate is never decreased, as a
+	// replacement must pay more fees than the tx it replaces.
+	ResetTrajectory(newEstimate chainfee.SatPerKWeight)
+
+	// Schedule returns the projected fee rates to be used at each block
+	// from the current one till the deadline, with the first element being
+	// the current fee rate and the last being the max fee rate. This is a
+	// pure projection which doesn't change the state of the fee function.
+	Schedule() []chainfee.SatPerKWeight
 }
 
 // LinearFeeFunction implements the FeeFunction interface with a linear
@@ -109,11 +148,58 @@ type LinearFeeFunction struct {
 	// whether we want to used the estimated fee rate or the calculated fee
 	// rate based on different strategies.
 	estimator chainfee.Estimator
+
+	// prevFee is the absolute fee paid by the tx being replaced.
+	prevFee btcutil.Amount
+
+	// txWeight is the weight of the tx being replaced, which is used as
+	// the estimated weight of the replacement. Zero means there's no tx
+	// to replace.
+	txWeight lntypes.WeightUnit
+
+	// curve is the optional curve used to allocate the fee rate range
+	// across the positions. If nil, the fee rate increases linearly.
+	curve AllocationCurve
+
+	// maxDeltaFeeRate is the optional max fee rate increase per block.
+	// Zero means the increase is not capped.
+	maxDeltaFeeRate chainfee.SatPerKWeight
 }
 
 // Compile-time check to ensure LinearFeeFunction satisfies the FeeFunction.
 var _ FeeFunction = (*LinearFeeFunction)(nil)
 
+// AllocationCurve maps the progress towards the deadline, in range [0, 1], to
+// the fraction of the range between the starting and ending fee rates to use,
+// which must be in range [0, 1] and must never decrease as the progress
+// increases. A front-loaded curve spends the budget early, while a back-loaded
+// curve saves it for the blocks close to the deadline.
+type AllocationCurve func(progress float64) float64
+
+// validateAllocationCurve checks the given curve maps each position of the
+// given width to a fraction in range [0, 1] that never decreases.
+func validateAllocationCurve(curve AllocationCurve, width uint32) error {
+	prev := 0.0
+	for p := uint32(0); p <= width; p++ {
+		fraction := curve(float64(p) / float64(width))
+		if math.IsNaN(fraction) || fraction < 0 || fraction > 1 {
+			return fmt.Errorf("allocation curve returned %v at "+
+				"position %v/%v, must be in range [0, 1]",
+				fraction, p, width)
+		}
+
+		if fraction < prev {
+			return fmt.Errorf("allocation curve decreased from %v "+
+				"to %v at position %v/%v", prev, fraction, p,
+				width)
+		}
+
+		prev = fraction
+	}
+
+	return nil
+}
+
 // NewLinearFeeFunction creates a new linear fee function and initializes it
 // with a starting fee rate which is an estimated value returned from the fee
 // estimator using the initial conf target.
@@ -122,6 +208,20 @@ func NewLinearFeeFunction(maxFeeRate chainfee.SatPerKWeight,
 	startingFeeRate fn.Option[chainfee.SatPerKWeight]) (
 	*LinearFeeFunction, error) {
 
+	return NewLinearFeeFunctionWithCurve(
+		maxFeeRate, confTarget, estimator, startingFeeRate, nil,
+	)
+}
+
+// NewLinearFeeFunctionWithCurve creates a new fee function that works the same
+// as the linear fee function, except that the fee rate range is allocated
+// across the positions using the given curve. A nil curve allocates the range
+// evenly. An error is returned if the curve is out of range or decreasing.
+func NewLinearFeeFunctionWithCurve(maxFeeRate chainfee.SatPerKWeight,
+	confTarget uint32, estimator chainfee.Estimator,
+	startingFeeRate fn.Option[chainfee.SatPerKWeight],
+	curve AllocationCurve) (*LinearFeeFunction, error) {
+
 	// If the deadline is one block away or has already been reached,
 	// there's nothing the fee function can do. In this case, we'll use the
 	// max fee rate immediately.
@@ -137,6 +237,13 @@ func NewLinearFeeFunction(maxFeeRate chainfee.SatPerKWeight,
 		endingFeeRate: maxFeeRate,
 		width:         confTarget - 1,
 		estimator:     estimator,
+		curve:         curve,
+	}
+
+	if curve != nil {
+		if err := validateAllocationCurve(curve, l.width); err != nil {
+			return nil, err
+		}
 	}
 
 	// If the caller specifies the starting fee rate, we'll use it instead
@@ -202,18 +309,32 @@ func (l *LinearFeeFunction) FeeRate() chainfee.SatPerKWeight {
 // its current fee rate.
 //
 // NOTE: part of the FeeFunction interface.
-func (l *LinearFeeFunction) Increment() (bool, error) {
+func (l *LinearFeeFunction) Increment(txWeight lntypes.WeightUnit) (bool,
+	error) {
+
+	// Only update the weight if there's a tx to replace.
+	if txWeight > 0 && l.txWeight > 0 {
+		l.txWeight = txWeight
+	}
+
 	return l.increaseFeeRate(l.position + 1)
 }
 
 // IncreaseFeeRate calculate a new position using the given conf target, and
 // increases the fee rate to the new position by calling the Increment method.
+// The given prevFee and txWeight are remembered so this and future increments
+// comply with the BIP125 absolute fee rules.
 //
 // NOTE: this method will change the state of the fee function as it increases
 // its current fee rate.
 //
 // NOTE: part of the FeeFunction interface.
-func (l *LinearFeeFunction) IncreaseFeeRate(confTarget uint32) (bool, error) {
+func (l *LinearFeeFunction) IncreaseFeeRate(confTarget uint32,
+	prevFee btcutil.Amount, txWeight lntypes.WeightUnit) (bool, error) {
+
+	l.prevFee = prevFee
+	l.txWeight = txWeight
+
 	newPosition := uint32(0)
 
 	// Only calculate the new position when the conf target is less than
@@ -237,6 +358,148 @@ func (l *LinearFeeFunction) IncreaseFeeRate(confTarget uint32) (bool, error) {
 	return l.increaseFeeRate(newPosition)
 }
 
+// RebaseFloor re-bases the fee function when its current fee rate is below the
+// given floor. The function is restarted from its current position, using the
+// floor as the new starting fee rate while keeping the same ending fee rate
+// and deadline, so the remaining positions are spread between the floor and
+// the ending fee rate.
+//
+// NOTE: this method will change the state of the fee function as it increases
+// its current fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (l *LinearFeeFunction) RebaseFloor(floor chainfee.SatPerKWeight) bool {
+	// Exit early if the current fee rate already clears the floor.
+	if l.currentFeeRate >= floor {
+		return false
+	}
+
+	// The fee rate can never exceed the ending fee rate.
+	if floor > l.endingFeeRate {
+		log.Warnf("Fee rate floor %v exceeds ending fee rate %v, "+
+			"using ending fee rate instead", floor, l.endingFeeRate)
+
+		floor = l.endingFeeRate
+	}
+
+	oldFeeRate := l.currentFeeRate
+
+	// Restart the function from the current position. Since the width is
+	// the conf target minus one, reducing both the width and the position
+	// by the same amount keeps the conf target to position mapping used
+	// in `IncreaseFeeRate` unchanged.
+	l.width -= l.position
+	l.position = 0
+	l.startingFeeRate = floor
+	l.currentFeeRate = floor
+
+	// Recalculate how much fee rate should be increased per block.
+	l.deltaFeeRate = 0
+	if l.width > 0 {
+		delta := btcutil.Amount(l.endingFeeRate - floor).MulF64(
+			1000 / float64(l.width),
+		)
+		l.deltaFeeRate = mSatPerKWeight(delta)
+	}
+
+	log.Debugf("Fee function rebased from %v to floor %v, width=%v, "+
+		"delta=%v", oldFeeRate, floor, l.width, l.deltaFeeRate)
+
+	return l.currentFeeRate > oldFeeRate
+}
+
+// ResetTrajectory restarts the fee function from its current position using
+// the given estimate as the new starting fee rate, while keeping the same
+// ending fee rate and deadline, so the remaining positions are spread between
+// the estimate and the ending fee rate. As the fee rate never decreases, the
+// current fee rate is kept until the trajectory rises above it. Nothing is
+// changed if the estimate is not below the current fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (l *LinearFeeFunction) ResetTrajectory(
+	newEstimate chainfee.SatPerKWeight) {
+
+	// Exit early if the estimate hasn't dropped below the current fee
+	// rate, or there are no positions left to reset.
+	if newEstimate >= l.currentFeeRate || l.position >= l.width {
+		return
+	}
+
+	// Restart the function from the current position, which keeps the
+	// conf target to position mapping unchanged as in `RebaseFloor`.
+	l.width -= l.position
+	l.position = 0
+	l.startingFeeRate = newEstimate
+
+	delta := btcutil.Amount(l.endingFeeRate - newEstimate).MulF64(
+		1000 / float64(l.width),
+	)
+	l.deltaFeeRate = mSatPerKWeight(delta)
+
+	log.Debugf("Fee function trajectory reset to start from %v, "+
+		"currentFeeRate=%v, width=%v, delta=%v", newEstimate,
+		l.currentFeeRate, l.width, l.deltaFeeRate)
+}
+
+// setMaxDeltaFeeRate caps the fee rate increase per block at the given delta.
+// A warning is logged if the cap prevents the fee rate from reaching the ending
+// fee rate by the deadline.
+func (l *LinearFeeFunction) setMaxDeltaFeeRate(
+	delta chainfee.SatPerKWeight) {
+
+	l.maxDeltaFeeRate = delta
+
+	blocks := chainfee.SatPerKWeight(l.width - l.position)
+	reachable := l.currentFeeRate + delta*blocks
+	if reachable < l.endingFeeRate {
+		log.Warnf("Max fee rate delta %v per block caps the fee rate "+
+			"at %v by the deadline, below the ending fee rate %v",
+			delta, reachable, l.endingFeeRate)
+	}
+}
+
+// capDelta caps the increase from the given fee rate to the target fee rate
+// at the max fee rate delta per block over the given number of blocks.
+func (l *LinearFeeFunction) capDelta(from, to chainfee.SatPerKWeight,
+	blocks uint32) chainfee.SatPerKWeight {
+
+	if l.maxDeltaFeeRate == 0 {
+		return to
+	}
+
+	limit := from + l.maxDeltaFeeRate*chainfee.SatPerKWeight(blocks)
+	if to <= limit {
+		return to
+	}
+
+	log.Tracef("Capped fee rate increase from %v to %v at %v over %v "+
+		"blocks", from, to, limit, blocks)
+
+	return limit
+}
+
+// Schedule returns the fee rates the function will use from its current
+// position till the end of its width, which gives one fee rate per block till
+// the deadline.
+//
+// NOTE: part of the FeeFunction interface.
+func (l *LinearFeeFunction) Schedule() []chainfee.SatPerKWeight {
+	schedule := make([]chainfee.SatPerKWeight, 0, l.width-l.position+1)
+	schedule = append(schedule, l.currentFeeRate)
+
+	// The fee rate never decreases, so we use the current fee rate as the
+	// floor for the following positions.
+	feeRate := l.currentFeeRate
+	for p := l.position + 1; p <= l.width; p++ {
+		feeRate = l.capDelta(
+			feeRate, max(feeRate, l.feeRateAtPosition(p)), 1,
+		)
+		schedule = append(schedule, feeRate)
+	}
+
+	return schedule
+}
+
 // increaseFeeRate increases the fee rate by the specified position, returns a
 // boolean to indicate whether the fee rate was increased, and an error if the
 // position is greater than the width. The increased fee rate will be set as
@@ -251,12 +514,41 @@ func (l *LinearFeeFunction) increaseFeeRate(position uint32) (bool, error) {
 		return false, ErrMaxPosition
 	}
 
-	// Get the old fee rate.
+	// Get the old fee rate and position.
 	oldFeeRate := l.currentFeeRate
+	oldPosition := l.position
 
-	// Update its internal state.
+	// Update its internal state. The fee rate never decreases, which may
+	// happen if the trajectory has been reset below the current fee rate.
+	// The increase is capped by the max fee rate delta per block, if set.
 	l.position = position
-	l.currentFeeRate = l.feeRateAtPosition(position)
+	l.currentFeeRate = l.capDelta(
+		oldFeeRate, max(l.feeRateAtPosition(position), oldFeeRate),
+		position-oldPosition,
+	)
+
+	// Make sure the replacement pays enough absolute fee to satisfy the
+	// BIP125 rules, even if it exceeds the max fee rate delta. The fee
+	// rate is still capped by the ending fee rate.
+	if l.txWeight > 0 {
+		relayFeeRate := chainfee.SatPerKWeight(0)
+		if l.estimator != nil {
+			relayFeeRate = l.estimator.RelayFeePerKW()
+		}
+
+		minFeeRate := minReplacementFeeRate(
+			l.prevFee, l.txWeight, relayFeeRate,
+		)
+		if l.currentFeeRate < minFeeRate {
+			log.Debugf("Fee rate %v at position %v doesn't pay "+
+				"enough fee to replace prev tx (fee=%v, "+
+				"weight=%v), using %v instead",
+				l.currentFeeRate, position, l.prevFee,
+				l.txWeight, minFeeRate)
+
+			l.currentFeeRate = min(minFeeRate, l.endingFeeRate)
+		}
+	}
 
 	log.Tracef("Fee rate increased from %v to %v at position %v",
 		oldFeeRate, l.currentFeeRate, l.position)
@@ -264,6 +556,23 @@ func (l *LinearFeeFunction) increaseFeeRate(position uint32) (bool, error) {
 	return l.currentFeeRate > oldFeeRate, nil
 }
 
+// minReplacementFeeRate returns the min fee rate a replacement tx of the given
+// weight must pay so its absolute fee exceeds the prevFee by at least the relay
+// fee rate times the weight, as required by BIP125 rules 3 and 4.
+func minReplacementFeeRate(prevFee btcutil.Amount, weight lntypes.WeightUnit,
+	relayFeeRate chainfee.SatPerKWeight) chainfee.SatPerKWeight {
+
+	// The absolute fee must be strictly greater than the previous fee.
+	minFee := prevFee + max(relayFeeRate.FeeForWeight(weight), 1)
+
+	// Round up so the fee calculated using the returned fee rate is no
+	// less than the min fee.
+	w := int64(weight)
+	feeRate := (int64(minFee)*1000 + w - 1) / w
+
+	return chainfee.SatPerKWeight(feeRate)
+}
+
 // feeRateAtPosition calculates the fee rate at a given position and caps it at
 // the ending fee rate.
 func (l *LinearFeeFunction) feeRateAtPosition(p uint32) chainfee.SatPerKWeight {
@@ -275,6 +584,15 @@ func (l *LinearFeeFunction) feeRateAtPosition(p uint32) chainfee.SatPerKWeight {
 	// fee rate in sat/kw.
 	feeRateDelta := btcutil.Amount(l.deltaFeeRate).MulF64(float64(p) / 1000)
 
+	// If a curve is used, allocate the fee rate range following the curve
+	// instead.
+	if l.curve != nil {
+		fraction := l.curve(float64(p) / float64(l.width))
+		feeRateDelta = btcutil.Amount(
+			l.endingFeeRate - l.startingFeeRate,
+		).MulF64(fraction)
+	}
+
 	feeRate := l.startingFeeRate + chainfee.SatPerKWeight(feeRateDelta)
 	if feeRate > l.endingFeeRate {
 		return l.endingFeeRate
@@ -312,3 +630,964 @@ func (l *LinearFeeFunction) estimateFeeRate(
 
 	return estimatedFeeRate, nil
 }
+
+// FeeFunctionParams are the params used by a FeeFunctionFactory to create the
+// fee function of a bump request.
+type FeeFunctionParams struct {
+	// StartingFeeRate is the fee rate the fee function should start with.
+	// If None, the fee function is expected to estimate it using the
+	// Estimator and the ConfTarget.
+	StartingFeeRate fn.Option[chainfee.SatPerKWeight]
+
+	// MaxFeeRate is the max fee rate the fee function can reach, which is
+	// derived from the budget of the request.
+	MaxFeeRate chainfee.SatPerKWeight
+
+	// ConfTarget is the number of blocks left until the deadline of the
+	// request.
+	ConfTarget uint32
+
+	// CurrentHeight is the best known height of the publisher.
+	CurrentHeight int32
+
+	// Estimator is the fee estimator used by the publisher.
+	Estimator chainfee.Estimator
+
+	// AllocationCurve is the optional curve used to allocate the budget
+	// across the deadline. If nil, the budget is allocated evenly.
+	AllocationCurve AllocationCurve
+
+	// MaxFeeRateDelta is the optional max fee rate increase per block. If
+	// zero, the increase is not capped.
+	MaxFeeRateDelta chainfee.SatPerKWeight
+}
+
+// FeeFunctionFactory creates the fee function used by a bump request.
+type FeeFunctionFactory func(FeeFunctionParams) (FeeFunction, error)
+
+// NewLinearFeeFunctionFromParams is a FeeFunctionFactory which creates a
+// LinearFeeFunction using the given params. This is the factory used by the
+// publisher by default.
+func NewLinearFeeFunctionFromParams(p FeeFunctionParams) (FeeFunction,
+	error) {
+
+	l, err := NewLinearFeeFunctionWithCurve(
+		p.MaxFeeRate, p.ConfTarget, p.Estimator, p.StartingFeeRate,
+		p.AllocationCurve,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.MaxFeeRateDelta > 0 {
+		l.setMaxDeltaFeeRate(p.MaxFeeRateDelta)
+	}
+
+	return l, nil
+}
+
+// FeeDistribution defines an interface that provides historical fee rate
+// distributions, which can be used to derive the fee rate needed to confirm a
+// tx within a given number of blocks with a given probability.
+type FeeDistribution interface {
+	// FeeRateAtPercentile returns the fee rate at the given percentile of
+	// the historical fee rates that were confirmed within the given number
+	// of blocks. The percentile is expressed as a value in range (0, 1].
+	FeeRateAtPercentile(blocks int32,
+		percentile float64) (chainfee.SatPerKWeight, error)
+}
+
+// NewProbabilisticFeeFunction creates a fee function whose starting and ending
+// fee rates are derived from the given fee distribution. The starting fee rate
+// is the rate which gives a probability of targetProb to confirm within the
+// specified blocks, and the ending fee rate is the rate which gives the same
+// probability to confirm within the next block. The fee rate then increases
+// linearly from the starting fee rate to the ending fee rate as the deadline
+// approaches.
+func NewProbabilisticFeeFunction(targetProb float64, blocks int32,
+	distribution FeeDistribution) (*LinearFeeFunction, error) {
+
+	if targetProb <= 0 || targetProb > 1 {
+		return nil, fmt.Errorf("target probability must be in range "+
+			"(0, 1], got %v", targetProb)
+	}
+
+	if blocks < 1 {
+		return nil, fmt.Errorf("blocks must be positive, got %v",
+			blocks)
+	}
+
+	if distribution == nil {
+		return nil, errors.New("nil fee distribution")
+	}
+
+	// Get the fee rate that can confirm within the given blocks.
+	start, err := distribution.FeeRateAtPercentile(blocks, targetProb)
+	if err != nil {
+		return nil, fmt.Errorf("get starting fee rate: %w", err)
+	}
+
+	// Get the fee rate that can confirm within the next block, which is
+	// used as the max fee rate.
+	end, err := distribution.FeeRateAtPercentile(1, targetProb)
+	if err != nil {
+		return nil, fmt.Errorf("get ending fee rate: %w", err)
+	}
+
+	// The fee rate required to confirm sooner should never be lower than
+	// the starting fee rate, but we guard against a malformed
+	// distribution here.
+	if end < start {
+		log.Warnf("Ending fee rate %v is below starting fee rate %v, "+
+			"using starting fee rate instead", end, start)
+
+		end = start
+	}
+
+	log.Debugf("Probabilistic fee function derived startingFeeRate=%v, "+
+		"endingFeeRate=%v using targetProb=%v, blocks=%v", start, end,
+		targetProb, blocks)
+
+	// Since the starting fee rate is specified, the estimator won't be
+	// used when creating the linear fee function.
+	return NewLinearFeeFunction(end, uint32(blocks), nil, fn.Some(start))
+}
+
+// MempoolFeeSource defines an interface that provides fee rates derived from
+// the current mempool.
+type MempoolFeeSource interface {
+	// FeeRateAtPercentile returns the fee rate at the given percentile of
+	// the fee rates in the current mempool blocks. The percentile is
+	// expressed as a value in range (0, 1].
+	FeeRateAtPercentile(percentile float64) (chainfee.SatPerKWeight,
+		error)
+}
+
+// MempoolPercentileFeeFunction implements the FeeFunction interface by tracking
+// a live mempool fee rate percentile. Each time the fee rate is increased, the
+// source is queried and the fee rate is set to the returned value, while
+// guaranteeing an increase of at least the min relay fee rate so the
+// replacement satisfies RBF rule 4. The fee rate will be capped at the max fee
+// rate.
+type MempoolPercentileFeeFunction struct {
+	// source is used to query the current mempool fee rates.
+	source MempoolFeeSource
+
+	// percentile specifies the mempool fee rate percentile to track.
+	percentile float64
+
+	// maxFeeRate specifies the max allowed fee rate.
+	maxFeeRate chainfee.SatPerKWeight
+
+	// minRelayFeeRate specifies the min increase of fee rate used in each
+	// round.
+	minRelayFeeRate chainfee.SatPerKWeight
+
+	// currentFeeRate specifies the current fee rate.
+	currentFeeRate chainfee.SatPerKWeight
+
+	// confTarget is the conf target used in the last increase.
+	confTarget uint32
+
+	// prevFee is the absolute fee paid by the tx being replaced.
+	prevFee btcutil.Amount
+
+	// txWeight is the weight of the tx being replaced. Zero means there's
+	// no tx to replace.
+	txWeight lntypes.WeightUnit
+}
+
+// Compile-time check to ensure MempoolPercentileFeeFunction satisfies the
+// FeeFunction.
+var _ FeeFunction = (*MempoolPercentileFeeFunction)(nil)
+
+// NewMempoolPercentileFeeFunction creates a new fee function that tracks the
+// given mempool fee rate percentile. The initial fee rate is the starting fee
+// rate if specified, otherwise the current percentile returned by the source,
+// and is capped by the max fee rate.
+func NewMempoolPercentileFeeFunction(source MempoolFeeSource,
+	percentile float64, maxFeeRate, minRelayFeeRate chainfee.SatPerKWeight,
+	confTarget uint32, startingFeeRate fn.Option[chainfee.SatPerKWeight]) (
+	*MempoolPercentileFeeFunction, error) {
+
+	if source == nil {
+		return nil, errors.New("nil mempool fee source")
+	}
+
+	if percentile <= 0 || percentile > 1 {
+		return nil, fmt.Errorf("percentile must be in range (0, 1], "+
+			"got %v", percentile)
+	}
+
+	m := &MempoolPercentileFeeFunction{
+		source:          source,
+		percentile:      percentile,
+		maxFeeRate:      maxFeeRate,
+		minRelayFeeRate: minRelayFeeRate,
+		confTarget:      confTarget,
+	}
+
+	// If the deadline is one block away or has already been reached,
+	// we'll use the max fee rate immediately.
+	if confTarget <= 1 {
+		m.currentFeeRate = maxFeeRate
+		return m, nil
+	}
+
+	start, err := startingFeeRate.UnwrapOrFuncErr(
+		func() (chainfee.SatPerKWeight, error) {
+			return source.FeeRateAtPercentile(percentile)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get starting fee rate: %w", err)
+	}
+
+	m.currentFeeRate = min(start, maxFeeRate)
+
+	log.Debugf("Mempool percentile fee function initialized with "+
+		"percentile=%v, starting fee rate=%v, max fee rate=%v",
+		percentile, m.currentFeeRate, maxFeeRate)
+
+	return m, nil
+}
+
+// FeeRate returns the current fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (m *MempoolPercentileFeeFunction) FeeRate() chainfee.SatPerKWeight {
+	return m.currentFeeRate
+}
+
+// Increment queries the mempool fee source and increases the fee rate to the
+// tracked percentile, or by the min relay fee rate if the percentile doesn't
+// give a large enough increase. It returns an error if the fee rate is already
+// at the max.
+//
+// NOTE: part of the FeeFunction interface.
+func (m *MempoolPercentileFeeFunction) Increment(
+	txWeight lntypes.WeightUnit) (bool, error) {
+
+	// Only update the weight if there's a tx to replace.
+	if txWeight > 0 && m.txWeight > 0 {
+		m.txWeight = txWeight
+	}
+
+	// If the fee rate is already at the max, we return an error.
+	if m.currentFeeRate >= m.maxFeeRate {
+		return false, ErrMaxPosition
+	}
+
+	target, err := m.source.FeeRateAtPercentile(m.percentile)
+	if err != nil {
+		return false, fmt.Errorf("get mempool fee rate: %w", err)
+	}
+
+	// Make sure the fee rate is increased by at least the min relay fee
+	// rate so the replacement pays for its own bandwidth.
+	minFeeRate := m.currentFeeRate + m.minRelayFeeRate
+
+	// Also make sure the replacement pays enough absolute fee to satisfy
+	// the BIP125 rules.
+	if m.txWeight > 0 {
+		minFeeRate = max(minFeeRate, minReplacementFeeRate(
+			m.prevFee, m.txWeight, m.minRelayFeeRate,
+		))
+	}
+
+	newFeeRate := min(max(target, minFeeRate), m.maxFeeRate)
+
+	log.Tracef("Mempool fee rate at percentile %v is %v, increasing fee "+
+		"rate from %v to %v", m.percentile, target, m.currentFeeRate,
+		newFeeRate)
+
+	oldFeeRate := m.currentFeeRate
+	m.currentFeeRate = newFeeRate
+
+	return m.currentFeeRate > oldFeeRate, nil
+}
+
+// IncreaseFeeRate increases the fee rate once per new conf target by calling
+// the Increment method. When the deadline is reached, the max fee rate is used.
+//
+// NOTE: part of the FeeFunction interface.
+func (m *MempoolPercentileFeeFunction) IncreaseFeeRate(confTarget uint32,
+	prevFee btcutil.Amount, txWeight lntypes.WeightUnit) (bool, error) {
+
+	m.prevFee = prevFee
+	m.txWeight = txWeight
+
+	// Skip the increase if the conf target hasn't decreased, which means
+	// there's no new block since the last increase.
+	if confTarget >= m.confTarget {
+		log.Tracef("Skipped increase feerate: confTarget=%v, "+
+			"lastConfTarget=%v", confTarget, m.confTarget)
+
+		return false, nil
+	}
+
+	m.confTarget = confTarget
+
+	// The deadline has been reached, use the max fee rate.
+	if confTarget <= 1 {
+		if m.currentFeeRate >= m.maxFeeRate {
+			return false, ErrMaxPosition
+		}
+
+		m.currentFeeRate = m.maxFeeRate
+
+		return true, nil
+	}
+
+	return m.Increment(txWeight)
+}
+
+// RebaseFloor raises the current fee rate to the given floor, capped by the
+// max fee rate, if the current fee rate is below it.
+//
+// NOTE: part of the FeeFunction interface.
+func (m *MempoolPercentileFeeFunction) RebaseFloor(
+	floor chainfee.SatPerKWeight) bool {
+
+	floor = min(floor, m.maxFeeRate)
+	if m.currentFeeRate >= floor {
+		return false
+	}
+
+	m.currentFeeRate = floor
+
+	return true
+}
+
+// ResetTrajectory is a no-op, as the fee rate already follows the live
+// mempool fee rates.
+//
+// NOTE: part of the FeeFunction interface.
+func (m *MempoolPercentileFeeFunction) ResetTrajectory(
+	_ chainfee.SatPerKWeight) {
+}
+
+// Schedule returns the projected fee rates to be used at each block till the
+// deadline. As the future mempool fee rates are unknown, the projection
+// assumes the minimum increase of the min relay fee rate per block, and the
+// max fee rate once the deadline is reached.
+//
+// NOTE: part of the FeeFunction interface.
+func (m *MempoolPercentileFeeFunction) Schedule() []chainfee.SatPerKWeight {
+	numBlocks := max(m.confTarget, 1)
+
+	schedule := make([]chainfee.SatPerKWeight, 0, numBlocks)
+	schedule = append(schedule, m.currentFeeRate)
+
+	feeRate := m.currentFeeRate
+	for i := uint32(1); i < numBlocks; i++ {
+		feeRate = min(feeRate+m.minRelayFeeRate, m.maxFeeRate)
+
+		// The max fee rate is used once the deadline is reached.
+		if i == numBlocks-1 {
+			feeRate = m.maxFeeRate
+		}
+
+		schedule = append(schedule, feeRate)
+	}
+
+	return schedule
+}
+
+// ConstantFeeFunction implements the FeeFunction interface with a fixed fee
+// rate, which is used when the caller knows exactly what fee rate to pay. The
+// fee rate is never increased, hence txns created using this fee function are
+// never replaced.
+type ConstantFeeFunction struct {
+	// feeRate is the fixed fee rate.
+	feeRate chainfee.SatPerKWeight
+
+	// confTarget is the number of blocks till the deadline.
+	confTarget uint32
+}
+
+// Compile-time check to ensure ConstantFeeFunction satisfies the FeeFunction.
+var _ FeeFunction = (*ConstantFeeFunction)(nil)
+
+// NewConstantFeeFunction creates a new fee function that always uses the
+// given fee rate.
+func NewConstantFeeFunction(feeRate chainfee.SatPerKWeight,
+	confTarget uint32) (*ConstantFeeFunction, error) {
+
+	if feeRate <= 0 {
+		return nil, fmt.Errorf("fixed fee rate must be positive, "+
+			"got %v", feeRate)
+	}
+
+	log.Debugf("Constant fee function initialized with feeRate=%v",
+		feeRate)
+
+	return &ConstantFeeFunction{
+		feeRate:    feeRate,
+		confTarget: confTarget,
+	}, nil
+}
+
+// FeeRate returns the fixed fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (c *ConstantFeeFunction) FeeRate() chainfee.SatPerKWeight {
+	return c.feeRate
+}
+
+// Increment never increases the fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (c *ConstantFeeFunction) Increment(_ lntypes.WeightUnit) (bool, error) {
+	return false, nil
+}
+
+// IncreaseFeeRate never increases the fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (c *ConstantFeeFunction) IncreaseFeeRate(confTarget uint32,
+	_ btcutil.Amount, _ lntypes.WeightUnit) (bool, error) {
+
+	c.confTarget = confTarget
+
+	return false, nil
+}
+
+// RebaseFloor never changes the fee rate, as the caller has asked for this
+// exact fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (c *ConstantFeeFunction) RebaseFloor(floor chainfee.SatPerKWeight) bool {
+	if c.feeRate < floor {
+		log.Warnf("Fixed fee rate %v is below fee rate floor %v",
+			c.feeRate, floor)
+	}
+
+	return false
+}
+
+// ResetTrajectory is a no-op, as the fee rate is never changed.
+//
+// NOTE: part of the FeeFunction interface.
+func (c *ConstantFeeFunction) ResetTrajectory(_ chainfee.SatPerKWeight) {}
+
+// Schedule returns the fixed fee rate for each block till the deadline.
+//
+// NOTE: part of the FeeFunction interface.
+func (c *ConstantFeeFunction) Schedule() []chainfee.SatPerKWeight {
+	schedule := make([]chainfee.SatPerKWeight, max(c.confTarget, 1))
+	for i := range schedule {
+		schedule[i] = c.feeRate
+	}
+
+	return schedule
+}
+
+// jitterFeeFunction wraps a FeeFunction and perturbs each fee rate it returns
+// by a random amount within the jitter percentage, which makes the fee rates
+// of the sweeping txns less predictable. The jittered fee rate never decreases,
+// never exceeds the max fee rate of the wrapped function, and always pays
+// enough fees to replace the previous tx as required by BIP125.
+type jitterFeeFunction struct {
+	FeeFunction
+
+	// percent is the max percentage by which the fee rate is perturbed.
+	percent float64
+
+	// rng is the source of randomness, which is seeded per request.
+	rng *rand.Rand
+
+	// relayFeeRate is the min relay fee rate, which is used as the floor
+	// of the jittered fee rate.
+	relayFeeRate chainfee.SatPerKWeight
+
+	// currentFeeRate is the current jittered fee rate.
+	currentFeeRate chainfee.SatPerKWeight
+
+	// prevFee and txWeight are the fee and weight of the previous tx,
+	// which are used to make sure the replacement pays enough fees.
+	prevFee  btcutil.Amount
+	txWeight lntypes.WeightUnit
+}
+
+// Compile-time check to ensure jitterFeeFunction satisfies the FeeFunction.
+var _ FeeFunction = (*jitterFeeFunction)(nil)
+
+// newJitterFeeFunction wraps the given fee function to jitter its fee rates
+// by up to the given percentage, using the seed as the source of randomness.
+func newJitterFeeFunction(f FeeFunction, percent float64, seed int64,
+	relayFeeRate chainfee.SatPerKWeight) *jitterFeeFunction {
+
+	j := &jitterFeeFunction{
+		FeeFunction:  f,
+		percent:      percent,
+		rng:          rand.New(rand.NewSource(seed)), //nolint:gosec
+		relayFeeRate: relayFeeRate,
+	}
+	j.jitter()
+
+	return j
+}
+
+// FeeRate returns the current jittered fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (j *jitterFeeFunction) FeeRate() chainfee.SatPerKWeight {
+	return j.currentFeeRate
+}
+
+// Increment increases the fee rate of the wrapped function by one step and
+// jitters the result. It returns true if the jittered fee rate is increased.
+//
+// NOTE: part of the FeeFunction interface.
+func (j *jitterFeeFunction) Increment(txWeight lntypes.WeightUnit) (bool,
+	error) {
+
+	increased, err := j.FeeFunction.Increment(txWeight)
+	if err != nil || !increased {
+		return false, err
+	}
+
+	return j.jitter(), nil
+}
+
+// IncreaseFeeRate increases the fee rate of the wrapped function based on the
+// conf target and jitters the result. It returns true if the jittered fee rate
+// is increased.
+//
+// NOTE: part of the FeeFunction interface.
+func (j *jitterFeeFunction) IncreaseFeeRate(confTarget uint32,
+	prevFee btcutil.Amount, txWeight lntypes.WeightUnit) (bool, error) {
+
+	j.prevFee = prevFee
+	j.txWeight = txWeight
+
+	increased, err := j.FeeFunction.IncreaseFeeRate(
+		confTarget, prevFee, txWeight,
+	)
+	if err != nil || !increased {
+		return false, err
+	}
+
+	return j.jitter(), nil
+}
+
+// RebaseFloor rebases the wrapped function and jitters the result if it's
+// rebased.
+//
+// NOTE: part of the FeeFunction interface.
+func (j *jitterFeeFunction) RebaseFloor(floor chainfee.SatPerKWeight) bool {
+	if !j.FeeFunction.RebaseFloor(floor) {
+		return false
+	}
+
+	j.relayFeeRate = max(j.relayFeeRate, floor)
+
+	return j.jitter()
+}
+
+// jitter perturbs the fee rate of the wrapped function and updates the current
+// fee rate. It returns true if the current fee rate is increased.
+func (j *jitterFeeFunction) jitter() bool {
+	feeRate := j.FeeFunction.FeeRate()
+
+	// The max fee rate is the last fee rate in the schedule.
+	maxFeeRate := feeRate
+	if schedule := j.FeeFunction.Schedule(); len(schedule) > 0 {
+		maxFeeRate = max(maxFeeRate, schedule[len(schedule)-1])
+	}
+
+	// Perturb the fee rate by a random amount in [-percent, percent].
+	delta := (j.rng.Float64()*2 - 1) * j.percent / 100
+	jittered := chainfee.SatPerKWeight(float64(feeRate) * (1 + delta))
+
+	// Use the max fee rate once the wrapped function reaches it, so the
+	// budget is fully used when the deadline is reached.
+	if feeRate >= maxFeeRate {
+		jittered = maxFeeRate
+	}
+
+	// Never go below the previous fee rate or the relay fee rate.
+	jittered = max(jittered, j.currentFeeRate, j.relayFeeRate)
+
+	// Make sure the replacement pays enough absolute fee.
+	if j.txWeight > 0 {
+		jittered = max(jittered, minReplacementFeeRate(
+			j.prevFee, j.txWeight, j.relayFeeRate,
+		))
+	}
+
+	// Cap the fee rate at the max.
+	jittered = min(jittered, maxFeeRate)
+
+	log.Tracef("Jittered fee rate from %v to %v", feeRate, jittered)
+
+	oldFeeRate := j.currentFeeRate
+	j.currentFeeRate = jittered
+
+	return j.currentFeeRate > oldFeeRate
+}
+
+// utilizationFeeFunction wraps a FeeFunction whose schedule ends below the min
+// fee rate required by the deadline, and raises its fee rates to a floor which
+// ramps linearly from the starting fee rate to the required fee rate at the
+// deadline.
+type utilizationFeeFunction struct {
+	FeeFunction
+
+	// startingFeeRate is the fee rate of the wrapped function when it's
+	// wrapped, which is where the floor starts.
+	startingFeeRate chainfee.SatPerKWeight
+
+	// minEndingFeeRate is the fee rate the floor reaches at the deadline.
+	minEndingFeeRate chainfee.SatPerKWeight
+
+	// width is the number of blocks the floor takes to reach the min
+	// ending fee rate, which is the conf target minus one, matching the
+	// LinearFeeFunction.
+	width uint32
+
+	// position is the number of blocks since the function was wrapped.
+	position uint32
+}
+
+// Compile-time check to ensure utilizationFeeFunction satisfies the
+// FeeFunction.
+var _ FeeFunction = (*utilizationFeeFunction)(nil)
+
+// withMinUtilization wraps the given fee function so its fee rate reaches at
+// least the given fraction of the max fee rate by the deadline, which is
+// confTarget blocks away. The fee function is returned as is if its schedule
+// already reaches it.
+func withMinUtilization(f FeeFunction, fraction float64,
+	maxFeeRate chainfee.SatPerKWeight, confTarget uint32) FeeFunction {
+
+	minEndingFeeRate := min(
+		chainfee.SatPerKWeight(fraction*float64(maxFeeRate)),
+		maxFeeRate,
+	)
+
+	schedule := f.Schedule()
+	if len(schedule) > 0 && schedule[len(schedule)-1] >= minEndingFeeRate {
+		return f
+	}
+
+	log.Debugf("Fee function ends below min fee rate %v required by the "+
+		"deadline, shaping it to ramp towards it", minEndingFeeRate)
+
+	return &utilizationFeeFunction{
+		FeeFunction:      f,
+		startingFeeRate:  f.FeeRate(),
+		minEndingFeeRate: minEndingFeeRate,
+		width:            max(confTarget, 1) - 1,
+	}
+}
+
+// floorAt returns the floor of the fee rate at the given position.
+func (u *utilizationFeeFunction) floorAt(
+	position uint32) chainfee.SatPerKWeight {
+
+	if position >= u.width || u.startingFeeRate >= u.minEndingFeeRate {
+		return max(u.minEndingFeeRate, u.startingFeeRate)
+	}
+
+	delta := u.minEndingFeeRate - u.startingFeeRate
+	step := delta * chainfee.SatPerKWeight(position) /
+		chainfee.SatPerKWeight(u.width)
+
+	return u.startingFeeRate + step
+}
+
+// FeeRate returns the fee rate of the wrapped function, raised to the floor at
+// the current position.
+//
+// NOTE: part of the FeeFunction interface.
+func (u *utilizationFeeFunction) FeeRate() chainfee.SatPerKWeight {
+	return max(u.FeeFunction.FeeRate(), u.floorAt(u.position))
+}
+
+// Increment increases the wrapped function and the floor by one step. It
+// returns true if the fee rate is increased.
+//
+// NOTE: part of the FeeFunction interface.
+func (u *utilizationFeeFunction) Increment(txWeight lntypes.WeightUnit) (bool,
+	error) {
+
+	oldFeeRate := u.FeeRate()
+
+	if u.position < u.width {
+		u.position++
+	}
+
+	_, err := u.FeeFunction.Increment(txWeight)
+
+	return u.increased(oldFeeRate, err)
+}
+
+// IncreaseFeeRate increases the wrapped function and moves the floor to the
+// position of the given conf target. It returns true if the fee rate is
+// increased.
+//
+// NOTE: part of the FeeFunction interface.
+func (u *utilizationFeeFunction) IncreaseFeeRate(confTarget uint32,
+	prevFee btcutil.Amount, txWeight lntypes.WeightUnit) (bool, error) {
+
+	oldFeeRate := u.FeeRate()
+
+	if confTarget < u.width+1 {
+		u.position = max(u.position, u.width+1-confTarget)
+	}
+
+	_, err := u.FeeFunction.IncreaseFeeRate(confTarget, prevFee, txWeight)
+
+	return u.increased(oldFeeRate, err)
+}
+
+// increased returns whether the fee rate is increased from the old fee rate
+// after updating the wrapped function. Reaching the max position of the
+// wrapped function is not an error as long as the floor still increases.
+func (u *utilizationFeeFunction) increased(oldFeeRate chainfee.SatPerKWeight,
+	err error) (bool, error) {
+
+	increased := u.FeeRate() > oldFeeRate
+
+	switch {
+	case err == nil:
+		return increased, nil
+
+	case errors.Is(err, ErrMaxPosition) && increased:
+		return true, nil
+
+	default:
+		return false, err
+	}
+}
+
+// Schedule returns the projected fee rates of the wrapped function, raised to
+// the floor at each block.
+//
+// NOTE: part of the FeeFunction interface.
+func (u *utilizationFeeFunction) Schedule() []chainfee.SatPerKWeight {
+	inner := u.FeeFunction.Schedule()
+
+	// The schedule lasts at least till the floor reaches its end.
+	remaining := int(u.width-min(u.position, u.width)) + 1
+	schedule := make(
+		[]chainfee.SatPerKWeight, max(len(inner), remaining),
+	)
+	for i := range schedule {
+		floor := u.floorAt(u.position + uint32(i))
+		schedule[i] = max(scheduleAt(inner, i), floor)
+	}
+
+	return schedule
+}
+
+// FeeSchedule identifies the fee schedule whose fee rate was used by a tx when
+// the request tracks more than one schedule.
+type FeeSchedule uint8
+
+const (
+	// FeeSchedulePrimary is the schedule derived from the deadline of the
+	// request, which is the only schedule used by most requests.
+	FeeSchedulePrimary FeeSchedule = iota
+
+	// FeeScheduleAlt is the alternative schedule derived from the
+	// AltConfTarget of the request.
+	FeeScheduleAlt
+)
+
+// String returns a human-readable string for the fee schedule.
+func (s FeeSchedule) String() string {
+	switch s {
+	case FeeSchedulePrimary:
+		return "Primary"
+
+	case FeeScheduleAlt:
+		return "Alt"
+
+	default:
+		return "Unknown"
+	}
+}
+
+// dualFeeFunction tracks two fee schedules, derived from two different conf
+// targets, and uses the higher of their fee rates each round. This allows
+// comparing how the conf targets perform, as the schedule which bound the fee
+// rate is reported in the results.
+type dualFeeFunction struct {
+	// primary is the fee function derived from the deadline of the
+	// request.
+	primary FeeFunction
+
+	// alt is the fee function derived from the alternative conf target.
+	alt FeeFunction
+
+	// targetOffset is the primary conf target minus the alternative conf
+	// target when the fee functions were created, which is used to map
+	// the conf target of the primary schedule to the alternative one.
+	targetOffset int64
+}
+
+// Compile-time check to ensure dualFeeFunction satisfies the FeeFunction.
+var _ FeeFunction = (*dualFeeFunction)(nil)
+
+// newDualFeeFunction creates a fee function tracking both the given primary
+// and alternative fee functions, which are created using the given conf
+// targets.
+func newDualFeeFunction(primary, alt FeeFunction, primaryTarget,
+	altTarget uint32) *dualFeeFunction {
+
+	return &dualFeeFunction{
+		primary:      primary,
+		alt:          alt,
+		targetOffset: int64(primaryTarget) - int64(altTarget),
+	}
+}
+
+// FeeRate returns the higher fee rate of the two schedules.
+//
+// NOTE: part of the FeeFunction interface.
+func (d *dualFeeFunction) FeeRate() chainfee.SatPerKWeight {
+	return max(d.primary.FeeRate(), d.alt.FeeRate())
+}
+
+// Bound returns the schedule whose fee rate is currently used. The primary
+// schedule is returned when both give the same fee rate.
+func (d *dualFeeFunction) Bound() FeeSchedule {
+	if d.alt.FeeRate() > d.primary.FeeRate() {
+		return FeeScheduleAlt
+	}
+
+	return FeeSchedulePrimary
+}
+
+// Increment increases both schedules by one step. It returns true if the
+// higher fee rate of the two is increased, and an error only when neither
+// schedule can be increased.
+//
+// NOTE: part of the FeeFunction interface.
+func (d *dualFeeFunction) Increment(txWeight lntypes.WeightUnit) (bool,
+	error) {
+
+	oldFeeRate := d.FeeRate()
+
+	_, errPrimary := d.primary.Increment(txWeight)
+	_, errAlt := d.alt.Increment(txWeight)
+
+	if err := combineScheduleErrs(errPrimary, errAlt); err != nil {
+		return false, err
+	}
+
+	return d.FeeRate() > oldFeeRate, nil
+}
+
+// IncreaseFeeRate increases both schedules based on the conf target of the
+// primary schedule, which is shifted by the target offset for the alternative
+// one. It returns true if the higher fee rate of the two is increased.
+//
+// NOTE: part of the FeeFunction interface.
+func (d *dualFeeFunction) IncreaseFeeRate(confTarget uint32,
+	prevFee btcutil.Amount, txWeight lntypes.WeightUnit) (bool, error) {
+
+	oldFeeRate := d.FeeRate()
+
+	_, errPrimary := d.primary.IncreaseFeeRate(
+		confTarget, prevFee, txWeight,
+	)
+
+	// The alternative schedule reaches its deadline once its shifted
+	// conf target drops to zero.
+	altTarget := max(int64(confTarget)-d.targetOffset, 0)
+	_, errAlt := d.alt.IncreaseFeeRate(
+		uint32(altTarget), prevFee, txWeight,
+	)
+
+	if err := combineScheduleErrs(errPrimary, errAlt); err != nil {
+		return false, err
+	}
+
+	return d.FeeRate() > oldFeeRate, nil
+}
+
+// combineScheduleErrs returns the error to be reported after updating both
+// schedules. Reaching the max position of a single schedule is not an error
+// as long as the other one can still be increased.
+func combineScheduleErrs(errPrimary, errAlt error) error {
+	switch {
+	case errPrimary != nil && errAlt != nil:
+		return errPrimary
+
+	case errPrimary != nil && !errors.Is(errPrimary, ErrMaxPosition):
+		return errPrimary
+
+	case errAlt != nil && !errors.Is(errAlt, ErrMaxPosition):
+		return errAlt
+
+	default:
+		return nil
+	}
+}
+
+// RebaseFloor rebases both schedules using the given floor. It returns true
+// if the higher fee rate of the two is changed.
+//
+// NOTE: part of the FeeFunction interface.
+func (d *dualFeeFunction) RebaseFloor(floor chainfee.SatPerKWeight) bool {
+	oldFeeRate := d.FeeRate()
+
+	d.primary.RebaseFloor(floor)
+	d.alt.RebaseFloor(floor)
+
+	return d.FeeRate() != oldFeeRate
+}
+
+// ResetTrajectory resets the primary schedule using the given estimate, which
+// is made for the conf target of the primary schedule.
+//
+// NOTE: part of the FeeFunction interface.
+func (d *dualFeeFunction) ResetTrajectory(newEstimate chainfee.SatPerKWeight) {
+	d.primary.ResetTrajectory(newEstimate)
+}
+
+// Schedule returns the projected fee rates of the dual fee function, which are
+// the higher fee rates of the two schedules at each block. A schedule shorter
+// than the other is padded using its last fee rate.
+//
+// NOTE: part of the FeeFunction interface.
+func (d *dualFeeFunction) Schedule() []chainfee.SatPerKWeight {
+	primary := d.primary.Schedule()
+	alt := d.alt.Schedule()
+
+	schedule := make(
+		[]chainfee.SatPerKWeight, max(len(primary), len(alt)),
+	)
+	for i := range schedule {
+		schedule[i] = max(scheduleAt(primary, i), scheduleAt(alt, i))
+	}
+
+	return schedule
+}
+
+// scheduleAt returns the fee rate of the schedule at the given index, or its
+// last fee rate if the index is beyond its length.
+func scheduleAt(schedule []chainfee.SatPerKWeight,
+	i int) chainfee.SatPerKWeight {
+
+	if len(schedule) == 0 {
+		return 0
+	}
+
+	return schedule[min(i, len(schedule)-1)]
+}
+
+// boundFeeSchedule returns the schedule whose fee rate is used by the given
+// fee function, which is always the primary one unless the fee function
+// tracks dual schedules.
+func boundFeeSchedule(f FeeFunction) FeeSchedule {
+	if d, ok := f.(*dualFeeFunction); ok {
+		return d.Bound()
+	}
+
+	return FeeSchedulePrimary
+}
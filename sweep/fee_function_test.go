@@ -1,9 +1,13 @@
 package sweep
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btclog/v2"
 	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/stretchr/testify/require"
 )
@@ -300,7 +304,7 @@ func TestLinearFeeFunctionIncrement(t *testing.T) {
 	// We now increase the position from 1 to 8.
 	for i := uint32(1); i <= confTarget-1; i++ {
 		// Increase the fee rate.
-		increased, err := f.Increment()
+		increased, err := f.Increment(0)
 		rt.NoError(err)
 		rt.True(increased)
 
@@ -316,7 +320,7 @@ func TestLinearFeeFunctionIncrement(t *testing.T) {
 
 	// Now the position is at 8th, increase it again should give us an
 	// error.
-	increased, err := f.Increment()
+	increased, err := f.Increment(0)
 	rt.ErrorIs(err, ErrMaxPosition)
 	rt.False(increased)
 }
@@ -351,12 +355,12 @@ func TestLinearFeeFunctionIncreaseFeeRate(t *testing.T) {
 
 	// If we are increasing the fee rate using the initial conf target, we
 	// should get a nil error and false.
-	increased, err := f.IncreaseFeeRate(confTarget)
+	increased, err := f.IncreaseFeeRate(confTarget, 0, 0)
 	rt.NoError(err)
 	rt.False(increased)
 
 	// Test that we are allowed to use a larger conf target.
-	increased, err = f.IncreaseFeeRate(confTarget + 1)
+	increased, err = f.IncreaseFeeRate(confTarget+1, 0, 0)
 	rt.NoError(err)
 	rt.False(increased)
 
@@ -364,7 +368,7 @@ func TestLinearFeeFunctionIncreaseFeeRate(t *testing.T) {
 	// get no error and true.
 	for i := uint32(1); i < confTarget-1; i++ {
 		// Increase the fee rate.
-		increased, err := f.IncreaseFeeRate(confTarget - i)
+		increased, err := f.IncreaseFeeRate(confTarget-i, 0, 0)
 		rt.NoError(err)
 		rt.True(increased)
 
@@ -380,7 +384,7 @@ func TestLinearFeeFunctionIncreaseFeeRate(t *testing.T) {
 
 	// Test that when we use a conf target of 1, we get the ending fee
 	// rate.
-	increased, err = f.IncreaseFeeRate(1)
+	increased, err = f.IncreaseFeeRate(1, 0, 0)
 	rt.NoError(err)
 	rt.True(increased)
 	rt.Equal(confTarget-1, f.position)
@@ -388,7 +392,838 @@ func TestLinearFeeFunctionIncreaseFeeRate(t *testing.T) {
 
 	// Test that when we use a conf target of 0, ErrMaxPosition is
 	// returned.
-	increased, err = f.IncreaseFeeRate(0)
+	increased, err = f.IncreaseFeeRate(0, 0, 0)
 	rt.ErrorIs(err, ErrMaxPosition)
 	rt.False(increased)
 }
+
+// TestProbabilisticFeeFunction checks the fee function derives its starting
+// and ending fee rates from the fee distribution.
+func TestProbabilisticFeeFunction(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a mock fee distribution.
+	distribution := &MockFeeDistribution{}
+	defer distribution.AssertExpectations(t)
+
+	// Create testing params.
+	targetProb := 0.9
+	blocks := int32(6)
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	endFeeRate := chainfee.SatPerKWeight(6000)
+
+	// Invalid params should be rejected without touching the
+	// distribution.
+	_, err := NewProbabilisticFeeFunction(0, blocks, distribution)
+	rt.Error(err)
+	_, err = NewProbabilisticFeeFunction(1.1, blocks, distribution)
+	rt.Error(err)
+	_, err = NewProbabilisticFeeFunction(targetProb, 0, distribution)
+	rt.Error(err)
+	_, err = NewProbabilisticFeeFunction(targetProb, blocks, nil)
+	rt.Error(err)
+
+	// When the distribution returns an error, it's returned.
+	distribution.On("FeeRateAtPercentile", blocks, targetProb).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+	_, err = NewProbabilisticFeeFunction(targetProb, blocks, distribution)
+	rt.ErrorIs(err, errDummy)
+
+	// Mock the distribution to return the percentile fee rates.
+	distribution.On("FeeRateAtPercentile", blocks, targetProb).Return(
+		startFeeRate, nil).Once()
+	distribution.On("FeeRateAtPercentile", int32(1), targetProb).Return(
+		endFeeRate, nil).Once()
+
+	f, err := NewProbabilisticFeeFunction(targetProb, blocks, distribution)
+	rt.NoError(err)
+
+	// The starting fee rate should match the target percentile for the
+	// given blocks.
+	rt.Equal(startFeeRate, f.FeeRate())
+	rt.Equal(startFeeRate, f.startingFeeRate)
+	rt.Equal(endFeeRate, f.endingFeeRate)
+	rt.Equal(uint32(blocks-1), f.width)
+
+	// Increment the fee function till the end, which should give us the
+	// ending fee rate.
+	for i := int32(1); i < blocks; i++ {
+		increased, err := f.Increment(0)
+		rt.NoError(err)
+		rt.True(increased)
+	}
+	rt.Equal(endFeeRate, f.FeeRate())
+}
+
+// TestLinearFeeFunctionRebaseFloor checks the fee function is rebased when its
+// current fee rate is below the floor.
+func TestLinearFeeFunctionRebaseFloor(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a fee func which goes from 1000 to 9000 with a width of 8,
+	// and it's at position 2 now, which gives a fee rate of 3000.
+	f := &LinearFeeFunction{
+		startingFeeRate: 1000,
+		endingFeeRate:   9000,
+		currentFeeRate:  3000,
+		position:        2,
+		deltaFeeRate:    1_000_000,
+		width:           8,
+	}
+
+	// A floor below the current fee rate is a no-op.
+	rt.False(f.RebaseFloor(2000))
+	rt.Equal(chainfee.SatPerKWeight(3000), f.FeeRate())
+	rt.EqualValues(2, f.position)
+
+	// A floor above the current fee rate rebases the function.
+	rt.True(f.RebaseFloor(6000))
+	rt.Equal(chainfee.SatPerKWeight(6000), f.FeeRate())
+	rt.EqualValues(0, f.position)
+	rt.EqualValues(6, f.width)
+
+	// The next increment should start from the new floor.
+	increased, err := f.Increment(0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(6500), f.FeeRate())
+
+	// The conf target mapping should be kept, so a conf target of 1 gives
+	// us the ending fee rate.
+	increased, err = f.IncreaseFeeRate(1, 0, 0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(9000), f.FeeRate())
+
+	// A floor above the ending fee rate is capped.
+	f = &LinearFeeFunction{
+		startingFeeRate: 1000,
+		endingFeeRate:   9000,
+		currentFeeRate:  1000,
+		deltaFeeRate:    1_000_000,
+		width:           8,
+	}
+	rt.True(f.RebaseFloor(10000))
+	rt.Equal(chainfee.SatPerKWeight(9000), f.FeeRate())
+}
+
+// TestLinearFeeFunctionResetTrajectory checks the fee function ramps from the
+// new estimate once its trajectory is reset, without decreasing its current
+// fee rate.
+func TestLinearFeeFunctionResetTrajectory(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a fee func which goes from 1000 to 9000 with a width of 8,
+	// and it's at position 2 now, which gives a fee rate of 3000.
+	f := &LinearFeeFunction{
+		startingFeeRate: 1000,
+		endingFeeRate:   9000,
+		currentFeeRate:  3000,
+		position:        2,
+		deltaFeeRate:    1_000_000,
+		width:           8,
+	}
+
+	// An estimate no lower than the current fee rate is a no-op.
+	f.ResetTrajectory(3000)
+	rt.EqualValues(2, f.position)
+	rt.EqualValues(8, f.width)
+	rt.Equal(chainfee.SatPerKWeight(1000), f.startingFeeRate)
+
+	// A dropped estimate restarts the function from the current position
+	// without changing the current fee rate.
+	f.ResetTrajectory(1000)
+	rt.Equal(chainfee.SatPerKWeight(3000), f.FeeRate())
+	rt.EqualValues(0, f.position)
+	rt.EqualValues(6, f.width)
+
+	// The next position gives a fee rate below the current one, which is
+	// kept as the fee rate never decreases.
+	increased, err := f.Increment(0)
+	rt.NoError(err)
+	rt.False(increased)
+	rt.Equal(chainfee.SatPerKWeight(3000), f.FeeRate())
+
+	// The following increments stay below the previous trajectory, which
+	// would have given 5000 and 6000.
+	increased, err = f.Increment(0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(3667), f.FeeRate())
+
+	increased, err = f.Increment(0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(5000), f.FeeRate())
+
+	// The conf target mapping should be kept, so a conf target of 1 gives
+	// us the ending fee rate.
+	increased, err = f.IncreaseFeeRate(1, 0, 0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(9000), f.FeeRate())
+}
+
+// TestMempoolPercentileFeeFunction checks the fee function tracks the mempool
+// fee rate percentile while guaranteeing the min relay fee increase and
+// respecting the max fee rate.
+func TestMempoolPercentileFeeFunction(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a mock mempool fee source.
+	source := &MockMempoolFeeSource{}
+	defer source.AssertExpectations(t)
+
+	// Create testing params.
+	percentile := 0.5
+	maxFeeRate := chainfee.SatPerKWeight(5000)
+	relayFeeRate := chainfee.SatPerKWeight(250)
+	confTarget := uint32(6)
+	noStartFeeRate := fn.None[chainfee.SatPerKWeight]()
+
+	// Invalid params should be rejected without touching the source.
+	_, err := NewMempoolPercentileFeeFunction(
+		nil, percentile, maxFeeRate, relayFeeRate, confTarget,
+		noStartFeeRate,
+	)
+	rt.Error(err)
+	_, err = NewMempoolPercentileFeeFunction(
+		source, 0, maxFeeRate, relayFeeRate, confTarget,
+		noStartFeeRate,
+	)
+	rt.Error(err)
+
+	// When the source returns an error, it's returned.
+	source.On("FeeRateAtPercentile", percentile).Return(
+		chainfee.SatPerKWeight(0), errDummy).Once()
+	_, err = NewMempoolPercentileFeeFunction(
+		source, percentile, maxFeeRate, relayFeeRate, confTarget,
+		noStartFeeRate,
+	)
+	rt.ErrorIs(err, errDummy)
+
+	// Mock the source to return the initial percentile.
+	source.On("FeeRateAtPercentile", percentile).Return(
+		chainfee.SatPerKWeight(1000), nil).Once()
+
+	f, err := NewMempoolPercentileFeeFunction(
+		source, percentile, maxFeeRate, relayFeeRate, confTarget,
+		noStartFeeRate,
+	)
+	rt.NoError(err)
+	rt.Equal(chainfee.SatPerKWeight(1000), f.FeeRate())
+
+	// Mock the source to return rising percentiles, the fee function
+	// should follow it.
+	source.On("FeeRateAtPercentile", percentile).Return(
+		chainfee.SatPerKWeight(2000), nil).Once()
+	increased, err := f.IncreaseFeeRate(confTarget-1, 0, 0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(2000), f.FeeRate())
+
+	// Calling it again using the same conf target is a no-op.
+	increased, err = f.IncreaseFeeRate(confTarget-1, 0, 0)
+	rt.NoError(err)
+	rt.False(increased)
+
+	// When the percentile only rises slightly, the fee rate should still
+	// be increased by the min relay fee rate.
+	source.On("FeeRateAtPercentile", percentile).Return(
+		chainfee.SatPerKWeight(2100), nil).Once()
+	increased, err = f.Increment(0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(2250), f.FeeRate())
+
+	// When the percentile exceeds the max fee rate, the fee rate should
+	// be capped.
+	source.On("FeeRateAtPercentile", percentile).Return(
+		chainfee.SatPerKWeight(8000), nil).Once()
+	increased, err = f.Increment(0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(maxFeeRate, f.FeeRate())
+
+	// Further increments should give us an error.
+	increased, err = f.Increment(0)
+	rt.ErrorIs(err, ErrMaxPosition)
+	rt.False(increased)
+}
+
+// TestLinearFeeFunctionReplacementFee checks the fee function bumps the fee
+// rate further when a naive bump would violate the BIP125 absolute fee rules.
+func TestLinearFeeFunctionReplacementFee(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a mock fee estimator.
+	estimator := &chainfee.MockEstimator{}
+	defer estimator.AssertExpectations(t)
+
+	// Create testing params. These params are chosen so the delta value is
+	// 1000.
+	maxFeeRate := chainfee.SatPerKWeight(9000)
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	relayFeeRate := chainfee.SatPerKWeight(250)
+	confTarget := uint32(9)
+	weight := lntypes.WeightUnit(1000)
+
+	estimator.On("RelayFeePerKW").Return(relayFeeRate)
+
+	f, err := NewLinearFeeFunction(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+	)
+	rt.NoError(err)
+
+	// The prev tx paid 2000 sats, a naive bump to the next position gives
+	// a fee rate of 2000 sat/kw, which pays the same absolute fee. We
+	// expect the fee rate to be bumped further to pay for the relay fee
+	// of the replacement.
+	prevFee := btcutil.Amount(2000)
+	increased, err := f.IncreaseFeeRate(confTarget-1, prevFee, weight)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(uint32(1), f.position)
+	rt.Equal(chainfee.SatPerKWeight(2250), f.FeeRate())
+
+	// The new fee must exceed the prev fee by at least the relay fee.
+	newFee := f.FeeRate().FeeForWeight(weight)
+	rt.GreaterOrEqual(newFee, prevFee+relayFeeRate.FeeForWeight(weight))
+
+	// When the naive bump already pays enough, it's used as is.
+	increased, err = f.Increment(0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(chainfee.SatPerKWeight(3000), f.FeeRate())
+
+	// When the required fee rate exceeds the max fee rate, the fee rate is
+	// capped.
+	prevFee = btcutil.Amount(9000)
+	increased, err = f.IncreaseFeeRate(confTarget-3, prevFee, weight)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(maxFeeRate, f.FeeRate())
+}
+
+// TestLinearFeeFunctionIncrementWeight checks the absolute fee delta required
+// by BIP125 rule 4 scales with the weight of the replacement given to
+// Increment, which may differ from the weight of a large replaced tx.
+func TestLinearFeeFunctionIncrementWeight(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock fee estimator.
+	estimator := &chainfee.MockEstimator{}
+	relayFeeRate := chainfee.SatPerKWeight(250)
+	estimator.On("RelayFeePerKW").Return(relayFeeRate)
+
+	// The replaced tx is a large batch, which paid a fee above what the
+	// naive bump to the next position gives.
+	prevFee := btcutil.Amount(1_400_000)
+	prevWeight := lntypes.WeightUnit(100_000)
+
+	increment := func(weight lntypes.WeightUnit) chainfee.SatPerKWeight {
+		f, err := NewLinearFeeFunction(
+			100_000, 9, estimator, fn.Some(
+				chainfee.SatPerKWeight(1000),
+			),
+		)
+		require.NoError(t, err)
+
+		// Remember the replaced tx without moving the position.
+		increased, err := f.IncreaseFeeRate(9, prevFee, prevWeight)
+		require.NoError(t, err)
+		require.False(t, increased)
+
+		increased, err = f.Increment(weight)
+		require.NoError(t, err)
+		require.True(t, increased)
+
+		return f.FeeRate()
+	}
+
+	testCases := []struct {
+		name   string
+		weight lntypes.WeightUnit
+	}{
+		{
+			name:   "same weight",
+			weight: prevWeight,
+		},
+		{
+			name:   "batch shrunk",
+			weight: 50_000,
+		},
+		{
+			name:   "batch shrunk further",
+			weight: 20_000,
+		},
+	}
+
+	for _, tc := range testCases {
+		feeRate := increment(tc.weight)
+
+		// The absolute fee delta must cover the relay fee of the
+		// replacement, which scales with its weight. Rounding up the
+		// fee rate adds at most one sat per kw.
+		delta := feeRate.FeeForWeight(tc.weight) - prevFee
+		minDelta := relayFeeRate.FeeForWeight(tc.weight)
+		require.GreaterOrEqual(t, delta, minDelta, tc.name)
+		require.LessOrEqual(t, delta,
+			minDelta+btcutil.Amount(tc.weight/1000), tc.name)
+	}
+
+	// Without the weight of the replacement, the weight of the replaced
+	// tx is used, which under-bumps a replacement with fewer inputs.
+	feeRate := increment(0)
+	require.Equal(t, increment(prevWeight), feeRate)
+	require.Less(t, feeRate.FeeForWeight(20_000), prevFee)
+}
+
+// TestLinearFeeFunctionSchedule checks the projected fee schedule is returned
+// without changing the state of the fee function.
+func TestLinearFeeFunctionSchedule(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a fee function that goes from 1000 to 10000 in 6 blocks.
+	estimator := &chainfee.MockEstimator{}
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	maxFeeRate := chainfee.SatPerKWeight(10000)
+	confTarget := uint32(6)
+
+	f, err := NewLinearFeeFunction(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+	)
+	rt.NoError(err)
+
+	// The schedule should give one fee rate per block till the deadline,
+	// starting from the starting fee rate and ending at the max fee rate.
+	schedule := f.Schedule()
+	rt.Len(schedule, int(confTarget))
+	rt.Equal(startFeeRate, schedule[0])
+	rt.Equal(maxFeeRate, schedule[len(schedule)-1])
+
+	// The state of the fee function should not be changed.
+	rt.Equal(startFeeRate, f.FeeRate())
+	rt.Zero(f.position)
+
+	// The schedule should match the fee rates given by the increments.
+	for _, expected := range schedule[1:] {
+		increased, err := f.Increment(0)
+		rt.NoError(err)
+		rt.True(increased)
+		rt.Equal(expected, f.FeeRate())
+	}
+
+	// Once at the end, the schedule should only contain the max fee rate.
+	rt.Equal([]chainfee.SatPerKWeight{maxFeeRate}, f.Schedule())
+}
+
+// TestLinearFeeFunctionMaxDelta checks no single increase of the fee rate
+// exceeds the max fee rate delta per block.
+func TestLinearFeeFunctionMaxDelta(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a fee function that goes from 1000 to 10000 in 6 blocks,
+	// which increases 1800 per block, and cap the delta at 1500.
+	estimator := &chainfee.MockEstimator{}
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	maxFeeRate := chainfee.SatPerKWeight(10000)
+	maxDelta := chainfee.SatPerKWeight(1500)
+
+	newFunc := func() *LinearFeeFunction {
+		f, err := NewLinearFeeFunctionFromParams(FeeFunctionParams{
+			StartingFeeRate: fn.Some(startFeeRate),
+			MaxFeeRate:      maxFeeRate,
+			ConfTarget:      6,
+			Estimator:       estimator,
+			MaxFeeRateDelta: maxDelta,
+		})
+		rt.NoError(err)
+
+		return f.(*LinearFeeFunction)
+	}
+
+	// The schedule should be capped by the delta.
+	f := newFunc()
+	schedule := f.Schedule()
+	for i := 1; i < len(schedule); i++ {
+		rt.LessOrEqual(schedule[i]-schedule[i-1], maxDelta)
+	}
+
+	// Each increment should not exceed the delta, and follow the
+	// schedule.
+	for _, expected := range schedule[1:] {
+		oldFeeRate := f.FeeRate()
+
+		increased, err := f.Increment(0)
+		rt.NoError(err)
+		rt.True(increased)
+		rt.Equal(expected, f.FeeRate())
+		rt.Equal(maxDelta, f.FeeRate()-oldFeeRate)
+	}
+
+	// The cap prevents the fee rate from reaching the max by the deadline.
+	rt.Equal(startFeeRate+maxDelta*5, f.FeeRate())
+	rt.Less(f.FeeRate(), maxFeeRate)
+
+	_, err := f.Increment(0)
+	rt.ErrorIs(err, ErrMaxPosition)
+
+	// When skipping blocks, the delta is allowed for each of them.
+	f = newFunc()
+	increased, err := f.IncreaseFeeRate(3, 0, 0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(startFeeRate+maxDelta*3, f.FeeRate())
+}
+
+// TestLinearFeeFunctionMaxDeltaWarning checks a warning is logged when the max
+// fee rate delta per block prevents the fee rate from reaching the max fee
+// rate by the deadline.
+//
+// NOTE: this test must not run in parallel as it replaces the package logger.
+func TestLinearFeeFunctionMaxDeltaWarning(t *testing.T) {
+	// Capture the log lines using a buffer.
+	var buf bytes.Buffer
+	logger := btclog.NewSLogger(btclog.NewDefaultHandler(&buf))
+
+	oldLogger := log
+	UseLogger(logger)
+	t.Cleanup(func() {
+		UseLogger(oldLogger)
+	})
+
+	params := FeeFunctionParams{
+		StartingFeeRate: fn.Some(chainfee.SatPerKWeight(1000)),
+		MaxFeeRate:      10000,
+		ConfTarget:      6,
+		Estimator:       &chainfee.MockEstimator{},
+	}
+
+	// A delta that can reach the max fee rate by the deadline gives no
+	// warning.
+	params.MaxFeeRateDelta = 1800
+	_, err := NewLinearFeeFunctionFromParams(params)
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "Max fee rate delta")
+
+	// A smaller delta gives a warning.
+	params.MaxFeeRateDelta = 1500
+	_, err = NewLinearFeeFunctionFromParams(params)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "Max fee rate delta")
+}
+
+// TestLinearFeeFunctionAllocationCurve checks the fee rate range is allocated
+// following the given curve, and invalid curves are rejected.
+func TestLinearFeeFunctionAllocationCurve(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a fee function that goes from 1000 to 10000 in 6 blocks
+	// using a back-loaded curve.
+	estimator := &chainfee.MockEstimator{}
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	maxFeeRate := chainfee.SatPerKWeight(10000)
+	confTarget := uint32(6)
+
+	backLoaded := func(progress float64) float64 {
+		return progress * progress
+	}
+
+	f, err := NewLinearFeeFunctionWithCurve(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+		backLoaded,
+	)
+	rt.NoError(err)
+
+	// The schedule should still start at the starting fee rate and end at
+	// the max fee rate.
+	schedule := f.Schedule()
+	rt.Len(schedule, int(confTarget))
+	rt.Equal(startFeeRate, schedule[0])
+	rt.Equal(maxFeeRate, schedule[len(schedule)-1])
+
+	// Each round should jump more than the previous one.
+	prevJump := chainfee.SatPerKWeight(0)
+	for i := 1; i < len(schedule); i++ {
+		jump := schedule[i] - schedule[i-1]
+		rt.Greater(jump, prevJump, "round %d", i)
+
+		prevJump = jump
+	}
+
+	// The increments should follow the schedule.
+	for _, expected := range schedule[1:] {
+		_, err := f.Increment(0)
+		rt.NoError(err)
+		rt.Equal(expected, f.FeeRate())
+	}
+
+	// An identity curve gives the same fee rates as the linear function.
+	linear, err := NewLinearFeeFunction(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+	)
+	rt.NoError(err)
+
+	identity, err := NewLinearFeeFunctionWithCurve(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+		func(progress float64) float64 {
+			return progress
+		},
+	)
+	rt.NoError(err)
+	rt.Equal(linear.Schedule(), identity.Schedule())
+
+	// A curve out of range should be rejected.
+	_, err = NewLinearFeeFunctionWithCurve(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+		func(progress float64) float64 {
+			return progress * 2
+		},
+	)
+	rt.ErrorContains(err, "must be in range")
+
+	// A decreasing curve should be rejected.
+	_, err = NewLinearFeeFunctionWithCurve(
+		maxFeeRate, confTarget, estimator, fn.Some(startFeeRate),
+		func(progress float64) float64 {
+			if progress > 0.5 {
+				return 0.2
+			}
+
+			return progress
+		},
+	)
+	rt.ErrorContains(err, "decreased")
+}
+
+// TestMempoolPercentileFeeFunctionSchedule checks the projected fee schedule
+// of the mempool percentile fee function.
+func TestMempoolPercentileFeeFunctionSchedule(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// Create a fee function using a starting fee rate so the source is
+	// not queried.
+	source := &MockMempoolFeeSource{}
+	defer source.AssertExpectations(t)
+
+	startFeeRate := chainfee.SatPerKWeight(1000)
+	maxFeeRate := chainfee.SatPerKWeight(5000)
+	relayFeeRate := chainfee.SatPerKWeight(250)
+	confTarget := uint32(4)
+
+	f, err := NewMempoolPercentileFeeFunction(
+		source, 0.5, maxFeeRate, relayFeeRate, confTarget,
+		fn.Some(startFeeRate),
+	)
+	rt.NoError(err)
+
+	// The schedule should start from the current fee rate, increase by at
+	// least the relay fee rate per block, and end at the max fee rate.
+	rt.Equal([]chainfee.SatPerKWeight{1000, 1250, 1500, 5000},
+		f.Schedule())
+
+	// The state of the fee function should not be changed.
+	rt.Equal(startFeeRate, f.FeeRate())
+}
+
+// TestConstantFeeFunction checks that the constant fee function never changes
+// its fee rate.
+func TestConstantFeeFunction(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// A non-positive fee rate should be rejected.
+	_, err := NewConstantFeeFunction(0, 6)
+	rt.Error(err)
+
+	feeRate := chainfee.SatPerKWeight(1000)
+	f, err := NewConstantFeeFunction(feeRate, 6)
+	rt.NoError(err)
+	rt.Equal(feeRate, f.FeeRate())
+
+	// Increment should never increase the fee rate.
+	increased, err := f.Increment(0)
+	rt.NoError(err)
+	rt.False(increased)
+	rt.Equal(feeRate, f.FeeRate())
+
+	// Neither should IncreaseFeeRate, even when the deadline is reached.
+	increased, err = f.IncreaseFeeRate(0, 0, 0)
+	rt.NoError(err)
+	rt.False(increased)
+	rt.Equal(feeRate, f.FeeRate())
+
+	// A higher floor should not change the fee rate.
+	rt.False(f.RebaseFloor(feeRate * 2))
+	rt.Equal(feeRate, f.FeeRate())
+
+	// The schedule should contain the fixed fee rate only.
+	f.confTarget = 3
+	rt.Equal([]chainfee.SatPerKWeight{feeRate, feeRate, feeRate},
+		f.Schedule())
+}
+
+// TestJitterFeeFunction checks the jittered fee rates stay within the jitter
+// bounds, never exceed the max fee rate, and never decrease.
+func TestJitterFeeFunction(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	const (
+		percent    = 20
+		seed       = 1
+		confTarget = 10
+	)
+
+	start := chainfee.SatPerKWeight(1000)
+	end := chainfee.SatPerKWeight(10_000)
+	relayFeeRate := chainfee.FeePerKwFloor
+	weight := lntypes.WeightUnit(1000)
+
+	// newFunc creates a new jittered linear fee function.
+	newFunc := func() (*LinearFeeFunction, *jitterFeeFunction) {
+		l, err := NewLinearFeeFunction(
+			end, confTarget, nil, fn.Some(start),
+		)
+		rt.NoError(err)
+
+		return l, newJitterFeeFunction(l, percent, seed, relayFeeRate)
+	}
+
+	l, f := newFunc()
+
+	// inBounds checks the jittered fee rate is within the jitter bounds
+	// of the unjittered one, unless it's clamped by the previous fee rate
+	// or the max fee rate.
+	inBounds := func(prev chainfee.SatPerKWeight) {
+		base := float64(l.FeeRate())
+		feeRate := f.FeeRate()
+
+		rt.LessOrEqual(feeRate, end)
+		rt.GreaterOrEqual(feeRate, prev)
+
+		if feeRate == prev || feeRate == end {
+			return
+		}
+		rt.GreaterOrEqual(float64(feeRate), base*(1-percent/100.0)-1)
+		rt.LessOrEqual(float64(feeRate), base*(1+percent/100.0)+1)
+	}
+	inBounds(0)
+
+	// Increase the fee rate till the deadline, and record the jittered
+	// fee rates.
+	rates := []chainfee.SatPerKWeight{f.FeeRate()}
+	for target := uint32(confTarget - 1); target > 0; target-- {
+		prev := f.FeeRate()
+		prevFee := prev.FeeForWeight(weight)
+
+		_, err := f.IncreaseFeeRate(target, prevFee, weight)
+		rt.NoError(err)
+		inBounds(prev)
+
+		// The replacement must pay enough fees.
+		if f.FeeRate() < end {
+			rt.GreaterOrEqual(f.FeeRate(), minReplacementFeeRate(
+				prevFee, weight, relayFeeRate,
+			))
+		}
+
+		rates = append(rates, f.FeeRate())
+	}
+
+	// The last fee rate should be the max.
+	rt.Equal(end, rates[len(rates)-1])
+
+	// The jitter should be deterministic for the same seed.
+	_, f = newFunc()
+	rt.Equal(rates[0], f.FeeRate())
+	for i, target := 1, uint32(confTarget-1); target > 0; target-- {
+		prevFee := rates[i-1].FeeForWeight(weight)
+		_, err := f.IncreaseFeeRate(target, prevFee, weight)
+		rt.NoError(err)
+		rt.Equal(rates[i], f.FeeRate())
+		i++
+	}
+}
+
+// TestDualFeeFunction checks the dual fee function uses the higher fee rate of
+// its two schedules and reports which one bound it.
+func TestDualFeeFunction(t *testing.T) {
+	t.Parallel()
+
+	rt := require.New(t)
+
+	// The primary schedule ramps from 1000 to 10,000 over 10 blocks,
+	// while the alternative one starts at 500 and reaches 10,000 in 4
+	// blocks.
+	maxFeeRate := chainfee.SatPerKWeight(10_000)
+	primary, err := NewLinearFeeFunction(
+		maxFeeRate, 10, nil, fn.Some(chainfee.SatPerKWeight(1000)),
+	)
+	rt.NoError(err)
+
+	alt, err := NewLinearFeeFunction(
+		maxFeeRate, 4, nil, fn.Some(chainfee.SatPerKWeight(500)),
+	)
+	rt.NoError(err)
+
+	f := newDualFeeFunction(primary, alt, 10, 4)
+
+	// The primary schedule binds at first.
+	rt.EqualValues(1000, f.FeeRate())
+	rt.Equal(FeeSchedulePrimary, f.Bound())
+
+	// Two blocks later, the alternative schedule is at position 2 of 3,
+	// which is above the primary one.
+	increased, err := f.IncreaseFeeRate(8, 0, 0)
+	rt.NoError(err)
+	rt.True(increased)
+	rt.Equal(alt.FeeRate(), f.FeeRate())
+	rt.Greater(alt.FeeRate(), primary.FeeRate())
+	rt.Equal(FeeScheduleAlt, f.Bound())
+
+	// Once the alternative schedule passes its deadline, it stays at the
+	// max fee rate while the primary one can still be increased.
+	_, err = f.IncreaseFeeRate(6, 0, 0)
+	rt.NoError(err)
+	rt.Equal(maxFeeRate, f.FeeRate())
+
+	_, err = f.IncreaseFeeRate(5, 0, 0)
+	rt.NoError(err)
+	rt.Equal(FeeScheduleAlt, f.Bound())
+
+	// The schedule uses the higher fee rate at each block, and is as long
+	// as the longer schedule.
+	schedule := f.Schedule()
+	rt.Len(schedule, len(primary.Schedule()))
+	for _, feeRate := range schedule {
+		rt.Equal(maxFeeRate, feeRate)
+	}
+
+	// An error is only returned once both schedules reach their max.
+	_, err = f.IncreaseFeeRate(0, 0, 0)
+	rt.NoError(err)
+	_, err = f.Increment(0)
+	rt.ErrorIs(err, ErrMaxPosition)
+}
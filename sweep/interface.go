@@ -8,14 +8,27 @@ import (
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 )
 
+// Broadcaster publishes txns to a Bitcoin backend.
+type Broadcaster interface {
+	// PublishTransaction broadcasts the passed transaction to the Bitcoin
+	// network.
+	PublishTransaction(tx *wire.MsgTx, label string) error
+}
+
 // Wallet contains all wallet related functionality required by sweeper.
 type Wallet interface {
 	// PublishTransaction performs cursory validation (dust checks, etc) and
 	// broadcasts the passed transaction to the Bitcoin network.
 	PublishTransaction(tx *wire.MsgTx, label string) error
 
+	// SubmitPackage submits the given txns to the mempool as a package,
+	// with the child being the last tx. ErrPackageRelayUnsupported is
+	// returned if the backend doesn't support package relay.
+	SubmitPackage(txns []*wire.MsgTx) error
+
 	// ListUnspentWitnessFromDefaultAccount returns all unspent outputs
 	// which are version 0 witness programs from the default wallet account.
 	// The 'minConfs' and 'maxConfs' parameters indicate the minimum
@@ -62,6 +75,33 @@ type Wallet interface {
 	BackEnd() string
 }
 
+// UtxoChecker is used to check whether an output is still unspent.
+type UtxoChecker interface {
+	// IsUnspent returns true if the given outpoint is still a member of
+	// the utxo set. The height hint should be the height at which the
+	// output was created, and the pkScript is the script of the output.
+	IsUnspent(op wire.OutPoint, pkScript []byte,
+		heightHint uint32) (bool, error)
+}
+
+// ConfirmedFeeRateSource provides the fee rates paid by the txns confirmed in
+// the recent blocks.
+type ConfirmedFeeRateSource interface {
+	// RecentConfirmedFeeRate returns the fee rate that was sufficient to
+	// get a tx confirmed in the recent blocks, such as the highest of
+	// their median fee rates.
+	RecentConfirmedFeeRate() (chainfee.SatPerKWeight, error)
+}
+
+// SequenceInput is an optional interface implemented by the inputs that
+// require a specific sequence, such as a time based relative locktime, which
+// cannot be expressed by BlocksToMaturity.
+type SequenceInput interface {
+	// RequiredSequence returns the sequence the input must use, and
+	// whether it's required.
+	RequiredSequence() (uint32, bool)
+}
+
 // SweepOutput is an output used to sweep funds from a channel output.
 type SweepOutput struct { //nolint:revive
 	wire.TxOut
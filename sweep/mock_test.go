@@ -7,6 +7,7 @@ import (
 	"github.com/lightningnetwork/lnd/fn/v2"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/stretchr/testify/mock"
@@ -161,6 +162,13 @@ func (m *MockWallet) WithCoinSelectLock(f func() error) error {
 	return f()
 }
 
+// SubmitPackage submits the given txns to the mempool as a package.
+func (m *MockWallet) SubmitPackage(txns []*wire.MsgTx) error {
+	args := m.Called(txns)
+
+	return args.Error(0)
+}
+
 // RemoveDescendants removes any wallet transactions that spends
 // outputs created by the specified transaction.
 func (m *MockWallet) RemoveDescendants(tx *wire.MsgTx) error {
@@ -310,15 +318,112 @@ func (m *MockFeeFunction) FeeRate() chainfee.SatPerKWeight {
 }
 
 // Increment adds one delta to the current fee rate.
-func (m *MockFeeFunction) Increment() (bool, error) {
-	args := m.Called()
+func (m *MockFeeFunction) Increment(txWeight lntypes.WeightUnit) (bool,
+	error) {
+
+	args := m.Called(txWeight)
 
 	return args.Bool(0), args.Error(1)
 }
 
 // IncreaseFeeRate increases the fee rate by one step.
-func (m *MockFeeFunction) IncreaseFeeRate(confTarget uint32) (bool, error) {
-	args := m.Called(confTarget)
+func (m *MockFeeFunction) IncreaseFeeRate(confTarget uint32,
+	prevFee btcutil.Amount, txWeight lntypes.WeightUnit) (bool, error) {
+
+	args := m.Called(confTarget, prevFee, txWeight)
+
+	return args.Bool(0), args.Error(1)
+}
+
+// RebaseFloor re-bases the fee function using the given floor.
+func (m *MockFeeFunction) RebaseFloor(floor chainfee.SatPerKWeight) bool {
+	args := m.Called(floor)
+
+	return args.Bool(0)
+}
+
+// ResetTrajectory re-bases the fee function using the given estimate.
+func (m *MockFeeFunction) ResetTrajectory(newEstimate chainfee.SatPerKWeight) {
+	m.Called(newEstimate)
+}
+
+// Schedule returns the projected fee rates till the deadline.
+func (m *MockFeeFunction) Schedule() []chainfee.SatPerKWeight {
+	args := m.Called()
+
+	return args.Get(0).([]chainfee.SatPerKWeight)
+}
+
+// MockFeeDistribution is a mock implementation of the FeeDistribution
+// interface.
+type MockFeeDistribution struct {
+	mock.Mock
+}
+
+// Compile-time constraint to ensure MockFeeDistribution implements
+// FeeDistribution.
+var _ FeeDistribution = (*MockFeeDistribution)(nil)
+
+// FeeRateAtPercentile returns the fee rate at the given percentile.
+func (m *MockFeeDistribution) FeeRateAtPercentile(blocks int32,
+	percentile float64) (chainfee.SatPerKWeight, error) {
+
+	args := m.Called(blocks, percentile)
+
+	return args.Get(0).(chainfee.SatPerKWeight), args.Error(1)
+}
+
+// MockMempoolFeeSource is a mock implementation of the MempoolFeeSource
+// interface.
+type MockMempoolFeeSource struct {
+	mock.Mock
+}
+
+// Compile-time constraint to ensure MockMempoolFeeSource implements
+// MempoolFeeSource.
+var _ MempoolFeeSource = (*MockMempoolFeeSource)(nil)
+
+// FeeRateAtPercentile returns the fee rate at the given percentile.
+func (m *MockMempoolFeeSource) FeeRateAtPercentile(
+	percentile float64) (chainfee.SatPerKWeight, error) {
+
+	args := m.Called(percentile)
+
+	return args.Get(0).(chainfee.SatPerKWeight), args.Error(1)
+}
+
+// MockConfirmedFeeRateSource is a mock implementation of the
+// ConfirmedFeeRateSource interface.
+type MockConfirmedFeeRateSource struct {
+	mock.Mock
+}
+
+// Compile-time constraint to ensure MockConfirmedFeeRateSource implements
+// ConfirmedFeeRateSource.
+var _ ConfirmedFeeRateSource = (*MockConfirmedFeeRateSource)(nil)
+
+// RecentConfirmedFeeRate returns the recently confirmed fee rate.
+func (m *MockConfirmedFeeRateSource) RecentConfirmedFeeRate() (
+	chainfee.SatPerKWeight, error) {
+
+	args := m.Called()
+
+	return args.Get(0).(chainfee.SatPerKWeight), args.Error(1)
+}
+
+// MockUtxoChecker is a mock implementation of the UtxoChecker interface.
+type MockUtxoChecker struct {
+	mock.Mock
+}
+
+// Compile-time constraint to ensure MockUtxoChecker implements UtxoChecker.
+var _ UtxoChecker = (*MockUtxoChecker)(nil)
+
+// IsUnspent returns whether the given outpoint is unspent.
+func (m *MockUtxoChecker) IsUnspent(op wire.OutPoint, pkScript []byte,
+	heightHint uint32) (bool, error) {
+
+	args := m.Called(op, pkScript, heightHint)
 
 	return args.Bool(0), args.Error(1)
 }
@@ -1603,7 +1603,9 @@ type bumpResp struct {
 }
 
 // monitorFeeBumpResult subscribes to the passed result chan to listen for
-// future updates about the sweeping tx.
+// future updates about the sweeping tx. Once the tx is confirmed, it keeps
+// listening until the tx is safe from reorgs, so a TxReorged event can be
+// handled.
 //
 // NOTE: must run as a goroutine.
 func (s *UtxoSweeper) monitorFeeBumpResult(set InputSet,
@@ -1611,6 +1613,10 @@ func (s *UtxoSweeper) monitorFeeBumpResult(set InputSet,
 
 	defer s.wg.Done()
 
+	// reorgSafe is closed once the confirmed sweeping tx is no longer
+	// watched for reorgs by the bumper.
+	var reorgSafe <-chan struct{}
+
 	for {
 		select {
 		case r := <-resultChan:
@@ -1635,13 +1641,41 @@ func (s *UtxoSweeper) monitorFeeBumpResult(set InputSet,
 				return
 			}
 
+			// The sweeping tx has been reorged out of the chain,
+			// we keep listening for its updates as the bumper will
+			// resume monitoring it.
+			if r.Event == TxReorged {
+				reorgSafe = nil
+
+				continue
+			}
+
 			// The sweeping tx has been confirmed, we can exit the
-			// monitor now.
+			// monitor now unless the bumper is still watching the
+			// tx for reorgs.
 			//
 			// TODO(yy): can instead remove the spend subscription
 			// in sweeper and rely solely on this event to mark
 			// inputs as Swept?
-			if r.Event == TxConfirmed || r.Event == TxFailed {
+			if r.Event == TxConfirmed && r.reorgSafe != nil {
+				log.Debugf("Sweep tx %v confirmed, waiting "+
+					"for it to be safe from reorgs",
+					r.Tx.TxHash())
+
+				// Cancel the rebroadcasting of the confirmed
+				// tx.
+				s.cfg.Wallet.CancelRebroadcast(r.Tx.TxHash())
+				reorgSafe = r.reorgSafe
+
+				continue
+			}
+
+			// Exit once the tx is confirmed, failed, or can no
+			// longer be confirmed as one of its inputs is spent.
+			if r.Event == TxConfirmed || r.Event == TxFailed ||
+				r.Event == TxInputSpent ||
+				r.Event == TxLostRBFRace {
+
 				// Exit if the tx is failed to be created.
 				if r.Tx == nil {
 					log.Debugf("Received %v for nil tx, "+
@@ -1660,6 +1694,12 @@ func (s *UtxoSweeper) monitorFeeBumpResult(set InputSet,
 				return
 			}
 
+		case <-reorgSafe:
+			log.Debugf("Sweep tx is safe from reorgs, exit fee " +
+				"bump monitor")
+
+			return
+
 		case <-s.quit:
 			log.Debugf("Sweeper shutting down, exit fee " +
 				"bump handler")
@@ -1689,6 +1729,34 @@ func (s *UtxoSweeper) handleBumpEventTxFailed(resp *bumpResp) {
 	s.markInputsPublishFailed(resp.set)
 }
 
+// handleBumpEventTxInputSpent handles the case where an input of the sweeping
+// tx has been spent by another tx, so the tx can no longer be confirmed and
+// the bumper has stopped monitoring it. The inputs are marked as publish
+// failed so the unspent ones can be swept again, while the spent input will
+// be marked as swept once its spend notification is received.
+func (s *UtxoSweeper) handleBumpEventTxInputSpent(resp *bumpResp) {
+	r := resp.result
+
+	log.Warnf("Sweep tx=%v has an input spent by another tx: %v",
+		r.Tx.TxHash(), r.Err)
+
+	s.markInputsPublishFailed(resp.set)
+}
+
+// handleBumpEventTxLostRBFRace handles the case where an input of the sweeping
+// tx has been spent by a conflicting mempool tx that pays more than the max
+// fee rate allowed, so the bumper has stopped bumping the tx. The inputs are
+// marked as publish failed so they can be swept again should the conflicting
+// tx be evicted, while the spent input will be marked as swept once the
+// conflicting tx confirms.
+func (s *UtxoSweeper) handleBumpEventTxLostRBFRace(resp *bumpResp) {
+	r := resp.result
+
+	log.Warnf("Sweep tx=%v lost the RBF race: %v", r.Tx.TxHash(), r.Err)
+
+	s.markInputsPublishFailed(resp.set)
+}
+
 // handleBumpEventTxReplaced handles the case where the sweeping tx has been
 // replaced by a new one.
 func (s *UtxoSweeper) handleBumpEventTxReplaced(resp *bumpResp) error {
@@ -1764,6 +1832,53 @@ func (s *UtxoSweeper) handleBumpEventTxPublished(resp *bumpResp) error {
 	return nil
 }
 
+// handleBumpEventTxReorged handles the case where the confirmed sweeping tx
+// has been reorged out of the chain. The inputs are moved back to the
+// Published state as the bumper will keep monitoring the tx, and their spend
+// notifications are registered again so they are marked as swept once the tx
+// is confirmed again.
+func (s *UtxoSweeper) handleBumpEventTxReorged(resp *bumpResp) {
+	log.Warnf("Sweep tx %v was reorged out of the chain",
+		resp.result.Tx.TxHash())
+
+	for _, inp := range resp.set.Inputs() {
+		op := inp.OutPoint()
+		pi, ok := s.inputs[op]
+		if !ok {
+			// The input may have been removed from the sweeper
+			// once it's swept, in which case we can no longer
+			// track it.
+			log.Warnf("Skipped marking input as published: %v "+
+				"not found in pending inputs", op)
+
+			continue
+		}
+
+		// Only the inputs swept by the reorged tx need to be moved
+		// back.
+		if pi.state != Swept {
+			log.Debugf("Expect input %v to have %v, instead it "+
+				"has %v", op, Swept, pi.state)
+
+			continue
+		}
+
+		// Start watching for the spend of this input again.
+		cancel, err := s.monitorSpend(
+			op, pi.SignDesc().Output.PkScript, pi.HeightHint(),
+		)
+		if err != nil {
+			log.Errorf("Unable to watch spend of reorged input "+
+				"%v: %v", op, err)
+
+			continue
+		}
+
+		pi.ntfnRegCancel = cancel
+		pi.state = Published
+	}
+}
+
 // handleBumpEventTxFatal handles the case where there's an unexpected error
 // when creating or publishing the sweeping tx. In this case, the tx will be
 // removed from the sweeper store and the inputs will be marked as `Failed`,
@@ -1839,6 +1954,18 @@ func (s *UtxoSweeper) handleBumpEvent(r *bumpResp) error {
 		s.handleBumpEventTxFailed(r)
 		return nil
 
+	// An input of the tx has been spent by another tx, we update the
+	// inputs' state so the unspent ones can be retried.
+	case TxInputSpent:
+		s.handleBumpEventTxInputSpent(r)
+		return nil
+
+	// The tx has been outbid by a conflicting tx, we update the inputs'
+	// state so they can be retried.
+	case TxLostRBFRace:
+		s.handleBumpEventTxLostRBFRace(r)
+		return nil
+
 	// The tx has been replaced, we will remove the old tx and replace it
 	// with the new one.
 	case TxReplaced:
@@ -1848,6 +1975,12 @@ func (s *UtxoSweeper) handleBumpEvent(r *bumpResp) error {
 	// the sweeper db and mark the inputs as failed.
 	case TxFatal:
 		return s.handleBumpEventTxFatal(r)
+
+	// The confirmed tx has been reorged out of the chain, we move the
+	// inputs back to the Published state.
+	case TxReorged:
+		s.handleBumpEventTxReorged(r)
+		return nil
 	}
 
 	return nil
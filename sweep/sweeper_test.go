@@ -756,6 +756,113 @@ func TestHandleBumpEventTxFailed(t *testing.T) {
 	require.NotContains(t, s.inputs, opNotExist)
 }
 
+// TestHandleBumpEventTxInputSpent checks that the sweeper marks the inputs as
+// publish failed when an input of the sweeping tx is spent by another tx.
+func TestHandleBumpEventTxInputSpent(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock input set.
+	set := &MockInputSet{}
+	defer set.AssertExpectations(t)
+
+	// Create a test sweeper.
+	s := New(&UtxoSweeperConfig{})
+
+	// Create two mock inputs, the first one is spent by another tx.
+	var (
+		input1 = createMockInput(t, s, Published)
+		input2 = createMockInput(t, s, Published)
+	)
+
+	op1 := input1.OutPoint()
+	op2 := input2.OutPoint()
+
+	// Construct the initial state for the sweeper.
+	set.On("Inputs").Return([]input.Input{input1, input2})
+
+	// Create a testing tx that spends the inputs.
+	tx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{PreviousOutPoint: op1},
+			{PreviousOutPoint: op2},
+		},
+	}
+
+	// Create a testing bump response.
+	resp := &bumpResp{
+		result: &BumpResult{
+			Tx:         tx,
+			Event:      TxInputSpent,
+			Err:        ErrInputSpent,
+			SpentInput: fn.Some(op1),
+		},
+		set: set,
+	}
+
+	// Call the method under test.
+	err := s.handleBumpEvent(resp)
+	require.NoError(t, err)
+
+	// Assert the states of the inputs are updated so the unspent one can
+	// be swept again.
+	require.Equal(t, PublishFailed, s.inputs[op1].state)
+	require.Equal(t, PublishFailed, s.inputs[op2].state)
+}
+
+// TestHandleBumpEventTxLostRBFRace checks that the sweeper marks the inputs as
+// publish failed when the sweeping tx lost the RBF race to a conflicting tx.
+func TestHandleBumpEventTxLostRBFRace(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock input set.
+	set := &MockInputSet{}
+	defer set.AssertExpectations(t)
+
+	// Create a test sweeper.
+	s := New(&UtxoSweeperConfig{})
+
+	// Create two mock inputs, the first one is spent by the conflicting
+	// tx.
+	var (
+		input1 = createMockInput(t, s, Published)
+		input2 = createMockInput(t, s, Published)
+	)
+
+	op1 := input1.OutPoint()
+	op2 := input2.OutPoint()
+
+	// Construct the initial state for the sweeper.
+	set.On("Inputs").Return([]input.Input{input1, input2})
+
+	// Create a testing tx that spends the inputs.
+	tx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{PreviousOutPoint: op1},
+			{PreviousOutPoint: op2},
+		},
+	}
+
+	// Create a testing bump response.
+	resp := &bumpResp{
+		result: &BumpResult{
+			Tx:         tx,
+			Event:      TxLostRBFRace,
+			Err:        ErrLostRBFRace,
+			SpentInput: fn.Some(op1),
+		},
+		set: set,
+	}
+
+	// Call the method under test.
+	err := s.handleBumpEvent(resp)
+	require.NoError(t, err)
+
+	// Assert the states of the inputs are updated so they can be swept
+	// again.
+	require.Equal(t, PublishFailed, s.inputs[op1].state)
+	require.Equal(t, PublishFailed, s.inputs[op2].state)
+}
+
 // TestHandleBumpEventTxReplaced checks that the sweeper correctly handles the
 // case where the bump event tx is replaced.
 func TestHandleBumpEventTxReplaced(t *testing.T) {
@@ -967,10 +1074,11 @@ func TestMonitorFeeBumpResult(t *testing.T) {
 				// Create a result chan.
 				resultChan := make(chan *BumpResult, 1)
 				resultChan <- &BumpResult{
-					Tx:      tx,
-					Event:   TxConfirmed,
-					Fee:     10000,
-					FeeRate: 100,
+					Tx:         tx,
+					Event:      TxConfirmed,
+					Fee:        10000,
+					FeeRate:    100,
+					ConfHeight: 100,
 				}
 
 				// We expect to cancel rebroadcasting the tx
@@ -1006,6 +1114,56 @@ func TestMonitorFeeBumpResult(t *testing.T) {
 			},
 			shouldExit: true,
 		},
+		{
+			// When a tx input spent event is received, we expect
+			// to exit the monitor loop.
+			name: "tx input spent",
+			// We send a result with TxInputSpent event to the
+			// result channel.
+			setupResultChan: func() <-chan *BumpResult {
+				// Create a result chan.
+				resultChan := make(chan *BumpResult, 1)
+				resultChan <- &BumpResult{
+					Tx:         tx,
+					Event:      TxInputSpent,
+					Err:        ErrInputSpent,
+					SpentInput: fn.Some(op),
+				}
+
+				// We expect to cancel rebroadcasting the tx
+				// once its input is spent.
+				wallet.On("CancelRebroadcast",
+					tx.TxHash()).Once()
+
+				return resultChan
+			},
+			shouldExit: true,
+		},
+		{
+			// When a tx lost RBF race event is received, we expect
+			// to exit the monitor loop.
+			name: "tx lost rbf race",
+			// We send a result with TxLostRBFRace event to the
+			// result channel.
+			setupResultChan: func() <-chan *BumpResult {
+				// Create a result chan.
+				resultChan := make(chan *BumpResult, 1)
+				resultChan <- &BumpResult{
+					Tx:         tx,
+					Event:      TxLostRBFRace,
+					Err:        ErrLostRBFRace,
+					SpentInput: fn.Some(op),
+				}
+
+				// We expect to cancel rebroadcasting the tx
+				// once it lost the RBF race.
+				wallet.On("CancelRebroadcast",
+					tx.TxHash()).Once()
+
+				return resultChan
+			},
+			shouldExit: true,
+		},
 		{
 			// When processing non-confirmed events, the monitor
 			// should not exit.
@@ -1024,6 +1182,64 @@ func TestMonitorFeeBumpResult(t *testing.T) {
 			},
 			shouldExit: false,
 		},
+		{
+			// When a tx confirmed event is received for a tx that's
+			// still watched for reorgs, the monitor should not
+			// exit.
+			name: "no exit before tx is reorg safe",
+			// We send a result with TxConfirmed event and an open
+			// reorgSafe chan.
+			setupResultChan: func() <-chan *BumpResult {
+				// Create a result chan.
+				resultChan := make(chan *BumpResult, 1)
+				resultChan <- &BumpResult{
+					Tx:         tx,
+					Event:      TxConfirmed,
+					Fee:        10000,
+					FeeRate:    100,
+					ConfHeight: 100,
+					reorgSafe:  make(chan struct{}),
+				}
+
+				// We expect to cancel rebroadcasting the tx
+				// once confirmed.
+				wallet.On("CancelRebroadcast",
+					tx.TxHash()).Once()
+
+				return resultChan
+			},
+			shouldExit: false,
+		},
+		{
+			// When the confirmed tx is safe from reorgs, we expect
+			// to exit the monitor loop.
+			name: "exit once tx is reorg safe",
+			// We send a result with TxConfirmed event and a closed
+			// reorgSafe chan.
+			setupResultChan: func() <-chan *BumpResult {
+				reorgSafe := make(chan struct{})
+				close(reorgSafe)
+
+				// Create a result chan.
+				resultChan := make(chan *BumpResult, 1)
+				resultChan <- &BumpResult{
+					Tx:         tx,
+					Event:      TxConfirmed,
+					Fee:        10000,
+					FeeRate:    100,
+					ConfHeight: 100,
+					reorgSafe:  reorgSafe,
+				}
+
+				// We expect to cancel rebroadcasting the tx
+				// once confirmed.
+				wallet.On("CancelRebroadcast",
+					tx.TxHash()).Once()
+
+				return resultChan
+			},
+			shouldExit: true,
+		},
 		{
 			// When the sweeper is shutting down, the monitor loop
 			// should exit.
@@ -1078,6 +1294,65 @@ func TestMonitorFeeBumpResult(t *testing.T) {
 	}
 }
 
+// TestHandleBumpEventTxReorged checks that the swept inputs are moved back to
+// the Published state and watched for spends again when the sweeping tx is
+// reorged out of the chain.
+func TestHandleBumpEventTxReorged(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock input set.
+	set := &MockInputSet{}
+	defer set.AssertExpectations(t)
+
+	// Create a mock notifier.
+	notifier := &chainntnfs.MockChainNotifier{}
+	defer notifier.AssertExpectations(t)
+
+	// Create a test sweeper.
+	s := New(&UtxoSweeperConfig{
+		Notifier: notifier,
+	})
+	defer close(s.quit)
+
+	// Create a swept input and a failed input.
+	inputSwept := createMockInput(t, s, Swept)
+	inputFailed := createMockInput(t, s, Failed)
+	set.On("Inputs").Return([]input.Input{inputSwept, inputFailed})
+
+	opSwept := inputSwept.OutPoint()
+	opFailed := inputFailed.OutPoint()
+
+	// Mock the swept input to be watched for spend again.
+	inputSwept.On("SignDesc").Return(&input.SignDescriptor{
+		Output: &wire.TxOut{},
+	}).Once()
+	inputSwept.On("HeightHint").Return(uint32(100)).Once()
+	notifier.On("RegisterSpendNtfn", &opSwept, mock.Anything,
+		uint32(100)).Return(&chainntnfs.SpendEvent{
+		Spend:  make(chan *chainntnfs.SpendDetail),
+		Cancel: func() {},
+	}, nil).Once()
+
+	// Create a testing bump response.
+	resp := &bumpResp{
+		result: &BumpResult{
+			Tx:    &wire.MsgTx{LockTime: 1},
+			Event: TxReorged,
+		},
+		set: set,
+	}
+
+	// Call the method under test.
+	err := s.handleBumpEvent(resp)
+	require.NoError(t, err)
+
+	// The swept input should be moved back to Published and watched for
+	// spend again, while the failed input stays unchanged.
+	require.Equal(t, Published, s.inputs[opSwept].state)
+	require.NotNil(t, s.inputs[opSwept].ntfnRegCancel)
+	require.Equal(t, Failed, s.inputs[opFailed].state)
+}
+
 // TestMarkInputsFailed checks that given a list of inputs with different
 // states, the method `markInputsFailed` correctly marks the inputs as failed.
 func TestMarkInputsFailed(t *testing.T) {
@@ -28,6 +28,10 @@ var (
 	// second level success/timeout txns, only the txns sharing the same
 	// nLockTime can exist in the same tx.
 	ErrLocktimeConflict = errors.New("incompatible locktime")
+
+	// ErrUnknownScriptType is returned when the size of an output cannot
+	// be derived from its pkScript.
+	ErrUnknownScriptType = errors.New("unknown script type")
 )
 
 // createSweepTx builds a signed tx spending the inputs to the given outputs,
@@ -236,41 +240,24 @@ func getWeightEstimate(inputs []input.Input, outputs []*wire.TxOut,
 	}
 
 	// If there is any leftover change after paying to the given outputs
-	// and required outputs, it will go to a single segwit p2wkh or p2tr
-	// address. This will be our change address, so ensure it contributes
-	// to our weight estimate. Note that if we have other outputs, we might
+	// and required outputs, it will go to a single change address, whose
+	// size is derived from its script type, so ensure it contributes to
+	// our weight estimate. Note that if we have other outputs, we might
 	// end up creating a sweep tx without a change output. It is okay to
 	// add the change output to the weight estimate regardless, since the
 	// estimated fee will just be subtracted from this already dust output,
 	// and trimmed.
 	for _, outputPkScript := range outputPkScripts {
-		switch {
-		case txscript.IsPayToTaproot(outputPkScript):
-			weightEstimate.addP2TROutput()
-
-		case txscript.IsPayToWitnessScriptHash(outputPkScript):
-			weightEstimate.addP2WSHOutput()
-
-		case txscript.IsPayToWitnessPubKeyHash(outputPkScript):
-			weightEstimate.addP2WKHOutput()
-
-		case txscript.IsPayToPubKeyHash(outputPkScript):
-			weightEstimate.estimator.AddP2PKHOutput()
-
-		case txscript.IsPayToScriptHash(outputPkScript):
-			weightEstimate.estimator.AddP2SHOutput()
-
-		default:
-			// Unknown script type.
-			return nil, nil, fmt.Errorf("unknown script "+
-				"type: %x", outputPkScript)
+		err := weightEstimate.addPkScriptOutput(outputPkScript)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
 	// For each output, use its witness type to determine the estimate
 	// weight of its witness, and add it to the proper set of spendable
 	// outputs.
-	var sweepInputs []input.Input
+	sweepInputs := make([]input.Input, 0, len(inputs))
 	for i := range inputs {
 		inp := inputs[i]
 
@@ -1,14 +1,50 @@
 package sweep
 
 import (
+	"fmt"
+
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnutils"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 )
 
+// witnessSize is the estimated witness size of a witness type, along with
+// whether it's a nested P2SH spend.
+type witnessSize struct {
+	size         lntypes.WeightUnit
+	isNestedP2SH bool
+}
+
+// witnessSizeCache caches the witness size upper bound of each standard
+// witness type, so repeated weight calculations, such as rebuilding a large
+// sweep in every block, reuse the estimate. The size only depends on the
+// witness type, so an entry never needs to be invalidated, and a new entry is
+// only calculated when an input of an unseen witness type is added.
+var witnessSizeCache lnutils.SyncMap[input.StandardWitnessType, witnessSize]
+
+// cachedWitnessSize returns the witness size upper bound of the given witness
+// type, which is calculated once and then cached.
+func cachedWitnessSize(wt input.StandardWitnessType) (witnessSize, error) {
+	if ws, ok := witnessSizeCache.Load(wt); ok {
+		return ws, nil
+	}
+
+	size, isNestedP2SH, err := wt.SizeUpperBound()
+	if err != nil {
+		return witnessSize{}, err
+	}
+
+	ws := witnessSize{size: size, isNestedP2SH: isNestedP2SH}
+	witnessSizeCache.Store(wt, ws)
+
+	return ws, nil
+}
+
 // weightEstimator wraps a standard weight estimator instance and adds to that
 // support for child-pays-for-parent.
 type weightEstimator struct {
@@ -20,6 +56,11 @@ type weightEstimator struct {
 
 	// maxFeeRate is the max allowed fee rate configured by the user.
 	maxFeeRate chainfee.SatPerKWeight
+
+	// sigHashWeight is the extra witness weight needed by inputs that are
+	// signed using non-default sighash flags, which is not covered by the
+	// witness size estimated using their witness types.
+	sigHashWeight lntypes.WeightUnit
 }
 
 // newWeightEstimator instantiates a new sweeper weight estimator.
@@ -38,9 +79,113 @@ func (w *weightEstimator) add(inp input.Input) error {
 	// If there is a parent tx, add the parent's fee and weight.
 	w.tryAddParent(inp)
 
+	// Account for the possibly larger signature.
+	w.sigHashWeight += sigHashWeightOverhead(inp)
+
+	// Use the witness size reported by the input if available, as the
+	// witness type may not reflect the actual size, e.g., for taproot
+	// script-path spends.
+	if e, ok := inp.(input.WitnessSizeEstimator); ok {
+		if size, ok := e.EstimatedWitnessSize(); ok {
+			// A P2SH-wrapped spend also carries the redeem script
+			// in its sigScript, which is sized as a nested P2WSH
+			// as the redeem script is unknown here.
+			if isP2SHInput(inp) {
+				w.estimator.AddNestedP2WSHInput(size)
+			} else {
+				w.estimator.AddWitnessInput(size)
+			}
+
+			return nil
+		}
+	}
+
 	wt := inp.WitnessType()
 
-	return wt.AddWeightEstimation(&w.estimator)
+	// The sigScript of a nested P2WKH spend pushes the 22-byte P2WKH
+	// redeem script, which is smaller than the P2WSH one assumed by its
+	// witness type.
+	if wt == input.NestedWitnessKeyHash {
+		w.estimator.AddNestedP2WKHInput()
+
+		return nil
+	}
+
+	// Other witness types may size their witness differently, so only the
+	// standard ones use the cache.
+	swt, ok := wt.(input.StandardWitnessType)
+	if !ok {
+		return wt.AddWeightEstimation(&w.estimator)
+	}
+
+	// This is the same as StandardWitnessType.AddWeightEstimation, but
+	// uses the cached size.
+	ws, err := cachedWitnessSize(swt)
+	if err != nil {
+		return err
+	}
+
+	if ws.isNestedP2SH {
+		w.estimator.AddNestedP2WSHInput(ws.size)
+	} else {
+		w.estimator.AddWitnessInput(ws.size)
+	}
+
+	return nil
+}
+
+// isP2SHInput returns true if the given input spends a P2SH output.
+func isP2SHInput(inp input.Input) bool {
+	signDesc := inp.SignDesc()
+	if signDesc == nil || signDesc.Output == nil {
+		return false
+	}
+
+	return txscript.IsPayToScriptHash(signDesc.Output.PkScript)
+}
+
+// sigHashWeightOverhead returns the extra witness weight needed by the input
+// when it's signed using a non-default sighash flag. Taproot signatures are 64
+// bytes when using SIGHASH_DEFAULT, and 65 bytes otherwise since the sighash
+// flag is appended, while ECDSA signatures always carry the sighash flag.
+func sigHashWeightOverhead(inp input.Input) lntypes.WeightUnit {
+	signDesc := inp.SignDesc()
+	if signDesc == nil || signDesc.Output == nil {
+		return 0
+	}
+
+	// The sizes of the standard witness types are upper bounds which
+	// already include the sighash flag, e.g., a key spend is sized using
+	// TaprootKeyPathCustomSighashWitnessSize, so there's no overhead unless
+	// the input reports its own witness size.
+	_, isStandard := inp.WitnessType().(input.StandardWitnessType)
+	if isStandard && !hasWitnessSize(inp) {
+		return 0
+	}
+
+	if !txscript.IsPayToTaproot(signDesc.Output.PkScript) {
+		return 0
+	}
+
+	if signDesc.HashType == txscript.SigHashDefault {
+		return 0
+	}
+
+	// One extra witness byte for the appended sighash flag.
+	return 1
+}
+
+// hasWitnessSize returns true if the given input reports its own estimated
+// witness size.
+func hasWitnessSize(inp input.Input) bool {
+	e, ok := inp.(input.WitnessSizeEstimator)
+	if !ok {
+		return false
+	}
+
+	_, ok = e.EstimatedWitnessSize()
+
+	return ok
 }
 
 // tryAddParent examines the input and updates parent tx totals if required for
@@ -54,10 +199,17 @@ func (w *weightEstimator) tryAddParent(inp input.Input) {
 		return
 	}
 
+	w.addParent(inp.OutPoint().Hash, *unconfParent)
+}
+
+// addParent updates the parent tx totals with the given unconfirmed parent if
+// it's not paying enough fees for this transaction.
+func (w *weightEstimator) addParent(parentHash chainhash.Hash,
+	unconfParent input.TxInfo) {
+
 	// If we've already accounted for the parent tx, don't do it
 	// again. This can happens when two outputs of the parent tx are
 	// included in the same sweep tx.
-	parentHash := inp.OutPoint().Hash
 	if _, ok := w.parents[parentHash]; ok {
 		return
 	}
@@ -78,6 +230,16 @@ func (w *weightEstimator) tryAddParent(inp input.Input) {
 	w.parentsWeight += unconfParent.Weight
 }
 
+// setParent replaces the unconfirmed parents found in the inputs with the
+// given parent tx, whose hash is unknown.
+func (w *weightEstimator) setParent(unconfParent input.TxInfo) {
+	w.parents = make(map[chainhash.Hash]struct{})
+	w.parentsFee = 0
+	w.parentsWeight = 0
+
+	w.addParent(chainhash.Hash{}, unconfParent)
+}
+
 // addP2WKHOutput updates the weight estimate to account for an additional
 // native P2WKH output.
 func (w *weightEstimator) addP2WKHOutput() {
@@ -96,6 +258,33 @@ func (w *weightEstimator) addP2WSHOutput() {
 	w.estimator.AddP2WSHOutput()
 }
 
+// addPkScriptOutput updates the weight estimate to account for an additional
+// output paying to the given pkScript, whose size is derived from the script
+// type. ErrUnknownScriptType is returned if the script type is not supported.
+func (w *weightEstimator) addPkScriptOutput(pkScript []byte) error {
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		w.addP2TROutput()
+
+	case txscript.IsPayToWitnessScriptHash(pkScript):
+		w.addP2WSHOutput()
+
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		w.addP2WKHOutput()
+
+	case txscript.IsPayToPubKeyHash(pkScript):
+		w.estimator.AddP2PKHOutput()
+
+	case txscript.IsPayToScriptHash(pkScript):
+		w.estimator.AddP2SHOutput()
+
+	default:
+		return fmt.Errorf("%w: %x", ErrUnknownScriptType, pkScript)
+	}
+
+	return nil
+}
+
 // addOutput updates the weight estimate to account for the known
 // output given.
 func (w *weightEstimator) addOutput(txOut *wire.TxOut) {
@@ -104,7 +293,13 @@ func (w *weightEstimator) addOutput(txOut *wire.TxOut) {
 
 // weight gets the estimated weight of the transaction.
 func (w *weightEstimator) weight() lntypes.WeightUnit {
-	return w.estimator.Weight()
+	return w.estimator.Weight() + w.sigHashWeight
+}
+
+// packageWeight gets the estimated weight of the transaction and the
+// unconfirmed parent transactions it pays for.
+func (w *weightEstimator) packageWeight() lntypes.WeightUnit {
+	return w.weight() + w.parentsWeight
 }
 
 // fee returns the tx fee to use for the aggregated inputs and outputs, which
@@ -112,7 +307,7 @@ func (w *weightEstimator) weight() lntypes.WeightUnit {
 // parent transactions.
 func (w *weightEstimator) fee() btcutil.Amount {
 	// Calculate the weight of the transaction.
-	weight := w.estimator.Weight()
+	weight := w.weight()
 
 	// Calculate the fee.
 	fee := w.feeRate.FeeForWeight(weight)
@@ -124,7 +319,7 @@ func (w *weightEstimator) fee() btcutil.Amount {
 // outputs, taking into account unconfirmed parent transactions (cpfp).
 func (w *weightEstimator) feeWithParent() btcutil.Amount {
 	// Calculate fee and weight for just this tx.
-	childWeight := w.estimator.Weight()
+	childWeight := w.weight()
 
 	// Add combined weight of unconfirmed parent txes.
 	totalWeight := childWeight + w.parentsWeight
@@ -3,10 +3,12 @@ package sweep
 import (
 	"testing"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
@@ -152,3 +154,323 @@ func TestWeightEstimatorAddOutput(t *testing.T) {
 	// Estimate hhould be the same.
 	require.Equal(t, w1.weight(), w2.weight())
 }
+
+// TestWeightEstimatorSigHash checks the weight estimator accounts for the
+// larger signature of taproot inputs signed with a non-default sighash flag,
+// without double counting it for witness types whose size already includes
+// the flag.
+func TestWeightEstimatorSigHash(t *testing.T) {
+	t.Parallel()
+
+	// Create a p2tr pkScript.
+	pkScript := make([]byte, 34)
+	pkScript[0], pkScript[1] = txscript.OP_1, txscript.OP_DATA_32
+
+	// makeInput creates a taproot input signed using the given sighash.
+	makeInput := func(wt input.WitnessType, hashType txscript.SigHashType,
+		opts ...input.InputOpt) input.Input {
+
+		inp := input.MakeBaseInput(
+			&wire.OutPoint{}, wt, &input.SignDescriptor{
+				Output: &wire.TxOut{
+					Value:    1000,
+					PkScript: pkScript,
+				},
+				HashType: hashType,
+			}, 0, nil, opts...,
+		)
+
+		return &inp
+	}
+
+	// calcWeight returns the weight of a tx spending the given input.
+	calcWeight := func(inp input.Input) lntypes.WeightUnit {
+		w := newWeightEstimator(chainfee.FeePerKwFloor, 0)
+		require.NoError(t, w.add(inp))
+
+		return w.weight()
+	}
+
+	customSigHash := txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
+
+	// The key spend witness type is sized using
+	// TaprootKeyPathCustomSighashWitnessSize, which already includes the
+	// sighash flag, so the weight doesn't change with the sighash.
+	for _, wt := range []input.WitnessType{
+		input.TaprootPubKeySpend, input.TaprootAnchorSweepSpend,
+	} {
+		defaultWeight := calcWeight(
+			makeInput(wt, txscript.SigHashDefault),
+		)
+		require.Equal(t, defaultWeight, calcWeight(
+			makeInput(wt, customSigHash),
+		), "witness type %v", wt)
+	}
+
+	// A taproot input reporting its own witness size, which is calculated
+	// using a 64-byte signature, needs one more byte for the sighash flag.
+	witnessSize := lntypes.WeightUnit(input.TaprootKeyPathWitnessSize)
+	defaultWeight := calcWeight(makeInput(
+		input.TaprootPubKeySpend, txscript.SigHashDefault,
+		input.WithWitnessSize(witnessSize),
+	))
+
+	w := newWeightEstimator(chainfee.FeePerKwFloor, 0)
+	require.NoError(t, w.add(makeInput(
+		input.TaprootPubKeySpend, customSigHash,
+		input.WithWitnessSize(witnessSize),
+	)))
+	require.Equal(t, defaultWeight+1, w.weight())
+	require.Equal(t, chainfee.FeePerKwFloor.FeeForWeight(defaultWeight+1),
+		w.fee())
+
+	// ECDSA signatures always carry the sighash flag, so there's no
+	// overhead for non-taproot inputs.
+	p2wkhInput := input.MakeBaseInput(
+		&wire.OutPoint{}, input.WitnessKeyHash, &input.SignDescriptor{
+			Output:   &wire.TxOut{Value: 1000},
+			HashType: txscript.SigHashAnyOneCanPay,
+		}, 0, nil,
+	)
+	require.Zero(t, sigHashWeightOverhead(&p2wkhInput))
+}
+
+// TestWeightEstimatorTapscript checks that the witness size reported by a
+// taproot script-path input is used instead of the size derived from its
+// witness type.
+func TestWeightEstimatorTapscript(t *testing.T) {
+	t.Parallel()
+
+	// Create a p2tr pkScript.
+	pkScript := make([]byte, 34)
+	pkScript[0], pkScript[1] = txscript.OP_1, txscript.OP_DATA_32
+
+	signDesc := &input.SignDescriptor{
+		Output: &wire.TxOut{
+			Value:    1000,
+			PkScript: pkScript,
+		},
+		HashType: txscript.SigHashDefault,
+	}
+
+	// Create a key-path input.
+	keyPath := input.MakeBaseInput(
+		&wire.OutPoint{}, input.TaprootPubKeySpend, signDesc, 0, nil,
+	)
+
+	// Create a script-path input that reveals a 100-byte script with a
+	// one-level inclusion proof, and is satisfied by a single signature.
+	tapscript := &waddrmgr.Tapscript{
+		RevealedScript: make([]byte, 100),
+		ControlBlock: &txscript.ControlBlock{
+			InclusionProof: make([]byte, 32),
+		},
+	}
+	leafWitnessSize := lntypes.WeightUnit(
+		1 + input.TaprootSignatureWitnessSize,
+	)
+	witnessSize := input.TapscriptWitnessSize(leafWitnessSize, tapscript)
+
+	scriptPath := input.MakeBaseInput(
+		&wire.OutPoint{Index: 1}, input.TaprootPubKeySpend, signDesc,
+		0, nil, input.WithWitnessSize(witnessSize),
+	)
+
+	// Calculate the weights of the two inputs.
+	w := newWeightEstimator(chainfee.FeePerKwFloor, 0)
+	require.NoError(t, w.add(&keyPath))
+	keyPathWeight := w.weight()
+
+	w = newWeightEstimator(chainfee.FeePerKwFloor, 0)
+	require.NoError(t, w.add(&scriptPath))
+	scriptPathWeight := w.weight()
+
+	// The script-path spend should be larger than the key-path spend.
+	require.Greater(t, scriptPathWeight, keyPathWeight)
+
+	// The weight should match the one estimated for a tapscript input.
+	var expected input.TxWeightEstimator
+	expected.AddTapscriptInput(leafWitnessSize, tapscript)
+	require.Equal(t, expected.Weight(), scriptPathWeight)
+}
+
+// TestWeightEstimatorNestedP2SH checks the sigScript of a P2SH-wrapped input
+// is included in its weight.
+func TestWeightEstimatorNestedP2SH(t *testing.T) {
+	t.Parallel()
+
+	signDesc := &input.SignDescriptor{
+		Output: &wire.TxOut{Value: 1000},
+	}
+	native := input.MakeBaseInput(
+		&wire.OutPoint{}, input.WitnessKeyHash, signDesc, 0, nil,
+	)
+	nested := input.MakeBaseInput(
+		&wire.OutPoint{Index: 1}, input.NestedWitnessKeyHash, signDesc,
+		0, nil,
+	)
+
+	// Calculate the weights of the two inputs.
+	w := newWeightEstimator(chainfee.FeePerKwFloor, 0)
+	require.NoError(t, w.add(&native))
+	nativeWeight := w.weight()
+
+	w = newWeightEstimator(chainfee.FeePerKwFloor, 0)
+	require.NoError(t, w.add(&nested))
+	nestedWeight := w.weight()
+
+	// The nested input should carry the extra sigScript bytes, which
+	// are non-witness data.
+	extraWeight := lntypes.WeightUnit(
+		input.NestedP2WPKHSize * blockchain.WitnessScaleFactor,
+	)
+	require.Equal(t, nativeWeight+extraWeight, nestedWeight)
+
+	// The weight should match a tx spending the nested input using the
+	// largest signature.
+	redeemScript := append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+		make([]byte, 20)...)
+	sigScript, err := txscript.NewScriptBuilder().AddData(
+		redeemScript,
+	).Script()
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		SignatureScript: sigScript,
+		Witness: wire.TxWitness{
+			make([]byte, 73), make([]byte, 33),
+		},
+	})
+	txWeight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+	require.EqualValues(t, txWeight, nestedWeight)
+
+	// The same extra weight should be included in the sweep tx.
+	sweepAddrs := [][]byte{changePkScript.DeliveryAddress}
+	nativeTxWeight, err := calcSweepTxWeight(
+		[]input.Input{&native}, sweepAddrs, nil,
+	)
+	require.NoError(t, err)
+	nestedTxWeight, err := calcSweepTxWeight(
+		[]input.Input{&nested}, sweepAddrs, nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, nativeTxWeight+extraWeight, nestedTxWeight)
+
+	// An input spending a P2SH output with an explicit witness size
+	// should be sized as a nested P2WSH spend.
+	p2shScript := append([]byte{txscript.OP_HASH160, txscript.OP_DATA_20},
+		make([]byte, 21)...)
+	p2shScript[len(p2shScript)-1] = txscript.OP_EQUAL
+	witnessSize := lntypes.WeightUnit(100)
+	wrapped := input.MakeBaseInput(
+		&wire.OutPoint{Index: 2}, input.WitnessKeyHash,
+		&input.SignDescriptor{
+			Output: &wire.TxOut{Value: 1000, PkScript: p2shScript},
+		}, 0, nil, input.WithWitnessSize(witnessSize),
+	)
+
+	w = newWeightEstimator(chainfee.FeePerKwFloor, 0)
+	require.NoError(t, w.add(&wrapped))
+
+	var expected input.TxWeightEstimator
+	expected.AddNestedP2WSHInput(witnessSize)
+	require.Equal(t, expected.Weight(), w.weight())
+}
+
+// TestWitnessSizeCache checks the weight calculated using the cached witness
+// sizes equals the weight freshly computed from the witness types.
+func TestWitnessSizeCache(t *testing.T) {
+	t.Parallel()
+
+	witnessTypes := []input.StandardWitnessType{
+		input.WitnessKeyHash,
+		input.NestedWitnessKeyHash,
+		input.CommitmentTimeLock,
+		input.HtlcOfferedTimeoutSecondLevel,
+		input.TaprootPubKeySpend,
+	}
+
+	signDesc := &input.SignDescriptor{
+		Output: &wire.TxOut{Value: 1000},
+	}
+
+	inputs := make([]input.Input, 0, len(witnessTypes))
+	for i, wt := range witnessTypes {
+		inp := input.MakeBaseInput(
+			&wire.OutPoint{Index: uint32(i)}, wt, signDesc, 0, nil,
+		)
+		inputs = append(inputs, &inp)
+	}
+
+	// Compute the weight without the cache.
+	var fresh input.TxWeightEstimator
+	for _, wt := range witnessTypes {
+		if wt == input.NestedWitnessKeyHash {
+			fresh.AddNestedP2WKHInput()
+			continue
+		}
+
+		require.NoError(t, wt.AddWeightEstimation(&fresh))
+	}
+	fresh.AddOutput(changePkScript.DeliveryAddress)
+
+	// The weight should be the same when the cache is populated and when
+	// it's reused.
+	sweepAddrs := [][]byte{changePkScript.DeliveryAddress}
+	for i := 0; i < 2; i++ {
+		weight, err := calcSweepTxWeight(inputs, sweepAddrs, nil)
+		require.NoError(t, err)
+		require.Equal(t, fresh.Weight(), weight)
+	}
+
+	// The cached sizes should match the upper bounds of the witness
+	// types.
+	for _, wt := range witnessTypes {
+		cached, err := cachedWitnessSize(wt)
+		require.NoError(t, err)
+
+		size, isNestedP2SH, err := wt.SizeUpperBound()
+		require.NoError(t, err)
+		require.Equal(t, witnessSize{size, isNestedP2SH}, cached)
+	}
+}
+
+// BenchmarkCalcSweepTxWeight benchmarks the weight calculation of a large
+// sweep, which is repeated each time the sweep is rebuilt.
+func BenchmarkCalcSweepTxWeight(b *testing.B) {
+	witnessTypes := []input.StandardWitnessType{
+		input.WitnessKeyHash,
+		input.CommitmentTimeLock,
+		input.HtlcOfferedTimeoutSecondLevel,
+		input.HtlcAcceptedSuccessSecondLevel,
+		input.TaprootPubKeySpend,
+	}
+
+	signDesc := &input.SignDescriptor{
+		Output: &wire.TxOut{Value: 1000},
+	}
+
+	inputs := make([]input.Input, 0, 500)
+	for i := 0; i < cap(inputs); i++ {
+		inp := input.MakeBaseInput(
+			&wire.OutPoint{Index: uint32(i)},
+			witnessTypes[i%len(witnessTypes)], signDesc, 0, nil,
+		)
+		inputs = append(inputs, &inp)
+	}
+
+	sweepAddrs := [][]byte{changePkScript.DeliveryAddress}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := calcSweepTxWeight(
+			inputs, sweepAddrs, nil,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
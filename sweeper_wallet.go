@@ -2,7 +2,9 @@ package lnd
 
 import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/sweep"
 )
 
 // sweeperWallet is a wrapper around the LightningWallet that implements the
@@ -19,6 +21,13 @@ func newSweeperWallet(w *lnwallet.LightningWallet) *sweeperWallet {
 	}
 }
 
+// SubmitPackage submits the given txns as a package. None of the current
+// chain backends expose package relay, so the sweeper is told to publish the
+// txns one by one instead.
+func (s *sweeperWallet) SubmitPackage(_ []*wire.MsgTx) error {
+	return sweep.ErrPackageRelayUnsupported
+}
+
 // CancelRebroadcast cancels the rebroadcast of the given transaction.
 func (s *sweeperWallet) CancelRebroadcast(txid chainhash.Hash) {
 	// For neutrino, we don't config the rebroadcaster for the wallet as it